@@ -0,0 +1,119 @@
+package shp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// AlignmentPolicy determines how a Reader handles a shapefile whose SHP and
+// DBF files hold different numbers of records, which otherwise makes the
+// attributes silently drift away from their shapes.
+type AlignmentPolicy int
+
+const (
+	// AlignIgnore reads all records of the SHP file as they are. Attributes
+	// of records beyond the end of the DBF file are empty. This is the
+	// default.
+	AlignIgnore AlignmentPolicy = iota
+	// AlignError makes Open fail with a *MisalignedError.
+	AlignError
+	// AlignTruncate stops reading after the records that both files have.
+	AlignTruncate
+	// AlignPad reads as many records as the longer file has. Records missing
+	// from the SHP file are returned as Null shapes and records missing from
+	// the DBF file have empty attributes.
+	AlignPad
+)
+
+// WithAlignment sets the policy for shapefiles with a different number of
+// records in their SHP and DBF files. It applies to Open only, because the
+// record counts cannot be known up front when reading sequentially.
+func WithAlignment(p AlignmentPolicy) ReaderOption {
+	return func(o *readerOptions) {
+		o.alignment = p
+	}
+}
+
+// MisalignedError is returned by Open with AlignError if the SHP and DBF
+// files hold different numbers of records.
+type MisalignedError struct {
+	SHPCount, DBFCount int
+}
+
+func (e *MisalignedError) Error() string {
+	return fmt.Sprintf("SHP file has %d records but DBF file has %d", e.SHPCount, e.DBFCount)
+}
+
+// Misaligned returns the number of records in the SHP and DBF files if they
+// differ, and 0, 0 otherwise or if either file is missing or not read. With
+// AlignIgnore, the records are only counted if there is an SHX file.
+func (r *Reader) Misaligned() (shpCount, dbfCount int) {
+	if r.shpCount >= 0 && r.shpCount != int(r.dbfNumRecords) {
+		return r.shpCount, int(r.dbfNumRecords)
+	}
+	return 0, 0
+}
+
+// checkAlignment counts the records of the SHP file and compares them with
+// the DBF header when both files are read.
+func (r *Reader) checkAlignment() error {
+	if r.shp == nil || r.skipAttributes || r.sidecars.DBF == "" {
+		return nil
+	}
+	if err := r.openDbf(); err != nil {
+		if r.alignment == AlignIgnore {
+			// reported when attributes are read
+			return nil
+		}
+		return err
+	}
+	if r.alignment == AlignIgnore && r.sidecars.SHX == "" {
+		// not worth walking the whole file for a warning
+		r.shpCount = -1
+		return nil
+	}
+	n, err := r.countRecords()
+	if err != nil {
+		if r.alignment == AlignIgnore {
+			r.shpCount = -1
+			return nil
+		}
+		return err
+	}
+	r.shpCount = n
+	if n == int(r.dbfNumRecords) {
+		return nil
+	}
+	err = &MisalignedError{SHPCount: n, DBFCount: int(r.dbfNumRecords)}
+	if r.alignment == AlignError {
+		return err
+	}
+	r.warn(&r.warnings, Warning{Index: -1, Err: err})
+	return nil
+}
+
+// countRecords returns the number of records of the SHP file from the size of
+// the SHX file, or by walking the record headers if there is none.
+func (r *Reader) countRecords() (int, error) {
+	if r.sidecars.SHX != "" {
+		if size, err := r.sidecarSize(r.sidecars.SHX); err == nil && size >= 100 {
+			return int((size - 100) / 8), nil
+		}
+	}
+	defer r.shp.Seek(100, io.SeekStart)
+	n := 0
+	for pos := int64(100); pos+8 <= r.filelength; n++ {
+		var header [2]int32
+		r.shp.Seek(pos, io.SeekStart)
+		if err := binary.Read(r.shp, binary.BigEndian, &header); err != nil {
+			return 0, fmt.Errorf("Error when counting records: %v", err)
+		}
+		length := int64(header[1]) * 2
+		if err := r.limits.checkRecordSize(length); err != nil {
+			return 0, fmt.Errorf("Error when counting records: %w", err)
+		}
+		pos += 8 + length
+	}
+	return n, nil
+}
@@ -0,0 +1,123 @@
+package shp
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMisaligned creates a shapefile in dir with shpCount points and a DBF
+// file with dbfCount rows and returns the path of its SHP file.
+func writeMisaligned(t *testing.T, dir string, shpCount, dbfCount int) string {
+	opts := GenOptions{Dir: dir, Fields: []Field{StringField("NAME", 8)}}
+	opts.Name, opts.Records = "shp", shpCount
+	filename, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Name, opts.Records = "dbf", dbfCount
+	if _, err := Generate(opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "dbf.dbf"), filepath.Join(dir, "shp.dbf")); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestMisaligned(t *testing.T) {
+	tests := []struct {
+		shpCount, dbfCount int
+		policy             AlignmentPolicy
+		records            int
+		nulls              int
+	}{
+		{3, 3, AlignError, 3, 0},
+		{3, 2, AlignIgnore, 3, 0},
+		{3, 2, AlignTruncate, 2, 0},
+		{3, 2, AlignPad, 3, 0},
+		{2, 3, AlignIgnore, 2, 0},
+		{2, 3, AlignTruncate, 2, 0},
+		{2, 3, AlignPad, 3, 1},
+	}
+	for _, test := range tests {
+		for _, shx := range []bool{true, false} {
+			filename := writeMisaligned(t, t.TempDir(), test.shpCount, test.dbfCount)
+			if !shx {
+				os.Remove(strings.TrimSuffix(filename, ".shp") + ".shx")
+			}
+			r, err := Open(filename, WithAlignment(test.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			shpCount, dbfCount := r.Misaligned()
+			if test.shpCount == test.dbfCount || (test.policy == AlignIgnore && !shx) {
+				if shpCount != 0 || dbfCount != 0 {
+					t.Errorf("Misaligned() = %d, %d for aligned files", shpCount, dbfCount)
+				}
+			} else if shpCount != test.shpCount || dbfCount != test.dbfCount {
+				t.Errorf("Misaligned() = %d, %d, want %d, %d", shpCount, dbfCount, test.shpCount, test.dbfCount)
+			}
+
+			records, nulls := 0, 0
+			for r.Next() {
+				n, s := r.Shape()
+				if n != records {
+					t.Errorf("%+v: record %d has index %d", test, records, n)
+				}
+				if _, ok := s.(*Null); ok {
+					nulls++
+				}
+				if v := r.Attribute(0); (v == "") != (n >= test.dbfCount) {
+					t.Errorf("%+v: record %d has attribute %q", test, n, v)
+				}
+				records++
+			}
+			if r.Err() != nil {
+				t.Fatal(r.Err())
+			}
+			r.Close()
+			if records != test.records || nulls != test.nulls {
+				t.Errorf("%+v (shx %v): read %d records with %d nulls, want %d with %d",
+					test, shx, records, nulls, test.records, test.nulls)
+			}
+		}
+	}
+}
+
+func TestMisalignedError(t *testing.T) {
+	filename := writeMisaligned(t, t.TempDir(), 3, 2)
+	_, err := Open(filename, WithAlignment(AlignError))
+	var me *MisalignedError
+	if !errors.As(err, &me) || me.SHPCount != 3 || me.DBFCount != 2 {
+		t.Errorf("got error %v, want MisalignedError", err)
+	}
+}
+
+func TestMisalignedNegativeLength(t *testing.T) {
+	filename := writeMisaligned(t, t.TempDir(), 3, 2)
+	os.Remove(strings.TrimSuffix(filename, ".shp") + ".shx")
+	f, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(0xfffffffc)) // -4 words
+	if _, err := f.WriteAt(length[:], 104); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	var le *LimitError
+	if _, err := Open(filename, WithAlignment(AlignError)); !errors.As(err, &le) {
+		t.Errorf("got error %v, want LimitError", err)
+	}
+}
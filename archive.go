@@ -0,0 +1,170 @@
+package shp
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ArchiveFile is a file in an archive, for OpenArchive.
+type ArchiveFile struct {
+	// Name is the path of the file in the archive, with forward slashes.
+	Name string
+	// Open returns the content of the file.
+	Open func() (io.ReadCloser, error)
+}
+
+// OpenTar returns the shapefiles in the tar archive filename as a Dataset.
+// Archives compressed with gzip (.tar.gz or .tgz), or with a format added
+// with RegisterDecompressor, are decompressed. See OpenArchive for how the
+// layers are named and stored.
+func OpenTar(filename string) (*Dataset, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	defer rc.Close()
+
+	x, err := newExtraction(filename)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(rc)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			x.abort()
+			return nil, fmt.Errorf("Error when reading %s: %v", filename, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := x.add(h.Name, tr); err != nil {
+			x.abort()
+			return nil, err
+		}
+	}
+	return x.dataset(), nil
+}
+
+// OpenArchive returns the shapefiles among files as a Dataset, to read archive
+// formats that need other packages, such as 7z with
+// github.com/bodgit/sevenzip:
+//
+//	r, err := sevenzip.OpenReader("layers.7z")
+//	...
+//	var files []shp.ArchiveFile
+//	for _, f := range r.File {
+//		files = append(files, shp.ArchiveFile{Name: f.Name, Open: f.Open})
+//	}
+//	d, err := shp.OpenArchive("layers.7z", files)
+//
+// The files of the shapefiles, with any of the extensions of FindSidecars,
+// are extracted into a temporary directory that is removed by Close of the
+// Dataset, as reading a layer requires seeking. Each layer is named by the
+// path of its SHP file in the archive without the extension, e.g.
+// "data/roads". Files with absolute paths or paths outside of the archive
+// are skipped. name identifies the archive in error messages.
+func OpenArchive(name string, files []ArchiveFile) (*Dataset, error) {
+	x, err := newExtraction(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if !x.wanted(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			x.abort()
+			return nil, fmt.Errorf("Error when opening %s in %s: %v", f.Name, name, err)
+		}
+		err = x.add(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			x.abort()
+			return nil, err
+		}
+	}
+	return x.dataset(), nil
+}
+
+// extraction collects the shapefiles of an archive in a temporary directory.
+type extraction struct {
+	archive string
+	tmp     string
+	layers  map[string]string
+}
+
+func newExtraction(archive string) (*extraction, error) {
+	tmp, err := os.MkdirTemp("", "shp-archive-")
+	if err != nil {
+		return nil, err
+	}
+	return &extraction{archive: archive, tmp: tmp, layers: map[string]string{}}, nil
+}
+
+// wanted returns whether name is a file of a shapefile that can be safely
+// extracted.
+func (x *extraction) wanted(name string) bool {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, ":") {
+		return false
+	}
+	return trimSidecarExt(clean) != clean
+}
+
+// add extracts the file name with the content r if it is wanted.
+func (x *extraction) add(name string, r io.Reader) error {
+	if !x.wanted(name) {
+		return nil
+	}
+	name = path.Clean(name)
+	target := filepath.Join(x.tmp, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("Error when extracting %s from %s: %v", name, x.archive, err)
+	}
+	if strings.EqualFold(path.Ext(name), ".shp") {
+		x.layers[trimSidecarExt(name)] = target
+	}
+	return nil
+}
+
+// abort removes the extracted files.
+func (x *extraction) abort() {
+	os.RemoveAll(x.tmp)
+}
+
+// dataset returns the Dataset of the extracted layers.
+func (x *extraction) dataset() *Dataset {
+	d := &Dataset{dir: x.archive, layers: x.layers, tmp: x.tmp}
+	for name := range x.layers {
+		d.names = append(d.names, name)
+	}
+	sort.Strings(d.names)
+	return d
+}
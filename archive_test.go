@@ -0,0 +1,82 @@
+package shp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOpenTar(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	files := map[string][]byte{"README.txt": []byte("cities"), "../evil.shp": []byte("evil")}
+	for _, ext := range []string{".shp", ".shx", ".dbf"} {
+		data, err := os.ReadFile(filename + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["data/cities"+ext] = data
+	}
+
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(zw)
+	for name, data := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+		tw.Write(data)
+	}
+	tw.Close()
+	zw.Close()
+	archive := filenamePrefix + "cities.tar.gz"
+	if err := os.WriteFile(archive, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(archive)
+
+	check := func(d *Dataset) {
+		t.Helper()
+		if layers := d.Layers(); len(layers) != 1 || layers[0] != "data/cities" {
+			t.Fatalf("got layers %v", layers)
+		}
+		r, err := d.Layer("data/cities")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Next()
+		if got := strings.TrimRight(r.Attribute(0), "\x00"); got != "Springfield" {
+			t.Errorf("got NAME %q", got)
+		}
+		r.Close()
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(d.tmp); !os.IsNotExist(err) {
+			t.Errorf("extracted files were not removed: %v", err)
+		}
+	}
+
+	d, err := OpenTar(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(d)
+
+	var entries []ArchiveFile
+	for name, data := range files {
+		data := data
+		entries = append(entries, ArchiveFile{Name: name, Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}})
+	}
+	d, err = OpenArchive("cities.7z", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(d)
+}
@@ -0,0 +1,170 @@
+package arrow
+
+import "encoding/binary"
+
+// This file implements just enough of a FlatBuffers builder to encode the
+// Arrow IPC metadata. Unlike the reference builders, it lays out the buffer
+// front to back: every object is written before the objects it refers to, so
+// that all offsets point forward.
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v)), uint32(v>>32))
+}
+
+// fbObject is an object that can be referenced by an offset.
+type fbObject interface {
+	// writeTo appends the object to b and returns the position that
+	// offsets must point to.
+	writeTo(b *fbBuilder) int
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// finish returns the buffer with root as its root table, padded to a multiple
+// of 8 bytes.
+func finish(root fbObject) []byte {
+	b := &fbBuilder{buf: make([]byte, 4)}
+	pos := root.writeTo(b)
+	binary.LittleEndian.PutUint32(b.buf, uint32(pos))
+	b.pad(8)
+	return b.buf
+}
+
+// fbField is a field of a table, either a scalar or a reference to another
+// object. The zero value is an absent field.
+type fbField struct {
+	scalar []byte
+	ref    fbObject
+}
+
+func fbUint8(v uint8) fbField { return fbField{scalar: []byte{v}} }
+
+func fbBool(v bool) fbField {
+	if v {
+		return fbUint8(1)
+	}
+	return fbUint8(0)
+}
+
+func fbInt16(v int16) fbField {
+	return fbField{scalar: appendUint16(nil, uint16(v))}
+}
+
+func fbInt32(v int32) fbField {
+	return fbField{scalar: appendUint32(nil, uint32(v))}
+}
+
+func fbInt64(v int64) fbField {
+	return fbField{scalar: appendUint64(nil, uint64(v))}
+}
+
+func fbRef(o fbObject) fbField { return fbField{ref: o} }
+
+func (f fbField) size() int {
+	if f.ref != nil {
+		return 4
+	}
+	return len(f.scalar)
+}
+
+// fbTable is a table whose i-th element is the field with id i.
+type fbTable []fbField
+
+func (t fbTable) writeTo(b *fbBuilder) int {
+	b.pad(2)
+	vt := len(b.buf)
+	b.buf = append(b.buf, make([]byte, 4+2*len(t))...)
+
+	b.pad(8)
+	start := len(b.buf)
+	b.buf = append(b.buf, make([]byte, 4)...)
+	type patch struct {
+		pos int
+		ref fbObject
+	}
+	var patches []patch
+	// place larger fields first to keep padding small
+	for _, size := range []int{8, 4, 2, 1} {
+		for id, f := range t {
+			if f.size() != size {
+				continue
+			}
+			b.pad(size)
+			binary.LittleEndian.PutUint16(b.buf[vt+4+2*id:], uint16(len(b.buf)-start))
+			if f.ref != nil {
+				patches = append(patches, patch{len(b.buf), f.ref})
+				b.buf = append(b.buf, 0, 0, 0, 0)
+			} else {
+				b.buf = append(b.buf, f.scalar...)
+			}
+		}
+	}
+	binary.LittleEndian.PutUint16(b.buf[vt:], uint16(4+2*len(t)))
+	binary.LittleEndian.PutUint16(b.buf[vt+2:], uint16(len(b.buf)-start))
+	binary.LittleEndian.PutUint32(b.buf[start:], uint32(start-vt))
+
+	for _, p := range patches {
+		pos := p.ref.writeTo(b)
+		binary.LittleEndian.PutUint32(b.buf[p.pos:], uint32(pos-p.pos))
+	}
+	return start
+}
+
+type fbString string
+
+func (s fbString) writeTo(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+// fbTables is a vector of tables.
+type fbTables []fbTable
+
+func (v fbTables) writeTo(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(v)))
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, t := range v {
+		slot := pos + 4 + 4*i
+		pos := t.writeTo(b)
+		binary.LittleEndian.PutUint32(b.buf[slot:], uint32(pos-slot))
+	}
+	return pos
+}
+
+// fbStructs is a vector of n structs of 8-byte aligned data.
+type fbStructs struct {
+	n    int
+	data []byte
+}
+
+func (v fbStructs) writeTo(b *fbBuilder) int {
+	for (len(b.buf)+4)%8 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(v.n))
+	b.buf = append(b.buf, v.data...)
+	return pos
+}
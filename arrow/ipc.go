@@ -0,0 +1,369 @@
+// Package arrow writes the records of a shapefile as an Apache Arrow IPC
+// stream, which clients like pyarrow can read without copying, e.g. with
+// pyarrow.ipc.open_stream. The geometry is stored as WKB in a binary column
+// named "geometry" that is marked with the GeoArrow extension name
+// "geoarrow.wkb". The attributes follow as columns of native Arrow types:
+//
+//	DBF type                  Arrow type
+//	C (character)             utf8
+//	N without decimals        int64, if it has at most 18 digits
+//	N with decimals, F        float64
+//	L (logical)               bool
+//	D (date)                  date32
+//
+// Empty values and values that cannot be parsed are null.
+package arrow
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// DefaultBatchSize is the number of rows per record batch of a new Writer.
+const DefaultBatchSize = 65536
+
+// Arrow type ids and other enum values of the IPC metadata.
+const (
+	typeInt           = 2
+	typeFloatingPoint = 3
+	typeBinary        = 4
+	typeUtf8          = 5
+	typeBool          = 6
+	typeDate          = 8
+
+	headerSchema      = 1
+	headerRecordBatch = 3
+
+	metadataV5       = 4
+	precisionDouble  = 2
+	dateUnitDay      = 0
+	endiannessLittle = 0
+)
+
+// column accumulates the values of one column of the current batch.
+type column struct {
+	name     string
+	typeID   uint8
+	typ      fbTable
+	metadata [][2]string // key and value pairs
+
+	n, nulls int
+	validity []byte
+	offsets  []int32 // utf8 and binary only
+	data     []byte
+}
+
+func (c *column) reset() {
+	c.n, c.nulls = 0, 0
+	c.validity = c.validity[:0]
+	c.data = c.data[:0]
+	if c.offsets != nil {
+		c.offsets = append(c.offsets[:0], 0)
+	}
+}
+
+func setBit(bm []byte, i int) []byte {
+	for len(bm) <= i/8 {
+		bm = append(bm, 0)
+	}
+	bm[i/8] |= 1 << (i % 8)
+	return bm
+}
+
+// appendNull appends a null value.
+func (c *column) appendNull() {
+	switch c.typeID {
+	case typeUtf8, typeBinary:
+		c.offsets = append(c.offsets, int32(len(c.data)))
+	case typeBool:
+	case typeDate:
+		c.data = appendUint32(c.data, 0)
+	default:
+		c.data = appendUint64(c.data, 0)
+	}
+	c.n++
+	c.nulls++
+}
+
+func (c *column) appendBytes(v []byte) {
+	c.validity = setBit(c.validity, c.n)
+	c.data = append(c.data, v...)
+	c.offsets = append(c.offsets, int32(len(c.data)))
+	c.n++
+}
+
+// appendValue appends the DBF value v, which is converted to the type of the
+// column.
+func (c *column) appendValue(v string) {
+	v = strings.TrimRight(strings.TrimSpace(v), "\x00")
+	if v == "" {
+		c.appendNull()
+		return
+	}
+	switch c.typeID {
+	case typeUtf8:
+		c.appendBytes([]byte(v))
+		return
+	case typeInt:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.appendNull()
+			return
+		}
+		c.data = appendUint64(c.data, uint64(n))
+	case typeFloatingPoint:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.appendNull()
+			return
+		}
+		c.data = appendUint64(c.data, math.Float64bits(f))
+	case typeBool:
+		switch v {
+		case "T", "t", "Y", "y":
+			c.data = setBit(c.data, c.n)
+		case "F", "f", "N", "n":
+		default:
+			c.appendNull()
+			return
+		}
+	case typeDate:
+		t, err := time.Parse("20060102", v)
+		if err != nil {
+			c.appendNull()
+			return
+		}
+		c.data = appendUint32(c.data, uint32(int32(t.Unix()/86400)))
+	}
+	c.validity = setBit(c.validity, c.n)
+	c.n++
+}
+
+// buffers returns the buffers of the column in the order of the Arrow
+// columnar format. The validity bitmap is omitted if there are no nulls.
+func (c *column) buffers() [][]byte {
+	validity := []byte(nil)
+	if c.nulls > 0 {
+		validity = c.validity
+		for len(validity) < (c.n+7)/8 {
+			validity = append(validity, 0)
+		}
+	}
+	switch c.typeID {
+	case typeUtf8, typeBinary:
+		offsets := make([]byte, 0, 4*len(c.offsets))
+		for _, o := range c.offsets {
+			offsets = appendUint32(offsets, uint32(o))
+		}
+		return [][]byte{validity, offsets, c.data}
+	case typeBool:
+		data := c.data
+		for len(data) < (c.n+7)/8 {
+			data = append(data, 0)
+		}
+		return [][]byte{validity, data}
+	default:
+		return [][]byte{validity, c.data}
+	}
+}
+
+func (c *column) field() fbTable {
+	var metadata fbTables
+	for _, kv := range c.metadata {
+		metadata = append(metadata, fbTable{fbRef(fbString(kv[0])), fbRef(fbString(kv[1]))})
+	}
+	t := fbTable{
+		fbRef(fbString(c.name)),
+		fbBool(true),
+		fbUint8(c.typeID),
+		fbRef(c.typ),
+		{},
+		fbRef(fbTables{}),
+	}
+	if len(metadata) > 0 {
+		t = append(t, fbRef(metadata))
+	}
+	return t
+}
+
+// newColumn returns the column for the DBF field f.
+func newColumn(f shp.Field) *column {
+	c := &column{name: f.String()}
+	switch f.Fieldtype {
+	case 'N':
+		if f.Precision == 0 && f.Size <= 18 {
+			c.typeID, c.typ = typeInt, fbTable{fbInt32(64), fbBool(true)}
+		} else {
+			c.typeID, c.typ = typeFloatingPoint, fbTable{fbInt16(precisionDouble)}
+		}
+	case 'F':
+		c.typeID, c.typ = typeFloatingPoint, fbTable{fbInt16(precisionDouble)}
+	case 'L':
+		c.typeID, c.typ = typeBool, fbTable{}
+	case 'D':
+		c.typeID, c.typ = typeDate, fbTable{fbInt16(dateUnitDay)}
+	default:
+		c.typeID, c.typ = typeUtf8, fbTable{}
+		c.offsets = []int32{0}
+	}
+	return c
+}
+
+// Writer writes shapes and their attributes as an Arrow IPC stream. Rows are
+// buffered and written as record batches of BatchSize rows.
+type Writer struct {
+	// BatchSize is the number of rows per record batch.
+	BatchSize int
+
+	w             io.Writer
+	cols          []*column
+	schemaWritten bool
+}
+
+// NewWriter returns a Writer for records with the attribute fields. The schema
+// is written to w together with the first batch.
+func NewWriter(w io.Writer, fields []shp.Field) *Writer {
+	geometry := &column{
+		name:     "geometry",
+		typeID:   typeBinary,
+		typ:      fbTable{},
+		metadata: [][2]string{{"ARROW:extension:name", "geoarrow.wkb"}, {"ARROW:extension:metadata", "{}"}},
+		offsets:  []int32{0},
+	}
+	aw := &Writer{BatchSize: DefaultBatchSize, w: w, cols: []*column{geometry}}
+	for _, f := range fields {
+		aw.cols = append(aw.cols, newColumn(f))
+	}
+	return aw
+}
+
+// Write adds a row with the shape s and the attributes attrs, which are in the
+// order of the fields passed to NewWriter.
+func (w *Writer) Write(s shp.Shape, attrs []string) error {
+	if b := toWKB(s); b != nil {
+		w.cols[0].appendBytes(b)
+	} else {
+		w.cols[0].appendNull()
+	}
+	for i, c := range w.cols[1:] {
+		if i < len(attrs) {
+			c.appendValue(attrs[i])
+		} else {
+			c.appendNull()
+		}
+	}
+	if w.cols[0].n >= w.BatchSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows as a record batch.
+func (w *Writer) Flush() error {
+	if err := w.writeSchema(); err != nil {
+		return err
+	}
+	n := w.cols[0].n
+	if n == 0 {
+		return nil
+	}
+	var nodes, buffers, body []byte
+	for _, c := range w.cols {
+		nodes = appendUint64(appendUint64(nodes, uint64(c.n)), uint64(c.nulls))
+		for _, b := range c.buffers() {
+			buffers = appendUint64(appendUint64(buffers, uint64(len(body))), uint64(len(b)))
+			body = append(body, b...)
+			for len(body)%8 != 0 {
+				body = append(body, 0)
+			}
+		}
+		c.reset()
+	}
+	batch := fbTable{
+		fbInt64(int64(n)),
+		fbRef(fbStructs{n: len(nodes) / 16, data: nodes}),
+		fbRef(fbStructs{n: len(buffers) / 16, data: buffers}),
+	}
+	return w.writeMessage(headerRecordBatch, batch, body)
+}
+
+func (w *Writer) writeSchema() error {
+	if w.schemaWritten {
+		return nil
+	}
+	w.schemaWritten = true
+	fields := make(fbTables, len(w.cols))
+	for i, c := range w.cols {
+		fields[i] = c.field()
+	}
+	return w.writeMessage(headerSchema, fbTable{fbInt16(endiannessLittle), fbRef(fields)}, nil)
+}
+
+// writeMessage writes an encapsulated IPC message.
+func (w *Writer) writeMessage(headerType uint8, header fbTable, body []byte) error {
+	meta := finish(fbTable{
+		fbInt16(metadataV5),
+		fbUint8(headerType),
+		fbRef(header),
+		fbInt64(int64(len(body))),
+	})
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix, 0xFFFFFFFF)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)))
+	for _, b := range [][]byte{prefix, meta, body} {
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the remaining rows and the end-of-stream marker. It does not
+// close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := w.w.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})
+	return err
+}
+
+// WriteStream writes all remaining records of sr to w as an Arrow IPC stream.
+func WriteStream(w io.Writer, sr shp.SequentialReader) error {
+	return WriteStreamContext(context.Background(), w, sr)
+}
+
+// WriteStreamContext is like WriteStream and traces the conversion as the
+// span "shp.convert" if ctx carries a shp.Tracer.
+func WriteStreamContext(ctx context.Context, w io.Writer, sr shp.SequentialReader) (err error) {
+	_, span := shp.StartSpan(ctx, "shp.convert")
+	span.SetAttribute("format", "arrow")
+	records := 0
+	defer func() {
+		span.SetAttribute("records", records)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	aw := NewWriter(w, sr.Fields())
+	for sr.Next() {
+		_, s := sr.Shape()
+		if err := aw.Write(s, shp.Attributes(sr)); err != nil {
+			return err
+		}
+		records++
+	}
+	if err := sr.Err(); err != nil {
+		return err
+	}
+	return aw.Close()
+}
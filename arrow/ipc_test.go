@@ -0,0 +1,242 @@
+package arrow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// fbTab is a table in a FlatBuffers buffer, used to decode the metadata that
+// the Writer produces.
+type fbTab struct {
+	buf []byte
+	pos int
+}
+
+func u16(b []byte, i int) int { return int(binary.LittleEndian.Uint16(b[i:])) }
+func u32(b []byte, i int) int { return int(binary.LittleEndian.Uint32(b[i:])) }
+
+func fbRoot(buf []byte) fbTab { return fbTab{buf, u32(buf, 0)} }
+
+// field returns the position of field id, or 0 if it is absent.
+func (t fbTab) field(id int) int {
+	vt := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*id >= u16(t.buf, vt) {
+		return 0
+	}
+	if off := u16(t.buf, vt+4+2*id); off != 0 {
+		return t.pos + off
+	}
+	return 0
+}
+
+func (t fbTab) int(id, size int) int64 {
+	p := t.field(id)
+	if p == 0 {
+		return 0
+	}
+	var v int64
+	for i := size - 1; i >= 0; i-- {
+		v = v<<8 | int64(t.buf[p+i])
+	}
+	return v
+}
+
+func (t fbTab) ref(id int) int {
+	p := t.field(id)
+	return p + u32(t.buf, p)
+}
+
+func (t fbTab) table(id int) fbTab { return fbTab{t.buf, t.ref(id)} }
+
+func (t fbTab) string(id int) string {
+	p := t.ref(id)
+	return string(t.buf[p+4 : p+4+u32(t.buf, p)])
+}
+
+func (t fbTab) tables(id int) []fbTab {
+	p := t.ref(id)
+	out := make([]fbTab, u32(t.buf, p))
+	for i := range out {
+		slot := p + 4 + 4*i
+		out[i] = fbTab{t.buf, slot + u32(t.buf, slot)}
+	}
+	return out
+}
+
+// structs returns the int64 fields of a vector of structs.
+func (t fbTab) structs(id int) []int64 {
+	p := t.ref(id)
+	n := u32(t.buf, p)
+	out := make([]int64, 2*n)
+	for i := range out {
+		out[i] = int64(binary.LittleEndian.Uint64(t.buf[p+4+8*i:]))
+	}
+	return out
+}
+
+// readMessage returns the next message of an IPC stream and its body.
+func readMessage(t *testing.T, r *bytes.Reader) (fbTab, []byte) {
+	prefix := make([]byte, 8)
+	if _, err := r.Read(prefix); err != nil {
+		t.Fatal(err)
+	}
+	if u32(prefix, 0) != 0xFFFFFFFF {
+		t.Fatalf("got continuation marker % x", prefix[:4])
+	}
+	meta := make([]byte, u32(prefix, 4))
+	r.Read(meta)
+	if len(meta) == 0 {
+		return fbTab{}, nil
+	}
+	if len(meta)%8 != 0 {
+		t.Errorf("got metadata of %d bytes, want multiple of 8", len(meta))
+	}
+	m := fbRoot(meta)
+	if v := m.int(0, 2); v != metadataV5 {
+		t.Errorf("got version %d", v)
+	}
+	body := make([]byte, m.int(3, 8))
+	r.Read(body)
+	return m, body
+}
+
+func writeTestFile(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "go-shp-arrow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := shp.Create(filepath.Join(dir, "cities.shp"), shp.POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]shp.Field{
+		shp.StringField("NAME", 20),
+		shp.NumberField("POP", 10),
+		shp.FloatField("AREA", 10, 2),
+		shp.DateField("FOUNDED"),
+		{Name: [11]byte{'C', 'A', 'P', 'I', 'T', 'A', 'L'}, Fieldtype: 'L', Size: 1},
+	})
+	w.Write(&shp.Point{X: 1, Y: 2})
+	w.WriteAttribute(0, 0, "Springfield")
+	w.WriteAttribute(0, 1, 30000)
+	w.WriteAttribute(0, 2, 12.5)
+	w.WriteAttribute(0, 3, "17960101")
+	w.WriteAttribute(0, 4, "F")
+	w.Write(&shp.Point{X: 3, Y: 4})
+	w.WriteAttribute(1, 0, "Capital City")
+	w.WriteAttribute(1, 4, "T")
+	w.Close()
+	return dir
+}
+
+func TestWriteStream(t *testing.T) {
+	dir := writeTestFile(t)
+	defer os.RemoveAll(dir)
+	shpFile, err := os.Open(filepath.Join(dir, "cities.shp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbfFile, err := os.Open(filepath.Join(dir, "cities.dbf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := shp.SequentialReaderFromExt(shpFile, dbfFile)
+	defer sr.Close()
+	var buf bytes.Buffer
+	if err := WriteStream(&buf, sr); err != nil {
+		t.Fatal(err)
+	}
+
+	in := bytes.NewReader(buf.Bytes())
+	m, _ := readMessage(t, in)
+	if m.int(1, 1) != headerSchema {
+		t.Fatalf("got header type %d, want schema", m.int(1, 1))
+	}
+	var names []string
+	var types []int64
+	for _, f := range m.table(2).tables(1) {
+		names = append(names, f.string(0))
+		types = append(types, f.int(2, 1))
+	}
+	if want := []string{"geometry", "NAME", "POP", "AREA", "FOUNDED", "CAPITAL"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got fields %v, want %v", names, want)
+	}
+	if want := []int64{typeBinary, typeUtf8, typeInt, typeFloatingPoint, typeDate, typeBool}; !reflect.DeepEqual(types, want) {
+		t.Errorf("got types %v, want %v", types, want)
+	}
+	geometry := m.table(2).tables(1)[0]
+	if kv := geometry.tables(6)[0]; kv.string(0) != "ARROW:extension:name" || kv.string(1) != "geoarrow.wkb" {
+		t.Errorf("got metadata %s=%s", kv.string(0), kv.string(1))
+	}
+
+	m, body := readMessage(t, in)
+	if m.int(1, 1) != headerRecordBatch {
+		t.Fatalf("got header type %d, want record batch", m.int(1, 1))
+	}
+	batch := m.table(2)
+	if batch.int(0, 8) != 2 {
+		t.Errorf("got %d rows, want 2", batch.int(0, 8))
+	}
+	nodes := batch.structs(1)
+	if want := []int64{2, 0, 2, 0, 2, 1, 2, 1, 2, 1, 2, 0}; !reflect.DeepEqual(nodes, want) {
+		t.Errorf("got nodes %v, want %v", nodes, want)
+	}
+	buffers := batch.structs(2)
+	buffer := func(i int) []byte {
+		return body[buffers[2*i] : buffers[2*i]+buffers[2*i+1]]
+	}
+	for i := 0; i < len(buffers); i += 2 {
+		if buffers[i]%8 != 0 {
+			t.Errorf("buffer %d at unaligned offset %d", i/2, buffers[i])
+		}
+	}
+
+	// geometry: validity, offsets, data
+	wkb := buffer(2)[u32(buffer(1), 0):u32(buffer(1), 4)]
+	if wkb[0] != 1 || u32(wkb, 1) != wkbPoint || math.Float64frombits(binary.LittleEndian.Uint64(wkb[5:])) != 1 {
+		t.Errorf("got WKB % x", wkb)
+	}
+	// NAME: validity, offsets, data
+	if got := string(buffer(5)); got != "SpringfieldCapital City" {
+		t.Errorf("got names %q", got)
+	}
+	// POP: validity, data
+	if buffer(6)[0] != 1 || binary.LittleEndian.Uint64(buffer(7)) != 30000 {
+		t.Errorf("got population % x % x", buffer(6), buffer(7))
+	}
+	// FOUNDED: validity, data
+	if days := int32(binary.LittleEndian.Uint32(buffer(11))); days != -63552 {
+		t.Errorf("got date %d, want -63552", days)
+	}
+	// CAPITAL: validity, data
+	if buffer(13)[0] != 2 {
+		t.Errorf("got logicals %08b, want 00000010", buffer(13)[0])
+	}
+
+	if m, _ := readMessage(t, in); m.buf != nil || in.Len() != 0 {
+		t.Error("missing end-of-stream marker")
+	}
+}
+
+func TestWKBPolygon(t *testing.T) {
+	p := shp.Polygon(*shp.NewPolyLine([][]shp.Point{
+		{{X: 0, Y: 0}, {X: 0, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 0}, {X: 0, Y: 0}},
+		{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 1, Y: 2}, {X: 1, Y: 1}},
+		{{X: 5, Y: 5}, {X: 5, Y: 6}, {X: 6, Y: 6}, {X: 6, Y: 5}, {X: 5, Y: 5}},
+	}))
+	b := toWKB(&p)
+	// a multipolygon of a polygon with a hole and one without
+	if u32(b, 1) != wkbMultiPolygon || u32(b, 5) != 2 || u32(b, 10) != wkbPolygon || u32(b, 14) != 2 {
+		t.Errorf("got WKB % x", b[:18])
+	}
+	if want := 1 + 4 + 4 + (1 + 4 + 4 + 2*(4+5*16)) + (1 + 4 + 4 + 4 + 5*16); len(b) != want {
+		t.Errorf("got %d bytes, want %d", len(b), want)
+	}
+}
@@ -0,0 +1,184 @@
+package arrow
+
+import (
+	"math"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// WKB geometry types. Z variants add 1000 and M variants 2000 (ISO WKB).
+const (
+	wkbPoint           = 1
+	wkbLineString      = 2
+	wkbPolygon         = 3
+	wkbMultiPoint      = 4
+	wkbMultiLineString = 5
+	wkbMultiPolygon    = 6
+
+	wkbZ = 1000
+	wkbM = 2000
+)
+
+// wkbWriter appends little-endian WKB to buf. extra holds the Z or M value of
+// every point, or is nil for 2D geometries.
+type wkbWriter struct {
+	buf   []byte
+	dim   uint32
+	extra []float64
+}
+
+func (w *wkbWriter) header(t uint32) {
+	w.buf = append(w.buf, 1)
+	w.buf = appendUint32(w.buf, t+w.dim)
+}
+
+func (w *wkbWriter) count(n int) {
+	w.buf = appendUint32(w.buf, uint32(n))
+}
+
+func (w *wkbWriter) float(v float64) {
+	w.buf = appendUint64(w.buf, math.Float64bits(v))
+}
+
+func (w *wkbWriter) point(points []shp.Point, i int) {
+	w.float(points[i].X)
+	w.float(points[i].Y)
+	if w.extra != nil {
+		v := 0.0
+		if i < len(w.extra) {
+			v = w.extra[i]
+		}
+		w.float(v)
+	}
+}
+
+func (w *wkbWriter) points(points []shp.Point, start, end int) {
+	w.count(end - start)
+	for i := start; i < end; i++ {
+		w.point(points, i)
+	}
+}
+
+// partRanges returns the start and end index of every valid part.
+func partRanges(parts []int32, n int) [][2]int {
+	var out [][2]int
+	for i := range parts {
+		start, end := int(parts[i]), n
+		if i+1 < len(parts) {
+			end = int(parts[i+1])
+		}
+		if start >= 0 && start <= end && end <= n {
+			out = append(out, [2]int{start, end})
+		}
+	}
+	return out
+}
+
+func (w *wkbWriter) lines(parts []int32, points []shp.Point) {
+	rs := partRanges(parts, len(points))
+	if len(rs) == 1 {
+		w.header(wkbLineString)
+		w.points(points, rs[0][0], rs[0][1])
+		return
+	}
+	w.header(wkbMultiLineString)
+	w.count(len(rs))
+	for _, r := range rs {
+		w.header(wkbLineString)
+		w.points(points, r[0], r[1])
+	}
+}
+
+// signedArea returns the area of a ring, negative if it is clockwise.
+func signedArea(points []shp.Point) float64 {
+	var a float64
+	for i := 0; i+1 < len(points); i++ {
+		a += points[i].X*points[i+1].Y - points[i+1].X*points[i].Y
+	}
+	return a / 2
+}
+
+// polygons writes the rings as polygons. Every clockwise ring starts a new
+// polygon, the following counterclockwise rings are its holes.
+func (w *wkbWriter) polygons(parts []int32, points []shp.Point) {
+	var polys [][][2]int
+	for _, r := range partRanges(parts, len(points)) {
+		if signedArea(points[r[0]:r[1]]) < 0 || len(polys) == 0 {
+			polys = append(polys, [][2]int{r})
+		} else {
+			polys[len(polys)-1] = append(polys[len(polys)-1], r)
+		}
+	}
+	polygon := func(rings [][2]int) {
+		w.header(wkbPolygon)
+		w.count(len(rings))
+		for _, r := range rings {
+			w.points(points, r[0], r[1])
+		}
+	}
+	if len(polys) == 1 {
+		polygon(polys[0])
+		return
+	}
+	w.header(wkbMultiPolygon)
+	w.count(len(polys))
+	for _, rings := range polys {
+		polygon(rings)
+	}
+}
+
+func (w *wkbWriter) multiPoint(points []shp.Point) {
+	w.header(wkbMultiPoint)
+	w.count(len(points))
+	for i := range points {
+		w.header(wkbPoint)
+		w.point(points, i)
+	}
+}
+
+// toWKB returns the WKB encoding of s. Z shapes are encoded with their Z
+// values and M shapes with their measures. Null shapes and multipatches have
+// no WKB equivalent and yield nil.
+func toWKB(s shp.Shape) []byte {
+	w := &wkbWriter{}
+	switch p := s.(type) {
+	case *shp.Point:
+		w.header(wkbPoint)
+		w.point([]shp.Point{*p}, 0)
+	case *shp.PointZ:
+		w.dim, w.extra = wkbZ, []float64{p.Z}
+		w.header(wkbPoint)
+		w.point([]shp.Point{{X: p.X, Y: p.Y}}, 0)
+	case *shp.PointM:
+		w.dim, w.extra = wkbM, []float64{p.M}
+		w.header(wkbPoint)
+		w.point([]shp.Point{{X: p.X, Y: p.Y}}, 0)
+	case *shp.MultiPoint:
+		w.multiPoint(p.Points)
+	case *shp.MultiPointZ:
+		w.dim, w.extra = wkbZ, p.ZArray
+		w.multiPoint(p.Points)
+	case *shp.MultiPointM:
+		w.dim, w.extra = wkbM, p.MArray
+		w.multiPoint(p.Points)
+	case *shp.PolyLine:
+		w.lines(p.Parts, p.Points)
+	case *shp.PolyLineZ:
+		w.dim, w.extra = wkbZ, p.ZArray
+		w.lines(p.Parts, p.Points)
+	case *shp.PolyLineM:
+		w.dim, w.extra = wkbM, p.MArray
+		w.lines(p.Parts, p.Points)
+	case *shp.Polygon:
+		w.polygons(p.Parts, p.Points)
+	case *shp.PolygonZ:
+		w.dim, w.extra = wkbZ, p.ZArray
+		w.polygons(p.Parts, p.Points)
+	case *shp.PolygonM:
+		w.dim, w.extra = wkbM, p.MArray
+		w.polygons(p.Parts, p.Points)
+	default:
+		return nil
+	}
+	return w.buf
+}
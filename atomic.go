@@ -0,0 +1,77 @@
+package shp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Atomic makes Create write the SHP, SHX and DBF files under temporary names
+// in the target directory and rename them into place only when Close
+// succeeds, so that consumers watching the directory never see half-written
+// files. The SHP file is renamed last. If writing fails, the temporary files
+// are removed and existing files of the same name are left untouched. Append
+// does not support this option.
+func Atomic() WriterOption {
+	return func(o *writerOptions) {
+		o.atomic = true
+	}
+}
+
+// createTemp creates the SHP file for the shapefile with the basename target
+// under a temporary name in the same directory and returns it together with
+// its basename.
+func createTemp(target string) (*os.File, string, error) {
+	dir, name := filepath.Split(target)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+name+"-*.shp")
+	if err != nil {
+		return nil, "", err
+	}
+	return f, strings.TrimSuffix(f.Name(), ".shp"), nil
+}
+
+// commit renames the temporary files of an atomic Writer into place if err is
+// nil and removes them otherwise.
+func (w *Writer) commit(err error) error {
+	exts := []string{".shx", ".dbf", ".shp"}
+	if w.prj != "" {
+		exts = append([]string{".prj"}, exts...)
+	}
+	if w.cpg != "" {
+		exts = append([]string{".cpg"}, exts...)
+	}
+	if w.style != nil {
+		exts = append(styleExts[:len(styleExts):len(styleExts)], exts...)
+	}
+	if err == nil {
+		for _, ext := range exts {
+			if err = os.Rename(w.filename+ext, w.target+ext); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		for _, ext := range exts {
+			os.Remove(w.filename + ext)
+		}
+		return err
+	}
+	w.debug("renamed temporary files", "file", w.filename, "target", w.target)
+	return nil
+}
+
+// abort closes the files of an atomic Writer without committing them, which
+// removes them and leaves the target untouched, and returns err.
+func (w *Writer) abort(err error) error {
+	w.shp.Close()
+	w.shx.Close()
+	if w.dbf != nil {
+		w.dbf.Close()
+	}
+	w.commit(err)
+	releaseLock(w.lock)
+	return err
+}
@@ -0,0 +1,51 @@
+package shp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "atomic.shp")
+	// an existing shapefile is replaced
+	writeCities(t, filepath.Join(dir, "atomic"))
+
+	w, err := Create(filename, POLYLINE, Atomic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 8)})
+	w.Write(NewPolyLine([][]Point{{{0, 0}, {1, 1}}}))
+	w.WriteAttribute(0, 0, "line")
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GeometryType != POINT {
+		t.Errorf("file replaced before Close: type %v", r.GeometryType)
+	}
+	r.Close()
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		for _, e := range entries {
+			t.Log(e.Name())
+		}
+		t.Errorf("found %d files, want 3", len(entries))
+	}
+	r, err = Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.GeometryType != POLYLINE || !r.Next() || strings.TrimRight(r.Attribute(0), "\x00") != "line" {
+		t.Errorf("got type %v, attribute %q", r.GeometryType, r.Attribute(0))
+	}
+}
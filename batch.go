@@ -0,0 +1,73 @@
+package shp
+
+import "io"
+
+// Record is a shape together with its attributes, as returned by NextBatch.
+type Record struct {
+	Index      int
+	Shape      Shape
+	Attributes []string
+}
+
+// ReuseBatches makes NextBatch return the same slice on every call. The
+// records of the previous batch, including their attribute slices, are
+// overwritten, so they must not be used after the next call. This saves the
+// allocations when batches are processed one after another.
+func ReuseBatches() ReaderOption {
+	return func(o *readerOptions) {
+		o.reuseBatches = true
+	}
+}
+
+// batchSource is the part of a reader that nextBatch needs.
+type batchSource interface {
+	Next() bool
+	Shape() (int, Shape)
+	Attribute(n int) string
+	Err() error
+}
+
+// nextBatch reads up to n records from src, which has numFields attribute
+// fields. With ReuseBatches, the records are stored in *prev.
+func (o readerOptions) nextBatch(prev *[]Record, n int, src batchSource, numFields int) ([]Record, error) {
+	var batch []Record
+	if o.reuseBatches {
+		batch = (*prev)[:0]
+	}
+	if batch == nil {
+		batch = make([]Record, 0, n)
+	}
+	for len(batch) < n && src.Next() {
+		var attrs []string
+		if len(batch) < cap(batch) {
+			attrs = batch[:len(batch)+1][len(batch)].Attributes[:0]
+		}
+		for i := 0; i < numFields; i++ {
+			attrs = append(attrs, src.Attribute(i))
+		}
+		index, s := src.Shape()
+		batch = append(batch, Record{Index: index, Shape: s, Attributes: attrs})
+	}
+	if o.reuseBatches {
+		*prev = batch
+	}
+	if err := src.Err(); err != nil {
+		return batch, err
+	}
+	if len(batch) == 0 && n > 0 {
+		return batch, io.EOF
+	}
+	return batch, nil
+}
+
+// NextBatch reads up to n records at once. It returns fewer records at the
+// end of the file and io.EOF once there are no more records. If an error
+// occurs, the records read before are returned along with it.
+func (r *Reader) NextBatch(n int) ([]Record, error) {
+	return r.nextBatch(&r.batch, n, r, len(r.Fields()))
+}
+
+// NextBatch implements BatchReader for seqReader.
+func (sr *seqReader) NextBatch(n int) ([]Record, error) {
+	return sr.nextBatch(&sr.batch, n, sr, len(sr.Fields()))
+}
@@ -0,0 +1,86 @@
+package shp
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNextBatch(t *testing.T) {
+	r, err := Open("test_files/point.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var sizes []int
+	for {
+		batch, err := r.NextBatch(2)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, rec := range batch {
+			if rec.Index != 2*len(sizes)+i || rec.Shape == nil || len(rec.Attributes) != 1 {
+				t.Errorf("got record %+v", rec)
+			}
+		}
+		sizes = append(sizes, len(batch))
+	}
+	if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 1 {
+		t.Errorf("got batches of %v records, want [2 1]", sizes)
+	}
+}
+
+func TestNextBatchReuse(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t), ReuseBatches())
+	defer sr.Close()
+	first, err := NextBatch(sr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimRight(first[0].Attributes[0], "\x00") != "Springfield" {
+		t.Errorf("got attributes %q", first[0].Attributes)
+	}
+	second, err := NextBatch(sr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &second[0] != &first[0] {
+		t.Error("got a new slice with ReuseBatches")
+	}
+	if second[0].Index != 1 || strings.TrimRight(second[0].Attributes[0], "\x00") != "Shelbyville" {
+		t.Errorf("got record %+v", second[0])
+	}
+	if _, err := NextBatch(sr, 1); err != io.EOF {
+		t.Errorf("got error %v, want io.EOF", err)
+	}
+}
+
+// plainReader hides the optional methods of the SequentialReader it embeds.
+type plainReader struct {
+	SequentialReader
+}
+
+func TestNextBatchFallback(t *testing.T) {
+	sr := plainReader{SequentialReaderFromExt(openFile("test_files/point.shp", t), openFile("test_files/point.dbf", t))}
+	defer sr.Close()
+	if _, ok := SequentialReader(sr).(BatchReader); ok {
+		t.Fatal("plainReader implements BatchReader")
+	}
+	batch, err := NextBatch(sr, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 3 || batch[2].Index != 2 || len(batch[2].Attributes) != 1 {
+		t.Errorf("got batch %+v", batch)
+	}
+	if _, err := NextBatch(sr, 5); err != io.EOF {
+		t.Errorf("got error %v, want io.EOF", err)
+	}
+}
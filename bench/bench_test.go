@@ -0,0 +1,268 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+var (
+	dirOnce sync.Once
+	dir     string
+	dirErr  error
+
+	fixtures sync.Map // name -> *fixture
+)
+
+type fixture struct {
+	once     sync.Once
+	filename string
+	err      error
+}
+
+// fixtureFile returns the path of the SHP file generated with opts, creating
+// it on first use.
+func fixtureFile(b *testing.B, opts shp.GenOptions) string {
+	dirOnce.Do(func() {
+		dir, dirErr = os.MkdirTemp("", "go-shp-bench")
+	})
+	if dirErr != nil {
+		b.Fatal(dirErr)
+	}
+	v, _ := fixtures.LoadOrStore(opts.Name, &fixture{})
+	f := v.(*fixture)
+	f.once.Do(func() {
+		opts.Dir = dir
+		f.filename, f.err = shp.Generate(opts)
+	})
+	if f.err != nil {
+		b.Fatal(f.err)
+	}
+	return f.filename
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if dir != "" {
+		os.RemoveAll(dir)
+	}
+	os.Exit(code)
+}
+
+const numPoints = 1000000
+
+func writePoints(filename string, n int) error {
+	w, err := shp.Create(filename, shp.POINT)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	w.SetFields([]shp.Field{shp.NumberField("ID", 10), shp.StringField("NAME", 16)})
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		p := shp.Point{X: rnd.Float64()*360 - 180, Y: rnd.Float64()*180 - 90}
+		w.Write(&p)
+		w.WriteAttribute(i, 0, i)
+		w.WriteAttribute(i, 1, fmt.Sprintf("point %d", i))
+	}
+	return nil
+}
+
+var (
+	points = shp.GenOptions{
+		Name:    "points",
+		Records: numPoints,
+		Fields:  []shp.Field{shp.NumberField("ID", 10), shp.StringField("NAME", 16)},
+		Seed:    1,
+	}
+	polygons = shp.GenOptions{
+		Name:        "polygons",
+		Type:        shp.POLYGON,
+		Records:     200,
+		MinVertices: 10000,
+		MaxVertices: 10000,
+		Extent:      shp.Box{MinX: 0, MinY: 0, MaxX: 1000, MaxY: 1000},
+		Seed:        2,
+	}
+	wide = shp.GenOptions{
+		Name:    "wide",
+		Records: 10000,
+		Fields:  wideFields(),
+		Seed:    3,
+	}
+)
+
+func wideFields() []shp.Field {
+	fields := make([]shp.Field, 200)
+	for i := range fields {
+		if i%2 == 0 {
+			fields[i] = shp.StringField(fmt.Sprintf("S%d", i), 20)
+		} else {
+			fields[i] = shp.FloatField(fmt.Sprintf("F%d", i), 16, 4)
+		}
+	}
+	return fields
+}
+
+func fileSize(filename string) int64 {
+	var n int64
+	for _, ext := range []string{".shp", ".dbf"} {
+		fi, err := os.Stat(filename[:len(filename)-4] + ext)
+		if err == nil {
+			n += fi.Size()
+		}
+	}
+	return n
+}
+
+// readAll reads all records of filename, including their attributes if
+// attributes is set, and returns the number of records.
+func readAll(b *testing.B, filename string, attributes bool, opts ...shp.ReaderOption) int {
+	r, err := shp.Open(filename, opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer r.Close()
+	numFields := len(r.Fields())
+	n := 0
+	for r.Next() {
+		if attributes {
+			for i := 0; i < numFields; i++ {
+				r.Attribute(i)
+			}
+		}
+		n++
+	}
+	if r.Err() != nil {
+		b.Fatal(r.Err())
+	}
+	return n
+}
+
+func benchmarkRead(b *testing.B, filename string, attributes bool, opts ...shp.ReaderOption) {
+	b.SetBytes(fileSize(filename))
+	b.ResetTimer()
+	start := time.Now()
+	n := 0
+	for i := 0; i < b.N; i++ {
+		n += readAll(b, filename, attributes, opts...)
+	}
+	b.ReportMetric(float64(n)/time.Since(start).Seconds(), "records/s")
+}
+
+func BenchmarkReadPoints(b *testing.B) {
+	benchmarkRead(b, fixtureFile(b, points), false)
+}
+
+func BenchmarkReadPointsWithAttributes(b *testing.B) {
+	benchmarkRead(b, fixtureFile(b, points), true)
+}
+
+func BenchmarkReadPolygons(b *testing.B) {
+	benchmarkRead(b, fixtureFile(b, polygons), false)
+}
+
+func BenchmarkReadWide(b *testing.B) {
+	benchmarkRead(b, fixtureFile(b, wide), true)
+}
+
+func BenchmarkSequentialReadPoints(b *testing.B) {
+	filename := fixtureFile(b, points)
+	b.SetBytes(fileSize(filename))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(filename)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sr := shp.SequentialReaderFromExt(f, nil)
+		for sr.Next() {
+		}
+		if sr.Err() != nil {
+			b.Fatal(sr.Err())
+		}
+		sr.Close()
+	}
+}
+
+func BenchmarkWritePoints(b *testing.B) {
+	dir := b.TempDir()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if err := writePoints(filepath.Join(dir, "points.shp"), numPoints/10); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N*numPoints/10)/time.Since(start).Seconds(), "records/s")
+}
+
+// benchmarkFilter selects the polygons in a tenth of the extent by their
+// bounding box.
+func benchmarkFilter(b *testing.B, opts ...shp.ReaderOption) {
+	filename := fixtureFile(b, polygons)
+	query := shp.Box{MinX: 0, MinY: 0, MaxX: 100, MaxY: 1000}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, err := shp.Open(filename, opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for r.Next() {
+			_, s := r.Shape()
+			if !s.BBox().Intersects(query) {
+				continue
+			}
+			if l, ok := s.(*shp.LazyShape); ok {
+				if _, err := l.Decode(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		r.Close()
+	}
+}
+
+func BenchmarkFilterPolygons(b *testing.B) {
+	benchmarkFilter(b)
+}
+
+func BenchmarkFilterPolygonsLazy(b *testing.B) {
+	benchmarkFilter(b, shp.LazyDecoding())
+}
+
+// benchmarkReleased reads all polygons and releases each of them.
+func benchmarkReleased(b *testing.B, opts ...shp.ReaderOption) {
+	filename := fixtureFile(b, polygons)
+	b.SetBytes(fileSize(filename))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, err := shp.Open(filename, opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for r.Next() {
+			_, s := r.Shape()
+			shp.Release(s)
+		}
+		if r.Err() != nil {
+			b.Fatal(r.Err())
+		}
+		r.Close()
+	}
+}
+
+func BenchmarkReadPolygonsReleased(b *testing.B) {
+	benchmarkReleased(b)
+}
+
+func BenchmarkReadPolygonsPooled(b *testing.B) {
+	benchmarkReleased(b, shp.PooledShapes())
+}
@@ -0,0 +1,18 @@
+// Package bench contains the benchmarks of go-shp. The shapefiles they read
+// are generated at test time in a temporary directory, so the benchmarks need
+// no fixtures and measure the same data on every machine:
+//
+//	points     1,000,000 points with two attributes
+//	polygons   200 polygons with 10,000 vertices each
+//	wide       10,000 points with 200 attribute fields
+//
+// The filter benchmarks select polygons by bounding box with a linear scan,
+// eagerly and with LazyDecoding.
+//
+// Run them and compare against a baseline with benchstat to gate changes that
+// are meant to improve performance:
+//
+//	go test ./bench -run NONE -bench . -count 10 > new.txt
+//	git stash && go test ./bench -run NONE -bench . -count 10 > old.txt
+//	benchstat old.txt new.txt
+package bench
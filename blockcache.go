@@ -0,0 +1,176 @@
+package shp
+
+import (
+	"container/list"
+	"io"
+	"sync"
+)
+
+// DefaultBlockSize is the block size of a BlockCache if none is given.
+const DefaultBlockSize = 64 << 10
+
+// BlockCache keeps recently read blocks of files opened with a RangeOpener in
+// memory, so that spatial queries reading overlapping byte ranges of remote
+// files, such as ShapeAt calls for neighbouring records, fetch every block
+// only once. The least recently used blocks are dropped when the cache holds
+// more than its memory cap. A BlockCache may be shared by several openers and
+// used concurrently.
+type BlockCache struct {
+	blockSize int64
+	maxBlocks int
+
+	mu      sync.Mutex
+	openers int
+	blocks  map[blockKey]*list.Element
+	lru     *list.List // of *cachedBlock, most recent first
+	hits    int64
+	misses  int64
+}
+
+// BlockCacheStats are the counters of a BlockCache.
+type BlockCacheStats struct {
+	Hits   int64 // blocks read from memory
+	Misses int64 // blocks fetched
+	Blocks int   // blocks in memory
+	Bytes  int64 // bytes in memory
+}
+
+type blockKey struct {
+	opener int
+	name   string
+	block  int64
+}
+
+type cachedBlock struct {
+	key  blockKey
+	data []byte
+}
+
+// NewBlockCache returns a BlockCache of blocks of blockSize bytes, or
+// DefaultBlockSize if it is not positive, that holds at most maxBytes.
+func NewBlockCache(blockSize int, maxBytes int64) *BlockCache {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	maxBlocks := int(maxBytes / int64(blockSize))
+	if maxBlocks < 1 {
+		maxBlocks = 1
+	}
+	return &BlockCache{
+		blockSize: int64(blockSize),
+		maxBlocks: maxBlocks,
+		blocks:    make(map[blockKey]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// Opener returns a RangeOpener that reads the files of open through the
+// cache. Files opened again by the same name share their cached blocks.
+func (c *BlockCache) Opener(open RangeOpener) RangeOpener {
+	c.mu.Lock()
+	c.openers++
+	id := c.openers
+	c.mu.Unlock()
+	return func(name string) (RangeReader, error) {
+		rr, err := open(name)
+		if err != nil {
+			return nil, err
+		}
+		return &cachedRange{RangeReader: rr, cache: c, opener: id, name: name}, nil
+	}
+}
+
+// Stats returns the counters of the cache.
+func (c *BlockCache) Stats() BlockCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := BlockCacheStats{Hits: c.hits, Misses: c.misses, Blocks: c.lru.Len()}
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		stats.Bytes += int64(len(e.Value.(*cachedBlock).data))
+	}
+	return stats
+}
+
+func (c *BlockCache) get(key blockKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.blocks[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedBlock).data, true
+}
+
+func (c *BlockCache) put(key blockKey, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.blocks[key]; ok {
+		// fetched concurrently
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.blocks[key] = c.lru.PushFront(&cachedBlock{key: key, data: data})
+	for c.lru.Len() > c.maxBlocks {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.blocks, e.Value.(*cachedBlock).key)
+	}
+}
+
+// cachedRange reads a RangeReader in blocks through a BlockCache.
+type cachedRange struct {
+	RangeReader
+	cache  *BlockCache
+	opener int
+	name   string
+}
+
+func (r *cachedRange) ReadAt(p []byte, off int64) (int, error) {
+	size := r.Size()
+	bs := r.cache.blockSize
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= size {
+			return n, io.EOF
+		}
+		block, err := r.block(pos / bs)
+		if err != nil {
+			return n, err
+		}
+		if pos%bs >= int64(len(block)) {
+			// the file ended before its size
+			return n, io.EOF
+		}
+		n += copy(p[n:], block[pos%bs:])
+	}
+	return n, nil
+}
+
+// block returns block i of the file from the cache, or fetches it. A block
+// that the file ends in holds only the bytes up to its end.
+func (r *cachedRange) block(i int64) ([]byte, error) {
+	key := blockKey{opener: r.opener, name: r.name, block: i}
+	if data, ok := r.cache.get(key); ok {
+		return data, nil
+	}
+	bs := r.cache.blockSize
+	data := make([]byte, min(bs, r.Size()-i*bs))
+	n, err := r.RangeReader.ReadAt(data, i*bs)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	data = data[:n]
+	r.cache.put(key, data)
+	return data, nil
+}
+
+func (r *cachedRange) Close() error {
+	if c, ok := r.RangeReader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
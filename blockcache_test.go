@@ -0,0 +1,105 @@
+package shp
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// countingRange is a RangeReader over data that counts its reads.
+type countingRange struct {
+	*bytes.Reader
+	reads atomic.Int64
+}
+
+func (r *countingRange) ReadAt(p []byte, off int64) (int, error) {
+	r.reads.Add(1)
+	return r.Reader.ReadAt(p, off)
+}
+
+func TestBlockCache(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	src := &countingRange{Reader: bytes.NewReader(data)}
+	cache := NewBlockCache(100, 300)
+	open := cache.Opener(func(string) (RangeReader, error) { return src, nil })
+	rr, err := open("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 150)
+	if n, err := rr.ReadAt(buf, 50); n != 150 || err != nil {
+		t.Fatalf("ReadAt = %d, %v", n, err)
+	}
+	if !bytes.Equal(buf, data[50:200]) {
+		t.Error("wrong data")
+	}
+	// blocks 0 and 1 again
+	if _, err := rr.ReadAt(buf[:100], 20); err != nil {
+		t.Fatal(err)
+	}
+	if src.reads.Load() != 2 {
+		t.Errorf("reads = %d, want 2", src.reads.Load())
+	}
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Blocks != 2 || stats.Bytes != 200 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// the end of the file
+	n, err := rr.ReadAt(buf[:100], 950)
+	if n != 50 || err != io.EOF || !bytes.Equal(buf[:50], data[950:]) {
+		t.Errorf("ReadAt at end = %d, %v", n, err)
+	}
+
+	// the cap of three blocks evicts block 0
+	rr.ReadAt(buf[:10], 500)
+	if stats := cache.Stats(); stats.Blocks != 3 {
+		t.Errorf("blocks = %d, want 3", stats.Blocks)
+	}
+	reads := src.reads.Load()
+	rr.ReadAt(buf[:10], 0)
+	if src.reads.Load() != reads+1 {
+		t.Error("evicted block was not fetched again")
+	}
+}
+
+// shortRange is a RangeReader whose size is larger than its data.
+type shortRange struct {
+	*bytes.Reader
+	size int64
+}
+
+func (r *shortRange) Size() int64 {
+	return r.size
+}
+
+func TestBlockCacheShortRead(t *testing.T) {
+	data := []byte("0123456789abcdef")
+	cache := NewBlockCache(10, 100)
+	open := cache.Opener(func(string) (RangeReader, error) {
+		return &shortRange{Reader: bytes.NewReader(data), size: 25}, nil
+	})
+	rr, err := open("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 10)
+	for i := 0; i < 2; i++ {
+		// the second read is served from the cache
+		n, err := rr.ReadAt(buf, 12)
+		if n != 4 || err != io.EOF || string(buf[:n]) != "cdef" {
+			t.Errorf("read %d: ReadAt = %d, %v, %q", i, n, err, buf[:n])
+		}
+	}
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Bytes != 6 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if n, err := rr.ReadAt(buf, 20); n != 0 || err != io.EOF {
+		t.Errorf("ReadAt past the data = %d, %v", n, err)
+	}
+}
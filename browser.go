@@ -0,0 +1,108 @@
+//go:build js && wasm
+
+package shp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall/js"
+)
+
+// The package builds for GOOS=js GOARCH=wasm, where files are not locked (see
+// lock_other.go), so that shapefiles can be previewed in the browser. The
+// functions below read the browser's File and ArrayBuffer objects.
+
+// ArrayBufferReader copies v, an ArrayBuffer or a Uint8Array, into memory and
+// returns a RangeReader over it.
+func ArrayBufferReader(v js.Value) (RangeReader, error) {
+	if v.InstanceOf(js.Global().Get("ArrayBuffer")) {
+		v = js.Global().Get("Uint8Array").New(v)
+	}
+	if !v.InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, errors.New("Value is neither an ArrayBuffer nor a Uint8Array")
+	}
+	data := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(data, v)
+	return bytes.NewReader(data), nil
+}
+
+// blobRange reads a Blob, such as a File, by slices.
+type blobRange struct {
+	blob js.Value
+	size int64
+}
+
+// BlobReader returns a RangeReader for v, a Blob or a File, that only reads
+// the slices that are asked for. Its ReadAt blocks until the browser has read
+// the slice, so it must not be called from the goroutine of a JavaScript
+// callback, but from a goroutine that the callback starts.
+func BlobReader(v js.Value) (RangeReader, error) {
+	if !v.InstanceOf(js.Global().Get("Blob")) {
+		return nil, errors.New("Value is not a Blob")
+	}
+	return &blobRange{blob: v, size: int64(v.Get("size").Float())}, nil
+}
+
+func (b *blobRange) Size() int64 {
+	return b.size
+}
+
+func (b *blobRange) ReadAt(p []byte, off int64) (int, error) {
+	if off >= b.size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), b.size)
+	buf, err := await(b.blob.Call("slice", off, end).Call("arrayBuffer"))
+	if err != nil {
+		return 0, err
+	}
+	n := js.CopyBytesToGo(p, js.Global().Get("Uint8Array").New(buf))
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// await waits for promise to settle.
+func await(promise js.Value) (js.Value, error) {
+	type result struct {
+		v   js.Value
+		err error
+	}
+	ch := make(chan result, 1)
+	then := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- result{v: args[0]}
+		return nil
+	})
+	defer then.Release()
+	catch := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- result{err: fmt.Errorf("Failed to read blob: %s", args[0].Call("toString").String())}
+		return nil
+	})
+	defer catch.Release()
+	promise.Call("then", then).Call("catch", catch)
+	r := <-ch
+	return r.v, r.err
+}
+
+// FileOpener returns a RangeOpener for the files of files, a FileList such as
+// the files property of an <input type="file" multiple> element, or an array
+// of Files. The names are matched case-insensitively, so that a shapefile is
+// opened by selecting its files:
+//
+//	r, err := shp.OpenRange(shp.FileOpener(input.Get("files")), "roads.shp")
+func FileOpener(files js.Value) RangeOpener {
+	return func(name string) (RangeReader, error) {
+		for i := 0; i < files.Get("length").Int(); i++ {
+			f := files.Index(i)
+			if strings.EqualFold(f.Get("name").String(), name) {
+				return BlobReader(f)
+			}
+		}
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+}
@@ -0,0 +1,63 @@
+//go:build js && wasm
+
+package shp
+
+import (
+	"io"
+	"os"
+	"syscall/js"
+	"testing"
+)
+
+// jsFile returns a browser File with the content of the file at path.
+func jsFile(t *testing.T, path, name string) js.Value {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+	return js.Global().Get("File").New([]any{array}, name)
+}
+
+func TestFileOpener(t *testing.T) {
+	filename := filenamePrefix + "browser"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	files := js.Global().Get("Array").New(
+		jsFile(t, filename+".shp", "CITIES.SHP"),
+		jsFile(t, filename+".dbf", "cities.dbf"),
+	)
+	r, err := OpenRange(FileOpener(files), "cities.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	n := 0
+	for r.Next() {
+		n++
+	}
+	if n != 2 || r.Err() != nil {
+		t.Errorf("read %d records, err %v", n, r.Err())
+	}
+	if len(r.Fields()) != 3 {
+		t.Errorf("fields = %v", r.Fields())
+	}
+}
+
+func TestArrayBufferReader(t *testing.T) {
+	array := js.Global().Get("Uint8Array").New(4)
+	js.CopyBytesToJS(array, []byte{1, 2, 3, 4})
+	rr, err := ArrayBufferReader(array.Get("buffer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 4)
+	if n, err := rr.ReadAt(p, 2); n != 2 || err != io.EOF || p[0] != 3 {
+		t.Errorf("ReadAt = %d, %v, %v", n, err, p)
+	}
+	if _, err := ArrayBufferReader(js.ValueOf(1)); err == nil {
+		t.Error("expected an error for a number")
+	}
+}
@@ -0,0 +1,93 @@
+package shp
+
+import (
+	"math"
+	"sort"
+)
+
+// Buffer returns the polygon of the points within distance of s, with the
+// circular arcs approximated by segments segments per full circle, or 32 if
+// segments is less than 3. Points become circles and convex polygons are
+// grown with rounded corners. Other shapes are buffered by their convex hull,
+// which is exact for convex shapes and covers more than the true buffer
+// otherwise, so it is suited to quick proximity tests and areas of interest
+// rather than to precise geometry. It returns nil for Null shapes, shapes
+// without points and distances that are not positive.
+func Buffer(s Shape, distance float64, segments int) *Polygon {
+	if distance <= 0 || math.IsNaN(distance) {
+		return nil
+	}
+	if segments < 3 {
+		segments = 32
+	}
+	var points []Point
+	switch p := s.(type) {
+	case *Point:
+		points = []Point{*p}
+	case *PointZ:
+		points = []Point{{p.X, p.Y}}
+	case *PointM:
+		points = []Point{{p.X, p.Y}}
+	default:
+		if a, ok := arraysOf(s); ok {
+			points = a.points
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+
+	// the buffer of a convex hull is the hull of the circles around its
+	// vertices
+	hull := convexHull(points)
+	circles := make([]Point, 0, len(hull)*segments)
+	for _, p := range hull {
+		for i := 0; i < segments; i++ {
+			a := 2 * math.Pi * float64(i) / float64(segments)
+			circles = append(circles, Point{p.X + distance*math.Cos(a), p.Y + distance*math.Sin(a)})
+		}
+	}
+	ring := convexHull(circles)
+
+	// convexHull is counterclockwise, outer rings are clockwise
+	for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+		ring[i], ring[j] = ring[j], ring[i]
+	}
+	ring = append(ring, ring[0])
+	return NewPolygon([][]Point{ring})
+}
+
+// convexHull returns the vertices of the convex hull of points in
+// counterclockwise order, without repeating the first one. It uses Andrew's
+// monotone chain algorithm.
+func convexHull(points []Point) []Point {
+	sorted := append([]Point(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+	cross := func(o, a, b Point) float64 {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+	hull := make([]Point, 0, 2*len(sorted))
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
@@ -0,0 +1,38 @@
+package shp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	circle := Buffer(&Point{10, 20}, 2, 64)
+	if circle == nil {
+		t.Fatal("got no buffer for a point")
+	}
+	if got, want := Area(circle), math.Pi*4; math.Abs(got-want)/want > 0.01 {
+		t.Errorf("got circle area %v, want about %v", got, want)
+	}
+	if got := circle.BBox(); math.Abs(got.MinX-8) > 1e-9 || math.Abs(got.MaxY-22) > 1e-9 {
+		t.Errorf("got circle box %v", got)
+	}
+	if len(circle.Points) != 65 || circle.Points[0] != circle.Points[64] {
+		t.Errorf("got %d points, want a closed ring of 65", len(circle.Points))
+	}
+
+	// a 2x2 square grows by the sides and the quarter circles at its corners
+	square := NewPolygon([][]Point{{{0, 0}, {0, 2}, {2, 2}, {2, 0}, {0, 0}}})
+	grown := Buffer(square, 1, 256)
+	if got, want := Area(grown), 4+4*2+math.Pi; math.Abs(got-want)/want > 0.001 {
+		t.Errorf("got buffered square area %v, want about %v", got, want)
+	}
+	for _, p := range square.Points {
+		if !PointInPolygon(p, grown) {
+			t.Errorf("corner %v is outside of the buffer", p)
+		}
+	}
+
+	if Buffer(&Null{}, 1, 8) != nil || Buffer(&Point{}, 0, 8) != nil {
+		t.Error("got a buffer for a Null shape or a zero distance")
+	}
+}
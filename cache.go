@@ -0,0 +1,148 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache keeps up to a fixed number of shapefiles open for servers and other
+// services that read the same files over and over. Each cached shapefile is
+// opened once, its SHX file is read and an R-tree of the bounding boxes of
+// its records is built, so that requests neither reopen nor reindex it. The
+// least recently used shapefile is closed when the cache is full.
+type Cache struct {
+	size int
+	opts []ReaderOption
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // path -> element of lru
+	lru     *list.List               // of *CachedReader, most recent first
+}
+
+// CachedReader is a shapefile held open by a Cache. Its ShapeAt,
+// AttributesAt and Search methods may be called concurrently, its Next method
+// and the other methods that read sequentially must not be used. Release must
+// be called when it is no longer used.
+type CachedReader struct {
+	*Reader
+	path  string
+	cache *Cache
+	tree  *rtree
+	refs  int  // guarded by cache.mu
+	done  bool // evicted, guarded by cache.mu
+}
+
+// NewCache returns a Cache that keeps up to size shapefiles open, which are
+// opened with opts.
+func NewCache(size int, opts ...ReaderOption) *Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache{
+		size:    size,
+		opts:    opts,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Open returns the shapefile at path from the cache, opening and indexing it
+// if it is not cached yet.
+func (c *Cache) Open(path string) (*CachedReader, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[path]; ok {
+		c.lru.MoveToFront(e)
+		cr := e.Value.(*CachedReader)
+		cr.refs++
+		c.mu.Unlock()
+		return cr, nil
+	}
+	c.mu.Unlock()
+
+	// open outside of the lock, as indexing may take a while
+	cr, err := c.open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[path]; ok {
+		// opened concurrently by another caller
+		cr.Reader.Close()
+		c.lru.MoveToFront(e)
+		cr = e.Value.(*CachedReader)
+		cr.refs++
+		return cr, nil
+	}
+	cr.refs = 1
+	c.entries[path] = c.lru.PushFront(cr)
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+	return cr, nil
+}
+
+func (c *Cache) open(path string) (*CachedReader, error) {
+	r, err := Open(path, c.opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.loadIndex()
+	if r.indexErr != nil {
+		r.Close()
+		return nil, r.indexErr
+	}
+	entries := make([]rtreeEntry, 0, len(r.index))
+	for i := range r.index {
+		box, ok, err := r.recordBox(i)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, rtreeEntry{box: box, id: i})
+		}
+	}
+	return &CachedReader{Reader: r, path: path, cache: c, tree: newRTree(entries)}, nil
+}
+
+// evict removes e from the cache and closes its reader unless it is in use.
+func (c *Cache) evict(e *list.Element) {
+	cr := c.lru.Remove(e).(*CachedReader)
+	delete(c.entries, cr.path)
+	cr.done = true
+	if cr.refs == 0 {
+		cr.Reader.Close()
+	}
+}
+
+// Close closes all shapefiles of the cache that are not in use; the others
+// are closed when they are released.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+	return nil
+}
+
+// Release returns cr to its cache. It must not be used afterwards.
+func (cr *CachedReader) Release() {
+	c := cr.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cr.refs--
+	if cr.refs == 0 && cr.done {
+		cr.Reader.Close()
+	}
+}
+
+// Search returns the indexes of the records whose bounding box intersects b
+// in ascending order. Null records are never returned.
+func (cr *CachedReader) Search(b Box) []int {
+	return cr.tree.search(b)
+}
@@ -0,0 +1,92 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i := range paths {
+		var err error
+		paths[i], err = Generate(GenOptions{Dir: dir, Name: fmt.Sprint("layer", i), Type: POLYGON, Records: 500, Seed: int64(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewCache(2)
+	defer c.Close()
+	cr, err := c.Open(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.Open(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != cr {
+		t.Error("cached shapefile was opened again")
+	}
+	again.Release()
+
+	query := Box{MinX: -20, MinY: -10, MaxX: 30, MaxY: 40}
+	var want []int
+	for i := 0; i < cr.NumRecords(); i++ {
+		s, err := cr.ShapeAt(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.BBox().Intersects(query) {
+			want = append(want, i)
+		}
+	}
+	if got := cr.Search(query); !reflect.DeepEqual(got, want) || len(want) == 0 {
+		t.Errorf("Search() = %v, want %v", got, want)
+	}
+
+	// evict paths[0] while it is still in use
+	for _, p := range paths[1:] {
+		r, err := c.Open(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Release()
+	}
+	if _, err := cr.ShapeAt(0); err != nil {
+		t.Errorf("evicted shapefile closed while in use: %v", err)
+	}
+	cr.Release()
+	if _, err := cr.ShapeAt(0); err == nil {
+		t.Error("evicted shapefile not closed after release")
+	}
+	if reopened, err := c.Open(paths[0]); err != nil || reopened == cr {
+		t.Errorf("evicted shapefile was not reopened: %v", err)
+	} else {
+		reopened.Release()
+	}
+}
+
+func TestRTree(t *testing.T) {
+	var entries []rtreeEntry
+	for i := 0; i < 1000; i++ {
+		x, y := float64(i%37), float64(i/37)
+		entries = append(entries, rtreeEntry{box: Box{x, y, x + 0.5, y + 0.5}, id: i})
+	}
+	tree := newRTree(append([]rtreeEntry(nil), entries...))
+	for _, q := range []Box{{0, 0, 1, 1}, {10.2, 3.6, 20.1, 9.9}, {-5, -5, -1, -1}, {0, 0, 100, 100}} {
+		var want []int
+		for _, e := range entries {
+			if e.box.Intersects(q) {
+				want = append(want, e.id)
+			}
+		}
+		if got := tree.search(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("search(%v) = %v, want %v", q, got, want)
+		}
+	}
+}
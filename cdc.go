@@ -0,0 +1,174 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// Added is a record whose key is not in the Manifest.
+	Added ChangeKind = iota
+	// Changed is a record whose shape or attributes differ from when the
+	// Manifest was taken.
+	Changed
+	// Deleted is a record of the Manifest whose key is no longer found.
+	Deleted
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Changed:
+		return "changed"
+	case Deleted:
+		return "deleted"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a change of a record since a Manifest was taken.
+type Change struct {
+	Kind ChangeKind
+	Key  string
+	// Index is the index of the record in the shapefile, or -1 if it is
+	// deleted.
+	Index int
+	// Shape is a *LazyShape of the record, and Attributes are its
+	// attributes. Both are nil if the record is deleted.
+	Shape      Shape
+	Attributes []string
+	Hash       RecordHash
+}
+
+// ChangeStream is the stream of changes returned by DiffSince.
+type ChangeStream struct {
+	changes chan Change
+	done    chan struct{}
+	change  Change
+	err     error
+}
+
+// DiffSince compares the records of r to the Manifest m taken earlier, with
+// the same key field, and returns a stream of the records that were added,
+// changed or deleted since, so that a copy of the data elsewhere can be
+// updated incrementally. The added and changed records come first, in the
+// order of r; the deleted records follow in the order of m. Records are
+// identified as described for Reader.Manifest; if they are identified by
+// their index, deleting a record makes those after it show up as changed.
+//
+// The records are read with random access in a goroutine while the stream is
+// consumed, so r must not be used until Next returns false or Close is
+// called, and one of them must happen for the goroutine to end.
+func (r *Reader) DiffSince(m *Manifest) (*ChangeStream, error) {
+	if len(m.Keys) != len(m.Hashes) {
+		return nil, fmt.Errorf("Manifest has %d keys but %d hashes", len(m.Keys), len(m.Hashes))
+	}
+	old := make(map[string]int, len(m.Keys))
+	for i, key := range m.Keys {
+		old[key] = i
+	}
+	s := &ChangeStream{changes: make(chan Change), done: make(chan struct{})}
+	go func() {
+		defer close(s.changes)
+		seen := make([]bool, len(m.Keys))
+		send := func(c Change) bool {
+			select {
+			case s.changes <- c:
+				return true
+			case <-s.done:
+				return false
+			}
+		}
+		err := r.eachRecordHash(m.KeyField, func(i int, key string, h RecordHash, content, row []byte) error {
+			kind := Added
+			if j, ok := old[key]; ok {
+				seen[j] = true
+				if m.Hashes[j] == h {
+					return nil
+				}
+				kind = Changed
+			}
+			c, err := r.recordChange(kind, i, key, h, content, row)
+			if err != nil {
+				return err
+			}
+			if !send(c) {
+				return errStopped
+			}
+			return nil
+		})
+		if err != nil {
+			if err != errStopped {
+				s.err = err
+			}
+			return
+		}
+		for j, key := range m.Keys {
+			if !seen[j] && !send(Change{Kind: Deleted, Key: key, Index: -1, Hash: m.Hashes[j]}) {
+				return
+			}
+		}
+	}()
+	return s, nil
+}
+
+// errStopped stops eachRecordHash when the ChangeStream is closed.
+var errStopped = errors.New("Change stream closed")
+
+// recordChange returns the Change of the i-th record from its content and
+// DBF row.
+func (r *Reader) recordChange(kind ChangeKind, i int, key string, h RecordHash, content, row []byte) (Change, error) {
+	c := Change{Kind: kind, Key: key, Index: i, Hash: h}
+	if len(content) >= 4 {
+		t := ShapeType(binary.LittleEndian.Uint32(content))
+		l, err := r.readLazy(bytes.NewReader(content[4:]), t, int64(len(content)))
+		if err != nil {
+			return c, fmt.Errorf("Error when reading record %d: %w", i, err)
+		}
+		c.Shape = l
+	}
+	if row != nil {
+		c.Attributes = make([]string, len(r.dbfFields))
+		for n := range c.Attributes {
+			c.Attributes[n] = strings.TrimRight(r.rowAttribute(row, n), "\x00")
+		}
+	}
+	return c, nil
+}
+
+// Next advances to the next change. It returns false at the end of the
+// stream or on an error, which Err returns.
+func (s *ChangeStream) Next() bool {
+	c, ok := <-s.changes
+	s.change = c
+	return ok
+}
+
+// Change returns the current change.
+func (s *ChangeStream) Change() Change {
+	return s.change
+}
+
+// Err returns the first error encountered, after Next returned false.
+func (s *ChangeStream) Err() error {
+	return s.err
+}
+
+// Close stops the stream early, after which the Reader may be used again.
+func (s *ChangeStream) Close() {
+	select {
+	case <-s.done:
+		return
+	default:
+		close(s.done)
+	}
+	for range s.changes {
+	}
+}
@@ -0,0 +1,106 @@
+package shp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffSince(t *testing.T) {
+	filename := filenamePrefix + "cdc"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := r.Manifest("NAME")
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Edit(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Delete(0)
+	tx.UpdateAttr(1, 1, 26000)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	w, err := Append(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := int(w.Write(&Point{5, 6}))
+	w.WriteAttribute(n, 0, "Ogdenville")
+	w.WriteAttribute(n, 1, 1000)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	s, err := r.DiffSince(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for s.Next() {
+		c := s.Change()
+		line := c.Kind.String() + " " + c.Key
+		if c.Shape != nil {
+			line += " " + strings.TrimSpace(c.Attributes[1])
+		}
+		got = append(got, line)
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	want := []string{"changed Shelbyville 26000", "added Ogdenville 1000", "deleted Springfield"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+
+	// an unchanged shapefile has no changes
+	m, err = r.Manifest("NAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = r.DiffSince(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Next() {
+		t.Errorf("unexpected change %+v", s.Change())
+	}
+}
+
+func TestDiffSinceClose(t *testing.T) {
+	filename := filenamePrefix + "cdc_close"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	s, err := r.DiffSince(&Manifest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Next() || s.Change().Kind != Added {
+		t.Fatalf("first change = %+v, want an added record", s.Change())
+	}
+	s.Close()
+	s.Close()
+	if _, err := r.ShapeAt(1); err != nil {
+		t.Errorf("ShapeAt after Close: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package shp
+
+import "fmt"
+
+// geohashAlphabet is the base 32 alphabet of geohashes.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash returns the geohash of p, whose X is the longitude and Y the
+// latitude in degrees, with precision characters. Points that share a prefix
+// of their geohashes are close to each other, which makes geohashes useful
+// for bucketing and joining records in data warehouses. The geohash is empty
+// if precision is not positive.
+func Geohash(p Point, precision int) string {
+	if precision <= 0 {
+		return ""
+	}
+	lon := [2]float64{-180, 180}
+	lat := [2]float64{-90, 90}
+	hash := make([]byte, precision)
+	even := true
+	for i := range hash {
+		var c byte
+		for bit := 4; bit >= 0; bit-- {
+			r, v := &lat, p.Y
+			if even {
+				r, v = &lon, p.X
+			}
+			mid := (r[0] + r[1]) / 2
+			if v >= mid {
+				c |= 1 << bit
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+		hash[i] = geohashAlphabet[c]
+	}
+	return string(hash)
+}
+
+// CellFunc returns the ID of the cell of a discrete global grid that contains
+// p at the level. Geohash is a CellFunc; S2 or H3 libraries can be adapted,
+// e.g. for github.com/golang/geo/s2:
+//
+//	func(p shp.Point, level int) string {
+//		ll := s2.LatLngFromDegrees(p.Y, p.X)
+//		return s2.CellIDFromLatLng(ll).Parent(level).ToToken()
+//	}
+type CellFunc func(p Point, level int) string
+
+// CellFieldName is the name of the attribute added by AssignCells.
+const CellFieldName = "CELL"
+
+// AssignCells returns a SequentialReader that reads sr and adds an attribute
+// named CellFieldName with the ID of the cell at the level that contains each
+// shape, as returned by cell. It is the geohash with level characters if cell
+// is nil. Points are assigned by their location, other shapes by the center of
+// their bounding box, and Null shapes get an empty ID. The added field is a
+// character field of level characters for geohashes and 32 otherwise. An
+// error is returned if level is negative, or if cell is nil and level is not
+// between 1 and 255, the largest size of a character field.
+func AssignCells(sr SequentialReader, level int, cell CellFunc) (SequentialReader, error) {
+	if level < 0 {
+		return nil, fmt.Errorf("Invalid cell level %d", level)
+	}
+	size := 32
+	if cell == nil {
+		if level < 1 || level > 255 {
+			return nil, fmt.Errorf("Invalid geohash precision %d, must be between 1 and 255", level)
+		}
+		cell = Geohash
+		size = level
+	}
+	return AddComputedField(sr, StringField(CellFieldName, uint8(size)), func(r Record) any {
+		switch p := r.Shape.(type) {
+		case nil, *Null:
+			return ""
+		case *Point:
+			return cell(*p, level)
+		}
+		b := r.Shape.BBox()
+		return cell(Point{(b.MinX + b.MaxX) / 2, (b.MinY + b.MaxY) / 2}, level)
+	}), nil
+}
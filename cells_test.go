@@ -0,0 +1,70 @@
+package shp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGeohash(t *testing.T) {
+	for _, c := range []struct {
+		p         Point
+		precision int
+		want      string
+	}{
+		{Point{-5.6, 42.6}, 5, "ezs42"},
+		{Point{10.40744, 57.64911}, 11, "u4pruydqqvj"},
+		{Point{0, 0}, 1, "s"},
+		{Point{0, 0}, 0, ""},
+		{Point{0, 0}, -1, ""},
+	} {
+		if got := Geohash(c.p, c.precision); got != c.want {
+			t.Errorf("got %q for %v, want %q", got, c.p, c.want)
+		}
+	}
+}
+
+func TestAssignCells(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr, err := AssignCells(SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t)), 6, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Close()
+	fields := sr.Fields()
+	if len(fields) != 4 || fields[3].String() != CellFieldName {
+		t.Fatalf("got fields %v", fields)
+	}
+	for sr.Next() {
+		_, s := sr.Shape()
+		if got, want := sr.Attribute(3), Geohash(*s.(*Point), 6); got != want {
+			t.Errorf("got cell %q, want %q", got, want)
+		}
+	}
+
+	custom := func(p Point, level int) string { return fmt.Sprintf("%d:%.0f", level, p.X) }
+	sr, err = AssignCells(SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t)), 3, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Close()
+	batch, err := NextBatch(sr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := batch[0].Shape
+	if got, want := batch[0].Attributes[3], custom(*s.(*Point), 3); got != want {
+		t.Errorf("got cell %q, want %q", got, want)
+	}
+
+	for _, c := range []struct {
+		level int
+		cell  CellFunc
+	}{{0, nil}, {-1, nil}, {256, nil}, {-1, custom}} {
+		if _, err := AssignCells(sr, c.level, c.cell); err == nil {
+			t.Errorf("got no error for level %d", c.level)
+		}
+	}
+}
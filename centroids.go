@@ -0,0 +1,44 @@
+package shp
+
+// CentroidMode selects the point written for each shape by Centroids.
+type CentroidMode int
+
+const (
+	// TrueCentroid is the center of mass as returned by Centroid, which may
+	// lie outside of concave polygons and off curved polylines.
+	TrueCentroid CentroidMode = iota
+	// InsidePoint is a point on the shape as returned by RepresentativePoint.
+	InsidePoint
+)
+
+// Centroids writes a point for every shape read from src to dst, which must
+// have been created with shape type POINT and without fields, as selected by
+// mode, together with the attributes of the shape. Shapes without points, such
+// as Null shapes, are skipped. It returns the first error of src or of writing
+// the attributes.
+func Centroids(src SequentialReader, dst *Writer, mode CentroidMode) error {
+	point := Centroid
+	if mode == InsidePoint {
+		point = RepresentativePoint
+	}
+	fields := src.Fields()
+	if len(fields) > 0 {
+		if err := dst.SetFields(fields); err != nil {
+			return err
+		}
+	}
+	for src.Next() {
+		_, s := src.Shape()
+		p, ok := point(s)
+		if !ok {
+			continue
+		}
+		row := int(dst.Write(&p))
+		for i := range fields {
+			if err := dst.WriteAttribute(row, i, src.Attribute(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return src.Err()
+}
@@ -0,0 +1,64 @@
+package shp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCentroids(t *testing.T) {
+	src := filenamePrefix + "centroids_src"
+	dst := filenamePrefix + "centroids_dst"
+	defer removeShapefile(src)
+	defer removeShapefile(dst)
+
+	w, err := Create(src+".shp", POLYGON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetFields([]Field{StringField("NAME", 10)}); err != nil {
+		t.Fatal(err)
+	}
+	u := NewPolygon([][]Point{{
+		{0, 0}, {0, 3}, {1, 3}, {1, 1}, {2, 1}, {2, 3}, {3, 3}, {3, 0}, {0, 0},
+	}})
+	w.WriteAttribute(int(w.Write(u)), 0, "u")
+	w.WriteAttribute(int(w.Write(&Null{})), 0, "null")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []CentroidMode{TrueCentroid, InsidePoint} {
+		w, err := Create(dst+".shp", POINT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sr := SequentialReaderFromExt(openFile(src+".shp", t), openFile(src+".dbf", t))
+		if err := Centroids(sr, w, mode); err != nil {
+			t.Fatal(err)
+		}
+		sr.Close()
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := Open(dst + ".shp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Next() {
+			t.Fatalf("mode %d: no points", mode)
+		}
+		_, s := r.Shape()
+		p := *s.(*Point)
+		if got := strings.TrimRight(r.ReadAttribute(0, 0), "\x00"); got != "u" {
+			t.Errorf("mode %d: got NAME %q, want u", mode, got)
+		}
+		if inside := PointInPolygon(p, u); inside != (mode == InsidePoint) {
+			t.Errorf("mode %d: got point %v, inside %v", mode, p, inside)
+		}
+		if r.Next() {
+			t.Errorf("mode %d: got a point for the Null shape", mode)
+		}
+		r.Close()
+	}
+}
@@ -0,0 +1,63 @@
+package shp
+
+import (
+	"context"
+)
+
+// ToChannel reads all records of sr in a new goroutine and sends them on the
+// returned record channel, which has a buffer of buf records. Reading blocks
+// while the buffer is full, so a slow consumer slows down the reader instead
+// of records piling up in memory. The record channel is closed at the end of
+// the file or on the first error; the error channel then receives the error,
+// if any, and is closed as well. The consumer must drain the record channel,
+// or use ToChannelContext to be able to stop early. sr is not closed.
+func ToChannel(sr SequentialReader, buf int) (<-chan Record, <-chan error) {
+	return ToChannelContext(context.Background(), sr, buf)
+}
+
+// ToChannelContext is like ToChannel, but stops reading when ctx is done, in
+// which case the error channel receives the error of ctx.
+func ToChannelContext(ctx context.Context, sr SequentialReader, buf int) (<-chan Record, <-chan error) {
+	records := make(chan Record, buf)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(records)
+		numFields := len(sr.Fields())
+		for sr.Next() {
+			index, shape := sr.Shape()
+			rec := Record{Index: index, Shape: shape, Attributes: make([]string, numFields)}
+			for i := range rec.Attributes {
+				rec.Attributes[i] = sr.Attribute(i)
+			}
+			select {
+			case records <- rec:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+		if err := sr.Err(); err != nil {
+			errc <- err
+		}
+	}()
+	return records, errc
+}
+
+// FromChannel writes the records received on records with w until the
+// channel is closed. The attributes of each record are written to the fields
+// of w in order, so SetFields must have been called before if there are any;
+// the Index of the records is ignored. On the first error FromChannel returns
+// without draining the channel, so the producer should be stopped then, e.g.
+// by canceling the context passed to ToChannelContext. w is not closed.
+func FromChannel(w *Writer, records <-chan Record) error {
+	for rec := range records {
+		row := int(w.Write(rec.Shape))
+		for i, v := range rec.Attributes {
+			if err := w.WriteAttribute(row, i, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package shp
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChannelRoundTrip(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+	defer sr.Close()
+	records, errc := ToChannel(sr, 0)
+
+	out := filepath.Join(t.TempDir(), "copy.shp")
+	w, err := Create(out, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields(sr.Fields())
+	if err := FromChannel(w, records); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	for r.Next() {
+		names = append(names, strings.TrimRight(r.Attribute(0), "\x00"))
+	}
+	if got := strings.Join(names, ","); got != "Springfield,Shelbyville" {
+		t.Errorf("got attributes %q", got)
+	}
+}
+
+func TestToChannelCancel(t *testing.T) {
+	sr := SequentialReaderFromExt(openFile("test_files/point.shp", t), openFile("test_files/point.dbf", t))
+	defer sr.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	records, errc := ToChannelContext(ctx, sr, 0)
+	if rec, ok := <-records; !ok || rec.Index != 0 {
+		t.Fatalf("got record %+v, %v", rec, ok)
+	}
+	cancel()
+	// the reader is blocked on sending the second record
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if _, ok := <-records; ok {
+		t.Error("record channel not closed")
+	}
+}
@@ -0,0 +1,56 @@
+package shp
+
+import (
+	"fmt"
+	"io"
+)
+
+// CheckpointToken is a position in a shapefile that reading can be resumed
+// from. Its fields are exported, so it can be stored, e.g. as JSON, by jobs
+// that need to survive restarts.
+type CheckpointToken struct {
+	Offset int64 // byte offset of the next record in the SHP file
+	Row    int32 // index of the next row in the DBF file
+}
+
+// Checkpoint implements Checkpointer for seqReader.
+func (sr *seqReader) Checkpoint() CheckpointToken {
+	return CheckpointToken{Offset: sr.pos, Row: sr.records}
+}
+
+// ResumeFrom returns a new SequentialReader like SequentialReaderFromExt that
+// starts with the record at t. shp and dbf must be the same files that t was
+// taken from, opened at their beginning. The headers are read as usual, then
+// the SHP file is skipped to t.Offset (seeking if possible) and the DBF file
+// is advanced by t.Row rows.
+func ResumeFrom(shp, dbf io.ReadCloser, t CheckpointToken, opts ...ReaderOption) SequentialReader {
+	sr := newSeqReader(shp, dbf, opts)
+	sr.readHeaders()
+	if sr.err != nil {
+		return sr
+	}
+	if !sr.skipGeometry {
+		if t.Offset < sr.pos {
+			sr.err = fmt.Errorf("Invalid checkpoint offset %d", t.Offset)
+			return sr
+		}
+		if err := sr.skip(t.Offset - sr.pos); err != nil {
+			sr.err = fmt.Errorf("Error when resuming at offset %d: %v", t.Offset, err)
+			return sr
+		}
+		sr.pos = t.Offset
+	}
+	if sr.db != nil {
+		for i := int32(0); i < t.Row; i++ {
+			if err := sr.nextDbfRow(); err != nil {
+				sr.err = fmt.Errorf("Error when resuming at DBF row %d: %v", t.Row, err)
+				return sr
+			}
+		}
+	}
+	sr.records = t.Row
+	if sr.skipGeometry {
+		sr.num = t.Row
+	}
+	return sr
+}
@@ -0,0 +1,47 @@
+package shp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResumeFrom(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+	if !sr.Next() {
+		t.Fatal(sr.Err())
+	}
+	b, err := json.Marshal(sr.(Checkpointer).Checkpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr.Close()
+
+	var token CheckpointToken
+	if err := json.Unmarshal(b, &token); err != nil {
+		t.Fatal(err)
+	}
+	if token != (CheckpointToken{Offset: 128, Row: 1}) {
+		t.Errorf("got token %+v", token)
+	}
+	sr = ResumeFrom(openFile(filename+".shp", t), openFile(filename+".dbf", t), token)
+	defer sr.Close()
+	var rows []city
+	for sr.Next() {
+		_, shape := sr.Shape()
+		rows = append(rows, city{Geometry: shape.(*Point), Name: strings.TrimRight(sr.Attribute(0), "\x00")})
+	}
+	if err := sr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Name != "Shelbyville" || *rows[0].Geometry != (Point{3, 4}) {
+		t.Errorf("got %+v after resuming", rows)
+	}
+	if n, _ := sr.Shape(); n != 1 {
+		t.Errorf("got index %d after resuming, want 1", n)
+	}
+}
@@ -0,0 +1,131 @@
+package shp
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// MD5Digester is implemented by a RangeReader that knows the MD5 digest of
+// its content, such as an object whose ETag is its digest. MD5 returns nil if
+// the digest is not known.
+type MD5Digester interface {
+	MD5() []byte
+}
+
+// ChecksumError is returned when the content of a file does not match its
+// digest.
+type ChecksumError struct {
+	Name     string
+	Expected []byte
+	Actual   []byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("MD5 of %s is %x, expected %x", e.Name, e.Actual, e.Expected)
+}
+
+// VerifyChecksums makes OpenRangeSequential compute the MD5 digest of the SHP
+// and DBF files while they are read and compare it with the digest of the
+// objects, see MD5Digester, so that truncated or corrupted downloads fail
+// instead of being processed silently. Objects whose digest is not known,
+// such as S3 objects uploaded in multiple parts or encrypted with SSE-KMS or
+// SSE-C, are not verified.
+func VerifyChecksums() ReaderOption {
+	return func(o *readerOptions) {
+		o.verifyChecksums = true
+	}
+}
+
+// ExpectMD5 makes OpenRangeSequential verify the file with the extension ext,
+// ".shp" or ".dbf", against digest, the hex encoded MD5 digest that was
+// published with the data.
+func ExpectMD5(ext, digest string) ReaderOption {
+	return func(o *readerOptions) {
+		if o.expectMD5 == nil {
+			o.expectMD5 = map[string]string{}
+		}
+		o.expectMD5[strings.ToLower(ext)] = digest
+	}
+}
+
+// expectedMD5 returns the digest that the file with extension ext, read
+// from rr, is verified against, or nil.
+func (o readerOptions) expectedMD5(ext string, rr RangeReader) ([]byte, error) {
+	if digest, ok := o.expectMD5[ext]; ok {
+		sum, err := hex.DecodeString(digest)
+		if err != nil || len(sum) != md5.Size {
+			return nil, fmt.Errorf("Invalid MD5 digest %q", digest)
+		}
+		return sum, nil
+	}
+	if d, ok := rr.(MD5Digester); ok && o.verifyChecksums {
+		return d.MD5(), nil
+	}
+	return nil, nil
+}
+
+// verifiedReader computes the MD5 digest of a file of known size while it is
+// read. Once all of it was read, or when it is closed, a mismatch is returned
+// as a ChecksumError.
+type verifiedReader struct {
+	io.ReadCloser
+	name     string
+	size     int64
+	read     int64
+	hash     hash.Hash
+	expected []byte
+	err      error
+}
+
+func newVerifiedReader(rc io.ReadCloser, name string, size int64, expected []byte) *verifiedReader {
+	return &verifiedReader{ReadCloser: rc, name: name, size: size, hash: md5.New(), expected: expected}
+}
+
+func (r *verifiedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	r.read += int64(n)
+	if r.read >= r.size || err == io.EOF {
+		if r.err = r.verify(); r.err != nil {
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+// verify compares the digest of the bytes read with the expected one.
+func (r *verifiedReader) verify() error {
+	if r.read != r.size {
+		return fmt.Errorf("Read %d bytes of %s, expected %d", r.read, r.name, r.size)
+	}
+	if sum := r.hash.Sum(nil); !bytes.Equal(sum, r.expected) {
+		return &ChecksumError{Name: r.name, Expected: r.expected, Actual: sum}
+	}
+	return nil
+}
+
+// Close reads the rest of the file, which the shapefile reader may have left
+// unread, verifies it and closes it.
+func (r *verifiedReader) Close() error {
+	if r.err == nil && r.read < r.size {
+		_, r.err = io.Copy(io.Discard, readerFunc(r.Read))
+	}
+	if err := r.ReadCloser.Close(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
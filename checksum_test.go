@@ -0,0 +1,140 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// etagServer serves the files of dir with their MD5 digest as ETag, or a
+// wrong one for the files in corrupt.
+func etagServer(t *testing.T, dir string, corrupt ...string) *httptest.Server {
+	files := http.FileServer(http.Dir(dir))
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.Base(r.URL.Path)))
+		if err == nil {
+			sum := md5.Sum(data)
+			for _, c := range corrupt {
+				if c == filepath.Base(r.URL.Path) {
+					sum[0]++
+				}
+			}
+			w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sum))
+		}
+		files.ServeHTTP(w, r)
+	}))
+}
+
+func readAllRecords(t *testing.T, sr SequentialReader) error {
+	for sr.Next() {
+	}
+	if err := sr.Err(); err != nil {
+		sr.Close()
+		return err
+	}
+	return sr.Close()
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	dir := t.TempDir()
+	writeCities(t, filepath.Join(dir, "cities"))
+	srv := etagServer(t, dir)
+	defer srv.Close()
+
+	sr, err := OpenRangeSequential(HTTPOpener(srv.Client(), srv.URL), "cities", VerifyChecksums())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := readAllRecords(t, sr); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVerifyChecksumsMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writeCities(t, filepath.Join(dir, "cities"))
+	for _, name := range []string{"cities.shp", "cities.dbf"} {
+		srv := etagServer(t, dir, name)
+		sr, err := OpenRangeSequential(HTTPOpener(srv.Client(), srv.URL), "cities", VerifyChecksums())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ce *ChecksumError
+		if err := readAllRecords(t, sr); !errors.As(err, &ce) {
+			t.Errorf("%s: err = %v, want a ChecksumError", name, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestExpectMD5(t *testing.T) {
+	dir := t.TempDir()
+	writeCities(t, filepath.Join(dir, "cities"))
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+	data, err := os.ReadFile(filepath.Join(dir, "cities.shp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+	open := HTTPOpener(srv.Client(), srv.URL)
+
+	sr, err := OpenRangeSequential(open, "cities", ExpectMD5(".shp", hex.EncodeToString(sum[:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := readAllRecords(t, sr); err != nil {
+		t.Error(err)
+	}
+
+	sum[1]++
+	sr, err = OpenRangeSequential(open, "cities", ExpectMD5(".SHP", hex.EncodeToString(sum[:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ce *ChecksumError
+	if err := readAllRecords(t, sr); !errors.As(err, &ce) {
+		t.Errorf("err = %v, want a ChecksumError", err)
+	}
+
+	if _, err := OpenRangeSequential(open, "cities", ExpectMD5(".shp", "abc")); err == nil {
+		t.Error("expected an error for an invalid digest")
+	}
+}
+
+func TestMD5FromHeader(t *testing.T) {
+	sum := md5.Sum([]byte("data"))
+	for _, h := range []http.Header{
+		{"Etag": {fmt.Sprintf(`"%x"`, sum)}},
+		{"X-Goog-Hash": {"crc32c=n03x6A==, md5=jXd/OF09/siBXSD3SWAm3A=="}},
+	} {
+		if got := md5FromHeader(h); hex.EncodeToString(got) != hex.EncodeToString(sum[:]) {
+			t.Errorf("md5FromHeader(%v) = %x", h, got)
+		}
+	}
+	if got := md5FromHeader(http.Header{"Etag": {`"abc-2"`}}); got != nil {
+		t.Errorf("multipart ETag gave %x", got)
+	}
+	etag := fmt.Sprintf(`"%x"`, sum)
+	for _, h := range []http.Header{
+		{"Etag": {etag}, "X-Amz-Server-Side-Encryption": {"aws:kms"}},
+		{"Etag": {etag}, "X-Amz-Server-Side-Encryption": {"aws:kms:dsse"}},
+		{"Etag": {etag}, "X-Amz-Server-Side-Encryption-Customer-Algorithm": {"AES256"}},
+	} {
+		if got := md5FromHeader(h); got != nil {
+			t.Errorf("md5FromHeader(%v) = %x, want nil", h, got)
+		}
+	}
+	h := http.Header{"Etag": {etag}, "X-Amz-Server-Side-Encryption": {"AES256"}}
+	if got := md5FromHeader(h); hex.EncodeToString(got) != hex.EncodeToString(sum[:]) {
+		t.Errorf("SSE-S3 ETag gave %x", got)
+	}
+}
@@ -0,0 +1,182 @@
+package shp
+
+import "math"
+
+// CleanShape returns a copy of s with the vertices that are within tolerance
+// of the vertex before them removed, spikes collapsed and degenerate parts
+// dropped. A spike is a vertex where a part goes out and comes back to within
+// tolerance of where it came from. Polylines keep parts with at least two
+// vertices and polygons rings with at least three distinct vertices and an
+// area larger than tolerance squared. Shapes without any part left become
+// Null shapes. Multipoints lose their consecutive duplicate points, while
+// points, Null shapes and multipatches are returned as they are. Z and M
+// values are kept with their vertices.
+func CleanShape(s Shape, tolerance float64) Shape {
+	a, ok := arraysOf(s)
+	if !ok || a.partTypes != nil {
+		return s
+	}
+
+	var keep [][]int
+	if a.parts == nil {
+		keep = append(keep, dedupe(a.points, seq(0, len(a.points)), tolerance))
+	} else {
+		for i := range a.parts {
+			// corrupt part offsets are clamped like by part
+			start := clampIndex(a.parts[i], len(a.points))
+			end := start + len(part(a.parts, a.points, i))
+			if idx := cleanPart(a.points, seq(start, end), tolerance, a.rings); idx != nil {
+				keep = append(keep, idx)
+			}
+		}
+		if len(keep) == 0 {
+			return &Null{}
+		}
+	}
+
+	var parts []int32
+	if a.parts != nil {
+		parts = make([]int32, len(keep))
+	}
+	var points []Point
+	var z, m []float64
+	if a.z != nil {
+		z = []float64{}
+	}
+	if a.m != nil {
+		m = []float64{}
+	}
+	for i, idx := range keep {
+		if parts != nil {
+			parts[i] = int32(len(points))
+		}
+		for _, j := range idx {
+			points = append(points, a.points[j])
+			if z != nil {
+				z = append(z, valueAt(a.z, j))
+			}
+			if m != nil {
+				m = append(m, valueAt(a.m, j))
+			}
+		}
+	}
+	if len(points) == 0 {
+		return &Null{}
+	}
+	return withArrays(s, parts, nil, points, z, m)
+}
+
+// seq returns the indices from start to end, none if end is before start.
+func seq(start, end int) []int {
+	idx := make([]int, 0, max(end-start, 0))
+	for i := start; i < end; i++ {
+		idx = append(idx, i)
+	}
+	return idx
+}
+
+// near returns whether p and q are within tolerance of each other.
+func near(p, q Point, tolerance float64) bool {
+	return math.Hypot(q.X-p.X, q.Y-p.Y) <= tolerance
+}
+
+// dedupe removes the indices of the points that are near the point before
+// them.
+func dedupe(points []Point, idx []int, tolerance float64) []int {
+	out := idx[:0]
+	for _, j := range idx {
+		if len(out) == 0 || !near(points[out[len(out)-1]], points[j], tolerance) {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// cleanPart returns the indices of the vertices to keep of the part idx, or
+// nil if the part is degenerate. Rings are cleaned without their closing
+// vertex, which is added again at the end.
+func cleanPart(points []Point, idx []int, tolerance float64, ring bool) []int {
+	if ring && len(idx) > 1 && near(points[idx[0]], points[idx[len(idx)-1]], tolerance) {
+		idx = idx[:len(idx)-1]
+	}
+	for changed := true; changed; {
+		idx = dedupe(points, idx, tolerance)
+		changed = false
+		n := len(idx)
+		for k := 0; k < n && n >= 3; k++ {
+			if !ring && (k == 0 || k == n-1) {
+				continue
+			}
+			prev, next := (k+n-1)%n, (k+1)%n
+			if near(points[idx[prev]], points[idx[next]], tolerance) {
+				// drop the spike and the vertex after it, which is back
+				// where the spike started
+				idx = removeIndices(idx, k, next)
+				changed = true
+				break
+			}
+		}
+	}
+
+	if !ring {
+		if len(idx) < 2 {
+			return nil
+		}
+		return idx
+	}
+	if len(idx) < 3 {
+		return nil
+	}
+	closed := append(idx, idx[0])
+	ringPoints := make([]Point, len(closed))
+	for i, j := range closed {
+		ringPoints[i] = points[j]
+	}
+	if math.Abs(signedArea(ringPoints)) <= tolerance*tolerance {
+		return nil
+	}
+	return closed
+}
+
+// removeIndices removes the elements at positions i and j of idx.
+func removeIndices(idx []int, i, j int) []int {
+	out := idx[:0:0]
+	for k, v := range idx {
+		if k != i && k != j {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// CleanShapes returns a SequentialReader that reads sr and cleans every shape
+// with CleanShape, to sanitize shapes before writing or indexing them.
+func CleanShapes(sr SequentialReader, tolerance float64) SequentialReader {
+	return &cleanReader{SequentialReader: sr, tolerance: tolerance}
+}
+
+// cleanReader cleans the shapes of a SequentialReader.
+type cleanReader struct {
+	SequentialReader
+	tolerance float64
+}
+
+// Shape implements a method of interface SequentialReader for cleanReader.
+func (c *cleanReader) Shape() (int, Shape) {
+	n, s := c.SequentialReader.Shape()
+	if s == nil {
+		return n, s
+	}
+	return n, CleanShape(s, c.tolerance)
+}
+
+// NextBatch implements BatchReader for cleanReader.
+func (c *cleanReader) NextBatch(n int) ([]Record, error) {
+	batch, err := NextBatch(c.SequentialReader, n)
+	for i := range batch {
+		if batch[i].Shape != nil {
+			batch[i].Shape = CleanShape(batch[i].Shape, c.tolerance)
+		}
+	}
+	return batch, err
+}
@@ -0,0 +1,60 @@
+package shp
+
+import "testing"
+
+func TestCleanShape(t *testing.T) {
+	polygon := &PolygonM{
+		NumParts:  2,
+		NumPoints: 12,
+		Parts:     []int32{0, 8},
+		Points: []Point{
+			// a square with a duplicate vertex and a spike to (0, 9)
+			{0, 0}, {0, 0.001}, {0, 2}, {0, 9}, {0, 2.001}, {2, 2}, {2, 0}, {0, 0},
+			// a sliver ring
+			{5, 5}, {5, 6}, {5.0001, 5}, {5, 5},
+		},
+		MArray: []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	}
+	c := CleanShape(polygon, 0.01).(*PolygonM)
+	want := []Point{{0, 0}, {0, 2}, {2, 2}, {2, 0}, {0, 0}}
+	if c.NumParts != 1 || len(c.Points) != len(want) {
+		t.Fatalf("got parts %v and points %v", c.Parts, c.Points)
+	}
+	for i := range want {
+		if c.Points[i] != want[i] {
+			t.Errorf("got point %d %v, want %v", i, c.Points[i], want[i])
+		}
+	}
+	if m := []float64{0, 2, 5, 6, 0}; c.MArray[1] != m[1] || c.MArray[2] != m[2] || c.MArray[4] != m[4] {
+		t.Errorf("got measures %v, want %v", c.MArray, m)
+	}
+	if c.Box != (Box{0, 0, 2, 2}) || c.MRange != [2]float64{0, 6} {
+		t.Errorf("got box %v and range %v", c.Box, c.MRange)
+	}
+
+	line := NewPolyLine([][]Point{{{0, 0}, {1, 0}, {1, 0}, {2, 0}}, {{3, 3}, {3, 3}}})
+	if c := CleanShape(line, 0).(*PolyLine); c.NumParts != 1 || c.NumPoints != 3 {
+		t.Errorf("got line parts %v and points %v", c.Parts, c.Points)
+	}
+	if _, ok := CleanShape(NewPolyLine([][]Point{{{1, 1}, {1, 1}}}), 0).(*Null); !ok {
+		t.Error("a degenerate line did not become a Null shape")
+	}
+	mp := PointsToMultiPoint([]Point{{0, 0}, {0, 0}, {1, 1}, {0, 0}})
+	if c := CleanShape(mp, 0).(*MultiPoint); c.NumPoints != 3 {
+		t.Errorf("got multipoint %v", c.Points)
+	}
+}
+
+func TestCleanShapeCorruptParts(t *testing.T) {
+	points := []Point{{0, 0}, {0, 2}, {2, 2}, {2, 0}, {0, 0}}
+	for _, parts := range [][]int32{
+		{0, 9},  // past the points
+		{3, 1},  // decreasing
+		{-2, 5}, // negative and at the end
+	} {
+		s := CleanShape(&Polygon{NumParts: 2, NumPoints: 5, Parts: parts, Points: points}, 0.01)
+		if err := CheckShapeInvariants(s); err != nil {
+			t.Errorf("parts %v: got %+v: %v", parts, s, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !shp_minimal
+
+// Command shpgen generates Go struct types or JSON Schemas from the attribute
+// schema of a shapefile, so that typed code stays in sync with the data:
+//
+//	shpgen -type City -package cities -o city.go cities.shp
+//	shpgen -json -o cities.schema.json cities.shp
+//
+// It is suited to go:generate directives.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func main() {
+	jsonSchema := flag.Bool("json", false, "generate a JSON Schema instead of Go code")
+	typeName := flag.String("type", "Record", "name of the generated Go type")
+	pkg := flag.String("package", "main", "package of the generated Go code")
+	out := flag.String("o", "", "output file, standard output if empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: shpgen [flags] file.shp\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *jsonSchema, *pkg, *typeName, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "shpgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(filename string, jsonSchema bool, pkg, typeName, out string) error {
+	r, err := shp.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var src []byte
+	if jsonSchema {
+		title := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		src, err = shp.GenerateJSONSchema(title, r.Fields())
+	} else {
+		src, err = shp.GenerateStruct(pkg, typeName, r.Fields(), r.GeometryType)
+	}
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
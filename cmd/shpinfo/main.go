@@ -0,0 +1,58 @@
+//go:build !shp_minimal
+
+// Command shpinfo prints the geometry type, number of records, extent and
+// fields of shapefiles:
+//
+//	shpinfo cities.shp
+//	shpinfo -preview cities.html cities.shp
+//
+// With -preview, it also writes an HTML file with a map of the shapefile, see
+// server.Preview, for an instant visual check.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	shp "github.com/brianolson/go-shp"
+	"github.com/brianolson/go-shp/server"
+)
+
+func main() {
+	preview := flag.String("preview", "", "write an HTML map of the shapefile to this file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: shpinfo [flags] file.shp\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(os.Stdout, flag.Arg(0), *preview); err != nil {
+		fmt.Fprintln(os.Stderr, "shpinfo:", err)
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, filename, preview string) error {
+	r, err := shp.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	box := r.BBox()
+	fmt.Fprintf(w, "Geometry type: %v\n", r.GeometryType)
+	fmt.Fprintf(w, "Records:       %d\n", r.AttributeCount())
+	fmt.Fprintf(w, "Extent:        %g, %g - %g, %g\n", box.MinX, box.MinY, box.MaxX, box.MaxY)
+	fmt.Fprintf(w, "Fields:\n")
+	for _, f := range r.Fields() {
+		fmt.Fprintf(w, "  %-11s %c(%d,%d)\n", f.String(), f.Fieldtype, f.Size, f.Precision)
+	}
+	if preview == "" {
+		return nil
+	}
+	return server.Preview(filename, preview)
+}
@@ -0,0 +1,132 @@
+//go:build !shp_minimal
+
+// Command shpreport writes a report about each shapefile, ready to attach to
+// a data delivery: its validation findings, schema and field statistics, a
+// map of its extent, its coordinate system and encoding, and the sizes and
+// SHA-256 hashes of its files.
+//
+//	shpreport parcels.shp roads.shp
+//	shpreport -json -d reports parcels.shp
+//
+// The report of parcels.shp is written to parcels.report.html, or to
+// parcels.report.json with -json, next to it or in the directory given with
+// -d.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "write JSON instead of HTML reports")
+	dir := flag.String("d", "", "directory of the reports, next to the shapefiles if empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: shpreport [flags] file.shp...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	failed := false
+	for _, filename := range flag.Args() {
+		out, err := run(filename, *dir, *asJSON)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "shpreport: %s: %v\n", filename, err)
+			failed = true
+			continue
+		}
+		fmt.Println(out)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// run writes the report of filename and returns its path.
+func run(filename, dir string, asJSON bool) (string, error) {
+	rep, err := analyze(filename)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	ext := ".report.html"
+	if asJSON {
+		ext = ".report.json"
+		enc := json.NewEncoder(&b)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rep)
+	} else {
+		err = reportTemplate.Execute(&b, rep)
+	}
+	if err != nil {
+		return "", err
+	}
+	out := strings.TrimSuffix(filename, filepath.Ext(filename)) + ext
+	if dir != "" {
+		out = filepath.Join(dir, filepath.Base(out))
+	}
+	return out, os.WriteFile(out, b.Bytes(), 0o644)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"svg": func(s string) template.HTML { return template.HTML(s) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Report of {{.File}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+td.num { text-align: right; }
+.wkt { font-family: monospace; font-size: small; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.File}}</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Geometry type</th><td>{{.GeometryType}}</td></tr>
+<tr><th>Records</th><td>{{.Records}}</td></tr>
+<tr><th>Extent</th><td>{{.Extent.MinX}}, {{.Extent.MinY}} &ndash; {{.Extent.MaxX}}, {{.Extent.MaxY}}</td></tr>
+<tr><th>Coordinate system</th><td>{{if .CRS.EPSG}}EPSG:{{.CRS.EPSG}}{{else if .CRS.WKT}}not identified{{else}}unknown{{end}}{{if .CRS.Geographic}}, geographic{{end}}{{with .CRS.WKT}}<div class="wkt">{{.}}</div>{{end}}</td></tr>
+<tr><th>Encoding</th><td>{{.Encoding}}</td></tr>
+<tr><th>DBF</th><td>version {{.DBF.Version}}{{with .DBF.LastUpdate}}, last updated {{.}}{{end}}</td></tr>
+</table>
+
+<h2>Map</h2>
+{{svg .Thumbnail}}
+
+<h2>Validation</h2>
+{{if .Findings}}<ul>
+{{range .Findings}}<li>{{.}}</li>
+{{end}}</ul>
+{{if .MoreFindings}}<p>and {{.MoreFindings}} more findings</p>{{end}}
+{{else}}<p>No findings.</p>
+{{end}}
+<h2>Fields</h2>
+<table>
+<tr><th>Name</th><th>Type</th><th>Size</th><th>Precision</th><th>Distinct</th><th>Empty</th><th>Max length</th><th>Min</th><th>Max</th></tr>
+{{range .Fields}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td class="num">{{.Size}}</td><td class="num">{{.Precision}}</td><td class="num">{{if .Overflow}}&ge; {{end}}{{.Distinct}}</td><td class="num">{{.Empty}}</td><td class="num">{{.MaxLength}}</td><td class="num">{{with .Min}}{{.}}{{end}}</td><td class="num">{{with .Max}}{{.}}{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Files</h2>
+<table>
+<tr><th>Name</th><th>Size</th><th>SHA-256</th></tr>
+{{range .Files}}<tr><td>{{.Name}}</td><td class="num">{{.Size}}</td><td class="wkt">{{.SHA256}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
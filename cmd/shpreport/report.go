@@ -0,0 +1,368 @@
+//go:build !shp_minimal
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// maxDistinct is the number of distinct values counted per field.
+const maxDistinct = 1000
+
+// maxFindings is the number of validation findings listed in a report.
+const maxFindings = 100
+
+// thumbnailSize is the width and height of the extent map in pixels.
+const thumbnailSize = 300
+
+// maxThumbnailShapes is the number of shapes drawn on the extent map.
+const maxThumbnailShapes = 10000
+
+// report is what shpreport finds out about a shapefile.
+type report struct {
+	File         string       `json:"file"`
+	Generated    time.Time    `json:"generated"`
+	GeometryType string       `json:"geometryType"`
+	Records      int          `json:"records"`
+	Extent       shp.Box      `json:"extent"`
+	CRS          crsReport    `json:"crs"`
+	Encoding     string       `json:"encoding"`
+	DBF          dbfReport    `json:"dbf"`
+	Fields       []fieldStats `json:"fields"`
+	Findings     []string     `json:"findings"`
+	// MoreFindings is the number of findings beyond those listed.
+	MoreFindings int          `json:"moreFindings,omitempty"`
+	Files        []fileReport `json:"files"`
+	// Thumbnail is an SVG image of the shapes, for the HTML report.
+	Thumbnail string `json:"-"`
+}
+
+type crsReport struct {
+	EPSG       int    `json:"epsg,omitempty"`
+	Geographic bool   `json:"geographic"`
+	WKT        string `json:"wkt,omitempty"`
+}
+
+type dbfReport struct {
+	Version    byte   `json:"version"`
+	LastUpdate string `json:"lastUpdate,omitempty"`
+}
+
+type fieldStats struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Size      int    `json:"size"`
+	Precision int    `json:"precision"`
+	// Distinct is the number of distinct non-empty values, at least that
+	// many if Overflow is set.
+	Distinct  int      `json:"distinct"`
+	Overflow  bool     `json:"overflow,omitempty"`
+	Empty     int      `json:"empty"`
+	MaxLength int      `json:"maxLength"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+}
+
+type fileReport struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// analyze reads the shapefile filename and returns its report.
+func analyze(filename string) (*report, error) {
+	r, err := shp.Open(filename, shp.CheckCRS())
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	rep := &report{
+		File:         filepath.Base(filename),
+		Generated:    time.Now().UTC().Truncate(time.Second),
+		GeometryType: r.GeometryType.String(),
+		Records:      r.AttributeCount(),
+		Extent:       r.BBox(),
+	}
+	for _, w := range r.Warnings() {
+		rep.addFinding(w.String())
+	}
+	if h, err := r.DBFHeader(); err == nil {
+		rep.DBF.Version = h.Version
+		if !h.LastUpdate.IsZero() {
+			rep.DBF.LastUpdate = h.LastUpdate.Format("2006-01-02")
+		}
+	}
+
+	sidecars, err := shp.FindSidecars(filename)
+	if err != nil {
+		return nil, err
+	}
+	if sidecars.PRJ != "" {
+		wkt, err := os.ReadFile(sidecars.PRJ)
+		if err != nil {
+			return nil, err
+		}
+		rep.CRS.WKT = strings.TrimSpace(string(wkt))
+		rep.CRS.EPSG, _ = shp.IdentifyEPSG(rep.CRS.WKT)
+		rep.CRS.Geographic = shp.IsGeographic(rep.CRS.WKT)
+	} else {
+		rep.addFinding("no PRJ file, the coordinate system is unknown")
+	}
+	rep.Encoding = "not declared"
+	if sidecars.CPG != "" {
+		cpg, err := os.ReadFile(sidecars.CPG)
+		if err != nil {
+			return nil, err
+		}
+		rep.Encoding = strings.TrimSpace(string(cpg))
+	}
+	for _, name := range []string{sidecars.SHP, sidecars.SHX, sidecars.DBF, sidecars.PRJ, sidecars.CPG} {
+		if name == "" {
+			continue
+		}
+		f, err := fingerprint(name)
+		if err != nil {
+			return nil, err
+		}
+		rep.Files = append(rep.Files, f)
+	}
+	if sidecars.SHX == "" {
+		rep.addFinding("no SHX file")
+	}
+	if sidecars.DBF == "" {
+		rep.addFinding("no DBF file")
+	}
+
+	if err := rep.scan(sidecars); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// addFinding adds a validation finding to rep.
+func (rep *report) addFinding(s string) {
+	if len(rep.Findings) >= maxFindings {
+		rep.MoreFindings++
+		return
+	}
+	rep.Findings = append(rep.Findings, s)
+}
+
+// scan reads all records sequentially to validate the shapes, compute the
+// field statistics and draw the thumbnail.
+func (rep *report) scan(sidecars shp.Sidecars) error {
+	shpFile, err := os.Open(sidecars.SHP)
+	if err != nil {
+		return err
+	}
+	var dbfFile io.ReadCloser = io.NopCloser(strings.NewReader(""))
+	if sidecars.DBF != "" {
+		if dbfFile, err = os.Open(sidecars.DBF); err != nil {
+			shpFile.Close()
+			return err
+		}
+	}
+	sr := &scanner{
+		SequentialReader: shp.SequentialReaderFromExt(shpFile, dbfFile, shp.ValidateShapes()),
+		thumbnail:        newThumbnail(rep.Extent),
+	}
+	defer sr.Close()
+	sr.init()
+	stats, err := shp.ColumnStatistics(sr, maxDistinct)
+	if err != nil {
+		return err
+	}
+	for i, s := range stats {
+		f := s.Field
+		fs := fieldStats{
+			Name:      f.String(),
+			Type:      string(f.Fieldtype),
+			Size:      int(f.Size),
+			Precision: int(f.Precision),
+			Distinct:  s.Distinct,
+			Overflow:  s.Overflow,
+			Empty:     s.Empty,
+			MaxLength: s.MaxLength,
+		}
+		if sr.numeric[i] {
+			fs.Min, fs.Max = &sr.min[i], &sr.max[i]
+		}
+		rep.Fields = append(rep.Fields, fs)
+	}
+	if wr, ok := sr.SequentialReader.(shp.WarningReporter); ok {
+		for _, w := range wr.Warnings() {
+			rep.addFinding(w.String())
+		}
+	}
+	var mixed map[shp.ShapeType]int
+	if mr, ok := sr.SequentialReader.(shp.MixedTypesReporter); ok {
+		mixed = mr.MixedTypes()
+	}
+	types := make([]shp.ShapeType, 0, len(mixed))
+	for t := range mixed {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	for _, t := range types {
+		rep.addFinding(fmt.Sprintf("%d records of type %v in a %s file", mixed[t], t, rep.GeometryType))
+	}
+	rep.Thumbnail = sr.thumbnail.svg()
+	return nil
+}
+
+// scanner is a SequentialReader that computes the ranges of numeric fields
+// and draws the shapes while ColumnStatistics reads it.
+type scanner struct {
+	shp.SequentialReader
+	fields    []shp.Field
+	numeric   []bool // whether a number was seen in the field
+	min, max  []float64
+	thumbnail *thumbnail
+}
+
+func (s *scanner) init() {
+	s.fields = s.Fields()
+	s.numeric = make([]bool, len(s.fields))
+	s.min = make([]float64, len(s.fields))
+	s.max = make([]float64, len(s.fields))
+}
+
+func (s *scanner) Next() bool {
+	if !s.SequentialReader.Next() {
+		return false
+	}
+	for i, f := range s.fields {
+		if f.Fieldtype != 'N' && f.Fieldtype != 'F' {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimRight(strings.TrimSpace(s.Attribute(i)), "\x00"), 64)
+		if err != nil {
+			continue
+		}
+		if !s.numeric[i] {
+			s.numeric[i], s.min[i], s.max[i] = true, v, v
+		}
+		s.min[i], s.max[i] = math.Min(s.min[i], v), math.Max(s.max[i], v)
+	}
+	_, shape := s.Shape()
+	s.thumbnail.draw(shape)
+	return true
+}
+
+// fingerprint returns the size and SHA-256 hash of the file name.
+func fingerprint(name string) (fileReport, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return fileReport{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return fileReport{}, err
+	}
+	return fileReport{Name: filepath.Base(name), Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// thumbnail draws shapes as SVG paths scaled to the extent of a shapefile.
+type thumbnail struct {
+	box    shp.Box
+	scale  float64
+	shapes int
+	paths  strings.Builder
+}
+
+func newThumbnail(box shp.Box) *thumbnail {
+	size := math.Max(box.MaxX-box.MinX, box.MaxY-box.MinY)
+	scale := 1.0
+	if size > 0 {
+		scale = thumbnailSize / size
+	}
+	return &thumbnail{box: box, scale: scale}
+}
+
+// point returns the pixel coordinates of p.
+func (t *thumbnail) point(p shp.Point) string {
+	return fmt.Sprintf("%.1f %.1f", (p.X-t.box.MinX)*t.scale, (t.box.MaxY-p.Y)*t.scale)
+}
+
+// draw adds s to the thumbnail.
+func (t *thumbnail) draw(s shp.Shape) {
+	if s == nil || t.shapes >= maxThumbnailShapes {
+		return
+	}
+	t.shapes++
+	var parts []int32
+	var points []shp.Point
+	class := "line"
+	switch s := s.(type) {
+	case *shp.Point:
+		points = []shp.Point{*s}
+	case *shp.PointZ:
+		points = []shp.Point{{X: s.X, Y: s.Y}}
+	case *shp.PointM:
+		points = []shp.Point{{X: s.X, Y: s.Y}}
+	case *shp.MultiPoint:
+		points = s.Points
+	case *shp.MultiPointZ:
+		points = s.Points
+	case *shp.MultiPointM:
+		points = s.Points
+	case *shp.PolyLine:
+		parts, points = s.Parts, s.Points
+	case *shp.PolyLineZ:
+		parts, points = s.Parts, s.Points
+	case *shp.PolyLineM:
+		parts, points = s.Parts, s.Points
+	case *shp.Polygon:
+		parts, points, class = s.Parts, s.Points, "area"
+	case *shp.PolygonZ:
+		parts, points, class = s.Parts, s.Points, "area"
+	case *shp.PolygonM:
+		parts, points, class = s.Parts, s.Points, "area"
+	default:
+		return
+	}
+	if parts == nil {
+		for _, p := range points {
+			fmt.Fprintf(&t.paths, "<circle class=\"point\" cx=\"%.1f\" cy=\"%.1f\" r=\"2\"/>\n",
+				(p.X-t.box.MinX)*t.scale, (t.box.MaxY-p.Y)*t.scale)
+		}
+		return
+	}
+	fmt.Fprintf(&t.paths, "<path class=\"%s\" d=\"", class)
+	part := 0
+	for i, p := range points {
+		if i > 0 {
+			t.paths.WriteByte(' ')
+		}
+		cmd := "L"
+		for part < len(parts) && int(parts[part]) <= i {
+			cmd = "M"
+			part++
+		}
+		t.paths.WriteString(cmd + t.point(p))
+	}
+	t.paths.WriteString("\"/>\n")
+}
+
+// svg returns the SVG image of the drawn shapes.
+func (t *thumbnail) svg() string {
+	w := math.Max((t.box.MaxX-t.box.MinX)*t.scale, 1)
+	h := math.Max((t.box.MaxY-t.box.MinY)*t.scale, 1)
+	return fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"-4 -4 %.1f %.1f\" width=\"%.0f\" height=\"%.0f\">\n"+
+		"<style>.area{fill:#9ecae1;fill-rule:evenodd;stroke:#3182bd;stroke-width:0.5}.line{fill:none;stroke:#3182bd;stroke-width:1}.point{fill:#3182bd}</style>\n%s</svg>",
+		w+8, h+8, w+8, h+8, t.paths.String())
+}
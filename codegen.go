@@ -0,0 +1,157 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// GenerateStruct returns the source code of a Go struct type named typeName
+// in package pkg that holds the records of a shapefile with fields and shape
+// type t, for use with ReadAll, Unmarshal and WriteAll. Character fields
+// become strings with their size, number fields without decimals int64 and
+// with decimals float64 with their size and precision, float and currency
+// fields float64, logical fields bool, date fields time.Time and other fields
+// strings. The geometry field is a pointer to the shape type of t, or a Shape
+// for NULL. Go field names are derived from the DBF names, e.g. POP_2020
+// becomes Pop2020, and the struct tags keep the DBF names.
+func GenerateStruct(pkg, typeName string, fields []Field, t ShapeType) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by shpgen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	usesTime := false
+	for _, f := range fields {
+		usesTime = usesTime || f.Fieldtype == 'D'
+	}
+	b.WriteString("import (\n")
+	if usesTime {
+		b.WriteString("\t\"time\"\n\n")
+	}
+	b.WriteString("\tshp \"github.com/brianolson/go-shp\"\n)\n\n")
+
+	geometry := "shp.Shape"
+	if s, err := newShape(t); err == nil && t != NULL {
+		geometry = "*shp." + reflect.TypeOf(s).Elem().Name()
+	}
+	fmt.Fprintf(&b, "// %s is a record of a %v shapefile.\n", typeName, t)
+	fmt.Fprintf(&b, "type %s struct {\n", typeName)
+	fmt.Fprintf(&b, "\tGeometry %s `shp:\",geometry\"`\n", geometry)
+	used := map[string]bool{"Geometry": true}
+	for _, f := range fields {
+		name := goName(f.String(), used)
+		goType, tag := "string", fmt.Sprintf("%s,size=%d", f.String(), f.Size)
+		switch f.Fieldtype {
+		case 'N':
+			if f.Precision == 0 {
+				goType = "int64"
+			} else {
+				goType, tag = "float64", fmt.Sprintf("%s,size=%d,prec=%d", f.String(), f.Size, f.Precision)
+			}
+		case 'F':
+			goType, tag = "float64", fmt.Sprintf("%s,size=%d,prec=%d", f.String(), f.Size, f.Precision)
+		case 'Y':
+			goType, tag = "float64", f.String()
+		case 'L':
+			goType, tag = "bool", f.String()
+		case 'D':
+			goType, tag = "time.Time", f.String()
+		}
+		fmt.Fprintf(&b, "\t%s %s `shp:%q`\n", name, goType, tag)
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to format the generated code: %v", err)
+	}
+	return src, nil
+}
+
+// goName returns an exported Go identifier for the DBF field name that is not
+// in used, and adds it to used.
+func goName(name string, used map[string]bool) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if upper {
+				b.WriteRune(unicode.ToUpper(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			upper = unicode.IsDigit(r)
+		default:
+			upper = true
+		}
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "F" + id
+	}
+	unique := id
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprint(id, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// GenerateJSONSchema returns a JSON Schema (draft 2020-12) with the given
+// title for the attributes of a shapefile with fields, as objects keyed by
+// the field names. The types match the GeoJSON properties of the server
+// package: numbers for number, float and currency fields, integers for number
+// fields without decimals, booleans for logical fields and strings otherwise.
+// Every property may be null, for empty values.
+func GenerateJSONSchema(title string, fields []Field) ([]byte, error) {
+	type property struct {
+		Type        []string `json:"type"`
+		Description string   `json:"description,omitempty"`
+		MaxLength   int      `json:"maxLength,omitempty"`
+		Pattern     string   `json:"pattern,omitempty"`
+	}
+	properties := make(map[string]property, len(fields))
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		p := property{Type: []string{"string", "null"}}
+		switch f.Fieldtype {
+		case 'C':
+			p.MaxLength = int(f.Size)
+		case 'N':
+			if f.Precision == 0 {
+				p.Type[0] = "integer"
+			} else {
+				p.Type[0] = "number"
+			}
+		case 'F', 'Y':
+			p.Type[0] = "number"
+		case 'L':
+			p.Type[0] = "boolean"
+		case 'D':
+			p.Pattern = "^[0-9]{8}$"
+			p.Description = "date as YYYYMMDD"
+		}
+		names[i] = f.String()
+		properties[names[i]] = p
+	}
+	schema := struct {
+		Schema     string              `json:"$schema"`
+		Title      string              `json:"title,omitempty"`
+		Type       string              `json:"type"`
+		Properties map[string]property `json:"properties"`
+		Required   []string            `json:"required"`
+	}{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      title,
+		Type:       "object",
+		Properties: properties,
+		Required:   names,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
@@ -0,0 +1,81 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStruct(t *testing.T) {
+	fields := []Field{
+		StringField("NAME", 20),
+		NumberField("POP_2020", 10),
+		FloatField("AREA", 10, 2),
+		LogicalField("ACTIVE"),
+		DateField("FOUNDED"),
+		StringField("name", 5),
+	}
+	src, err := GenerateStruct("cities", "City", fields, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package cities",
+		`"time"`,
+		"type City struct {",
+		"Geometry *shp.Point `shp:\",geometry\"`",
+		"Name string `shp:\"NAME,size=20\"`",
+		"Pop2020 int64 `shp:\"POP_2020,size=10\"`",
+		"Area float64 `shp:\"AREA,size=10,prec=2\"`",
+		"Active bool `shp:\"ACTIVE\"`",
+		"Founded time.Time `shp:\"FOUNDED\"`",
+		"Name2 string `shp:\"name,size=5\"`",
+	} {
+		if !strings.Contains(strings.Join(strings.Fields(string(src)), " "), want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+
+	src, err = GenerateStruct("p", "Any", nil, NULL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "Geometry shp.Shape") || strings.Contains(string(src), "time") {
+		t.Errorf("got\n%s", src)
+	}
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	src, err := GenerateJSONSchema("cities", []Field{
+		StringField("NAME", 20),
+		NumberField("POP", 10),
+		FloatField("AREA", 10, 2),
+		LogicalField("ACTIVE"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Title      string
+		Properties map[string]struct {
+			Type      []string
+			MaxLength int
+		}
+	}
+	if err := json.Unmarshal(src, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Title != "cities" || len(schema.Properties) != 4 {
+		t.Fatalf("got %s", src)
+	}
+	for name, want := range map[string]string{"NAME": "string", "POP": "integer", "AREA": "number", "ACTIVE": "boolean"} {
+		if got := schema.Properties[name].Type; len(got) != 2 || got[0] != want || got[1] != "null" {
+			t.Errorf("got type %v for %s, want %s", got, name, want)
+		}
+	}
+	if schema.Properties["NAME"].MaxLength != 20 {
+		t.Errorf("got maxLength %d", schema.Properties["NAME"].MaxLength)
+	}
+}
@@ -0,0 +1,273 @@
+package shp
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ColumnStore holds the records of a shapefile column-wise: the coordinates
+// of all vertices in X and Y slices, the bounding boxes of the records in four
+// slices and every attribute in a typed array. Analytical scans that touch
+// one coordinate or attribute of every record read contiguous memory instead
+// of chasing a Shape and an attribute slice per record.
+type ColumnStore struct {
+	Types []ShapeType // of every record, NULL for null shapes
+
+	// X and Y are the coordinates of the vertices of all records. The
+	// vertices of record i are [Offsets[i], Offsets[i+1]).
+	X, Y    []float64
+	Offsets []int
+	// Z and M are parallel to X, or nil if no record has z or m values.
+	// Missing z values are 0, missing measures are NaN.
+	Z, M []float64
+
+	// Parts are the indices of the first vertex of every part in X. The
+	// parts of record i are Parts[PartOffsets[i]:PartOffsets[i+1]].
+	Parts       []int
+	PartOffsets []int
+	// PartTypes are the types of the parts of multipatches, parallel to
+	// Parts, or nil if there are none.
+	PartTypes []int32
+
+	// The bounding boxes of the records. Null shapes have empty boxes.
+	MinX, MinY, MaxX, MaxY []float64
+
+	Columns []Column
+}
+
+// Column is an attribute of all records, stored in the array that fits the
+// type of its field: integers for N fields without decimals, floats for N and
+// F fields with decimals, bools for L fields and strings otherwise. Values
+// that are empty or cannot be parsed are zero and not Valid.
+type Column struct {
+	Field   Field
+	Strings []string
+	Ints    []int64
+	Floats  []float64
+	Bools   []bool
+	Valid   []bool
+}
+
+// LoadColumns reads all records of sr into a ColumnStore.
+func LoadColumns(sr SequentialReader) (*ColumnStore, error) {
+	c := &ColumnStore{Offsets: []int{0}, PartOffsets: []int{0}}
+	for _, f := range sr.Fields() {
+		c.Columns = append(c.Columns, Column{Field: f})
+	}
+	for sr.Next() {
+		_, s := sr.Shape()
+		c.appendShape(s)
+		for i := range c.Columns {
+			c.Columns[i].append(sr.Attribute(i))
+		}
+	}
+	if err := sr.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Len returns the number of records.
+func (c *ColumnStore) Len() int {
+	return len(c.Types)
+}
+
+func (c *ColumnStore) appendShape(s Shape) {
+	t := NULL
+	if s != nil {
+		t = shapeTypeOf(s)
+	}
+	c.Types = append(c.Types, t)
+	first := len(c.X)
+	var box Box
+	switch p := s.(type) {
+	case *Point:
+		c.appendVertices([]Point{*p}, nil, nil)
+		box = p.BBox()
+	case *PointZ:
+		c.appendVertices([]Point{{p.X, p.Y}}, []float64{p.Z}, []float64{p.M})
+		box = p.BBox()
+	case *PointM:
+		c.appendVertices([]Point{{p.X, p.Y}}, nil, []float64{p.M})
+		box = p.BBox()
+	default:
+		a, ok := arraysOf(s)
+		if !ok {
+			box = EmptyBox()
+			break
+		}
+		for i, part := range a.parts {
+			c.Parts = append(c.Parts, first+clampIndex(part, len(a.points)))
+			if a.partTypes != nil {
+				c.appendPartType(a.partTypes[i])
+			}
+		}
+		c.appendVertices(a.points, a.z, a.m)
+		box = BBoxFromPoints(a.points)
+		if len(a.points) == 0 {
+			box = EmptyBox()
+		}
+	}
+	c.Offsets = append(c.Offsets, len(c.X))
+	c.PartOffsets = append(c.PartOffsets, len(c.Parts))
+	c.MinX = append(c.MinX, box.MinX)
+	c.MinY = append(c.MinY, box.MinY)
+	c.MaxX = append(c.MaxX, box.MaxX)
+	c.MaxY = append(c.MaxY, box.MaxY)
+}
+
+func (c *ColumnStore) appendVertices(points []Point, z, m []float64) {
+	n := len(c.X)
+	for _, p := range points {
+		c.X = append(c.X, p.X)
+		c.Y = append(c.Y, p.Y)
+	}
+	c.Z = appendValues(c.Z, n, len(points), z, 0)
+	c.M = appendValues(c.M, n, len(points), m, math.NaN())
+}
+
+// appendValues appends count values to dst, which holds n values or is nil
+// if there have been none so far, filling in missing ones.
+func appendValues(dst []float64, n, count int, values []float64, missing float64) []float64 {
+	if values == nil && dst == nil {
+		return nil
+	}
+	for len(dst) < n {
+		dst = append(dst, missing)
+	}
+	for i := 0; i < count; i++ {
+		if i < len(values) {
+			dst = append(dst, values[i])
+		} else {
+			dst = append(dst, missing)
+		}
+	}
+	return dst
+}
+
+func (c *ColumnStore) appendPartType(t int32) {
+	for len(c.PartTypes) < len(c.Parts)-1 {
+		c.PartTypes = append(c.PartTypes, 0)
+	}
+	c.PartTypes = append(c.PartTypes, t)
+}
+
+func (col *Column) append(v string) {
+	v = strings.TrimRight(strings.TrimSpace(v), "\x00")
+	valid := v != ""
+	switch t := col.Field.Fieldtype; {
+	case t == 'N' && col.Field.Precision == 0:
+		n, err := strconv.ParseInt(v, 10, 64)
+		valid = valid && err == nil
+		col.Ints = append(col.Ints, n)
+	case t == 'N' || t == 'F':
+		f, err := strconv.ParseFloat(v, 64)
+		valid = valid && err == nil
+		col.Floats = append(col.Floats, f)
+	case t == 'L':
+		b, ok := parseLogical(v)
+		valid = valid && ok
+		col.Bools = append(col.Bools, b)
+	default:
+		col.Strings = append(col.Strings, v)
+	}
+	col.Valid = append(col.Valid, valid)
+}
+
+// parseLogical parses the value of an L field.
+func parseLogical(v string) (value, ok bool) {
+	switch v {
+	case "T", "t", "Y", "y":
+		return true, true
+	case "F", "f", "N", "n":
+		return false, true
+	}
+	return false, false
+}
+
+// Column returns the column of the field called name, matched
+// case-insensitively, or nil.
+func (c *ColumnStore) Column(name string) *Column {
+	for i := range c.Columns {
+		if strings.EqualFold(c.Columns[i].Field.String(), name) {
+			return &c.Columns[i]
+		}
+	}
+	return nil
+}
+
+// FilterBBox appends the indices of the records whose bounding box
+// intersects box to dst and returns it. The bounding boxes are compared in a
+// single pass over the four box columns without branching per comparison.
+func (c *ColumnStore) FilterBBox(box Box, dst []int) []int {
+	minX, minY, maxX, maxY := c.MinX, c.MinY[:len(c.MinX)], c.MaxX[:len(c.MinX)], c.MaxY[:len(c.MinX)]
+	for i := range minX {
+		hit := b2i(minX[i] <= box.MaxX) & b2i(maxX[i] >= box.MinX) &
+			b2i(minY[i] <= box.MaxY) & b2i(maxY[i] >= box.MinY)
+		if hit != 0 {
+			dst = append(dst, i)
+		}
+	}
+	return dst
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Shape returns record i as a Shape, with slices of its own.
+func (c *ColumnStore) Shape(i int) Shape {
+	start, end := c.Offsets[i], c.Offsets[i+1]
+	points := make([]Point, end-start)
+	for j := range points {
+		points[j] = Point{c.X[start+j], c.Y[start+j]}
+	}
+	var z, m []float64
+	if c.Z != nil {
+		z = append([]float64(nil), c.Z[start:end]...)
+	}
+	if c.M != nil {
+		m = append([]float64(nil), c.M[start:end]...)
+	}
+	switch c.Types[i] {
+	case NULL:
+		return &Null{}
+	case POINT:
+		return &points[0]
+	case POINTZ:
+		return &PointZ{X: points[0].X, Y: points[0].Y, Z: z[0], M: m[0]}
+	case POINTM:
+		return &PointM{X: points[0].X, Y: points[0].Y, M: m[0]}
+	}
+	s, err := newShape(c.Types[i])
+	if err != nil {
+		return &Null{}
+	}
+	// the ranges of an empty shape tell which values its type has
+	a, _ := arraysOf(s)
+	if a.zRange == nil {
+		z = nil
+	}
+	if a.mRange == nil {
+		m = nil
+	}
+	var parts, partTypes []int32
+	switch c.Types[i] {
+	case MULTIPOINT, MULTIPOINTZ, MULTIPOINTM:
+	default:
+		pstart, pend := c.PartOffsets[i], c.PartOffsets[i+1]
+		parts = make([]int32, pend-pstart)
+		for j := range parts {
+			parts[j] = int32(c.Parts[pstart+j] - start)
+		}
+		if c.Types[i] == MULTIPATCH && c.PartTypes != nil {
+			partTypes = make([]int32, len(parts))
+			copy(partTypes, c.PartTypes[pstart:min(pend, len(c.PartTypes))])
+		}
+	}
+	return withArrays(s, parts, partTypes, points, z, m)
+}
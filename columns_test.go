@@ -0,0 +1,73 @@
+package shp
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLoadColumns(t *testing.T) {
+	filename := filenamePrefix + "columns"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+	defer sr.Close()
+	c, err := LoadColumns(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", c.Len())
+	}
+	if !reflect.DeepEqual(c.X, []float64{1, 3}) || !reflect.DeepEqual(c.Y, []float64{2, 4}) {
+		t.Errorf("X = %v, Y = %v", c.X, c.Y)
+	}
+	if c.Z != nil || c.M != nil {
+		t.Errorf("Z = %v, M = %v, want nil", c.Z, c.M)
+	}
+	if got := c.Column("name").Strings; !reflect.DeepEqual(got, []string{"Springfield", "Shelbyville"}) {
+		t.Errorf("NAME = %q", got)
+	}
+	if got := c.Column("POP").Ints; !reflect.DeepEqual(got, []int64{30000, 25000}) {
+		t.Errorf("POP = %v", got)
+	}
+	if got := c.Column("AREA").Floats; !reflect.DeepEqual(got, []float64{12.5, 9.25}) {
+		t.Errorf("AREA = %v", got)
+	}
+	if c.Column("missing") != nil {
+		t.Error("Column of a missing field is not nil")
+	}
+
+	if got := c.FilterBBox(Box{2, 2, 5, 5}, nil); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("FilterBBox = %v, want [1]", got)
+	}
+	if got := c.FilterBBox(Box{0, 0, 5, 5}, nil); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("FilterBBox = %v, want [0 1]", got)
+	}
+}
+
+func TestColumnStoreShapes(t *testing.T) {
+	for _, name := range []string{"point", "pointz", "pointm", "polyline", "polygonz", "multipointm", "polylinem", "multipatch"} {
+		want := readShapes(t, "test_files/"+name+".shp")
+		sr := SequentialReaderFromExt(openFile("test_files/"+name+".shp", t), nil)
+		c, err := LoadColumns(sr)
+		sr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Len() != len(want) {
+			t.Fatalf("%s: Len = %d, want %d", name, c.Len(), len(want))
+		}
+		for i := range want {
+			// the ranges are recomputed from the values
+			if a, ok := arraysOf(want[i]); ok {
+				want[i] = withArrays(want[i], a.parts, a.partTypes, a.points, a.z, a.m)
+			}
+			// compared as text, as M values may be NaN
+			if got := c.Shape(i); fmt.Sprint(got) != fmt.Sprint(want[i]) {
+				t.Errorf("%s: shape %d = %+v, want %+v", name, i, got, want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package shp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CompatOptions configures CompatibilityReport. The zero value uses the
+// defaults.
+type CompatOptions struct {
+	// ExtentTolerance is how far, in the units of the coordinates, each edge
+	// of the extent may move before the shift is reported. Any shift is
+	// reported if it is zero.
+	ExtentTolerance float64
+}
+
+// IssueKind is the kind of a CompatIssue.
+type IssueKind int
+
+const (
+	// FieldRemoved is a field of the old release that the new one lacks.
+	FieldRemoved IssueKind = iota
+	// FieldRenamed is a field that has another name in the new release, at
+	// the same position and with the same type.
+	FieldRenamed
+	// FieldRetyped is a field whose type changed.
+	FieldRetyped
+	// FieldNarrowed is a field whose size or precision decreased, so values
+	// of the old release may not fit.
+	FieldNarrowed
+	// ShapeTypeChanged is a change of the geometry type.
+	ShapeTypeChanged
+	// CRSChanged is a change of the coordinate system declared by the .prj
+	// file, including its addition or removal.
+	CRSChanged
+	// ExtentShifted is a change of the bounding box beyond the tolerance.
+	ExtentShifted
+)
+
+func (k IssueKind) String() string {
+	switch k {
+	case FieldRemoved:
+		return "field removed"
+	case FieldRenamed:
+		return "field renamed"
+	case FieldRetyped:
+		return "field type changed"
+	case FieldNarrowed:
+		return "field narrowed"
+	case ShapeTypeChanged:
+		return "shape type changed"
+	case CRSChanged:
+		return "CRS changed"
+	case ExtentShifted:
+		return "extent shifted"
+	}
+	return fmt.Sprintf("IssueKind(%d)", int(k))
+}
+
+// CompatIssue is an incompatibility between two releases of a shapefile.
+type CompatIssue struct {
+	Kind IssueKind
+	// Field is the name of the field in the old release, for the issues
+	// of fields.
+	Field   string
+	Message string
+}
+
+func (i CompatIssue) String() string {
+	return i.Kind.String() + ": " + i.Message
+}
+
+// CompatReport lists the incompatibilities found by CompatibilityReport.
+type CompatReport struct {
+	Issues []CompatIssue
+}
+
+// Compatible reports whether no incompatibilities were found.
+func (r *CompatReport) Compatible() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *CompatReport) add(kind IssueKind, field, format string, args ...any) {
+	r.Issues = append(r.Issues, CompatIssue{Kind: kind, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// CompatibilityReport compares a new release of a shapefile to the old one
+// and reports the changes that can break consumers of the old one: removed,
+// renamed, retyped and narrowed fields, a changed geometry type, a changed
+// coordinate system and a shifted extent. Added and widened fields are
+// compatible. Fields are matched by name, case-insensitively. opts may be
+// nil. It can serve as a gate before publishing recurring datasets.
+func CompatibilityReport(old, new *Reader, opts *CompatOptions) (*CompatReport, error) {
+	if opts == nil {
+		opts = &CompatOptions{}
+	}
+	report := &CompatReport{}
+	compareFields(report, old.Fields(), new.Fields())
+
+	if old.GeometryType != new.GeometryType {
+		report.add(ShapeTypeChanged, "", "%v to %v", old.GeometryType, new.GeometryType)
+	}
+
+	oldWKT, err := old.prjWKT()
+	if err != nil {
+		return nil, err
+	}
+	newWKT, err := new.prjWKT()
+	if err != nil {
+		return nil, err
+	}
+	if msg := compareCRS(oldWKT, newWKT); msg != "" {
+		report.add(CRSChanged, "", "%s", msg)
+	}
+
+	ob, nb := old.BBox(), new.BBox()
+	shift := math.Max(math.Max(math.Abs(nb.MinX-ob.MinX), math.Abs(nb.MinY-ob.MinY)),
+		math.Max(math.Abs(nb.MaxX-ob.MaxX), math.Abs(nb.MaxY-ob.MaxY)))
+	if shift > opts.ExtentTolerance {
+		report.add(ExtentShifted, "", "%v to %v, edges moved by up to %g", ob, nb, shift)
+	}
+	return report, nil
+}
+
+// compareFields adds the issues of the fields to report.
+func compareFields(report *CompatReport, old, new []Field) {
+	newIndex := columnIndex(new)
+	oldIndex := columnIndex(old)
+	for i, of := range old {
+		name := of.String()
+		j, ok := newIndex[strings.ToUpper(name)]
+		if !ok {
+			// a field of the same type at the same position that is not in
+			// the old release is taken to be the renamed field
+			if i < len(new) && new[i].Fieldtype == of.Fieldtype {
+				if _, inOld := oldIndex[strings.ToUpper(new[i].String())]; !inOld {
+					report.add(FieldRenamed, name, "%s to %s", name, new[i].String())
+					continue
+				}
+			}
+			report.add(FieldRemoved, name, "%s", name)
+			continue
+		}
+		nf := new[j]
+		switch {
+		case nf.Fieldtype != of.Fieldtype:
+			report.add(FieldRetyped, name, "%s from %s to %s", name, describeField(of), describeField(nf))
+		case nf.Size < of.Size || nf.Precision < of.Precision:
+			report.add(FieldNarrowed, name, "%s from %s to %s", name, describeField(of), describeField(nf))
+		}
+	}
+}
+
+// describeField formats the type, size and precision of f like "N(10,2)".
+func describeField(f Field) string {
+	return fmt.Sprintf("%c(%d,%d)", f.Fieldtype, f.Size, f.Precision)
+}
+
+// prjWKT returns the contents of the .prj file, or "" if there is none.
+func (r *Reader) prjWKT() (string, error) {
+	if r.sidecars.PRJ == "" {
+		return "", nil
+	}
+	wkt, err := r.readSidecar(r.sidecars.PRJ)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(wkt)), nil
+}
+
+// compareCRS describes the change from the coordinate system oldWKT to
+// newWKT, or returns "" if they are the same. Systems with EPSG codes are
+// compared by code, others by their WKT without whitespace.
+func compareCRS(oldWKT, newWKT string) string {
+	describe := func(wkt string) string {
+		if wkt == "" {
+			return "none"
+		}
+		if code, ok := IdentifyEPSG(wkt); ok {
+			return fmt.Sprintf("EPSG:%d", code)
+		}
+		return "custom WKT"
+	}
+	oldCode, oldOK := IdentifyEPSG(oldWKT)
+	newCode, newOK := IdentifyEPSG(newWKT)
+	switch {
+	case oldOK && newOK:
+		if oldCode == newCode {
+			return ""
+		}
+	case strings.Join(strings.Fields(oldWKT), "") == strings.Join(strings.Fields(newWKT), ""):
+		return ""
+	}
+	return describe(oldWKT) + " to " + describe(newWKT)
+}
@@ -0,0 +1,94 @@
+package shp
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCompatibilityReport(t *testing.T) {
+	oldName := filenamePrefix + "compat_old"
+	newName := filenamePrefix + "compat_new"
+	defer removeShapefile(oldName)
+	defer removeShapefile(newName)
+	defer os.Remove(oldName + ".prj")
+	defer os.Remove(newName + ".prj")
+
+	writeCities(t, oldName)
+	if err := WritePrjForEPSG(oldName+".shp", 4326); err != nil {
+		t.Fatal(err)
+	}
+	w, err := Create(newName+".shp", POINTZ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("TITLE", 20), NumberField("POP", 8)})
+	w.Write(&PointZ{X: 1, Y: 2})
+	w.Write(&PointZ{X: 3, Y: 5})
+	w.Close()
+	if err := WritePrjForEPSG(newName+".shp", 32633); err != nil {
+		t.Fatal(err)
+	}
+
+	oldR, err := Open(oldName + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer oldR.Close()
+	newR, err := Open(newName + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newR.Close()
+
+	report, err := CompatibilityReport(oldR, newR, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, issue := range report.Issues {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"field renamed: NAME to TITLE",
+		"field narrowed: POP from N(10,0) to N(8,0)",
+		"field removed: AREA",
+		"shape type changed: POINT to POINTZ",
+		"CRS changed: EPSG:4326 to EPSG:32633",
+		"extent shifted: {1 2 3 4} to {1 2 3 5}, edges moved by up to 1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues =\n%q\nwant\n%q", got, want)
+	}
+	if report.Compatible() {
+		t.Error("Compatible() = true")
+	}
+
+	report, err = CompatibilityReport(oldR, newR, &CompatOptions{ExtentTolerance: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range report.Issues {
+		if issue.Kind == ExtentShifted {
+			t.Errorf("shift within tolerance reported: %v", issue)
+		}
+	}
+
+	report, err = CompatibilityReport(oldR, oldR, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Compatible() {
+		t.Errorf("release is incompatible with itself: %v", report.Issues)
+	}
+}
+
+func TestCompareFields(t *testing.T) {
+	old := []Field{StringField("NAME", 20), FloatField("AREA", 10, 2), NumberField("POP", 10)}
+	new := []Field{StringField("name", 30), StringField("AREA", 10), NumberField("POP", 10), DateField("DATE")}
+	var report CompatReport
+	compareFields(&report, old, new)
+	if len(report.Issues) != 1 || report.Issues[0].Kind != FieldRetyped || report.Issues[0].Field != "AREA" {
+		t.Errorf("issues = %v, want AREA retyped", report.Issues)
+	}
+}
@@ -0,0 +1,111 @@
+package shp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Decompressor returns a reader of the decompressed content of r.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+type decompressor struct {
+	magic []byte
+	open  Decompressor
+}
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = []decompressor{
+		{[]byte{0x1f, 0x8b}, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+	}
+)
+
+// RegisterDecompressor makes SequentialReaderFromExt and ResumeFrom
+// decompress the SHP and DBF files that start with magic with d. Files
+// compressed with gzip, such as the .shp.gz and .dbf.gz files of data lakes,
+// are decompressed by default. Other formats can be added without this
+// package depending on them, e.g. zstd with github.com/klauspost/compress:
+//
+//	shp.RegisterDecompressor([]byte{0x28, 0xb5, 0x2f, 0xfd}, func(r io.Reader) (io.Reader, error) {
+//		return zstd.NewReader(r)
+//	})
+//
+// Compressed files are read as streams, so the readers never seek in them.
+func RegisterDecompressor(magic []byte, d Decompressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	decompressors = append(decompressors, decompressor{append([]byte(nil), magic...), d})
+}
+
+// decompress returns rc, or a reader of its decompressed content if it starts
+// with the magic bytes of a registered Decompressor. Seekable files are
+// returned as they are if they are not compressed.
+func decompress(rc io.ReadCloser) (io.ReadCloser, error) {
+	decompressorsMu.RLock()
+	registered := decompressors
+	decompressorsMu.RUnlock()
+	n := 0
+	for _, d := range registered {
+		n = max(n, len(d.magic))
+	}
+
+	var magic []byte
+	var src io.Reader = rc
+	if s, ok := rc.(io.ReadSeeker); ok && isSeekable(s) {
+		magic = make([]byte, n)
+		m, err := io.ReadFull(s, magic)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, err
+		}
+		magic = magic[:m]
+		if _, err := s.Seek(int64(-m), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	} else {
+		br := bufio.NewReader(rc)
+		magic, _ = br.Peek(n)
+		src = br
+	}
+
+	for _, d := range registered {
+		if bytes.HasPrefix(magic, d.magic) {
+			r, err := d.open(src)
+			if err != nil {
+				return nil, fmt.Errorf("Error when decompressing: %v", err)
+			}
+			return decompressed{r, rc}, nil
+		}
+	}
+	if src == io.Reader(rc) {
+		return rc, nil
+	}
+	return decompressed{src, rc}, nil
+}
+
+// isSeekable returns whether s supports seeking; files like pipes implement
+// io.Seeker but fail to seek.
+func isSeekable(s io.Seeker) bool {
+	_, err := s.Seek(0, io.SeekCurrent)
+	return err == nil
+}
+
+// decompressed reads the decompressed content of a file and closes both.
+type decompressed struct {
+	io.Reader
+	file io.Closer
+}
+
+func (d decompressed) Close() error {
+	var err error
+	if c, ok := d.Reader.(io.Closer); ok {
+		err = c.Close()
+	}
+	if e := d.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
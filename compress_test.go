@@ -0,0 +1,69 @@
+package shp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCompressedSequentialReader(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	compressed := func(ext string) []byte {
+		data, err := os.ReadFile(filename + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		zw.Write(data)
+		zw.Close()
+		return b.Bytes()
+	}
+	shpGz, dbfGz := compressed(".shp"), compressed(".dbf")
+
+	check := func(name string, sr SequentialReader) {
+		t.Helper()
+		defer sr.Close()
+		var names []string
+		for sr.Next() {
+			names = append(names, strings.TrimRight(sr.Attribute(0), "\x00"))
+		}
+		if err := sr.Err(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if got := strings.Join(names, ","); got != "Springfield,Shelbyville" {
+			t.Errorf("%s: got %q", name, got)
+		}
+	}
+
+	// a bytes.Reader can seek, a NopCloser hides that like a pipe does
+	seekable := func(b []byte) io.ReadCloser {
+		return struct {
+			io.ReadSeeker
+			io.Closer
+		}{bytes.NewReader(b), io.NopCloser(nil)}
+	}
+	check("seekable", SequentialReaderFromExt(seekable(shpGz), seekable(dbfGz)))
+	check("stream", SequentialReaderFromExt(io.NopCloser(bytes.NewReader(shpGz)), io.NopCloser(bytes.NewReader(dbfGz))))
+
+	shpData, _ := os.ReadFile(filename + ".shp")
+	check("plain stream", SequentialReaderFromExt(io.NopCloser(bytes.NewReader(shpData)), openFile(filename+".dbf", t)))
+
+	defer func(registered []decompressor) { decompressors = registered }(decompressors)
+	RegisterDecompressor([]byte("RAW!"), func(r io.Reader) (io.Reader, error) {
+		_, err := io.CopyN(io.Discard, r, 4)
+		return r, err
+	})
+	check("registered", SequentialReaderFromExt(io.NopCloser(io.MultiReader(strings.NewReader("RAW!"), bytes.NewReader(shpData))), openFile(filename+".dbf", t)))
+
+	sr := SequentialReaderFromExt(io.NopCloser(bytes.NewReader(shpGz[:5])), nil)
+	if sr.Next() || sr.Err() == nil {
+		t.Error("got no error for a truncated gzip file")
+	}
+}
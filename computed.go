@@ -0,0 +1,108 @@
+package shp
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AddComputedField returns a SequentialReader that reads sr and adds the
+// attribute field, whose value is computed from each record by compute. The
+// value may be a string, bool, integer, float, Currency or nil for an empty
+// value; floats are formatted with the precision of field. Wrappers can be
+// stacked to add several fields, and the records can be written with their
+// new columns by FromChannel, e.g.:
+//
+//	sr = shp.AddComputedField(sr, shp.FloatField("AREA", 18, 2), shp.ComputeArea)
+//	sr = shp.AddComputedField(sr, shp.NumberField("VERTICES", 9), shp.ComputeNumVertices)
+func AddComputedField(sr SequentialReader, field Field, compute func(Record) any) SequentialReader {
+	return &computedReader{SequentialReader: sr, field: field, compute: compute}
+}
+
+// Built-in computations for AddComputedField, based on the planar
+// measurements Area, Perimeter, Length, NumVertices and Centroid.
+var (
+	ComputeArea        = func(r Record) any { return Area(r.Shape) }
+	ComputePerimeter   = func(r Record) any { return Perimeter(r.Shape) }
+	ComputeLength      = func(r Record) any { return Length(r.Shape) }
+	ComputeNumVertices = func(r Record) any { return NumVertices(r.Shape) }
+	ComputeCentroidX   = func(r Record) any {
+		if c, ok := Centroid(r.Shape); ok {
+			return c.X
+		}
+		return nil
+	}
+	ComputeCentroidY = func(r Record) any {
+		if c, ok := Centroid(r.Shape); ok {
+			return c.Y
+		}
+		return nil
+	}
+)
+
+// computedReader adds a computed attribute to the records of a
+// SequentialReader.
+type computedReader struct {
+	SequentialReader
+	field   Field
+	compute func(Record) any
+
+	value    string // of the current record, if computed
+	computed bool
+}
+
+// formatValue returns the attribute value of v for field.
+func formatComputed(v any, field Field) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "T"
+		}
+		return "F"
+	case float64:
+		return strconv.FormatFloat(v, 'f', int(field.Precision), 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', int(field.Precision), 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Next implements a method of interface SequentialReader for computedReader.
+func (c *computedReader) Next() bool {
+	c.computed = false
+	return c.SequentialReader.Next()
+}
+
+// Fields implements a method of interface SequentialReader for computedReader.
+func (c *computedReader) Fields() []Field {
+	fields := c.SequentialReader.Fields()
+	return append(fields[:len(fields):len(fields)], c.field)
+}
+
+// Attribute implements a method of interface SequentialReader for
+// computedReader.
+func (c *computedReader) Attribute(n int) string {
+	if n != len(c.SequentialReader.Fields()) {
+		return c.SequentialReader.Attribute(n)
+	}
+	if !c.computed {
+		index, s := c.Shape()
+		c.value = formatComputed(c.compute(Record{Index: index, Shape: s, Attributes: Attributes(c.SequentialReader)}), c.field)
+		c.computed = true
+	}
+	return c.value
+}
+
+// NextBatch implements BatchReader for computedReader.
+func (c *computedReader) NextBatch(n int) ([]Record, error) {
+	c.computed = false
+	batch, err := NextBatch(c.SequentialReader, n)
+	for i := range batch {
+		batch[i].Attributes = append(batch[i].Attributes, formatComputed(c.compute(batch[i]), c.field))
+	}
+	return batch, err
+}
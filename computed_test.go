@@ -0,0 +1,49 @@
+package shp
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAddComputedField(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+	sr = AddComputedField(sr, FloatField("X", 12, 1), ComputeCentroidX)
+	sr = AddComputedField(sr, NumberField("VERTICES", 4), ComputeNumVertices)
+	sr = AddComputedField(sr, StringField("UPPER", 20), func(r Record) any {
+		return r.Attributes[0] + "!"
+	})
+	defer sr.Close()
+
+	fields := sr.Fields()
+	if len(fields) != 6 || fields[3].String() != "X" || fields[5].String() != "UPPER" {
+		t.Fatalf("got fields %v", fields)
+	}
+	if !sr.Next() {
+		t.Fatal(sr.Err())
+	}
+	_, s := sr.Shape()
+	if got, want := sr.Attribute(3), strconv.FormatFloat(s.(*Point).X, 'f', 1, 64); got != want {
+		t.Errorf("got X %q, want %q", got, want)
+	}
+	if got := sr.Attribute(4); got != "1" {
+		t.Errorf("got VERTICES %q, want 1", got)
+	}
+	if got, want := sr.Attribute(5), sr.Attribute(0)+"!"; got != want {
+		t.Errorf("got UPPER %q, want %q", got, want)
+	}
+
+	batch, err := NextBatch(sr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 1 || len(batch[0].Attributes) != 6 {
+		t.Fatalf("got batch %v", batch)
+	}
+	if got, want := batch[0].Attributes[5], batch[0].Attributes[0]+"!"; got != want {
+		t.Errorf("got UPPER %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package shp
+
+import "fmt"
+
+// splitParts returns the points of each part as sub-slices of points.
+func splitParts(parts []int32, points []Point) [][]Point {
+	out := make([][]Point, len(parts))
+	for i := range parts {
+		out[i] = part(parts, points, i)
+	}
+	return out
+}
+
+// PointsToMultiPoint returns a MultiPoint consisting of points.
+func PointsToMultiPoint(points []Point) *MultiPoint {
+	return &MultiPoint{
+		Box:       BBoxFromPoints(points),
+		NumPoints: int32(len(points)),
+		Points:    points,
+	}
+}
+
+// NewPolygon returns a pointer to a new Polygon created from the rings in
+// parts. The rings are used as they are, see PolyLineToPolygon for closing
+// them.
+func NewPolygon(parts [][]Point) *Polygon {
+	return (*Polygon)(NewPolyLine(parts))
+}
+
+// PolyLineToPolygon returns a Polygon with one ring for every part of p. Parts
+// whose last point differs from their first point are closed by appending the
+// first point again.
+func PolyLineToPolygon(p *PolyLine) *Polygon {
+	parts := splitParts(p.Parts, p.Points)
+	for i, part := range parts {
+		if len(part) > 0 && part[0] != part[len(part)-1] {
+			ring := make([]Point, len(part), len(part)+1)
+			copy(ring, part)
+			parts[i] = append(ring, part[0])
+		}
+	}
+	return NewPolygon(parts)
+}
+
+// PolygonToPolyLine returns a PolyLine with one part for every ring of p.
+func PolygonToPolyLine(p *Polygon) *PolyLine {
+	return NewPolyLine(splitParts(p.Parts, p.Points))
+}
+
+// ExplodeMultipart splits s into single-part shapes: a PolyLine or a Polygon
+// into one shape per part and a MultiPoint into its Points. Note that the
+// rings of a Polygon are split without any regard to holes. Other shapes are
+// returned as the only element of the slice.
+func ExplodeMultipart(s Shape) []Shape {
+	var out []Shape
+	switch p := s.(type) {
+	case *PolyLine:
+		for _, part := range splitParts(p.Parts, p.Points) {
+			out = append(out, NewPolyLine([][]Point{part}))
+		}
+	case *Polygon:
+		for _, part := range splitParts(p.Parts, p.Points) {
+			out = append(out, NewPolygon([][]Point{part}))
+		}
+	case *MultiPoint:
+		for i := range p.Points {
+			point := p.Points[i]
+			out = append(out, &point)
+		}
+	default:
+		out = append(out, s)
+	}
+	return out
+}
+
+// CollectParts combines shapes of the same type into one multipart shape:
+// Points and MultiPoints into a MultiPoint, PolyLines into a PolyLine and
+// Polygons into a Polygon. It is the inverse of ExplodeMultipart.
+func CollectParts(shapes []Shape) (Shape, error) {
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("no shapes to collect")
+	}
+	var points []Point
+	var parts [][]Point
+	want := shapeTypeOf(shapes[0])
+	if want == POINT {
+		want = MULTIPOINT
+	}
+	for i, s := range shapes {
+		if err := requireFloat64(s); err != nil {
+			return nil, fmt.Errorf("shape %d: %w", i, err)
+		}
+		t := shapeTypeOf(s)
+		if t == POINT {
+			t = MULTIPOINT
+		}
+		if t != want {
+			return nil, fmt.Errorf("shape %d is %v, want %v", i, shapeTypeOf(s), want)
+		}
+		switch p := s.(type) {
+		case *Point:
+			points = append(points, *p)
+		case *MultiPoint:
+			points = append(points, p.Points...)
+		case *PolyLine:
+			parts = append(parts, splitParts(p.Parts, p.Points)...)
+		case *Polygon:
+			parts = append(parts, splitParts(p.Parts, p.Points)...)
+		default:
+			return nil, fmt.Errorf("cannot collect parts of %v", t)
+		}
+	}
+	switch want {
+	case MULTIPOINT:
+		return PointsToMultiPoint(points), nil
+	case POLYLINE:
+		return NewPolyLine(parts), nil
+	default:
+		return NewPolygon(parts), nil
+	}
+}
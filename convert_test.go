@@ -0,0 +1,65 @@
+package shp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolyLineToPolygon(t *testing.T) {
+	l := NewPolyLine([][]Point{
+		{{0, 0}, {0, 1}, {1, 1}},
+		{{5, 5}, {5, 6}, {6, 6}, {5, 5}},
+	})
+	p := PolyLineToPolygon(l)
+	want := NewPolygon([][]Point{
+		{{0, 0}, {0, 1}, {1, 1}, {0, 0}},
+		{{5, 5}, {5, 6}, {6, 6}, {5, 5}},
+	})
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+	if len(l.Points) != 7 {
+		t.Error("PolyLineToPolygon modified its input")
+	}
+	if back := PolygonToPolyLine(p); back.NumParts != 2 || back.NumPoints != 8 {
+		t.Errorf("got %+v from PolygonToPolyLine", back)
+	}
+}
+
+func TestExplodeAndCollect(t *testing.T) {
+	l := NewPolyLine([][]Point{
+		{{0, 0}, {5, 5}},
+		{{10, 10}, {15, 15}, {20, 20}},
+	})
+	parts := ExplodeMultipart(l)
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+	if p := parts[1].(*PolyLine); p.NumPoints != 3 || p.Box != (Box{10, 10, 20, 20}) {
+		t.Errorf("got second part %+v", p)
+	}
+	collected, err := CollectParts(parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(collected, l) {
+		t.Errorf("got %+v, want %+v", collected, l)
+	}
+
+	mp := PointsToMultiPoint([]Point{{1, 2}, {3, 4}})
+	points := ExplodeMultipart(mp)
+	if len(points) != 2 || *points[1].(*Point) != (Point{3, 4}) {
+		t.Errorf("got points %v", points)
+	}
+	collected, err = CollectParts(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(collected, mp) {
+		t.Errorf("got %+v, want %+v", collected, mp)
+	}
+
+	if _, err := CollectParts([]Shape{l, mp}); err == nil {
+		t.Error("collected mixed shape types without error")
+	}
+}
@@ -0,0 +1,147 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Converter decodes the attribute value raw of a DBF field, as returned by
+// Attribute, into a Go value. It returns nil for empty values.
+type Converter func(raw []byte) (any, error)
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[byte]Converter{}
+)
+
+// RegisterConverter makes AttributeValue and Unmarshal decode the values of
+// all fields of fieldType, e.g. 'N', with c instead of the default
+// conversion, for DBF dialects that store values in unusual ways such as
+// numbers with decimal commas or dates as seconds since the epoch. A nil c
+// removes the converter of fieldType. Converters apply to all readers, so
+// register them before reading, e.g. in an init function.
+func RegisterConverter(fieldType byte, c Converter) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	if c == nil {
+		delete(converters, fieldType)
+	} else {
+		converters[fieldType] = c
+	}
+}
+
+// converterFor returns the converter registered for fieldType, or nil.
+func converterFor(fieldType byte) Converter {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	return converters[fieldType]
+}
+
+// AttributeValue returns the value of the n-th attribute of the current
+// record of r, decoded by the converter registered for the type of the field
+// if any. By default, number fields without decimals are decoded to int64,
+// other number, float and currency fields to float64, logical fields to
+// bool, date fields to time.Time and other fields to strings. Empty values
+// are ErrNull.
+func AttributeValue(r AttributeReader, n int) (any, error) {
+	fields := r.Fields()
+	if n < 0 || n >= len(fields) {
+		return nil, fmt.Errorf("No field %d", n)
+	}
+	f := fields[n]
+	if c := converterFor(f.Fieldtype); c != nil {
+		v, err := c([]byte(r.Attribute(n)))
+		if err == nil && v == nil {
+			err = ErrNull
+		}
+		return v, err
+	}
+
+	s := strings.Trim(r.Attribute(n), " \x00")
+	switch f.Fieldtype {
+	case 'N':
+		if f.Precision == 0 {
+			if s == "" {
+				return nil, ErrNull
+			}
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i, nil
+			}
+		}
+		return AttributeFloat(r, n)
+	case 'F', 'Y':
+		return AttributeFloat(r, n)
+	case 'L':
+		return AttributeBool(r, n)
+	case 'D':
+		if s == "" {
+			return nil, ErrNull
+		}
+		t, err := time.Parse("20060102", s)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid date %q", s)
+		}
+		return t, nil
+	}
+	if s == "" {
+		return nil, ErrNull
+	}
+	return s, nil
+}
+
+// DecimalCommaConverter decodes numbers written with a decimal comma, such
+// as "1234,5", as float64, for use with RegisterConverter.
+func DecimalCommaConverter(raw []byte) (any, error) {
+	s := strings.Trim(string(raw), " \x00")
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid number %q", s)
+	}
+	return f, nil
+}
+
+// EpochSecondsConverter decodes numbers of seconds since January 1, 1970 UTC
+// as time.Time, for use with RegisterConverter.
+func EpochSecondsConverter(raw []byte) (any, error) {
+	s := strings.Trim(string(raw), " \x00")
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid number %q", s)
+	}
+	sec, frac := int64(f), f-float64(int64(f))
+	return time.Unix(sec, int64(frac*1e9)).UTC(), nil
+}
+
+// setConverted decodes the attribute value s with c into v. Values are
+// assigned or converted to the type of v, and formatted for strings; nil
+// values leave v untouched.
+func setConverted(v reflect.Value, c Converter, s string) error {
+	val, err := c([]byte(s))
+	if err != nil || val == nil {
+		return err
+	}
+	rv := reflect.ValueOf(val)
+	switch {
+	case rv.Type().AssignableTo(v.Type()):
+		v.Set(rv)
+	case v.Kind() == reflect.String:
+		v.SetString(fmt.Sprint(val))
+	case rv.Kind() != reflect.String && rv.CanConvert(v.Type()):
+		v.Set(rv.Convert(v.Type()))
+	default:
+		return fmt.Errorf("cannot assign %T to %v", val, v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConverters(t *testing.T) {
+	filename := filenamePrefix + "converters"
+	defer removeShapefile(filename)
+
+	w, err := Create(filename+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{NumberField("VALUE", 12), LogicalField("OK"), DateField("DAY")})
+	row := int(w.Write(&Point{1, 2}))
+	w.WriteAttribute(row, 0, "1234,5")
+	w.WriteAttribute(row, 1, true)
+	w.WriteAttribute(row, 2, "20240229")
+	row = int(w.Write(&Point{3, 4}))
+	w.WriteAttribute(row, 0, "1700000000")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(f func(sr SequentialReader)) {
+		sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+		defer sr.Close()
+		f(sr)
+	}
+
+	// the default conversion cannot parse decimal commas
+	read(func(sr SequentialReader) {
+		sr.Next()
+		if _, err := AttributeValue(sr, 0); err == nil {
+			t.Error("got no error for a decimal comma")
+		}
+		if v, err := AttributeValue(sr, 1); v != true || err != nil {
+			t.Errorf("got %v, %v for a logical", v, err)
+		}
+		if v, err := AttributeValue(sr, 2); v != time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC) || err != nil {
+			t.Errorf("got %v, %v for a date", v, err)
+		}
+		sr.Next()
+		if v, err := AttributeValue(sr, 0); v != int64(1700000000) || err != nil {
+			t.Errorf("got %v, %v for an integer", v, err)
+		}
+		if _, err := AttributeValue(sr, 1); err != ErrNull {
+			t.Errorf("got %v for an empty value, want ErrNull", err)
+		}
+	})
+
+	RegisterConverter('N', DecimalCommaConverter)
+	defer RegisterConverter('N', nil)
+	read(func(sr SequentialReader) {
+		sr.Next()
+		if v, err := AttributeValue(sr, 0); v != 1234.5 || err != nil {
+			t.Errorf("got %v, %v with DecimalCommaConverter", v, err)
+		}
+		var rec struct {
+			Value float32 `shp:"VALUE"`
+			Text  string  `shp:"VALUE"`
+		}
+		if err := Unmarshal(sr, &rec); err != nil || rec.Value != 1234.5 || rec.Text != "1234.5" {
+			t.Errorf("got %+v, %v", rec, err)
+		}
+	})
+
+	RegisterConverter('N', EpochSecondsConverter)
+	read(func(sr SequentialReader) {
+		sr.Next()
+		sr.Next()
+		var rec struct {
+			Value time.Time `shp:"VALUE"`
+		}
+		if err := Unmarshal(sr, &rec); err != nil || !rec.Value.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("got %+v, %v with EpochSecondsConverter", rec, err)
+		}
+		var wrong struct {
+			Value bool `shp:"VALUE"`
+		}
+		if err := Unmarshal(sr, &wrong); err == nil {
+			t.Error("got no error for assigning a time to a bool")
+		}
+	})
+}
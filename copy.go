@@ -0,0 +1,128 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CopyWhere copies the records of src for which keep returns true to dst and
+// returns the number of records copied. keep gets the index of the record,
+// its attributes and its shape as a *LazyShape, whose type and bounding box
+// are known without decoding it. Unless keep calls Decode, the bytes of a
+// kept record are spliced into dst unchanged and only renumbered, which makes
+// subsetting by bounding box or attributes much faster than decoding and
+// encoding every shape.
+//
+// If dst has no fields yet, it gets the fields of src. If its fields are the
+// same as those of src, the DBF rows are copied as they are, too. Otherwise
+// every attribute is written to the field of dst with the same name, matched
+// case-insensitively, and attributes without such a field are dropped.
+//
+// The records are read with random access, by the SHX file if there is one,
+// so src may be used for other reads before or after, but not concurrently.
+func CopyWhere(src *Reader, dst *Writer, keep func(Record) bool) (int, error) {
+	src.loadIndex()
+	if src.indexErr != nil {
+		return 0, src.indexErr
+	}
+	shp, ok := src.shp.(io.ReaderAt)
+	if !ok {
+		return 0, errors.New("SHP file does not support random access")
+	}
+	var dbf io.ReaderAt
+	if src.dbf != nil {
+		if dbf, ok = src.dbf.(io.ReaderAt); !ok {
+			return 0, errors.New("DBF file does not support random access")
+		}
+		if dst.dbf == nil {
+			if err := dst.SetFields(src.dbfFields); err != nil {
+				return 0, err
+			}
+		}
+	}
+	raw := dbf != nil && sameFields(src.dbfFields, dst.dbfFields)
+	mapping := columnIndex(dst.dbfFields)
+
+	copied := 0
+	for i, e := range src.index {
+		if err := src.limits.checkRecordSize(e.length); err != nil {
+			return copied, fmt.Errorf("Error when reading record %d: %w", i, err)
+		}
+		buf := make([]byte, 8+e.length)
+		if _, err := shp.ReadAt(buf, e.offset); err != nil {
+			return copied, fmt.Errorf("Error when reading record %d: %v", i, err)
+		}
+		t := ShapeType(binary.LittleEndian.Uint32(buf[8:]))
+		if t != NULL && t != dst.GeometryType {
+			return copied, fmt.Errorf("Record %d is a %v, cannot copy it to a %v shapefile", i, t, dst.GeometryType)
+		}
+		l, err := src.readLazy(bytes.NewReader(buf[12:]), t, e.length)
+		if err != nil {
+			return copied, fmt.Errorf("Error when reading record %d: %w", i, err)
+		}
+
+		var row []byte
+		var attrs []string
+		if dbf != nil && i < int(src.dbfNumRecords) {
+			row = make([]byte, src.dbfRecordLength)
+			offset := int64(src.dbfHeaderLength) + int64(i)*int64(src.dbfRecordLength)
+			if _, err := dbf.ReadAt(row, offset); err != nil {
+				return copied, fmt.Errorf("Error when reading row %d: %v", i, err)
+			}
+			attrs = make([]string, len(src.dbfFields))
+			for n := range attrs {
+				attrs[n] = src.rowAttribute(row, n)
+			}
+		}
+		if !keep(Record{Index: i, Shape: l, Attributes: attrs}) {
+			continue
+		}
+
+		n := int(dst.Write(l))
+		switch {
+		case row == nil:
+		case raw:
+			if err := dst.writeRow(n, row); err != nil {
+				return copied, err
+			}
+		default:
+			for j, v := range attrs {
+				k, ok := mapping[strings.ToUpper(src.dbfFields[j].String())]
+				if !ok {
+					continue
+				}
+				if err := dst.WriteAttribute(n, k, v); err != nil {
+					return copied, err
+				}
+			}
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+// sameFields returns whether a and b describe the same DBF layout.
+func sameFields(a, b []Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Fieldtype != b[i].Fieldtype ||
+			a[i].Size != b[i].Size || a[i].Precision != b[i].Precision {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRow overwrites the DBF row of record n, including the deletion flag,
+// with buf.
+func (w *Writer) writeRow(n int, buf []byte) error {
+	w.dbf.Seek(int64(w.dbfHeaderLength)+int64(n)*int64(w.dbfRecordLength), io.SeekStart)
+	_, err := w.dbf.Write(buf)
+	return err
+}
@@ -0,0 +1,105 @@
+package shp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCopyWhere(t *testing.T) {
+	src := filenamePrefix + "copy_src"
+	dst := filenamePrefix + "copy_dst"
+	defer removeShapefile(src)
+	defer removeShapefile(dst)
+	writeCities(t, src)
+
+	r, err := Open(src + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w, err := Create(dst+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := CopyWhere(r, w, func(rec Record) bool {
+		if _, ok := rec.Shape.(*LazyShape); !ok {
+			t.Errorf("shape is a %T, want *LazyShape", rec.Shape)
+		}
+		return rec.Shape.BBox().MinX > 2 || rec.Attributes[0] == "nowhere"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("copied %d records, want 1", n)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := Open(dst + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if len(out.Fields()) != 3 {
+		t.Errorf("fields = %v", out.Fields())
+	}
+	var rows []string
+	for out.Next() {
+		_, s := out.Shape()
+		p := s.(*Point)
+		rows = append(rows, strings.TrimRight(out.Attribute(0), "\x00")+" "+strings.TrimRight(out.Attribute(1), "\x00"))
+		if p.X != 3 || p.Y != 4 {
+			t.Errorf("shape = %v", p)
+		}
+	}
+	if strings.Join(rows, ",") != "Shelbyville 25000" {
+		t.Errorf("rows = %q", rows)
+	}
+}
+
+func TestCopyWhereByName(t *testing.T) {
+	src := filenamePrefix + "copy_src2"
+	dst := filenamePrefix + "copy_dst2"
+	defer removeShapefile(src)
+	defer removeShapefile(dst)
+	writeCities(t, src)
+
+	r, err := Open(src + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w, err := Create(dst+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{NumberField("pop", 12), StringField("OTHER", 5)})
+	if _, err := CopyWhere(r, w, func(Record) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	out, err := Open(dst + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	var pops []string
+	for out.Next() {
+		pops = append(pops, strings.TrimRight(out.Attribute(0), "\x00"))
+	}
+	if strings.Join(pops, ",") != "30000,25000" {
+		t.Errorf("POP = %q", pops)
+	}
+
+	w, err = Create(dst+".shp", POLYGON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := CopyWhere(r, w, func(Record) bool { return true }); err == nil {
+		t.Error("expected an error when copying points to a polygon shapefile")
+	}
+}
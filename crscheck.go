@@ -0,0 +1,84 @@
+package shp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckCRS makes Open compare the bounding box of the shapefile with the
+// coordinate system declared by its .prj file, using CheckCoordinates, and
+// report a mismatch as a warning. This catches a wrong .prj file and
+// projected data labeled as longitude and latitude.
+func CheckCRS() ReaderOption {
+	return func(o *readerOptions) {
+		o.checkCRS = true
+	}
+}
+
+// CRSMismatchError is returned by CheckCoordinates if the coordinates do not
+// fit the declared coordinate system.
+type CRSMismatchError struct {
+	Geographic bool // whether the declared coordinate system is geographic
+	Box        Box
+}
+
+func (e *CRSMismatchError) Error() string {
+	if e.Geographic {
+		return fmt.Sprintf("Coordinates %v exceed the range of degrees of the declared geographic coordinate system", e.Box)
+	}
+	return fmt.Sprintf("Coordinates %v look like degrees but a projected coordinate system is declared", e.Box)
+}
+
+// IsGeographic reports whether the WKT contents of a .prj file describe a
+// geographic coordinate system, in degrees of longitude and latitude.
+func IsGeographic(wkt string) bool {
+	wkt = strings.ToUpper(strings.TrimSpace(wkt))
+	for _, prefix := range []string{"GEOGCS[", "GEOGCRS[", "GEODCRS["} {
+		if strings.HasPrefix(wkt, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCoordinates returns a *CRSMismatchError if box cannot be in the
+// coordinate system described by wkt: if a geographic system is declared
+// but box exceeds [-180, 360] x [-90, 90], or if a projected system is
+// declared but box lies within [-180, 180] x [-90, 90], which is unlikely for
+// projected coordinates in meters or feet. It returns nil for an empty box
+// and for WKT it does not recognize.
+func CheckCoordinates(wkt string, box Box) error {
+	if box == (Box{}) || box.MinX > box.MaxX || box.MinY > box.MaxY {
+		return nil
+	}
+	const eps = 1e-6 // rounding of coordinates at the edges
+	degrees := box.MinX >= -180-eps && box.MaxX <= 180+eps && box.MinY >= -90-eps && box.MaxY <= 90+eps
+	switch {
+	case IsGeographic(wkt):
+		// longitudes from 0 to 360 are common as well
+		if box.MinX < -180-eps || box.MaxX > 360+eps || box.MinY < -90-eps || box.MaxY > 90+eps {
+			return &CRSMismatchError{Geographic: true, Box: box}
+		}
+	case strings.HasPrefix(strings.ToUpper(strings.TrimSpace(wkt)), "PROJCS["):
+		if degrees {
+			return &CRSMismatchError{Box: box}
+		}
+	}
+	return nil
+}
+
+// checkCoordinates reads the .prj file and reports a mismatch of the header
+// bounding box as a warning.
+func (r *Reader) checkCoordinates() {
+	if r.sidecars.PRJ == "" {
+		return
+	}
+	wkt, err := r.readSidecar(r.sidecars.PRJ)
+	if err != nil {
+		r.warn(&r.warnings, Warning{Index: -1, Err: err})
+		return
+	}
+	if err := CheckCoordinates(string(wkt), r.bbox); err != nil {
+		r.warn(&r.warnings, Warning{Index: -1, Err: err})
+	}
+}
@@ -0,0 +1,57 @@
+package shp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckCoordinates(t *testing.T) {
+	utm, _ := LookupEPSG(32633)
+	for _, c := range []struct {
+		wkt      string
+		box      Box
+		mismatch bool
+	}{
+		{wktWGS84, Box{-122.5, 37.7, -122.3, 37.8}, false},
+		{wktWGS84, Box{0, 0, 359, 10}, false},
+		{wktWGS84, Box{500000, 4000000, 510000, 4100000}, true},
+		{utm.WKT, Box{500000, 4000000, 510000, 4100000}, false},
+		{utm.WKT, Box{13.1, 52.3, 13.7, 52.7}, true},
+		{utm.WKT, Box{}, false},
+		{"", Box{500000, 4000000, 510000, 4100000}, false},
+	} {
+		err := CheckCoordinates(c.wkt, c.box)
+		var mismatch *CRSMismatchError
+		if errors.As(err, &mismatch) != c.mismatch {
+			t.Errorf("got %v for box %v", err, c.box)
+		}
+	}
+}
+
+func TestCheckCRSWarning(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "utm.shp")
+	w, err := Create(filename, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(&Point{500000, 4000000})
+	w.Close()
+	if err := os.WriteFile(filepath.Join(filepath.Dir(filename), "utm.prj"), []byte(wktWGS84), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(filename, CheckCRS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	warnings := r.Warnings()
+	if len(warnings) != 1 || warnings[0].Index != -1 {
+		t.Fatalf("got warnings %v", warnings)
+	}
+	if e, ok := warnings[0].Err.(*CRSMismatchError); !ok || !e.Geographic {
+		t.Errorf("got warning %v", warnings[0])
+	}
+}
@@ -0,0 +1,165 @@
+package shp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dataset is a directory that contains one or more shapefiles. Each shapefile
+// is a layer of the dataset and is identified by its basename.
+type Dataset struct {
+	dir    string
+	layers map[string]string // layer name -> path of the SHP file
+	names  []string
+	tmp    string // directory of the files extracted from an archive
+}
+
+// OpenDir scans the directory dir for shapefiles and returns them as a
+// Dataset. The files themselves are opened only when they are needed.
+func OpenDir(dir string) (*Dataset, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dataset{dir: dir, layers: make(map[string]string)}
+	for _, fi := range entries {
+		ext := filepath.Ext(fi.Name())
+		if fi.IsDir() || strings.ToLower(ext) != ".shp" {
+			continue
+		}
+		name := strings.TrimSuffix(fi.Name(), ext)
+		d.layers[name] = filepath.Join(dir, fi.Name())
+		d.names = append(d.names, name)
+	}
+	sort.Strings(d.names)
+	return d, nil
+}
+
+// Close removes the files extracted by OpenTar and OpenArchive. It does
+// nothing for datasets opened with OpenDir. Readers of layers must be closed
+// first.
+func (d *Dataset) Close() error {
+	if d.tmp == "" {
+		return nil
+	}
+	return os.RemoveAll(d.tmp)
+}
+
+// Layers returns the names of all layers in the dataset in sorted order.
+func (d *Dataset) Layers() []string {
+	return append([]string(nil), d.names...)
+}
+
+// Layer opens the layer called name for reading. The caller is responsible for
+// closing the returned Reader.
+func (d *Dataset) Layer(name string) (*Reader, error) {
+	filename, err := d.LayerPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return Open(filename)
+}
+
+// LayerPath returns the path of the SHP file of the layer called name, e.g.
+// to open it through a Cache.
+func (d *Dataset) LayerPath(name string) (string, error) {
+	filename, ok := d.layers[name]
+	if !ok {
+		return "", fmt.Errorf("No such layer in %s: %s", d.dir, name)
+	}
+	return filename, nil
+}
+
+// LayerSchema describes the geometry type and the attribute fields of a layer.
+type LayerSchema struct {
+	Layer        string
+	GeometryType ShapeType
+	BBox         Box
+	Fields       []Field
+}
+
+// Schema returns the schemas of all layers in the order of Layers.
+func (d *Dataset) Schema() ([]LayerSchema, error) {
+	schemas := make([]LayerSchema, 0, len(d.names))
+	for _, name := range d.names {
+		r, err := d.Layer(name)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, LayerSchema{
+			Layer:        name,
+			GeometryType: r.GeometryType,
+			BBox:         r.BBox(),
+			Fields:       r.Fields(),
+		})
+		r.Close()
+	}
+	return schemas, nil
+}
+
+// Extent returns the bounding box that contains all layers of the dataset.
+func (d *Dataset) Extent() (Box, error) {
+	schemas, err := d.Schema()
+	if err != nil {
+		return Box{}, err
+	}
+	box := EmptyBox()
+	for _, s := range schemas {
+		box = box.Union(s.BBox)
+	}
+	return box, nil
+}
+
+// FieldReport describes a field name that is used in one or more layers of a
+// dataset.
+type FieldReport struct {
+	Name   string
+	Layers map[string]Field // layer name -> definition of the field
+}
+
+// Consistent returns whether the field has the same type, size and precision
+// in all layers that contain it.
+func (f FieldReport) Consistent() bool {
+	var first *Field
+	for _, field := range f.Layers {
+		field := field
+		if first == nil {
+			first = &field
+		} else if field.Fieldtype != first.Fieldtype || field.Size != first.Size || field.Precision != first.Precision {
+			return false
+		}
+	}
+	return true
+}
+
+// Fields returns a report of all attribute fields in the dataset, sorted by
+// field name. Fields are matched case-insensitively across layers.
+func (d *Dataset) Fields() ([]FieldReport, error) {
+	schemas, err := d.Schema()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*FieldReport)
+	var names []string
+	for _, s := range schemas {
+		for _, f := range s.Fields {
+			key := strings.ToUpper(f.String())
+			fr, ok := byName[key]
+			if !ok {
+				fr = &FieldReport{Name: f.String(), Layers: make(map[string]Field)}
+				byName[key] = fr
+				names = append(names, key)
+			}
+			fr.Layers[s.Layer] = f
+		}
+	}
+	sort.Strings(names)
+	reports := make([]FieldReport, len(names))
+	for i, key := range names {
+		reports[i] = *byName[key]
+	}
+	return reports, nil
+}
@@ -0,0 +1,59 @@
+package shp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenDir(t *testing.T) {
+	d, err := OpenDir("test_files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers := d.Layers()
+	if len(layers) != len(dataForReadTests) {
+		t.Fatalf("got %d layers, want %d: %v", len(layers), len(dataForReadTests), layers)
+	}
+	for _, name := range layers {
+		if _, ok := dataForReadTests["test_files/"+name]; !ok {
+			t.Errorf("unexpected layer %s", name)
+		}
+	}
+
+	r, err := d.Layer("point")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.GeometryType != POINT {
+		t.Errorf("got geometry type %v, want POINT", r.GeometryType)
+	}
+	if _, err := d.Layer("nonexistent"); err == nil {
+		t.Error("opened nonexistent layer without error")
+	}
+	if path, err := d.LayerPath("point"); err != nil || path != filepath.Join("test_files", "point.shp") {
+		t.Errorf("LayerPath = %q, %v", path, err)
+	}
+
+	extent, err := d.Extent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Box{0, 0, 25, 25}); extent != want {
+		t.Errorf("got extent %v, want %v", extent, want)
+	}
+
+	fields, err := d.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fields {
+		if f.Name == "point_ID" {
+			if _, ok := f.Layers["point"]; !ok || !f.Consistent() {
+				t.Errorf("got report %+v for point_ID", f)
+			}
+			return
+		}
+	}
+	t.Error("field point_ID missing from report")
+}
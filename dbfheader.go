@@ -0,0 +1,58 @@
+package shp
+
+import "time"
+
+// DBFHeader is the metadata in the header of a DBF file.
+type DBFHeader struct {
+	// Version is the first byte of the file, e.g. 3 for dBase III without
+	// memo file.
+	Version byte
+	// LastUpdate is the date the table was last modified, or the zero time
+	// if the file holds no valid date.
+	LastUpdate   time.Time
+	NumRecords   int
+	HeaderLength int
+	RecordLength int
+}
+
+// DBFHeader returns the header metadata of the DBF file, e.g. for provenance
+// checks. It opens the DBF file if it was not read yet.
+func (r *Reader) DBFHeader() (DBFHeader, error) {
+	if err := r.openDbf(); err != nil {
+		return DBFHeader{}, err
+	}
+	return DBFHeader{
+		Version:      r.dbfVersion,
+		LastUpdate:   dbfDate(r.dbfLastUpdate),
+		NumRecords:   int(r.dbfNumRecords),
+		HeaderLength: int(r.dbfHeaderLength),
+		RecordLength: int(r.dbfRecordLength),
+	}, nil
+}
+
+// dbfDate decodes the last update date of a DBF header, whose year counts
+// from 1900.
+func dbfDate(b [3]byte) time.Time {
+	if b[1] < 1 || b[1] > 12 || b[2] < 1 || b[2] > 31 {
+		return time.Time{}
+	}
+	return time.Date(1900+int(b[0]), time.Month(b[1]), int(b[2]), 0, 0, 0, 0, time.UTC)
+}
+
+// WithLastUpdate sets the last update date written to the DBF header. Without
+// it, the Writer writes a fixed date, so that the output does not depend on
+// when it was written. Years before 1900 and after 2155 cannot be stored.
+func WithLastUpdate(t time.Time) WriterOption {
+	return func(o *writerOptions) {
+		o.lastUpdate = t
+	}
+}
+
+// dbfDateBytes encodes t for the DBF header, or returns the fixed date that
+// the Writer has always written if t is zero or out of range.
+func dbfDateBytes(t time.Time) []byte {
+	if t.IsZero() || t.Year() < 1900 || t.Year() > 1900+255 {
+		return []byte{24, 5, 3}
+	}
+	return []byte{byte(t.Year() - 1900), byte(t.Month()), byte(t.Day())}
+}
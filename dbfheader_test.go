@@ -0,0 +1,41 @@
+package shp
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDBFHeader(t *testing.T) {
+	dir := t.TempDir()
+	for _, c := range []struct {
+		opts []WriterOption
+		want time.Time
+	}{
+		{nil, time.Date(1924, 5, 3, 0, 0, 0, 0, time.UTC)},
+		{[]WriterOption{WithLastUpdate(time.Date(2024, 2, 29, 13, 0, 0, 0, time.UTC))}, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	} {
+		filename := filepath.Join(dir, "header.shp")
+		w, err := Create(filename, POINT, c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.SetFields([]Field{StringField("NAME", 10), NumberField("POP", 5)})
+		w.Write(&Point{0, 0})
+		w.Close()
+
+		r, err := Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := r.DBFHeader()
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := DBFHeader{Version: 3, LastUpdate: c.want, NumRecords: 1, HeaderLength: 97, RecordLength: 16}
+		if h != want {
+			t.Errorf("got header %+v, want %+v", h, want)
+		}
+	}
+}
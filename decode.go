@@ -0,0 +1,80 @@
+package shp
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+)
+
+// The coordinate arrays of shapes are decoded in bulk: a chunk of the file is
+// read into a buffer and converted with a plain loop, which the compiler turns
+// into unaligned loads without byte swapping on little-endian machines such
+// as amd64 and arm64. binary.Read would instead go through reflection for
+// every slice.
+
+const decodeChunk = 32 << 10
+
+var decodeBuffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, decodeChunk)
+		return &b
+	},
+}
+
+// readChunks reads n values of size bytes from r in chunks and passes every
+// chunk to decode together with the index of its first value. Like
+// binary.Read, it returns io.EOF if nothing was read and io.ErrUnexpectedEOF
+// if r ended early.
+func readChunks(r io.Reader, n, size int, decode func(buf []byte, first int)) error {
+	if n == 0 {
+		return nil
+	}
+	bp := decodeBuffers.Get().(*[]byte)
+	defer decodeBuffers.Put(bp)
+	per := len(*bp) / size
+	for first := 0; first < n; first += per {
+		count := min(per, n-first)
+		buf := (*bp)[:count*size]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if err == io.EOF && first > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		decode(buf, first)
+	}
+	return nil
+}
+
+// readFloats reads little-endian float64 values into dst.
+func readFloats(r io.Reader, dst []float64) error {
+	return readChunks(r, len(dst), 8, func(buf []byte, first int) {
+		out := dst[first : first+len(buf)/8]
+		for i := range out {
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[8*i:]))
+		}
+	})
+}
+
+// readPoints reads little-endian X and Y pairs into dst.
+func readPoints(r io.Reader, dst []Point) error {
+	return readChunks(r, len(dst), 16, func(buf []byte, first int) {
+		out := dst[first : first+len(buf)/16]
+		for i := range out {
+			b := buf[16*i : 16*i+16]
+			out[i].X = math.Float64frombits(binary.LittleEndian.Uint64(b))
+			out[i].Y = math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+		}
+	})
+}
+
+// readInt32s reads little-endian int32 values into dst.
+func readInt32s(r io.Reader, dst []int32) error {
+	return readChunks(r, len(dst), 4, func(buf []byte, first int) {
+		out := dst[first : first+len(buf)/4]
+		for i := range out {
+			out[i] = int32(binary.LittleEndian.Uint32(buf[4*i:]))
+		}
+	})
+}
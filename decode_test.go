@@ -0,0 +1,58 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBulkDecode(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	// more values than fit in one chunk
+	n := decodeChunk/8 + 123
+	floats := make([]float64, n)
+	points := make([]Point, n)
+	ints := make([]int32, n)
+	for i := 0; i < n; i++ {
+		floats[i] = rnd.NormFloat64()
+		points[i] = Point{rnd.NormFloat64(), rnd.NormFloat64()}
+		ints[i] = rnd.Int31() - 1<<30
+	}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, floats)
+	binary.Write(&buf, binary.LittleEndian, points)
+	binary.Write(&buf, binary.LittleEndian, ints)
+
+	gotFloats, gotPoints, gotInts := make([]float64, n), make([]Point, n), make([]int32, n)
+	if err := readFloats(&buf, gotFloats); err != nil {
+		t.Fatal(err)
+	}
+	if err := readPoints(&buf, gotPoints); err != nil {
+		t.Fatal(err)
+	}
+	if err := readInt32s(&buf, gotInts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotFloats, floats) || !reflect.DeepEqual(gotPoints, points) || !reflect.DeepEqual(gotInts, ints) {
+		t.Error("decoded values differ")
+	}
+}
+
+func TestBulkDecodeShort(t *testing.T) {
+	if err := readFloats(bytes.NewReader(nil), make([]float64, 2)); err != io.EOF {
+		t.Errorf("empty input: err = %v, want io.EOF", err)
+	}
+	if err := readFloats(bytes.NewReader(make([]byte, 12)), make([]float64, 2)); err != io.ErrUnexpectedEOF {
+		t.Errorf("short input: err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	n := decodeChunk/16 + 1
+	if err := readPoints(bytes.NewReader(make([]byte, decodeChunk)), make([]Point, n)); err != io.ErrUnexpectedEOF {
+		t.Errorf("input ending at a chunk: err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if err := readInt32s(bytes.NewReader(nil), nil); err != nil {
+		t.Errorf("no values: err = %v", err)
+	}
+}
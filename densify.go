@@ -0,0 +1,74 @@
+package shp
+
+import "math"
+
+// Densify returns a copy of s with vertices inserted so that no segment of its
+// parts or rings is longer than maxSegmentLength. The inserted vertices are
+// spaced evenly along each segment, with Z and M values interpolated linearly
+// and "no data" measures where either end has none. Densifying lines before
+// reprojecting their vertices keeps long segments from cutting corners across
+// the projection. Points, multipoints, Null shapes and a maxSegmentLength that
+// is not positive return s itself.
+func Densify(s Shape, maxSegmentLength float64) Shape {
+	a, ok := arraysOf(s)
+	if !ok || a.parts == nil || !(maxSegmentLength > 0) {
+		return s
+	}
+
+	var z, m []float64
+	if a.z != nil {
+		z = make([]float64, 0, len(a.z))
+	}
+	if a.m != nil {
+		m = make([]float64, 0, len(a.m))
+	}
+	parts := make([]int32, len(a.parts))
+	points := make([]Point, 0, len(a.points))
+	for i := range a.parts {
+		// corrupt part offsets are clamped like by part
+		start := clampIndex(a.parts[i], len(a.points))
+		end := start + len(part(a.parts, a.points, i))
+		parts[i] = int32(len(points))
+		for j := start; j < end; j++ {
+			if j > start {
+				p1, p2 := a.points[j-1], a.points[j]
+				n := math.Ceil(math.Hypot(p2.X-p1.X, p2.Y-p1.Y) / maxSegmentLength)
+				for k := 1.0; k < n; k++ {
+					f := k / n
+					points = append(points, Point{p1.X + f*(p2.X-p1.X), p1.Y + f*(p2.Y-p1.Y)})
+					if z != nil {
+						z = append(z, interpolate(a.z, j, f))
+					}
+					if m != nil {
+						m = append(m, interpolate(a.m, j, f))
+					}
+				}
+			}
+			points = append(points, a.points[j])
+			if z != nil {
+				z = append(z, valueAt(a.z, j))
+			}
+			if m != nil {
+				m = append(m, valueAt(a.m, j))
+			}
+		}
+	}
+	return withArrays(s, parts, append([]int32(nil), a.partTypes...), points, z, m)
+}
+
+// valueAt returns vs[i], or "no data" if vs is too short.
+func valueAt(vs []float64, i int) float64 {
+	if i < len(vs) {
+		return vs[i]
+	}
+	return math.NaN()
+}
+
+// interpolate returns the value at fraction f from vs[i-1] to vs[i].
+func interpolate(vs []float64, i int, f float64) float64 {
+	v1, v2 := valueAt(vs, i-1), valueAt(vs, i)
+	if IsNoData(v1) || IsNoData(v2) {
+		return math.NaN()
+	}
+	return v1 + f*(v2-v1)
+}
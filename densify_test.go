@@ -0,0 +1,53 @@
+package shp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDensify(t *testing.T) {
+	line := &PolyLineZ{
+		NumParts:  2,
+		NumPoints: 4,
+		Parts:     []int32{0, 2},
+		Points:    []Point{{0, 0}, {10, 0}, {0, 5}, {0, 6}},
+		ZArray:    []float64{0, 100, 1, 2},
+		MArray:    []float64{0, math.NaN(), 3, 4},
+	}
+	d := Densify(line, 2.5).(*PolyLineZ)
+	if d.NumPoints != 7 || d.NumParts != 2 || d.Parts[1] != 5 {
+		t.Fatalf("got %d points in parts %v", d.NumPoints, d.Parts)
+	}
+	for i, x := range []float64{0, 2.5, 5, 7.5, 10} {
+		if d.Points[i] != (Point{x, 0}) {
+			t.Errorf("got point %d %v, want x %v", i, d.Points[i], x)
+		}
+		if d.ZArray[i] != x*10 {
+			t.Errorf("got Z %d %v, want %v", i, d.ZArray[i], x*10)
+		}
+	}
+	if !math.IsNaN(d.MArray[2]) {
+		t.Errorf("got M %v next to no data", d.MArray[2])
+	}
+	if d.ZRange != [2]float64{0, 100} || d.MRange != [2]float64{0, 4} {
+		t.Errorf("got ranges %v %v", d.ZRange, d.MRange)
+	}
+	if line.NumPoints != 4 {
+		t.Error("Densify modified its argument")
+	}
+
+	p := &Point{1, 2}
+	if Densify(p, 1) != Shape(p) {
+		t.Error("Densify copied a point")
+	}
+}
+
+func TestDensifyCorruptParts(t *testing.T) {
+	for _, parts := range [][]int32{{0, 7}, {2, 1}, {-3, 9}} {
+		line := &PolyLine{NumParts: 2, NumPoints: 3, Parts: parts, Points: []Point{{0, 0}, {10, 0}, {10, 10}}}
+		d := Densify(line, 2.5).(*PolyLine)
+		if int(d.NumPoints) != len(d.Points) || len(d.Parts) != 2 {
+			t.Errorf("parts %v: got %+v", parts, d)
+		}
+	}
+}
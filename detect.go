@@ -0,0 +1,102 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Kind is a file format recognized by DetectFormat.
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindSHP
+	KindSHX
+	KindDBF
+	KindZip // a ZIP archive, such as a zipped shapefile
+	KindGeoJSON
+	KindFlatGeobuf
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindUnknown:
+		return "unknown"
+	case KindSHP:
+		return "SHP"
+	case KindSHX:
+		return "SHX"
+	case KindDBF:
+		return "DBF"
+	case KindZip:
+		return "ZIP"
+	case KindGeoJSON:
+		return "GeoJSON"
+	case KindFlatGeobuf:
+		return "FlatGeobuf"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// sniffLength is how many bytes DetectFormat looks at.
+const sniffLength = 4096
+
+// DetectFormat tells the format of the file r from its first bytes, so that
+// services accepting "a file" can pass it to the right reader. SHP and SHX
+// files share their header and are told apart by their first record: an SHP
+// file of no records is reported as SHP. A ZIP archive is not opened to see
+// whether it holds a shapefile. KindUnknown is returned, without an error, if
+// the format is not recognized.
+func DetectFormat(r io.ReaderAt) (Kind, error) {
+	head := make([]byte, sniffLength)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return KindUnknown, err
+	}
+	head = head[:n]
+	switch {
+	case len(head) >= 100 && binary.BigEndian.Uint32(head) == 9994:
+		if len(head) >= 104 && binary.BigEndian.Uint32(head[100:]) == 50 {
+			// the offset of the first record in 16-bit words
+			return KindSHX, nil
+		}
+		return KindSHP, nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return KindZip, nil
+	case len(head) >= 8 && string(head[:3]) == "fgb" && string(head[4:7]) == "fgb":
+		return KindFlatGeobuf, nil
+	case isDBFHeader(head):
+		return KindDBF, nil
+	case isGeoJSON(head):
+		return KindGeoJSON, nil
+	}
+	return KindUnknown, nil
+}
+
+// isDBFHeader reports whether head starts with a plausible dBASE header: a
+// known version, a date of last update and room for at least the header
+// terminator.
+func isDBFHeader(head []byte) bool {
+	if len(head) < 32 {
+		return false
+	}
+	switch head[0] {
+	case 0x02, 0x03, 0x04, 0x05, 0x30, 0x31, 0x32, 0x43, 0x83, 0x8b, 0x8e, 0xcb, 0xf5, 0xfb:
+	default:
+		return false
+	}
+	month, day := head[2], head[3]
+	headerLength := binary.LittleEndian.Uint16(head[8:])
+	return month >= 1 && month <= 12 && day >= 1 && day <= 31 &&
+		headerLength >= 33
+}
+
+// isGeoJSON reports whether head starts with a JSON object that has a "type"
+// member.
+func isGeoJSON(head []byte) bool {
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimLeft(head, " \t\r\n")
+	return bytes.HasPrefix(head, []byte("{")) && bytes.Contains(head, []byte(`"type"`))
+}
@@ -0,0 +1,50 @@
+package shp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeCities(t, filepath.Join(dir, "cities"))
+	var zipped bytes.Buffer
+	w, err := CreateZip(&zipped, "empty", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	for _, c := range []struct {
+		name string
+		data []byte
+		want Kind
+	}{
+		{"shp", read("cities.shp"), KindSHP},
+		{"shx", read("cities.shx"), KindSHX},
+		{"dbf", read("cities.dbf"), KindDBF},
+		{"zip", zipped.Bytes(), KindZip},
+		{"geojson", []byte("\xef\xbb\xbf\n {\"type\": \"FeatureCollection\", \"features\": []}"), KindGeoJSON},
+		{"fgb", []byte("fgb\x03fgb\x00rest"), KindFlatGeobuf},
+		{"json array", []byte(`[{"type": "Feature"}]`), KindUnknown},
+		{"text", []byte("hello"), KindUnknown},
+		{"empty", nil, KindUnknown},
+	} {
+		got, err := DetectFormat(bytes.NewReader(c.data))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: DetectFormat = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package shp
+
+import (
+	"bytes"
+	"time"
+)
+
+// Deterministic makes the Writer produce byte-identical files for identical
+// input, so that published files can be verified by their hashes. The DBF
+// header gets the fixed date that the Writer writes by default, also if
+// WithLastUpdate is used, as the date passed to it often comes from the
+// clock. The reserved bytes of the field descriptors passed to SetFields are
+// zeroed, as fields taken from other files may carry garbage there, and every
+// attribute written fills its whole field, so that overwriting a value with a
+// shorter one leaves no trace of the old one.
+func Deterministic() WriterOption {
+	return func(o *writerOptions) {
+		o.deterministic = true
+	}
+}
+
+// applyDeterministic overrides the options that Deterministic excludes.
+func (o *writerOptions) applyDeterministic() {
+	if o.deterministic {
+		o.lastUpdate = time.Time{}
+	}
+}
+
+// normalizeField returns f with the bytes after the name and the reserved
+// bytes zeroed.
+func normalizeField(f Field) Field {
+	if n := bytes.IndexByte(f.Name[:], 0); n >= 0 {
+		clear(f.Name[n:])
+	}
+	f.Addr = [4]byte{}
+	f.Padding = [14]byte{}
+	return f
+}
@@ -0,0 +1,54 @@
+package shp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDeterministic(t *testing.T) {
+	// write writes a shapefile with one record whose NAME ends up as "Ogden";
+	// if overwrite is set, it is written as "Springfield" first.
+	write := func(name string, overwrite bool, opts ...WriterOption) [][]byte {
+		t.Helper()
+		filename := filenamePrefix + name
+		defer removeShapefile(filename)
+		w, err := Create(filename+".shp", POINT, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		field := StringField("NAME", 20)
+		if overwrite {
+			// garbage after the name and in the reserved bytes, as in
+			// fields read from other files
+			field.Name[6] = 'X'
+			field.Padding[3] = 1
+		}
+		w.SetFields([]Field{field})
+		w.Write(&Point{1, 2})
+		if overwrite {
+			w.WriteAttribute(0, 0, "Springfield")
+		}
+		w.WriteAttribute(0, 0, "Ogden")
+		w.Close()
+		return readShapefile(t, filename)
+	}
+
+	now := WithLastUpdate(time.Now())
+	a := write("deterministic_a", false, Deterministic(), now)
+	b := write("deterministic_b", true, Deterministic(), now)
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			t.Errorf("file %d differs between runs", i)
+		}
+	}
+	plain := write("deterministic_plain", false)
+	for i := range a {
+		if !bytes.Equal(a[i], plain[i]) {
+			t.Errorf("file %d differs from the output without Deterministic", i)
+		}
+	}
+	if c := write("deterministic_c", true); bytes.Equal(c[2], plain[2]) {
+		t.Error("overwritten DBF is identical without Deterministic, the test does not test anything")
+	}
+}
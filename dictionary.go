@@ -0,0 +1,147 @@
+package shp
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ColumnStats describes the values of an attribute field as reported by
+// ColumnStatistics.
+type ColumnStats struct {
+	Field Field
+	// Distinct is the number of distinct non-empty values, counted up to
+	// the limit passed to ColumnStatistics.
+	Distinct int
+	// Overflow is set if there are more distinct values than the limit.
+	Overflow bool
+	// Empty is the number of empty values.
+	Empty int
+	// MaxLength is the length of the longest value.
+	MaxLength int
+	// Savings is the estimated number of bytes that normalizing the column
+	// with Normalize saves in the DBF, minus the size of its lookup table.
+	Savings int64
+	// Normalize suggests to normalize the column, which is the case if
+	// Savings is positive.
+	Normalize bool
+	// CodeSize is the width of the number field of codes that replaces the
+	// column when it is normalized.
+	CodeSize int
+}
+
+// ColumnStatistics reads all records of sr and returns statistics about the
+// values of every attribute field, in particular its cardinality, to find
+// columns that repeat a few long values, such as a field of 50 characters
+// holding one of 5 names. Normalizing those columns with Normalize shrinks
+// the DBF, e.g. when preparing shapefiles for distribution. Distinct values
+// are counted up to maxDistinct per column; columns with more values are not
+// suggested for normalization.
+func ColumnStatistics(sr SequentialReader, maxDistinct int) ([]ColumnStats, error) {
+	fields := sr.Fields()
+	stats := make([]ColumnStats, len(fields))
+	seen := make([]map[string]struct{}, len(fields))
+	lookupBytes := make([]int64, len(fields))
+	for i, f := range fields {
+		stats[i].Field = f
+		seen[i] = map[string]struct{}{}
+	}
+	var records int64
+	for sr.Next() {
+		records++
+		for i := range fields {
+			v := strings.TrimRight(sr.Attribute(i), "\x00 ")
+			if len(v) > stats[i].MaxLength {
+				stats[i].MaxLength = len(v)
+			}
+			if v == "" {
+				stats[i].Empty++
+				continue
+			}
+			if _, ok := seen[i][v]; ok || stats[i].Overflow {
+				continue
+			}
+			if len(seen[i]) >= maxDistinct {
+				stats[i].Overflow = true
+				continue
+			}
+			seen[i][v] = struct{}{}
+			lookupBytes[i] += int64(len(v))
+		}
+	}
+	if err := sr.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		s := &stats[i]
+		s.Distinct = len(seen[i])
+		s.CodeSize = len(strconv.Itoa(max(s.Distinct, 1)))
+		if s.Overflow {
+			continue
+		}
+		// every row of the lookup table holds the field name, the code,
+		// the value, two commas and a newline
+		lookup := lookupBytes[i] + int64(s.Distinct)*int64(len(s.Field.String())+s.CodeSize+3)
+		s.Savings = records*int64(int(s.Field.Size)-s.CodeSize) - lookup
+		s.Normalize = s.Savings > 0
+	}
+	return stats, nil
+}
+
+// Normalize copies the records of src to dst, which must have been created
+// without fields, replacing the values of the columns whose stats have
+// Normalize set by numeric codes. The codes start at 1 in the order in which
+// the values first appear and empty values stay empty. The values of the
+// codes are written to lookup as CSV with the header FIELD,CODE,VALUE. The
+// stats must have been computed by ColumnStatistics for the same records.
+func Normalize(src SequentialReader, dst *Writer, stats []ColumnStats, lookup io.Writer) error {
+	fields := append([]Field(nil), src.Fields()...)
+	codes := make([]map[string]int, len(fields))
+	for i, s := range stats {
+		if i < len(fields) && s.Normalize {
+			fields[i] = NumberField(s.Field.String(), uint8(s.CodeSize))
+			codes[i] = map[string]int{}
+		}
+	}
+	if len(fields) > 0 {
+		if err := dst.SetFields(fields); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(lookup)
+	cw.Write([]string{"FIELD", "CODE", "VALUE"})
+	for src.Next() {
+		_, s := src.Shape()
+		row := int(dst.Write(s))
+		for i := range fields {
+			v := src.Attribute(i)
+			if codes[i] != nil {
+				v = strings.TrimRight(v, "\x00 ")
+				if v == "" {
+					continue
+				}
+				code, ok := codes[i][v]
+				if !ok {
+					code = len(codes[i]) + 1
+					codes[i][v] = code
+					cw.Write([]string{fields[i].String(), strconv.Itoa(code), v})
+				}
+				if err := dst.WriteAttribute(row, i, code); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := dst.WriteAttribute(row, i, v); err != nil {
+				return err
+			}
+		}
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,78 @@
+package shp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestColumnStatistics(t *testing.T) {
+	src := filenamePrefix + "dictionary_src"
+	dst := filenamePrefix + "dictionary_dst"
+	defer removeShapefile(src)
+	defer removeShapefile(dst)
+
+	kinds := []string{"residential building", "commercial building", "", "industrial building"}
+	w, err := Create(src+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{NumberField("ID", 5), StringField("KIND", 50)})
+	for i := 0; i < 50; i++ {
+		row := int(w.Write(&Point{float64(i), 0}))
+		w.WriteAttribute(row, 0, i)
+		w.WriteAttribute(row, 1, kinds[i%len(kinds)])
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	open := func() SequentialReader {
+		return SequentialReaderFromExt(openFile(src+".shp", t), openFile(src+".dbf", t))
+	}
+	sr := open()
+	stats, err := ColumnStatistics(sr, 10)
+	sr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := stats[0]; !s.Overflow || s.Normalize || s.Distinct != 10 {
+		t.Errorf("got stats %+v for ID", s)
+	}
+	if s := stats[1]; s.Overflow || !s.Normalize || s.Distinct != 3 || s.Empty != 12 || s.CodeSize != 1 || s.MaxLength != 20 {
+		t.Errorf("got stats %+v for KIND", s)
+	}
+
+	w, err = Create(dst+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lookup bytes.Buffer
+	sr = open()
+	defer sr.Close()
+	if err := Normalize(sr, w, stats, &lookup); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "FIELD,CODE,VALUE\nKIND,1,residential building\nKIND,2,commercial building\nKIND,3,industrial building\n"
+	if lookup.String() != want {
+		t.Errorf("got lookup table\n%s", lookup.String())
+	}
+
+	r, err := Open(dst + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if f := r.Fields()[1]; f.Fieldtype != 'N' || f.Size != 1 {
+		t.Errorf("got field %v of type %c and size %d", f, f.Fieldtype, f.Size)
+	}
+	for i, want := range []string{"1", "2", "", "3", "1"} {
+		r.Next()
+		if got := strings.TrimRight(r.Attribute(1), "\x00"); got != want {
+			t.Errorf("record %d: got code %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ByteRange is a range of bytes in a file.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+// trackedFile records the byte ranges written to a file and, if it has an
+// undoLog, keeps the bytes they overwrote.
+type trackedFile struct {
+	*os.File
+	pos   int64
+	dirty []ByteRange // sorted and merged
+	undo  *undoLog
+}
+
+func (f *trackedFile) Seek(offset int64, whence int) (int64, error) {
+	pos, err := f.File.Seek(offset, whence)
+	if err == nil {
+		f.pos = pos
+	}
+	return pos, err
+}
+
+func (f *trackedFile) Write(p []byte) (int, error) {
+	if f.undo != nil {
+		if err := f.undo.save(f.File, f.pos, int64(len(p))); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.File.Write(p)
+	f.dirty = addRange(f.dirty, f.pos, int64(n))
+	f.pos += int64(n)
+	return n, err
+}
+
+// ReadFrom and WriteString hide those of os.File, which would bypass Write.
+func (f *trackedFile) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{f}, r)
+}
+
+func (f *trackedFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *trackedFile) Truncate(size int64) error {
+	if f.undo != nil {
+		if err := f.undo.save(f.File, size, f.undo.length-size); err != nil {
+			return err
+		}
+	}
+	return f.File.Truncate(size)
+}
+
+// addRange adds the range of length bytes at offset to the sorted ranges,
+// merging it with the ranges it overlaps or touches.
+func addRange(ranges []ByteRange, offset, length int64) []ByteRange {
+	if length <= 0 {
+		return ranges
+	}
+	end := offset + length
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].Offset+ranges[i].Length >= offset
+	})
+	j := i
+	for j < len(ranges) && ranges[j].Offset <= end {
+		offset = min(offset, ranges[j].Offset)
+		end = max(end, ranges[j].Offset+ranges[j].Length)
+		j++
+	}
+	r := ByteRange{Offset: offset, Length: end - offset}
+	return append(ranges[:i], append([]ByteRange{r}, ranges[j:]...)...)
+}
+
+// sync flushes f to disk if it has dirty ranges and forgets them.
+func (f *trackedFile) sync() error {
+	if f == nil || len(f.dirty) == 0 {
+		return nil
+	}
+	f.dirty = nil
+	return f.File.Sync()
+}
+
+// Editor changes the records of an existing shapefile in place. Only the
+// bytes of the edited attributes and shapes are written, plus the header
+// fields they affect, and Sync flushes only the files that were written to,
+// so small edits of huge files take no longer than of small ones.
+type Editor struct {
+	w     *Writer
+	shp   *trackedFile
+	shx   *trackedFile
+	dbf   *trackedFile // nil if there is no DBF file
+	index []indexEntry
+	rows  int // number of rows in the DBF file
+
+	box           Box   // bounding box in the SHP and SHX headers
+	boxChanged    bool  // whether box must be written to the headers
+	lengthChanged bool  // whether the SHP file length must be updated
+	attrsDirty    bool  // whether the DBF date must be updated
+	dead          int64 // bytes of replaced records in the SHP file
+	undo          io.Writer
+}
+
+// OpenEditor opens the shapefile filename for editing in place. It accepts the
+// options of Append, except for Atomic, Journal and OverflowWiden. Close must
+// be called when done.
+func OpenEditor(filename string, opts ...WriterOption) (*Editor, error) {
+	o := newWriterOptions(opts)
+	if o.atomic {
+		return nil, errors.New("Cannot edit a shapefile atomically")
+	}
+	if o.journal {
+		return nil, errors.New("Cannot journal edits of a shapefile")
+	}
+	if o.overflow == OverflowWiden {
+		return nil, errors.New("Cannot widen fields when editing a shapefile in place")
+	}
+	var lock *os.File
+	if o.lock {
+		var err error
+		if lock, err = acquireLock(filename); err != nil {
+			return nil, err
+		}
+	}
+	w, err := appendTo(filename)
+	if err != nil {
+		releaseLock(lock)
+		return nil, err
+	}
+	w.lock = lock
+	w.transformer = o.transformer
+	w.overflow = o.overflow
+	w.truncateStrings = o.truncateStrings
+	w.lastUpdate = o.lastUpdate
+
+	e := &Editor{w: w, box: w.extent.Box(), undo: o.undo}
+	e.shp = &trackedFile{File: w.shp.(*os.File)}
+	e.shx = &trackedFile{File: w.shx.(*os.File)}
+	w.shp, w.shx = e.shp, e.shx
+	if w.dbf != nil {
+		e.dbf = &trackedFile{File: w.dbf.(*os.File)}
+		w.dbf = e.dbf
+	}
+	if err := e.init(); err != nil {
+		e.close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// init reads the SHX file and the number of rows of the DBF file and starts
+// the undo logs.
+func (e *Editor) init() error {
+	data, err := io.ReadAll(io.NewSectionReader(e.shx.File, 0, 1<<62))
+	if err != nil {
+		return fmt.Errorf("Error when reading SHX file: %v", err)
+	}
+	if e.index, err = parseIndex(data); err != nil {
+		return err
+	}
+	if e.undo != nil {
+		for _, f := range e.files() {
+			fi, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			f.undo = &undoLog{length: fi.Size()}
+		}
+	}
+	if e.dbf != nil {
+		var n [4]byte
+		if _, err := e.dbf.File.ReadAt(n[:], 4); err != nil {
+			return fmt.Errorf("Error when reading DBF header: %v", err)
+		}
+		e.rows = int(binary.LittleEndian.Uint32(n[:]))
+	}
+	return nil
+}
+
+// NumRecords returns the number of records in the shapefile.
+func (e *Editor) NumRecords() int {
+	return len(e.index)
+}
+
+// Fields returns the fields of the DBF file, or nil if there is none.
+func (e *Editor) Fields() []Field {
+	return e.w.dbfFields
+}
+
+// WriteAttribute overwrites the value of field in row, like
+// Writer.WriteAttribute. The whole field is rewritten, so the old value does
+// not show through a shorter new one.
+func (e *Editor) WriteAttribute(row, field int, value interface{}) error {
+	if e.dbf == nil {
+		return errors.New("Shapefile has no DBF file")
+	}
+	if row < 0 || row >= e.rows {
+		return fmt.Errorf("Row %d out of range [0, %d)", row, e.rows)
+	}
+	if field < 0 || field >= len(e.w.dbfFields) {
+		return fmt.Errorf("Field %d out of range [0, %d)", field, len(e.w.dbfFields))
+	}
+	buf, err := e.w.encodeAttribute(row, field, value)
+	if err != nil {
+		return err
+	}
+	return e.writeField(row, field, buf)
+}
+
+// writeField overwrites field in row with the encoded value buf.
+func (e *Editor) writeField(row, field int, buf []byte) error {
+	e.attrsDirty = true
+	// the new value may be shorter than the old one
+	if err := e.w.writeField(row, field, make([]byte, e.w.dbfFields[field].Size)); err != nil {
+		return err
+	}
+	return e.w.writeField(row, field, buf)
+}
+
+// ReplaceShape overwrites the i-th shape with s. If the record of s fits in
+// the place of the old one, it is written there and padded with zeros, which
+// readers skip. Otherwise it is appended to the SHP file and the SHX file is
+// updated to point to it. The place of the old record then becomes dead
+// space: readers that use the SHX file, like Reader.ShapeAt, see only the new
+// record, but those that walk through the SHP file, like Reader.Next, see
+// both until Compact is called. If s extends beyond the bounding box in the
+// headers, the box is grown; it is never shrunk.
+func (e *Editor) ReplaceShape(i int, s Shape) error {
+	if i < 0 || i >= len(e.index) {
+		return fmt.Errorf("Record %d out of range [0, %d)", i, len(e.index))
+	}
+	s, content, err := e.encodeShape(s)
+	if err != nil {
+		return err
+	}
+	return e.replace(i, s, content)
+}
+
+// encodeShape transforms s, if the Editor has a Transformer, and encodes it
+// into the content of a record.
+func (e *Editor) encodeShape(s Shape) (Shape, []byte, error) {
+	if e.w.transformer != nil {
+		s = cloneShape(s)
+		TransformShape(s, e.w.transformer)
+	}
+	t := shapeTypeOf(s)
+	if t != NULL && t != e.w.GeometryType {
+		return nil, nil, fmt.Errorf("Cannot write a %v to a %v shapefile", t, e.w.GeometryType)
+	}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, t)
+	s.write(&buf)
+	return s, buf.Bytes(), nil
+}
+
+// fits returns whether the record content fits in the place of the i-th
+// record.
+func (e *Editor) fits(i int, content []byte) bool {
+	return int64(len(content)) <= e.index[i].length
+}
+
+// replace writes content, the encoding of s, as the i-th record.
+func (e *Editor) replace(i int, s Shape, content []byte) error {
+	if entry := e.index[i]; e.fits(i, content) {
+		buf := make([]byte, entry.length)
+		copy(buf, content)
+		if _, err := e.shp.Seek(entry.offset+8, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := e.shp.Write(buf); err != nil {
+			return fmt.Errorf("Error when writing record %d: %v", i, err)
+		}
+	} else if err := e.move(i, content); err != nil {
+		return err
+	}
+	if shapeTypeOf(s) != NULL && !e.box.ContainsBox(s.BBox()) {
+		e.box = e.box.Union(s.BBox())
+		e.boxChanged = true
+	}
+	return nil
+}
+
+// move appends the record content to the SHP file as the i-th record and
+// points the SHX entry to it.
+func (e *Editor) move(i int, content []byte) error {
+	offset, err := e.shp.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(i+1))
+	binary.BigEndian.PutUint32(header[4:], uint32(len(content)/2))
+	if _, err := e.shp.Write(append(header, content...)); err != nil {
+		return fmt.Errorf("Error when writing record %d: %v", i, err)
+	}
+	if err := e.writeIndexEntry(i, indexEntry{offset: offset, length: int64(len(content))}); err != nil {
+		return err
+	}
+	e.dead += 8 + e.index[i].length
+	e.index[i] = indexEntry{offset: offset, length: int64(len(content))}
+	e.lengthChanged = true
+	return nil
+}
+
+// writeIndexEntry writes the SHX entry of the i-th record.
+func (e *Editor) writeIndexEntry(i int, entry indexEntry) error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint32(buf, uint32(entry.offset/2))
+	binary.BigEndian.PutUint32(buf[4:], uint32(entry.length/2))
+	if _, err := e.shx.Seek(100+8*int64(i), io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := e.shx.Write(buf); err != nil {
+		return fmt.Errorf("Error when writing index of record %d: %v", i, err)
+	}
+	return nil
+}
+
+// DeadBytes returns the number of bytes of the SHP file taken by records that
+// were replaced by ReplaceShape and not yet removed by Compact.
+func (e *Editor) DeadBytes() int64 {
+	return e.dead
+}
+
+// Compact rewrites the SHP file without the dead space left by ReplaceShape,
+// with the records in order, and updates the SHX file. The records are first
+// copied to a temporary file in the same directory and then back, so that
+// the SHP file stays the same file, and a lock on it is kept. If there is no
+// dead space, Compact does nothing.
+func (e *Editor) Compact() error {
+	if e.dead == 0 {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(e.shp.Name()), ".compact-*.shp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	index := make([]indexEntry, len(e.index))
+	offset := int64(100)
+	var buf []byte
+	for i, entry := range e.index {
+		n := 8 + entry.length
+		if int64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		if _, err := e.shp.ReadAt(buf, entry.offset); err != nil {
+			return fmt.Errorf("Error when reading record %d: %v", i, err)
+		}
+		binary.BigEndian.PutUint32(buf, uint32(i+1))
+		if _, err := tmp.Write(buf); err != nil {
+			return fmt.Errorf("Error when compacting: %v", err)
+		}
+		index[i] = indexEntry{offset: offset, length: entry.length}
+		offset += n
+	}
+
+	if _, err := e.shp.Seek(100, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(e.shp, io.NewSectionReader(tmp, 0, offset-100)); err != nil {
+		return fmt.Errorf("Error when compacting: %v", err)
+	}
+	if err := e.shp.Truncate(offset); err != nil {
+		return fmt.Errorf("Error when compacting: %v", err)
+	}
+	for i, entry := range index {
+		if entry != e.index[i] {
+			if err := e.writeIndexEntry(i, entry); err != nil {
+				return err
+			}
+		}
+	}
+	e.index = index
+	e.dead = 0
+	e.lengthChanged = true
+	return nil
+}
+
+// DirtyRanges returns the byte ranges of the file with the extension ext
+// (".shp", ".shx" or ".dbf") that were written since the last Sync, in order.
+// The header fixups are written by Sync and are not included.
+func (e *Editor) DirtyRanges(ext string) []ByteRange {
+	var f *trackedFile
+	switch ext {
+	case ".shp":
+		f = e.shp
+	case ".shx":
+		f = e.shx
+	case ".dbf":
+		f = e.dbf
+	}
+	if f == nil {
+		return nil
+	}
+	return append([]ByteRange(nil), f.dirty...)
+}
+
+// Sync patches the headers where the edits require it, i.e. the bounding box
+// of the SHP and SHX files, the length of the SHP file and the date of last
+// update of the DBF file, and flushes the files that were written to since
+// the last Sync to disk.
+func (e *Editor) Sync() error {
+	var err error
+	if e.boxChanged {
+		for _, f := range []*trackedFile{e.shp, e.shx} {
+			if _, err = f.Seek(36, io.SeekStart); err != nil {
+				break
+			}
+			if err = binary.Write(f, binary.LittleEndian, e.box); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Error when patching header: %v", err)
+		}
+		e.boxChanged = false
+	}
+	if e.lengthChanged {
+		length, err := e.shp.Seek(0, io.SeekEnd)
+		if err == nil {
+			if _, err = e.shp.Seek(24, io.SeekStart); err == nil {
+				err = binary.Write(e.shp, binary.BigEndian, int32(length/2))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Error when patching header: %v", err)
+		}
+		e.lengthChanged = false
+	}
+	if e.attrsDirty {
+		if _, err = e.dbf.Seek(1, io.SeekStart); err == nil {
+			_, err = e.dbf.Write(dbfDateBytes(e.w.lastUpdate))
+		}
+		if err != nil {
+			return fmt.Errorf("Error when patching DBF header: %v", err)
+		}
+		e.attrsDirty = false
+	}
+	for _, f := range []*trackedFile{e.shp, e.shx, e.dbf} {
+		if serr := f.sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// Close syncs the files, writes the undo patch if UndoPatch was used, and
+// closes the files. It returns the first error encountered.
+func (e *Editor) Close() error {
+	err := e.Sync()
+	if e.undo != nil {
+		var files []patchFile
+		for _, f := range e.files() {
+			files = append(files, patchFile{
+				ext:    filepath.Ext(f.Name()),
+				length: f.undo.length,
+				ranges: f.undo.ranges,
+			})
+		}
+		if perr := writePatch(e.undo, files); err == nil {
+			err = perr
+		}
+	}
+	if cerr := e.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// files returns the files of the shapefile.
+func (e *Editor) files() []*trackedFile {
+	if e.dbf == nil {
+		return []*trackedFile{e.shp, e.shx}
+	}
+	return []*trackedFile{e.shp, e.shx, e.dbf}
+}
+
+// close closes the files and releases the lock.
+func (e *Editor) close() error {
+	err := e.shp.Close()
+	if cerr := e.shx.Close(); err == nil {
+		err = cerr
+	}
+	if e.dbf != nil {
+		if cerr := e.dbf.Close(); err == nil {
+			err = cerr
+		}
+	}
+	releaseLock(e.w.lock)
+	return err
+}
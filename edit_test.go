@@ -0,0 +1,183 @@
+package shp
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	filename := filenamePrefix + "edit"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	if _, err := OpenEditor(filename+".shp", WithOverflow(OverflowWiden)); err == nil {
+		t.Error("OpenEditor with OverflowWiden succeeded")
+	}
+	e, err := OpenEditor(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := e.NumRecords(); n != 2 {
+		t.Fatalf("NumRecords() = %d, want 2", n)
+	}
+	if err := e.WriteAttribute(1, 1, 26000); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteAttribute(0, 0, "Ogden"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ReplaceShape(0, &Point{5, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ReplaceShape(1, &PolyLine{}); err == nil {
+		t.Error("ReplaceShape with another type succeeded")
+	}
+	if err := e.WriteAttribute(2, 0, "Capital City"); err == nil {
+		t.Error("WriteAttribute beyond the last row succeeded")
+	}
+
+	// the first record starts after the header of 100 bytes and the record
+	// header of 8 bytes; its shape type and point take 20 bytes
+	if got, want := e.DirtyRanges(".shp"), []ByteRange{{108, 20}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DirtyRanges(.shp) = %v, want %v", got, want)
+	}
+	if got := e.DirtyRanges(".shx"); got != nil {
+		t.Errorf("DirtyRanges(.shx) = %v, want none", got)
+	}
+	// NAME of the first row after the header of 4*32+1 bytes and the deletion
+	// flag, and POP of the second row after one row of 41 bytes, the deletion
+	// flag and NAME of 20 bytes
+	if got, want := e.DirtyRanges(".dbf"), []ByteRange{{130, 20}, {129 + 41 + 21, 10}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DirtyRanges(.dbf) = %v, want %v", got, want)
+	}
+	if err := e.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.DirtyRanges(".shp"); got != nil {
+		t.Errorf("DirtyRanges(.shp) after Sync = %v, want none", got)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got, want := r.BBox(), (Box{1, 0, 5, 4}); got != want {
+		t.Errorf("BBox() = %v, want %v", got, want)
+	}
+	var points []Point
+	var names, pops []string
+	for r.Next() {
+		_, s := r.Shape()
+		points = append(points, *s.(*Point))
+		names = append(names, strings.TrimRight(r.Attribute(0), "\x00"))
+		pops = append(pops, strings.TrimSpace(strings.TrimRight(r.Attribute(1), "\x00")))
+	}
+	if want := []Point{{5, 0}, {3, 4}}; !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+	if want := []string{"Ogden", "Shelbyville"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("NAME = %q, want %q", names, want)
+	}
+	if want := []string{"30000", "26000"}; !reflect.DeepEqual(pops, want) {
+		t.Errorf("POP = %v, want %v", pops, want)
+	}
+}
+
+func TestAddRange(t *testing.T) {
+	var ranges []ByteRange
+	for _, r := range []ByteRange{{10, 5}, {30, 5}, {0, 2}, {15, 5}, {12, 20}} {
+		ranges = addRange(ranges, r.Offset, r.Length)
+	}
+	if want := []ByteRange{{0, 2}, {10, 25}}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+}
+
+func TestEditReplaceShapeMove(t *testing.T) {
+	filename := filenamePrefix + "edit_move"
+	defer removeShapefile(filename)
+	w, err := Create(filename+".shp", POLYLINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		x := float64(i)
+		w.Write(NewPolyLine([][]Point{{{x, 0}, {x, 1}}}))
+	}
+	w.Close()
+
+	e, err := OpenEditor(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	longer := NewPolyLine([][]Point{{{1, 0}, {1, 1}, {2, 2}}})
+	shorter := NewPolyLine([][]Point{{{2, 0}}})
+	if err := e.ReplaceShape(1, longer); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ReplaceShape(2, shorter); err != nil {
+		t.Fatal(err)
+	}
+	// the first record takes 8+44+4+32 bytes
+	if got, want := e.DeadBytes(), int64(88); got != want {
+		t.Errorf("DeadBytes() = %d, want %d", got, want)
+	}
+	if err := e.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Shape{NewPolyLine([][]Point{{{0, 0}, {0, 1}}}), longer, shorter}
+	check := func(sequential int) {
+		t.Helper()
+		r, err := Open(filename + ".shp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		if got := r.BBox(); got != (Box{0, 0, 2, 2}) {
+			t.Errorf("BBox() = %v, want %v", got, Box{0, 0, 2, 2})
+		}
+		for i, s := range want {
+			got, err := r.ShapeAt(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, s) {
+				t.Errorf("ShapeAt(%d) = %v, want %v", i, got, s)
+			}
+		}
+		n := 0
+		for r.Next() {
+			n++
+		}
+		if r.Err() != nil {
+			t.Fatal(r.Err())
+		}
+		if n != sequential {
+			t.Errorf("Next returned %d records, want %d", n, sequential)
+		}
+	}
+	check(4)
+
+	if err := e.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.DeadBytes(); got != 0 {
+		t.Errorf("DeadBytes() after Compact = %d, want 0", got)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	check(3)
+	if fi, err := os.Stat(filename + ".shp"); err != nil {
+		t.Fatal(err)
+	} else if fi.Size() != 100+3*88+16 {
+		t.Errorf("SHP file has %d bytes, want %d", fi.Size(), 100+3*88+16)
+	}
+}
@@ -0,0 +1,186 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+//go:generate go run gen_encoding.go
+
+// The shape types implement encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler, so they can also be used with encoding/gob, as
+// well as json.Marshaler and json.Unmarshaler. The binary form is the shape
+// type followed by the record content as in a SHP file. The JSON form is the
+// object of the struct fields with an additional "type" member, e.g.
+// {"type":"POINT","X":1,"Y":2}. The bounding box is the member "Box" of all
+// types, and the vertices in "Points" are objects {"X":1,"Y":2} without a
+// type. Measures that are NaN are encoded as NoDataM in both forms and
+// decoded to NaN again. The methods are generated by gen_encoding.go.
+
+// marshalShape returns the binary form of s.
+func marshalShape(s Shape) ([]byte, error) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, shapeTypeOf(s))
+	s.write(&buf)
+	return buf.Bytes(), nil
+}
+
+// unmarshalShape decodes data into s without decoding "no data" measures.
+func unmarshalShape(s Shape, data []byte) error {
+	er := &errReader{Reader: bytes.NewReader(data), size: int64(len(data)), limits: DefaultLimits}
+	var t ShapeType
+	binary.Read(er, binary.LittleEndian, &t)
+	if er.e != nil {
+		return fmt.Errorf("Error decoding shape type: %v", er.e)
+	}
+	if want := shapeTypeOf(s); t != want {
+		return fmt.Errorf("Cannot decode %v into %v", t, want)
+	}
+	s.read(er)
+	if er.e != nil {
+		return fmt.Errorf("Error decoding %v: %w", t, er.e)
+	}
+	return nil
+}
+
+// UnmarshalShape decodes a shape of any type from the binary form returned by
+// its MarshalBinary method.
+func UnmarshalShape(data []byte) (Shape, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("Error decoding shape type: %d bytes", len(data))
+	}
+	s, err := newShape(ShapeType(binary.LittleEndian.Uint32(data)))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.(interface{ UnmarshalBinary([]byte) error }).UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// unmarshalBinary decodes data into s and decodes its "no data" measures.
+func unmarshalBinary(s Shape, data []byte) error {
+	if err := unmarshalShape(s, data); err != nil {
+		return err
+	}
+	readerOptions{}.decodeMeasures(s)
+	return nil
+}
+
+// vertex is a Point without the methods of a shape, so that the vertices of
+// a shape are encoded without a "type" member.
+type vertex Point
+
+var pointsType = reflect.TypeOf([]Point(nil))
+
+// marshalShapeJSON returns the JSON object of the fields of s with an
+// additional "type" member.
+func marshalShapeJSON(s Shape) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(`{"type":"` + shapeTypeOf(s).String() + `"`)
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		var f interface{}
+		switch fv := v.Field(i); {
+		case fv.Type() == pointsType:
+			var vertices []vertex
+			for _, p := range fv.Interface().([]Point) {
+				vertices = append(vertices, vertex(p))
+			}
+			f = vertices
+		case name == "M":
+			f = encodeMeasure(fv.Float())
+		case name == "MRange":
+			r := fv.Interface().([2]float64)
+			f = [2]float64{encodeMeasure(r[0]), encodeMeasure(r[1])}
+		case name == "MArray":
+			ms := append([]float64(nil), fv.Interface().([]float64)...)
+			for j, m := range ms {
+				ms[j] = encodeMeasure(m)
+			}
+			f = ms
+		default:
+			f = fv.Interface()
+		}
+		data, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, ",%q:", name)
+		b.Write(data)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// unmarshalShapeJSON decodes data into s after checking its "type" member, if
+// present, and decodes its "no data" measures.
+func unmarshalShapeJSON(s Shape, data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	t := shapeTypeOf(s)
+	if raw, ok := members["type"]; ok {
+		var name string
+		if err := json.Unmarshal(raw, &name); err != nil {
+			return err
+		}
+		if name != t.String() {
+			return fmt.Errorf("Cannot decode %s into %v", name, t)
+		}
+	}
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		raw, ok := members[v.Type().Field(i).Name]
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Type() != pointsType {
+			if err := json.Unmarshal(raw, fv.Addr().Interface()); err != nil {
+				return fmt.Errorf("Error decoding %v: %v", t, err)
+			}
+			continue
+		}
+		var vertices []vertex
+		if err := json.Unmarshal(raw, &vertices); err != nil {
+			return fmt.Errorf("Error decoding %v: %v", t, err)
+		}
+		var points []Point
+		for _, p := range vertices {
+			points = append(points, Point(p))
+		}
+		fv.Set(reflect.ValueOf(points))
+	}
+	readerOptions{}.decodeMeasures(s)
+	return nil
+}
+
+// UnmarshalShapeJSON decodes a shape of any type from the JSON form returned
+// by its MarshalJSON method.
+func UnmarshalShapeJSON(data []byte) (Shape, error) {
+	var header struct{ Type string }
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	for t := range _ShapeType_map {
+		if t.String() != header.Type {
+			continue
+		}
+		s, err := newShape(t)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.(json.Unmarshaler).UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("Unsupported shape type: %q", header.Type)
+}
@@ -0,0 +1,283 @@
+// Code generated by "go run gen_encoding.go"; DO NOT EDIT.
+
+package shp
+
+// MarshalBinary implements encoding.BinaryMarshaler for Null.
+func (p *Null) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Null.
+func (p *Null) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for Null.
+func (p *Null) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Null.
+func (p *Null) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Point.
+func (p *Point) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Point.
+func (p *Point) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for Point.
+func (p *Point) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Point.
+func (p *Point) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for PolyLine.
+func (p *PolyLine) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for PolyLine.
+func (p *PolyLine) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for PolyLine.
+func (p *PolyLine) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PolyLine.
+func (p *PolyLine) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Polygon.
+func (p *Polygon) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Polygon.
+func (p *Polygon) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for Polygon.
+func (p *Polygon) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Polygon.
+func (p *Polygon) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for MultiPoint.
+func (p *MultiPoint) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for MultiPoint.
+func (p *MultiPoint) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for MultiPoint.
+func (p *MultiPoint) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MultiPoint.
+func (p *MultiPoint) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for PointZ.
+func (p *PointZ) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for PointZ.
+func (p *PointZ) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for PointZ.
+func (p *PointZ) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PointZ.
+func (p *PointZ) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for PolyLineZ.
+func (p *PolyLineZ) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for PolyLineZ.
+func (p *PolyLineZ) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for PolyLineZ.
+func (p *PolyLineZ) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PolyLineZ.
+func (p *PolyLineZ) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for PolygonZ.
+func (p *PolygonZ) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for PolygonZ.
+func (p *PolygonZ) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for PolygonZ.
+func (p *PolygonZ) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PolygonZ.
+func (p *PolygonZ) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for MultiPointZ.
+func (p *MultiPointZ) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for MultiPointZ.
+func (p *MultiPointZ) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for MultiPointZ.
+func (p *MultiPointZ) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MultiPointZ.
+func (p *MultiPointZ) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for PointM.
+func (p *PointM) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for PointM.
+func (p *PointM) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for PointM.
+func (p *PointM) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PointM.
+func (p *PointM) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for PolyLineM.
+func (p *PolyLineM) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for PolyLineM.
+func (p *PolyLineM) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for PolyLineM.
+func (p *PolyLineM) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PolyLineM.
+func (p *PolyLineM) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for PolygonM.
+func (p *PolygonM) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for PolygonM.
+func (p *PolygonM) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for PolygonM.
+func (p *PolygonM) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PolygonM.
+func (p *PolygonM) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for MultiPointM.
+func (p *MultiPointM) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for MultiPointM.
+func (p *MultiPointM) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for MultiPointM.
+func (p *MultiPointM) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MultiPointM.
+func (p *MultiPointM) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for MultiPatch.
+func (p *MultiPatch) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for MultiPatch.
+func (p *MultiPatch) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for MultiPatch.
+func (p *MultiPatch) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MultiPatch.
+func (p *MultiPatch) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
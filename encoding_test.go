@@ -0,0 +1,117 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func encodingTestShapes() []Shape {
+	l := NewPolyLine([][]Point{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}, {4, 4}}})
+	return []Shape{
+		&Null{},
+		&Point{1, 2},
+		l,
+		&MultiPoint{Box: Box{0, 0, 1, 1}, NumPoints: 2, Points: []Point{{0, 0}, {1, 1}}},
+		&PointZ{1, 2, 3, 4},
+		&PolyLineM{
+			Box:       l.Box,
+			NumParts:  l.NumParts,
+			NumPoints: l.NumPoints,
+			Parts:     l.Parts,
+			Points:    l.Points,
+			MRange:    [2]float64{1, 5},
+			MArray:    []float64{1, 2, math.NaN(), 4, 5},
+		},
+	}
+}
+
+// equalShapes compares shapes, treating NaN measures as equal.
+func equalShapes(a, b Shape) bool {
+	ab, _ := marshalShape(a)
+	bb, _ := marshalShape(b)
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && bytes.Equal(ab, bb)
+}
+
+func TestShapeBinaryMarshaling(t *testing.T) {
+	for _, s := range encodingTestShapes() {
+		b, err := s.(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnmarshalShape(b)
+		if err != nil {
+			t.Fatalf("%T: %v", s, err)
+		}
+		if !equalShapes(got, s) {
+			t.Errorf("got %+v, want %+v", got, s)
+		}
+		if lm, ok := got.(*PolyLineM); ok && !math.IsNaN(lm.MArray[2]) {
+			t.Errorf("got measure %v, want NaN", lm.MArray[2])
+		}
+	}
+
+	var p Point
+	b, _ := (&PointM{1, 2, 3}).MarshalBinary()
+	if err := p.UnmarshalBinary(b); err == nil {
+		t.Error("decoded PointM into Point")
+	}
+	if _, err := UnmarshalShape(b[:10]); err == nil {
+		t.Error("decoded truncated data")
+	}
+}
+
+func TestShapeJSONMarshaling(t *testing.T) {
+	for _, s := range encodingTestShapes() {
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("%T: %v", s, err)
+		}
+		got, err := UnmarshalShapeJSON(b)
+		if err != nil {
+			t.Fatalf("%s: %v", b, err)
+		}
+		if !equalShapes(got, s) {
+			t.Errorf("got %+v, want %+v", got, s)
+		}
+	}
+
+	b, _ := json.Marshal(&Point{1, 2})
+	if string(b) != `{"type":"POINT","X":1,"Y":2}` {
+		t.Errorf("got %s", b)
+	}
+	var p PointM
+	if err := json.Unmarshal(b, &p); err == nil {
+		t.Error("decoded POINT into PointM")
+	}
+
+	l := NewPolyLine([][]Point{{{0, 0}, {1, 2}}})
+	b, _ = json.Marshal(l)
+	want := `{"type":"POLYLINE","Box":{"MinX":0,"MinY":0,"MaxX":1,"MaxY":2},"NumParts":1,"NumPoints":2,"Parts":[0],"Points":[{"X":0,"Y":0},{"X":1,"Y":2}]}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	b, _ = json.Marshal(&PolyLineZ{Box: l.Box, NumParts: 1, NumPoints: 2, Parts: l.Parts, Points: l.Points})
+	want = `{"type":"POLYLINEZ","Box":{"MinX":0,"MinY":0,"MaxX":1,"MaxY":2},"NumParts":1,"NumPoints":2,"Parts":[0],"Points":[{"X":0,"Y":0},{"X":1,"Y":2}],"ZRange":[0,0],"ZArray":null,"MRange":[0,0],"MArray":null}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestShapeGob(t *testing.T) {
+	in := encodingTestShapes()[2].(*PolyLine)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out PolyLine
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&out, in) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
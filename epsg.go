@@ -0,0 +1,253 @@
+package shp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CRS is a coordinate reference system of the embedded EPSG registry.
+type CRS struct {
+	Code int
+	// Name is the name used in the WKT of .prj files written by ESRI
+	// software, e.g. "WGS_1984_UTM_Zone_33N".
+	Name string
+	// EPSGName is the name in the EPSG registry, e.g. "WGS 84 / UTM zone 33N".
+	EPSGName string
+	// WKT is the definition in the ESRI flavor of WKT used in .prj files.
+	WKT string
+	// Geographic is set for coordinate systems in degrees of longitude and
+	// latitude.
+	Geographic bool
+}
+
+// ellipsoid is the name, semi-major axis and inverse flattening of an
+// ellipsoid.
+type ellipsoid struct {
+	name    string
+	a, invF float64
+}
+
+var (
+	wgs84      = ellipsoid{"WGS_1984", 6378137.0, 298.257223563}
+	grs80      = ellipsoid{"GRS_1980", 6378137.0, 298.257222101}
+	clarke1866 = ellipsoid{"Clarke_1866", 6378206.4, 294.9786982}
+	airy1830   = ellipsoid{"Airy_1830", 6377563.396, 299.3249646}
+	bessel1841 = ellipsoid{"Bessel_1841", 6377397.155, 299.1528128}
+)
+
+// geographicCRS is a geographic coordinate system that projected ones are
+// based on.
+type geographicCRS struct {
+	code      int
+	name      string // without the "GCS_" prefix
+	epsgName  string
+	datum     string // without the "D_" prefix
+	ellipsoid ellipsoid
+}
+
+var (
+	gcsWGS84      = geographicCRS{4326, "WGS_1984", "WGS 84", "WGS_1984", wgs84}
+	gcsNAD83      = geographicCRS{4269, "North_American_1983", "NAD83", "North_American_1983", grs80}
+	gcsNAD27      = geographicCRS{4267, "North_American_1927", "NAD27", "North_American_1927", clarke1866}
+	gcsETRS89     = geographicCRS{4258, "ETRS_1989", "ETRS89", "European_Terrestrial_Reference_System_1989", grs80}
+	gcsOSGB36     = geographicCRS{4277, "OSGB_1936", "OSGB36", "OSGB_1936", airy1830}
+	gcsRGF93      = geographicCRS{4171, "RGF_1993", "RGF93", "RGF_1993", grs80}
+	gcsAmersfoort = geographicCRS{4289, "Amersfoort", "Amersfoort", "Amersfoort", bessel1841}
+	gcsGDA94      = geographicCRS{4283, "GDA_1994", "GDA94", "GDA_1994", grs80}
+)
+
+func (g geographicCRS) wkt() string {
+	e := g.ellipsoid
+	return fmt.Sprintf(`GEOGCS["GCS_%s",DATUM["D_%s",SPHEROID["%s",%s,%s]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`,
+		g.name, g.datum, e.name, wktNumber(e.a), wktNumber(e.invF))
+}
+
+// param is a parameter of a projection.
+type param struct {
+	name  string
+	value float64
+}
+
+func projected(code int, name, epsgName string, g geographicCRS, projection string, params ...param) CRS {
+	var b strings.Builder
+	fmt.Fprintf(&b, `PROJCS["%s",%s,PROJECTION["%s"]`, name, g.wkt(), projection)
+	for _, p := range params {
+		fmt.Fprintf(&b, `,PARAMETER["%s",%s]`, p.name, wktNumber(p.value))
+	}
+	b.WriteString(`,UNIT["Meter",1.0]]`)
+	return CRS{Code: code, Name: name, EPSGName: epsgName, WKT: b.String()}
+}
+
+func transverseMercator(falseEasting, falseNorthing, centralMeridian, scale, latitudeOfOrigin float64) []param {
+	return []param{
+		{"False_Easting", falseEasting},
+		{"False_Northing", falseNorthing},
+		{"Central_Meridian", centralMeridian},
+		{"Scale_Factor", scale},
+		{"Latitude_Of_Origin", latitudeOfOrigin},
+	}
+}
+
+// wktNumber formats v like ESRI software does, with at least one decimal.
+func wktNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// epsgRegistry holds the coordinate systems known to LookupEPSG by code.
+var epsgRegistry = map[int]CRS{}
+
+func registerCRS(c CRS) {
+	epsgRegistry[c.Code] = c
+}
+
+func init() {
+	for _, g := range []geographicCRS{gcsWGS84, gcsNAD83, gcsNAD27, gcsETRS89, gcsOSGB36, gcsRGF93, gcsAmersfoort, gcsGDA94} {
+		registerCRS(CRS{Code: g.code, Name: "GCS_" + g.name, EPSGName: g.epsgName, WKT: g.wkt(), Geographic: true})
+	}
+
+	utm := func(zone int) float64 { return float64(zone*6 - 183) }
+	for zone := 1; zone <= 60; zone++ {
+		registerCRS(projected(32600+zone, fmt.Sprintf("WGS_1984_UTM_Zone_%dN", zone), fmt.Sprintf("WGS 84 / UTM zone %dN", zone),
+			gcsWGS84, "Transverse_Mercator", transverseMercator(500000, 0, utm(zone), 0.9996, 0)...))
+		registerCRS(projected(32700+zone, fmt.Sprintf("WGS_1984_UTM_Zone_%dS", zone), fmt.Sprintf("WGS 84 / UTM zone %dS", zone),
+			gcsWGS84, "Transverse_Mercator", transverseMercator(500000, 10000000, utm(zone), 0.9996, 0)...))
+	}
+	for zone := 1; zone <= 23; zone++ {
+		registerCRS(projected(26900+zone, fmt.Sprintf("NAD_1983_UTM_Zone_%dN", zone), fmt.Sprintf("NAD83 / UTM zone %dN", zone),
+			gcsNAD83, "Transverse_Mercator", transverseMercator(500000, 0, utm(zone), 0.9996, 0)...))
+	}
+	for zone := 1; zone <= 22; zone++ {
+		registerCRS(projected(26700+zone, fmt.Sprintf("NAD_1927_UTM_Zone_%dN", zone), fmt.Sprintf("NAD27 / UTM zone %dN", zone),
+			gcsNAD27, "Transverse_Mercator", transverseMercator(500000, 0, utm(zone), 0.9996, 0)...))
+	}
+	for zone := 28; zone <= 38; zone++ {
+		registerCRS(projected(25800+zone, fmt.Sprintf("ETRS_1989_UTM_Zone_%dN", zone), fmt.Sprintf("ETRS89 / UTM zone %dN", zone),
+			gcsETRS89, "Transverse_Mercator", transverseMercator(500000, 0, utm(zone), 0.9996, 0)...))
+	}
+	for zone := 48; zone <= 58; zone++ {
+		registerCRS(projected(28300+zone, fmt.Sprintf("GDA_1994_MGA_Zone_%d", zone), fmt.Sprintf("GDA94 / MGA zone %d", zone),
+			gcsGDA94, "Transverse_Mercator", transverseMercator(500000, 10000000, utm(zone), 0.9996, 0)...))
+	}
+
+	registerCRS(projected(3857, "WGS_1984_Web_Mercator_Auxiliary_Sphere", "WGS 84 / Pseudo-Mercator", gcsWGS84,
+		"Mercator_Auxiliary_Sphere",
+		param{"False_Easting", 0}, param{"False_Northing", 0}, param{"Central_Meridian", 0},
+		param{"Standard_Parallel_1", 0}, param{"Auxiliary_Sphere_Type", 0}))
+	registerCRS(projected(27700, "British_National_Grid", "OSGB36 / British National Grid", gcsOSGB36,
+		"Transverse_Mercator", transverseMercator(400000, -100000, -2, 0.9996012717, 49)...))
+	registerCRS(projected(2154, "RGF_1993_Lambert_93", "RGF93 / Lambert-93", gcsRGF93, "Lambert_Conformal_Conic",
+		param{"False_Easting", 700000}, param{"False_Northing", 6600000}, param{"Central_Meridian", 3},
+		param{"Standard_Parallel_1", 44}, param{"Standard_Parallel_2", 49}, param{"Latitude_Of_Origin", 46.5}))
+	registerCRS(projected(28992, "RD_New", "Amersfoort / RD New", gcsAmersfoort, "Double_Stereographic",
+		param{"False_Easting", 155000}, param{"False_Northing", 463000}, param{"Central_Meridian", 5.38763888888889},
+		param{"Scale_Factor", 0.9999079}, param{"Latitude_Of_Origin", 52.15616055555555}))
+	registerCRS(projected(3035, "ETRS_1989_LAEA", "ETRS89 / LAEA Europe", gcsETRS89, "Lambert_Azimuthal_Equal_Area",
+		param{"False_Easting", 4321000}, param{"False_Northing", 3210000}, param{"Central_Meridian", 10},
+		param{"Latitude_Of_Origin", 52}))
+	registerCRS(projected(5070, "NAD_1983_Contiguous_USA_Albers", "NAD83 / Conus Albers", gcsNAD83, "Albers",
+		param{"False_Easting", 0}, param{"False_Northing", 0}, param{"Central_Meridian", -96},
+		param{"Standard_Parallel_1", 29.5}, param{"Standard_Parallel_2", 45.5}, param{"Latitude_Of_Origin", 23}))
+
+	for code, c := range epsgRegistry {
+		crsByName[normalizeCRSName(c.Name)] = code
+		crsByName[normalizeCRSName(c.EPSGName)] = code
+	}
+}
+
+// crsByName maps the normalized names of the registered coordinate systems
+// to their codes.
+var crsByName = map[string]int{}
+
+// normalizeCRSName lowercases name and drops everything but letters and
+// digits, so that the ESRI and EPSG spellings of names are more likely to
+// match.
+func normalizeCRSName(name string) string {
+	name = strings.TrimPrefix(name, "GCS_")
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// LookupEPSG returns the coordinate system with the EPSG code from the
+// embedded registry. It covers the WGS 84 UTM zones, the UTM zones of NAD83,
+// NAD27, ETRS89 and GDA94, Web Mercator, common geographic systems and a few
+// national grids.
+func LookupEPSG(code int) (CRS, bool) {
+	c, ok := epsgRegistry[code]
+	return c, ok
+}
+
+// IdentifyEPSG returns the EPSG code of the coordinate system described by
+// the WKT contents of a .prj file. It uses the EPSG authority of the outermost
+// object if it has one, and otherwise looks up its name in the embedded
+// registry. The authorities of nested objects, like the GEOGCS or the UNIT of
+// a PROJCS, are not those of the coordinate system and are ignored.
+func IdentifyEPSG(wkt string) (code int, ok bool) {
+	wkt = strings.TrimSpace(wkt)
+	if args, found := rootAuthority(wkt); found {
+		fields := strings.Split(args, ",")
+		if len(fields) == 2 && strings.EqualFold(strings.Trim(strings.TrimSpace(fields[0]), `"`), "EPSG") {
+			if code, err := strconv.Atoi(strings.Trim(strings.TrimSpace(fields[1]), `"`)); err == nil {
+				return code, true
+			}
+		}
+	}
+	start := strings.IndexByte(wkt, '"')
+	if start < 0 {
+		return 0, false
+	}
+	end := strings.IndexByte(wkt[start+1:], '"')
+	if end < 0 {
+		return 0, false
+	}
+	code, ok = crsByName[normalizeCRSName(wkt[start+1:start+1+end])]
+	return code, ok
+}
+
+// rootAuthority returns the arguments of the AUTHORITY that is a direct child
+// of the outermost object of wkt, e.g. `"EPSG","32633"`.
+func rootAuthority(wkt string) (string, bool) {
+	depth, quoted, keyword := 0, false, 0
+	for i := 0; i < len(wkt); i++ {
+		switch c := wkt[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[' || c == '(':
+			depth++
+			if depth == 2 && strings.EqualFold(strings.TrimSpace(wkt[keyword:i]), "AUTHORITY") {
+				end := strings.IndexAny(wkt[i+1:], "])")
+				if end < 0 {
+					return "", false
+				}
+				return wkt[i+1 : i+1+end], true
+			}
+		case c == ']' || c == ')':
+			depth--
+		case c == ',':
+			keyword = i + 1
+		}
+	}
+	return "", false
+}
+
+// WritePrjForEPSG writes the .prj file of the shapefile filename with the
+// definition of the coordinate system with the EPSG code from the embedded
+// registry.
+func WritePrjForEPSG(filename string, code int) error {
+	c, ok := LookupEPSG(code)
+	if !ok {
+		return fmt.Errorf("Unknown EPSG code %d", code)
+	}
+	return os.WriteFile(trimSidecarExt(filename)+".prj", []byte(c.WKT), 0666)
+}
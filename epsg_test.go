@@ -0,0 +1,62 @@
+package shp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookupEPSG(t *testing.T) {
+	c, ok := LookupEPSG(32633)
+	if !ok || c.Name != "WGS_1984_UTM_Zone_33N" || c.Geographic {
+		t.Fatalf("got %+v, %v", c, ok)
+	}
+	if !strings.Contains(c.WKT, `PARAMETER["Central_Meridian",15.0]`) {
+		t.Errorf("got WKT %s", c.WKT)
+	}
+	if name, meters, ok := LinearUnit(c.WKT); !ok || name != "Meter" || meters != 1 {
+		t.Errorf("got linear unit %q, %v, %v", name, meters, ok)
+	}
+	if c, _ := LookupEPSG(4326); c.WKT != wktWGS84 || !c.Geographic {
+		t.Errorf("got WKT %s", c.WKT)
+	}
+	if _, ok := LookupEPSG(1); ok {
+		t.Error("found EPSG:1")
+	}
+}
+
+func TestIdentifyEPSG(t *testing.T) {
+	for code, c := range epsgRegistry {
+		if got, ok := IdentifyEPSG(c.WKT); !ok || got != code {
+			t.Errorf("identified %s as %d, %v", c.Name, got, ok)
+		}
+	}
+	for wkt, want := range map[string]int{
+		`PROJCS["WGS 84 / UTM zone 10N",GEOGCS["WGS 84",AUTHORITY["EPSG","4326"]],AUTHORITY["EPSG","32610"]]`: 32610,
+		`PROJCS["RGF93 / Lambert-93",GEOGCS["RGF93"]]`:                                                        2154,
+		wktStatePlaneFeet: 0,
+		`PROJCS["WGS_1984_UTM_Zone_33N",GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],PARAMETER["Central_Meridian",15.0],UNIT["Meter",1.0,AUTHORITY["EPSG","9001"]]]`: 32633,
+	} {
+		if got, ok := IdentifyEPSG(wkt); got != want || ok != (want != 0) {
+			t.Errorf("identified %s as %d, %v, want %d", wkt, got, ok, want)
+		}
+	}
+}
+
+func TestWritePrjForEPSG(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "utm.shp")
+	if err := WritePrjForEPSG(filename, 25832); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(strings.TrimSuffix(filename, ".shp") + ".prj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, ok := IdentifyEPSG(string(data)); !ok || code != 25832 {
+		t.Errorf("identified %d, %v", code, ok)
+	}
+	if err := WritePrjForEPSG(filename, 1); err == nil {
+		t.Error("wrote unknown code")
+	}
+}
@@ -12,6 +12,12 @@ type errReader struct {
 	io.Reader
 	e error
 	n int64
+
+	// size is the number of bytes that may be read, as declared by the
+	// record header. If zero, counts are only checked against limits.
+	size   int64
+	limits Limits
+	pooled bool // take slices from the pools, see PooledShapes
 }
 
 func (er *errReader) Read(p []byte) (n int, err error) {
@@ -0,0 +1,102 @@
+package shp
+
+import "io"
+
+// MaxFileSize is the size of 2 GB that most software requires every file of
+// a shapefile to stay below, as the offsets in the SHX file are 32-bit
+// integers read as signed by many implementations.
+const MaxFileSize int64 = 1<<31 - 1
+
+// ShapeStats summarizes the shapes of a shapefile for EstimateSize.
+type ShapeStats struct {
+	Type    ShapeType
+	Records int64 // number of records, including Null shapes
+	Parts   int64 // total number of parts of all shapes
+	Points  int64 // total number of points of all shapes
+}
+
+// Add counts s, e.g. to extrapolate the size of an export from a sample.
+func (st *ShapeStats) Add(s Shape) {
+	st.Records++
+	switch s.(type) {
+	case *Point, *PointZ, *PointM:
+		st.Points++
+		return
+	}
+	if a, ok := arraysOf(s); ok {
+		st.Parts += int64(len(a.parts))
+		st.Points += int64(len(a.points))
+	}
+}
+
+// SizeEstimate is the size in bytes of the files of a shapefile.
+type SizeEstimate struct {
+	SHP, SHX, DBF int64
+}
+
+// Total returns the size of all files.
+func (e SizeEstimate) Total() int64 {
+	return e.SHP + e.SHX + e.DBF
+}
+
+// Exceeds returns whether any file is larger than limit, e.g. MaxFileSize.
+func (e SizeEstimate) Exceeds(limit int64) bool {
+	return e.SHP > limit || e.SHX > limit || e.DBF > limit
+}
+
+// EstimateSize returns the size of the shapefile that a Writer creates for
+// records with the fields of schema and the shapes summarized by stats, so
+// services can check that an export fits MaxFileSize or a quota before doing
+// the work. The sizes are exact if all shapes are of stats.Type and none of
+// them is a Null shape.
+func EstimateSize(schema Schema, stats ShapeStats) SizeEstimate {
+	n, parts, points := stats.Records, stats.Parts, stats.Points
+	var content int64 // of all records, without their headers
+	switch stats.Type {
+	case POINT:
+		content = 16 * n
+	case POINTM:
+		content = 24 * n
+	case POINTZ:
+		content = 32 * n
+	case MULTIPOINT:
+		content = 36*n + 16*points
+	case MULTIPOINTM:
+		content = 52*n + 24*points
+	case MULTIPOINTZ:
+		content = 68*n + 32*points
+	case POLYLINE, POLYGON:
+		content = 40*n + 4*parts + 16*points
+	case POLYLINEM, POLYGONM:
+		content = 56*n + 4*parts + 24*points
+	case POLYLINEZ, POLYGONZ:
+		content = 72*n + 4*parts + 32*points
+	case MULTIPATCH:
+		content = 72*n + 8*parts + 32*points
+	}
+	recordLength := int64(1)
+	for _, f := range schema.Fields {
+		recordLength += int64(f.Size)
+	}
+	return SizeEstimate{
+		SHP: 100 + 12*n + content, // record header and shape type
+		SHX: 100 + 8*n,
+		DBF: int64(len(schema.Fields))*32 + 33 + n*recordLength,
+	}
+}
+
+// BytesWritten returns the number of bytes written to the files of w so far,
+// including their headers, to report the progress of an export.
+func (w *Writer) BytesWritten() int64 {
+	cur, err := w.shp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	shp, _ := w.shp.Seek(0, io.SeekEnd)
+	w.shp.Seek(cur, io.SeekStart)
+	n := shp + 100 + 8*int64(w.num)
+	if w.dbf != nil {
+		n += int64(w.dbfHeaderLength) + int64(w.num)*int64(w.dbfRecordLength)
+	}
+	return n
+}
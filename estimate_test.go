@@ -0,0 +1,63 @@
+package shp
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEstimateSize(t *testing.T) {
+	filename := filenamePrefix + "estimate"
+	defer removeShapefile(filename)
+
+	lineZ := &PolyLineZ{
+		NumParts:  2,
+		NumPoints: 5,
+		Parts:     []int32{0, 2},
+		Points:    []Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}},
+		ZArray:    []float64{1, 2, 3, 4, 5},
+		MArray:    []float64{1, 2, 3, 4, 5},
+	}
+	schema := Schema{Fields: []Field{StringField("NAME", 20), NumberField("POP", 10)}}
+	for _, c := range []struct {
+		t     ShapeType
+		shape Shape
+	}{
+		{POLYLINEZ, lineZ},
+		{POINT, &Point{1, 2}},
+	} {
+		w, err := Create(filename+".shp", c.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.SetFields(schema.Fields)
+		stats := ShapeStats{Type: c.t}
+		for i := 0; i < 7; i++ {
+			w.Write(c.shape)
+			stats.Add(c.shape)
+		}
+		written := w.BytesWritten()
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		size := func(ext string) int64 {
+			fi, err := os.Stat(filename + ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return fi.Size()
+		}
+		got := SizeEstimate{size(".shp"), size(".shx"), size(".dbf")}
+		if want := EstimateSize(schema, stats); got != want {
+			t.Errorf("%v: got files of %+v, estimated %+v", c.t, got, want)
+		}
+		if written != got.Total() {
+			t.Errorf("%v: got %d bytes written, files of %d", c.t, written, got.Total())
+		}
+	}
+
+	huge := EstimateSize(schema, ShapeStats{Type: POINT, Records: 100000000})
+	if !huge.Exceeds(MaxFileSize) || huge.SHX > MaxFileSize {
+		t.Errorf("got %+v for 100 million points", huge)
+	}
+}
@@ -0,0 +1,36 @@
+package shp
+
+// ExtentTracker accumulates the bounding box of a sequence of shapes. This is
+// the logic Writer uses for the bounding box in the file header, so extents
+// computed with an ExtentTracker match those of a file written from the same
+// shapes. The zero value is ready to use.
+type ExtentTracker struct {
+	box Box
+	n   int
+}
+
+// Add extends the extent with the bounding box of s.
+func (e *ExtentTracker) Add(s Shape) {
+	e.AddBox(s.BBox())
+}
+
+// AddBox extends the extent with b.
+func (e *ExtentTracker) AddBox(b Box) {
+	if e.n == 0 {
+		e.box = b
+	} else {
+		e.box.Extend(b)
+	}
+	e.n++
+}
+
+// Box returns the extent of all shapes added so far. If nothing was added, it
+// is the zero Box.
+func (e *ExtentTracker) Box() Box {
+	return e.box
+}
+
+// Count returns the number of shapes and boxes that were added.
+func (e *ExtentTracker) Count() int {
+	return e.n
+}
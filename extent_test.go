@@ -0,0 +1,46 @@
+package shp
+
+import "testing"
+
+func TestExtentTracker(t *testing.T) {
+	var e ExtentTracker
+	if e.Box() != (Box{}) {
+		t.Errorf("got %v for empty tracker", e.Box())
+	}
+	e.Add(&Point{5, 5})
+	if e.Box() != (Box{5, 5, 5, 5}) {
+		t.Errorf("got %v after first point, want {5 5 5 5}", e.Box())
+	}
+	e.Add(NewPolyLine([][]Point{{{1, 7}, {3, 9}}}))
+	e.AddBox(Box{4, 0, 6, 1})
+	if want := (Box{1, 0, 6, 9}); e.Box() != want {
+		t.Errorf("got %v, want %v", e.Box(), want)
+	}
+	if e.Count() != 3 {
+		t.Errorf("got count %d, want 3", e.Count())
+	}
+}
+
+func TestExtentTrackerMatchesWriter(t *testing.T) {
+	filename := filenamePrefix + "extent"
+	defer removeShapefile(filename)
+	w, err := Create(filename+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e ExtentTracker
+	for _, p := range []Point{{-3, 2}, {4, -1}, {0, 10}} {
+		p := p
+		w.Write(&p)
+		e.Add(&p)
+	}
+	w.Close()
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.BBox() != e.Box() {
+		t.Errorf("got %v from file, %v from tracker", r.BBox(), e.Box())
+	}
+}
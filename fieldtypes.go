@@ -0,0 +1,152 @@
+package shp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// LogicalField returns a Field that can be used in SetFields to initialize the
+// DBF file. Used to store booleans as T, F or ? for unknown.
+func LogicalField(name string) Field {
+	field := Field{Fieldtype: 'L', Size: 1}
+	copy(field.Name[:], []byte(name))
+	return field
+}
+
+// CurrencyField returns a Field that can be used in SetFields to initialize
+// the DBF file. Used to store amounts of money in the FoxPro currency format:
+// a binary 64-bit integer of ten-thousandths.
+func CurrencyField(name string) Field {
+	field := Field{Fieldtype: 'Y', Size: 8, Precision: 4}
+	copy(field.Name[:], []byte(name))
+	return field
+}
+
+// Currency is an amount of money in ten-thousandths, as stored in currency
+// fields.
+type Currency int64
+
+// CurrencyFromFloat returns the Currency closest to f.
+func CurrencyFromFloat(f float64) Currency {
+	return Currency(math.Round(f * 10000))
+}
+
+// Float64 returns c as a float.
+func (c Currency) Float64() float64 {
+	return float64(c) / 10000
+}
+
+// String formats c with four decimals, e.g. "-12.3400".
+func (c Currency) String() string {
+	sign := ""
+	u := uint64(c)
+	if c < 0 {
+		sign, u = "-", uint64(-c)
+	}
+	return fmt.Sprintf("%s%d.%04d", sign, u/10000, u%10000)
+}
+
+// encodeCurrency returns the 8 bytes of a currency field holding v.
+func encodeCurrency(v interface{}) ([]byte, error) {
+	var c Currency
+	switch v := v.(type) {
+	case Currency:
+		c = v
+	case int:
+		c = Currency(v) * 10000
+	case float64:
+		c = CurrencyFromFloat(v)
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid currency value %q", v)
+		}
+		c = CurrencyFromFloat(f)
+	default:
+		return nil, fmt.Errorf("Unsupported value type for currency field: %T", v)
+	}
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(c))
+	return buf, nil
+}
+
+// decodeCurrency formats the raw 8 bytes of a currency field like
+// Currency.String, or returns "" if raw is not 8 bytes long.
+func decodeCurrency(raw []byte) string {
+	if len(raw) != 8 {
+		return ""
+	}
+	return Currency(binary.LittleEndian.Uint64(raw)).String()
+}
+
+// ErrNull is returned by the typed attribute accessors for empty values and
+// unknown logical values.
+var ErrNull = errors.New("Attribute is null")
+
+// AttributeReader is the part of Reader, ZipReader and SequentialReader that
+// the typed attribute accessors use.
+type AttributeReader interface {
+	Attribute(n int) string
+	Fields() []Field
+}
+
+// AttributeBool returns the value of the n-th attribute of the current record
+// of r, which should be a logical field. T, t, Y and y are true; F, f, N and
+// n are false; ? and empty values are ErrNull.
+func AttributeBool(r AttributeReader, n int) (bool, error) {
+	switch s := strings.Trim(r.Attribute(n), " \x00"); s {
+	case "T", "t", "Y", "y":
+		return true, nil
+	case "F", "f", "N", "n":
+		return false, nil
+	case "?", "":
+		return false, ErrNull
+	default:
+		return false, fmt.Errorf("Invalid logical value %q", s)
+	}
+}
+
+// AttributeFloat returns the value of the n-th attribute of the current
+// record of r, which should be a number, float or currency field. Empty values
+// are ErrNull.
+func AttributeFloat(r AttributeReader, n int) (float64, error) {
+	s := strings.Trim(r.Attribute(n), " \x00")
+	if s == "" {
+		return 0, ErrNull
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid number %q", s)
+	}
+	return f, nil
+}
+
+// AttributeCurrency returns the value of the n-th attribute of the current
+// record of r, which should be a currency field, without rounding errors.
+// Values of number fields are converted as well.
+func AttributeCurrency(r AttributeReader, n int) (Currency, error) {
+	s := strings.Trim(r.Attribute(n), " \x00")
+	if s == "" {
+		return 0, ErrNull
+	}
+	intPart, frac, _ := strings.Cut(s, ".")
+	neg := strings.HasPrefix(intPart, "-")
+	i, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil && intPart != "-" && intPart != "" {
+		return 0, fmt.Errorf("Invalid currency value %q", s)
+	}
+	frac = (frac + "0000")[:4]
+	f, err := strconv.ParseUint(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid currency value %q", s)
+	}
+	c := Currency(i)*10000 + Currency(f)
+	if neg {
+		c = Currency(i)*10000 - Currency(f)
+	}
+	return c, nil
+}
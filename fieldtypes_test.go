@@ -0,0 +1,119 @@
+package shp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func writeTyped(t *testing.T) string {
+	filename := filepath.Join(t.TempDir(), "typed.shp")
+	w, err := Create(filename, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{LogicalField("ACTIVE"), FloatField("RATE", 8, 3), CurrencyField("BUDGET")})
+	for row, values := range [][]interface{}{
+		{true, 1.25, 1234.5},
+		{false, -0.5, Currency(-12345)},
+		{"?", "", 7},
+	} {
+		w.Write(&Point{float64(row), 0})
+		for i, v := range values {
+			if err := w.WriteAttribute(row, i, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestTypedAttributes(t *testing.T) {
+	filename := writeTyped(t)
+	type row struct {
+		active     bool
+		activeErr  error
+		rate       float64
+		rateErr    error
+		budget     Currency
+		budgetText string
+	}
+	want := []row{
+		{true, nil, 1.25, nil, 12345000, "1234.5000"},
+		{false, nil, -0.5, nil, -12345, "-1.2345"},
+		{false, ErrNull, 0, ErrNull, 70000, "7.0000"},
+	}
+	check := func(name string, r AttributeReader, i int) {
+		var got row
+		got.active, got.activeErr = AttributeBool(r, 0)
+		got.rate, got.rateErr = AttributeFloat(r, 1)
+		got.budget, _ = AttributeCurrency(r, 2)
+		got.budgetText = r.Attribute(2)
+		if got != want[i] {
+			t.Errorf("%s: got row %d %+v, want %+v", name, i, got, want[i])
+		}
+	}
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for i := 0; r.Next(); i++ {
+		check("Reader", r, i)
+	}
+	if attrs, err := r.AttributesAt(1); err != nil || attrs[2] != "-1.2345" {
+		t.Errorf("got attributes %q, %v", attrs, err)
+	}
+
+	if f := r.Fields()[2]; f.Fieldtype != 'Y' || f.Size != 8 {
+		t.Errorf("got field %+v", f)
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	for c, want := range map[Currency]string{0: "0.0000", 12345: "1.2345", -1: "-0.0001", 100000000: "10000.0000"} {
+		if got := c.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if c := CurrencyFromFloat(0.1 + 0.2); c != 3000 {
+		t.Errorf("got %v, want 0.3000", c)
+	}
+}
+
+func TestSequentialCurrency(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "currency.shp")
+	w, err := Create(filename, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 4), CurrencyField("BUDGET")})
+	// the binary values start or end with spaces and zeros
+	values := []Currency{0x20, 0x2020202020202020, 0x2000000000000000, -12345}
+	for row, v := range values {
+		w.Write(&Point{float64(row), 0})
+		w.WriteAttribute(row, 0, "x")
+		if err := w.WriteAttribute(row, 1, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dbfName := filename[:len(filename)-4] + ".dbf"
+	sr := SequentialReaderFromExt(openFile(filename, t), openFile(dbfName, t))
+	defer sr.Close()
+	i := 0
+	for ; sr.Next(); i++ {
+		if got := sr.Attribute(1); got != values[i].String() {
+			t.Errorf("row %d: got %q, want %q", i, got, values[i].String())
+		}
+	}
+	if sr.Err() != nil || i != len(values) {
+		t.Errorf("read %d rows: %v", i, sr.Err())
+	}
+}
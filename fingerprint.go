@@ -0,0 +1,213 @@
+package shp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RecordHash is the SHA-256 hash of the content of a record in the SHP file
+// and its row in the DBF file. It does not include the record number, so
+// records keep their hash when records before them are deleted.
+type RecordHash [sha256.Size]byte
+
+// Manifest is a fingerprint of the records of a shapefile: the key and the
+// hash of every record. DiffSince compares a shapefile to a Manifest taken
+// earlier, which can be stored with WriteManifest and ReadManifest.
+type Manifest struct {
+	// KeyField is the name of the field whose values identify records, or ""
+	// if records are identified by their index.
+	KeyField string
+	Keys     []string
+	Hashes   []RecordHash
+}
+
+// Manifest returns the Manifest of the records of r. If keyField is not "",
+// records are identified by the value of that field, which is matched
+// case-insensitively and must be unique; otherwise they are identified by
+// their index. The records are read with random access, so r may be used for
+// other reads before or after, but not concurrently.
+func (r *Reader) Manifest(keyField string) (*Manifest, error) {
+	m := &Manifest{KeyField: keyField}
+	err := r.eachRecordHash(keyField, func(i int, key string, h RecordHash, content, row []byte) error {
+		m.Keys = append(m.Keys, key)
+		m.Hashes = append(m.Hashes, h)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// eachRecordHash calls fn with the index, key, hash, content and DBF row of
+// every record of r, in order. row is nil if there is no DBF row.
+func (r *Reader) eachRecordHash(keyField string, fn func(i int, key string, h RecordHash, content, row []byte) error) error {
+	r.loadIndex()
+	if r.indexErr != nil {
+		return r.indexErr
+	}
+	shp, ok := r.shp.(io.ReaderAt)
+	if !ok {
+		return errors.New("SHP file does not support random access")
+	}
+	var dbf io.ReaderAt
+	if r.dbf != nil {
+		if dbf, ok = r.dbf.(io.ReaderAt); !ok {
+			return errors.New("DBF file does not support random access")
+		}
+	}
+	keyCol := -1
+	if keyField != "" {
+		col, ok := columnIndex(r.dbfFields)[strings.ToUpper(keyField)]
+		if !ok || dbf == nil {
+			return fmt.Errorf("Key field %s not found", keyField)
+		}
+		keyCol = col
+	}
+
+	seen := make(map[string]bool)
+	for i, e := range r.index {
+		if err := r.limits.checkRecordSize(e.length); err != nil {
+			return fmt.Errorf("Error when reading record %d: %w", i, err)
+		}
+		content := make([]byte, e.length)
+		if _, err := shp.ReadAt(content, e.offset+8); err != nil {
+			return fmt.Errorf("Error when reading record %d: %v", i, err)
+		}
+		var row []byte
+		if dbf != nil && i < int(r.dbfNumRecords) {
+			row = make([]byte, r.dbfRecordLength)
+			offset := int64(r.dbfHeaderLength) + int64(i)*int64(r.dbfRecordLength)
+			if _, err := dbf.ReadAt(row, offset); err != nil {
+				return fmt.Errorf("Error when reading row %d: %v", i, err)
+			}
+		}
+
+		key := strconv.Itoa(i)
+		if keyCol >= 0 {
+			if row == nil {
+				return fmt.Errorf("Record %d has no DBF row for its key", i)
+			}
+			key = strings.TrimRight(r.rowAttribute(row, keyCol), "\x00")
+			if seen[key] {
+				return fmt.Errorf("Key %q of record %d is not unique", key, i)
+			}
+			seen[key] = true
+		}
+		if err := fn(i, key, hashRecord(content, row), content, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashRecord returns the RecordHash of a record with the given content and
+// DBF row.
+func hashRecord(content, row []byte) RecordHash {
+	h := sha256.New()
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(content)))
+	h.Write(n[:])
+	h.Write(content)
+	h.Write(row)
+	var sum RecordHash
+	h.Sum(sum[:0])
+	return sum
+}
+
+const manifestMagic = "SHPM"
+
+// WriteManifest writes m to w in a binary format that ReadManifest reads.
+func WriteManifest(w io.Writer, m *Manifest) error {
+	if len(m.Keys) != len(m.Hashes) {
+		return fmt.Errorf("Manifest has %d keys but %d hashes", len(m.Keys), len(m.Hashes))
+	}
+	var buf bytes.Buffer
+	buf.WriteString(manifestMagic)
+	writeString := func(s string) {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		buf.WriteString(s)
+	}
+	writeString(m.KeyField)
+	buf.Write(binary.AppendUvarint(nil, uint64(len(m.Keys))))
+	for i, key := range m.Keys {
+		writeString(key)
+		buf.Write(m.Hashes[i][:])
+		if buf.Len() >= 1<<16 {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadManifest reads a Manifest written by WriteManifest.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	rd, ok := r.(byteReaderReader)
+	if !ok {
+		rd = &byteReader{Reader: r}
+	}
+	magic := make([]byte, len(manifestMagic))
+	if _, err := io.ReadFull(rd, magic); err != nil || string(magic) != manifestMagic {
+		return nil, errors.New("Not a manifest")
+	}
+	readString := func() (string, error) {
+		n, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return "", err
+		}
+		if n > 1<<20 {
+			return "", fmt.Errorf("String of %d bytes is too long", n)
+		}
+		s := make([]byte, n)
+		_, err = io.ReadFull(rd, s)
+		return string(s), err
+	}
+	m := &Manifest{}
+	var err error
+	if m.KeyField, err = readString(); err != nil {
+		return nil, fmt.Errorf("Error when reading manifest: %v", err)
+	}
+	n, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("Error when reading manifest: %v", err)
+	}
+	for i := uint64(0); i < n; i++ {
+		key, err := readString()
+		if err != nil {
+			return nil, fmt.Errorf("Error when reading manifest: %v", err)
+		}
+		var h RecordHash
+		if _, err := io.ReadFull(rd, h[:]); err != nil {
+			return nil, fmt.Errorf("Error when reading manifest: %v", err)
+		}
+		m.Keys = append(m.Keys, key)
+		m.Hashes = append(m.Hashes, h)
+	}
+	return m, nil
+}
+
+type byteReaderReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// byteReader adds ReadByte to a Reader, without reading ahead.
+type byteReader struct {
+	io.Reader
+	b [1]byte
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(r.Reader, r.b[:])
+	return r.b[0], err
+}
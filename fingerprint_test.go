@@ -0,0 +1,80 @@
+package shp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	filename := filenamePrefix + "manifest"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	m, err := r.Manifest("name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Springfield", "Shelbyville"}; !reflect.DeepEqual(m.Keys, want) {
+		t.Errorf("Keys = %q, want %q", m.Keys, want)
+	}
+	if m.Hashes[0] == m.Hashes[1] {
+		t.Error("different records have the same hash")
+	}
+	byIndex, err := r.Manifest("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"0", "1"}; !reflect.DeepEqual(byIndex.Keys, want) {
+		t.Errorf("Keys by index = %q, want %q", byIndex.Keys, want)
+	}
+	if !reflect.DeepEqual(byIndex.Hashes, m.Hashes) {
+		t.Error("hashes depend on the key field")
+	}
+	if _, err := r.Manifest("MISSING"); err == nil {
+		t.Error("Manifest with a missing key field succeeded")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("ReadManifest() = %+v, want %+v", got, m)
+	}
+	if _, err := ReadManifest(bytes.NewReader([]byte("SHPX"))); err == nil {
+		t.Error("ReadManifest of another format succeeded")
+	}
+}
+
+func TestManifestDuplicateKey(t *testing.T) {
+	filename := filenamePrefix + "manifest_duplicate"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+	tx, err := Edit(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.UpdateAttr(1, 0, "Springfield")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := r.Manifest("NAME"); err == nil {
+		t.Error("Manifest with duplicate keys succeeded")
+	}
+}
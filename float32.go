@@ -0,0 +1,189 @@
+package shp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrFloat32Coords is returned by operations that need the full precision of
+// the coordinates when they are given a Shape32.
+var ErrFloat32Coords = errors.New("shape has float32 coordinates")
+
+// Float32Coords makes the reader return every shape as a *Shape32, which
+// stores the coordinates as float32 and needs about half the memory of the
+// regular shape types. This is meant for visualization workloads that keep
+// many millions of vertices in memory.
+//
+// A float32 keeps about 7 significant digits, so coordinates are rounded by up
+// to 1e-5 degrees (about a metre) for longitude and latitude and by up to
+// 0.5 m for projected coordinates in the millions of metres. Operations that
+// depend on the full precision, like CollectParts and WriteAll, reject shapes
+// with float32 coordinates with ErrFloat32Coords. ForEachVertex, SwapXY and
+// ScaleCoords modify them through Float64, so the results are rounded to
+// float32 again, and measurements like Area use the rounded coordinates.
+func Float32Coords() ReaderOption {
+	return func(o *readerOptions) {
+		o.float32Coords = true
+	}
+}
+
+// Shape32 is a shape of any type whose coordinates are stored as float32. The
+// bounding box is kept as read from the file. Single points are stored like a
+// MultiPoint with one point.
+type Shape32 struct {
+	Type      ShapeType
+	Box       Box
+	Parts     []int32
+	PartTypes []int32   // MultiPatch only
+	XY        []float32 // X and Y of every point, interleaved
+	Z         []float32 // nil unless Type has Z values
+	M         []float32 // nil unless Type has measures
+	ZRange    [2]float64
+	MRange    [2]float64
+}
+
+// BBox returns the bounding box of the shape.
+func (s *Shape32) BBox() Box {
+	return s.Box
+}
+
+// NumPoints returns the number of points of the shape.
+func (s *Shape32) NumPoints() int {
+	return len(s.XY) / 2
+}
+
+// Point returns the i-th point of the shape.
+func (s *Shape32) Point(i int) Point {
+	return Point{float64(s.XY[2*i]), float64(s.XY[2*i+1])}
+}
+
+func (s *Shape32) read(file io.Reader) {
+	shape, err := newShape(s.Type)
+	if err != nil {
+		return
+	}
+	shape.read(file)
+	*s = *toShape32(shape)
+}
+
+func (s *Shape32) write(file io.Writer) {
+	s.Float64().write(file)
+}
+
+func toFloat32(vs []float64) []float32 {
+	if vs == nil {
+		return nil
+	}
+	out := make([]float32, len(vs))
+	for i, v := range vs {
+		out[i] = float32(v)
+	}
+	return out
+}
+
+func toFloat64(vs []float32, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		if i < len(vs) {
+			out[i] = float64(vs[i])
+		}
+	}
+	return out
+}
+
+// toShape32 converts s to a Shape32.
+func toShape32(s Shape) *Shape32 {
+	out := &Shape32{Type: shapeTypeOf(s), Box: s.BBox()}
+	points := shapePoints(s)
+	var z, m []float64
+	switch p := s.(type) {
+	case *Point:
+		points = []Point{*p}
+	case *PointZ:
+		points, z, m = []Point{{p.X, p.Y}}, []float64{p.Z}, []float64{p.M}
+	case *PointM:
+		points, m = []Point{{p.X, p.Y}}, []float64{p.M}
+	case *PolyLine:
+		out.Parts = p.Parts
+	case *Polygon:
+		out.Parts = p.Parts
+	case *PolyLineZ:
+		out.Parts, z, m = p.Parts, p.ZArray, p.MArray
+		out.ZRange, out.MRange = p.ZRange, p.MRange
+	case *PolygonZ:
+		out.Parts, z, m = p.Parts, p.ZArray, p.MArray
+		out.ZRange, out.MRange = p.ZRange, p.MRange
+	case *MultiPointZ:
+		z, m = p.ZArray, p.MArray
+		out.ZRange, out.MRange = p.ZRange, p.MRange
+	case *PolyLineM:
+		out.Parts, m, out.MRange = p.Parts, p.MArray, p.MRange
+	case *PolygonM:
+		out.Parts, m, out.MRange = p.Parts, p.MArray, p.MRange
+	case *MultiPointM:
+		m, out.MRange = p.MArray, p.MRange
+	case *MultiPatch:
+		out.Parts, out.PartTypes, z, m = p.Parts, p.PartTypes, p.ZArray, p.MArray
+		out.ZRange, out.MRange = p.ZRange, p.MRange
+	}
+	out.XY = make([]float32, 2*len(points))
+	for i, pt := range points {
+		out.XY[2*i], out.XY[2*i+1] = float32(pt.X), float32(pt.Y)
+	}
+	out.Z, out.M = toFloat32(z), toFloat32(m)
+	return out
+}
+
+// Float64 converts s back to the regular shape type of s.Type. The rounding
+// that happened when s was created cannot be undone.
+func (s *Shape32) Float64() Shape {
+	n := s.NumPoints()
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = s.Point(i)
+	}
+	var first Point
+	if n > 0 {
+		first = points[0]
+	}
+	z, m := toFloat64(s.Z, n), toFloat64(s.M, n)
+	numParts, numPoints := int32(len(s.Parts)), int32(n)
+	switch s.Type {
+	case POINT:
+		return &first
+	case POINTZ:
+		return &PointZ{first.X, first.Y, toFloat64(s.Z, 1)[0], toFloat64(s.M, 1)[0]}
+	case POINTM:
+		return &PointM{first.X, first.Y, toFloat64(s.M, 1)[0]}
+	case POLYLINE:
+		return &PolyLine{s.Box, numParts, numPoints, s.Parts, points}
+	case POLYGON:
+		return &Polygon{s.Box, numParts, numPoints, s.Parts, points}
+	case MULTIPOINT:
+		return &MultiPoint{s.Box, numPoints, points}
+	case POLYLINEZ:
+		return &PolyLineZ{s.Box, numParts, numPoints, s.Parts, points, s.ZRange, z, s.MRange, m}
+	case POLYGONZ:
+		return &PolygonZ{s.Box, numParts, numPoints, s.Parts, points, s.ZRange, z, s.MRange, m}
+	case MULTIPOINTZ:
+		return &MultiPointZ{s.Box, numPoints, points, s.ZRange, z, s.MRange, m}
+	case POLYLINEM:
+		return &PolyLineM{s.Box, numParts, numPoints, s.Parts, points, s.MRange, m}
+	case POLYGONM:
+		return &PolygonM{Box: s.Box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, Points: points, MRange: s.MRange, MArray: m}
+	case MULTIPOINTM:
+		return &MultiPointM{s.Box, numPoints, points, s.MRange, m}
+	case MULTIPATCH:
+		return &MultiPatch{s.Box, numParts, numPoints, s.Parts, s.PartTypes, points, s.ZRange, z, s.MRange, m}
+	}
+	return &Null{}
+}
+
+// requireFloat64 returns an error wrapping ErrFloat32Coords if s is a Shape32.
+func requireFloat64(s Shape) error {
+	if p, ok := s.(*Shape32); ok {
+		return fmt.Errorf("%w: %v", ErrFloat32Coords, p.Type)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package shp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFloat32Coords(t *testing.T) {
+	for _, prefix := range []string{"test_files/polylinez", "test_files/pointm", "test_files/multipatch"} {
+		full := getShapesFromFile(prefix, t)
+		r, err := Open(prefix+".shp", Float32Coords())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; r.Next(); i++ {
+			_, s := r.Shape()
+			s32, ok := s.(*Shape32)
+			if !ok {
+				t.Fatalf("%s: got %T, want *Shape32", prefix, s)
+			}
+			if s32.Type != shapeTypeOf(full[i]) || s32.BBox() != full[i].BBox() {
+				t.Errorf("%s: got type %v and box %v", prefix, s32.Type, s32.BBox())
+			}
+			// the coordinates of the test files are exact in float32
+			if got := s32.Float64(); !equalShapes(got, full[i]) {
+				t.Errorf("%s: got %+v, want %+v", prefix, got, full[i])
+			}
+		}
+		r.Close()
+	}
+
+	sr := SequentialReaderFromExt(openFile("test_files/point.shp", t), nil, Float32Coords())
+	defer sr.Close()
+	if !sr.Next() {
+		t.Fatal(sr.Err())
+	}
+	_, s := sr.Shape()
+	if p := s.(*Shape32).Point(0); p != (Point{10, 10}) {
+		t.Errorf("got point %v", p)
+	}
+	if _, err := CollectParts([]Shape{s}); !errors.Is(err, ErrFloat32Coords) {
+		t.Errorf("got error %v, want ErrFloat32Coords", err)
+	}
+}
+
+func TestShape32Rounding(t *testing.T) {
+	p := toShape32(&Point{8.123456789, 47.123456789}).Point(0)
+	if d := p.X - 8.123456789; d == 0 || d > 1e-6 || d < -1e-6 {
+		t.Errorf("got X %v", p.X)
+	}
+}
+
+func TestShape32Vertices(t *testing.T) {
+	s := toShape32(NewPolyLine([][]Point{{{1, 2}, {3, 4}}}))
+	SwapXY(s)
+	ScaleCoords(s, 2)
+	if p := s.Point(1); p != (Point{8, 6}) {
+		t.Errorf("got point %v, want {8 6}", p)
+	}
+	if want := (Box{4, 2, 8, 6}); s.BBox() != want {
+		t.Errorf("got box %v, want %v", s.BBox(), want)
+	}
+	if s.Type != POLYLINE || len(s.Parts) != 1 {
+		t.Errorf("got %+v", s)
+	}
+}
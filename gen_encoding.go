@@ -0,0 +1,59 @@
+//go:build ignore
+
+// gen_encoding generates encoding_methods.go, the binary and JSON marshaling
+// methods of the shape types.
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"log"
+	"os"
+	"text/template"
+)
+
+var types = []string{
+	"Null", "Point", "PolyLine", "Polygon", "MultiPoint",
+	"PointZ", "PolyLineZ", "PolygonZ", "MultiPointZ",
+	"PointM", "PolyLineM", "PolygonM", "MultiPointM",
+	"MultiPatch",
+}
+
+var methods = template.Must(template.New("methods").Parse(`// Code generated by "go run gen_encoding.go"; DO NOT EDIT.
+
+package shp
+{{range .}}
+// MarshalBinary implements encoding.BinaryMarshaler for {{.}}.
+func (p *{{.}}) MarshalBinary() ([]byte, error) {
+	return marshalShape(p)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for {{.}}.
+func (p *{{.}}) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(p, data)
+}
+
+// MarshalJSON implements json.Marshaler for {{.}}.
+func (p *{{.}}) MarshalJSON() ([]byte, error) {
+	return marshalShapeJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for {{.}}.
+func (p *{{.}}) UnmarshalJSON(data []byte) error {
+	return unmarshalShapeJSON(p, data)
+}
+{{end}}`))
+
+func main() {
+	var b bytes.Buffer
+	if err := methods.Execute(&b, types); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("encoding_methods.go", src, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,285 @@
+package shp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// GenOptions configures the shapefile created by Generate. The zero value
+// generates one hundred points with no attributes.
+type GenOptions struct {
+	// Dir is the directory the files are created in. A new temporary
+	// directory is created if it is empty.
+	Dir string
+	// Name is the base name of the files, "generated" if empty.
+	Name string
+	// Type is the shape type of all records, POINT if zero.
+	Type ShapeType
+	// Records is the number of records, 100 if zero.
+	Records int
+	// Parts is the number of parts of each multi-part shape, 1 if zero.
+	Parts int
+	// MinVertices and MaxVertices bound the number of vertices of each part;
+	// the count is uniformly distributed between them. Polygon rings have at
+	// least 4 vertices and polylines at least 2.
+	MinVertices, MaxVertices int
+	// Extent is the box the coordinates are drawn from, the whole world in
+	// degrees if empty.
+	Extent Box
+	// Fields is the attribute schema. Values are generated according to the
+	// type, size and precision of each field.
+	Fields []Field
+	// Seed seeds the random source. The same options always generate the
+	// same files.
+	Seed int64
+}
+
+// Generate creates a shapefile with random records as described by opts and
+// returns the path of its SHP file. It is meant for tests and load tests that
+// need data of a particular size or shape.
+func Generate(opts GenOptions) (path string, err error) {
+	if opts.Type == 0 {
+		opts.Type = POINT
+	}
+	if _, ok := _ShapeType_map[opts.Type]; !ok || opts.Type == NULL {
+		return "", fmt.Errorf("Unsupported shape type: %v", opts.Type)
+	}
+	if opts.Records == 0 {
+		opts.Records = 100
+	}
+	if opts.Parts == 0 {
+		opts.Parts = 1
+	}
+	if opts.Extent.IsEmpty() {
+		opts.Extent = Box{MinX: -180, MinY: -90, MaxX: 180, MaxY: 90}
+	}
+	if opts.Name == "" {
+		opts.Name = "generated"
+	}
+	if opts.Dir == "" {
+		if opts.Dir, err = os.MkdirTemp("", "go-shp"); err != nil {
+			return "", err
+		}
+	}
+	if opts.MaxVertices < opts.MinVertices {
+		return "", fmt.Errorf("MaxVertices %d is less than MinVertices %d", opts.MaxVertices, opts.MinVertices)
+	}
+
+	path = filepath.Join(opts.Dir, opts.Name+".shp")
+	w, err := Create(path, opts.Type)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		w.Close()
+		if err != nil {
+			path = ""
+		}
+	}()
+	if len(opts.Fields) > 0 {
+		if err = w.SetFields(opts.Fields); err != nil {
+			return "", err
+		}
+	}
+
+	g := generator{GenOptions: opts, rnd: rand.New(rand.NewSource(opts.Seed))}
+	for row := 0; row < opts.Records; row++ {
+		w.Write(g.shape())
+		for i, f := range opts.Fields {
+			if err = w.WriteAttribute(row, i, g.value(f)); err != nil {
+				return "", err
+			}
+		}
+	}
+	return path, nil
+}
+
+type generator struct {
+	GenOptions
+	rnd *rand.Rand
+}
+
+func (g *generator) point() Point {
+	e := g.Extent
+	return Point{
+		X: e.MinX + g.rnd.Float64()*(e.MaxX-e.MinX),
+		Y: e.MinY + g.rnd.Float64()*(e.MaxY-e.MinY),
+	}
+}
+
+func (g *generator) vertices(min int) int {
+	lo, hi := g.MinVertices, g.MaxVertices
+	if lo < min {
+		lo = min
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo + g.rnd.Intn(hi-lo+1)
+}
+
+// part returns the points of a part. Rings are closed and clockwise, as the
+// outer rings of polygons; other parts are random walks.
+func (g *generator) part(ring bool) []Point {
+	e := g.Extent
+	if !ring {
+		points := make([]Point, g.vertices(2))
+		step := math.Min(e.MaxX-e.MinX, e.MaxY-e.MinY) / 100
+		points[0] = g.point()
+		for i := 1; i < len(points); i++ {
+			p := points[i-1]
+			p.X = math.Max(e.MinX, math.Min(e.MaxX, p.X+(g.rnd.Float64()*2-1)*step))
+			p.Y = math.Max(e.MinY, math.Min(e.MaxY, p.Y+(g.rnd.Float64()*2-1)*step))
+			points[i] = p
+		}
+		return points
+	}
+	points := make([]Point, g.vertices(4))
+	c := g.point()
+	r := math.Min(math.Min(c.X-e.MinX, e.MaxX-c.X), math.Min(c.Y-e.MinY, e.MaxY-c.Y))
+	r *= 0.5 + g.rnd.Float64()/2
+	n := len(points) - 1
+	for i := 0; i < n; i++ {
+		a := -2 * math.Pi * float64(i) / float64(n)
+		points[i] = Point{X: c.X + r*math.Cos(a), Y: c.Y + r*math.Sin(a)}
+	}
+	points[n] = points[0]
+	return points
+}
+
+func (g *generator) parts(ring bool) [][]Point {
+	parts := make([][]Point, g.Parts)
+	for i := range parts {
+		parts[i] = g.part(ring)
+	}
+	return parts
+}
+
+func (g *generator) multiPoint() []Point {
+	points := make([]Point, g.vertices(1))
+	for i := range points {
+		points[i] = g.point()
+	}
+	return points
+}
+
+// measures returns n random values in [0, 100) and their range.
+func (g *generator) measures(n int) ([]float64, [2]float64) {
+	if n == 0 {
+		return nil, [2]float64{}
+	}
+	v := make([]float64, n)
+	r := [2]float64{math.Inf(1), math.Inf(-1)}
+	for i := range v {
+		v[i] = g.rnd.Float64() * 100
+		r[0] = math.Min(r[0], v[i])
+		r[1] = math.Max(r[1], v[i])
+	}
+	return v, r
+}
+
+func (g *generator) shape() Shape {
+	switch g.Type {
+	case POINT:
+		p := g.point()
+		return &p
+	case POINTZ:
+		p := g.point()
+		return &PointZ{X: p.X, Y: p.Y, Z: g.rnd.Float64() * 100, M: g.rnd.Float64() * 100}
+	case POINTM:
+		p := g.point()
+		return &PointM{X: p.X, Y: p.Y, M: g.rnd.Float64() * 100}
+	case POLYLINE:
+		return NewPolyLine(g.parts(false))
+	case POLYGON:
+		return NewPolygon(g.parts(true))
+	case MULTIPOINT:
+		return PointsToMultiPoint(g.multiPoint())
+	}
+
+	// types with measures
+	var pl *PolyLine
+	switch g.Type {
+	case POLYLINEZ, POLYLINEM:
+		pl = NewPolyLine(g.parts(false))
+	case POLYGONZ, POLYGONM, MULTIPATCH:
+		pl = NewPolyLine(g.parts(true))
+	default:
+		pl = &PolyLine{Parts: []int32{0}, Points: g.multiPoint()}
+		pl.NumPoints = int32(len(pl.Points))
+		pl.Box = pl.BBox()
+	}
+	z, zr := g.measures(len(pl.Points))
+	m, mr := g.measures(len(pl.Points))
+	switch g.Type {
+	case POLYLINEZ:
+		return &PolyLineZ{pl.Box, pl.NumParts, pl.NumPoints, pl.Parts, pl.Points, zr, z, mr, m}
+	case POLYGONZ:
+		return &PolygonZ{pl.Box, pl.NumParts, pl.NumPoints, pl.Parts, pl.Points, zr, z, mr, m}
+	case POLYLINEM:
+		return &PolyLineM{pl.Box, pl.NumParts, pl.NumPoints, pl.Parts, pl.Points, mr, m}
+	case POLYGONM:
+		return &PolygonM{pl.Box, pl.NumParts, pl.NumPoints, pl.Parts, pl.Points, zr, z, mr, m}
+	case MULTIPOINTZ:
+		return &MultiPointZ{pl.Box, pl.NumPoints, pl.Points, zr, z, mr, m}
+	case MULTIPOINTM:
+		return &MultiPointM{pl.Box, pl.NumPoints, pl.Points, mr, m}
+	}
+	types := make([]int32, pl.NumParts)
+	for i := range types {
+		types[i] = 5 // ring
+	}
+	return &MultiPatch{pl.Box, pl.NumParts, pl.NumPoints, pl.Parts, types, pl.Points, zr, z, mr, m}
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// value returns a random attribute value that fits field f.
+func (g *generator) value(f Field) interface{} {
+	size := int(f.Size)
+	switch f.Fieldtype {
+	case 'N', 'F':
+		digits := size
+		if f.Precision > 0 {
+			digits -= int(f.Precision) + 1
+		}
+		if digits > 15 {
+			digits = 15
+		}
+		if digits < 1 {
+			return ""
+		}
+		max := math.Pow(10, float64(digits))
+		if f.Precision == 0 {
+			return int(g.rnd.Int63n(int64(max)))
+		}
+		v := math.Floor(g.rnd.Float64()*max*math.Pow(10, float64(f.Precision))) / math.Pow(10, float64(f.Precision))
+		if len(strconv.FormatFloat(v, 'f', int(f.Precision), 64)) > size {
+			v = 0
+		}
+		return v
+	case 'L':
+		if g.rnd.Intn(2) == 0 {
+			return "F"
+		}
+		return "T"
+	case 'Y':
+		return float64(g.rnd.Int63n(100000000)) / 100
+	case 'D':
+		t := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+		return t.AddDate(0, 0, g.rnd.Intn(200*365)).Format("20060102")
+	}
+	if size == 0 {
+		return ""
+	}
+	b := make([]byte, 1+g.rnd.Intn(size))
+	for i := range b {
+		b[i] = letters[g.rnd.Intn(len(letters))]
+	}
+	return string(b)
+}
@@ -0,0 +1,75 @@
+package shp
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	fields := []Field{StringField("NAME", 12), NumberField("COUNT", 6), FloatField("VALUE", 10, 3), DateField("DAY")}
+	for typ := range _ShapeType_map {
+		if typ == NULL {
+			continue
+		}
+		opts := GenOptions{
+			Dir:         t.TempDir(),
+			Type:        typ,
+			Records:     20,
+			Parts:       2,
+			MinVertices: 3,
+			MaxVertices: 10,
+			Extent:      Box{MinX: 10, MinY: 20, MaxX: 30, MaxY: 40},
+			Fields:      fields,
+			Seed:        42,
+		}
+		path, err := Generate(opts)
+		if err != nil {
+			t.Fatalf("%v: %v", typ, err)
+		}
+		r, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for r.Next() {
+			_, s := r.Shape()
+			if got := shapeTypeOf(s); got != typ {
+				t.Errorf("%v: record %d has type %v", typ, n, got)
+			}
+			if !opts.Extent.ContainsBox(s.BBox()) {
+				t.Errorf("%v: record %d bbox %v outside of extent", typ, n, s.BBox())
+			}
+			for i := range fields {
+				if r.Attribute(i) == "" {
+					t.Errorf("%v: record %d field %d is empty", typ, n, i)
+				}
+			}
+			n++
+		}
+		r.Close()
+		if n != opts.Records {
+			t.Errorf("%v: read %d records, want %d", typ, n, opts.Records)
+		}
+
+		// the same options generate the same files
+		want, _ := os.ReadFile(path)
+		opts.Dir = t.TempDir()
+		path, err = Generate(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(path); !bytes.Equal(got, want) {
+			t.Errorf("%v: files differ for the same seed", typ)
+		}
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	if _, err := Generate(GenOptions{Dir: t.TempDir(), Type: 2}); err == nil {
+		t.Error("expected error for unknown shape type")
+	}
+	if _, err := Generate(GenOptions{Dir: t.TempDir(), MinVertices: 5, MaxVertices: 2}); err == nil {
+		t.Error("expected error for MaxVertices < MinVertices")
+	}
+}
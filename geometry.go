@@ -0,0 +1,270 @@
+package shp
+
+import (
+	"math"
+	"sort"
+)
+
+// The measurements below use planar geometry, so the coordinates should be
+// projected, e.g. in meters. Measured in degrees of longitude and latitude,
+// areas and lengths are in square degrees and degrees. Shapes of readers with
+// LazyDecoding are decoded, and those with Float32Coords are measured with
+// their rounded coordinates.
+
+// signedArea returns the area of a ring, negative if it is clockwise.
+func signedArea(points []Point) float64 {
+	var a float64
+	for i := 0; i+1 < len(points); i++ {
+		a += points[i].X*points[i+1].Y - points[i+1].X*points[i].Y
+	}
+	return a / 2
+}
+
+// lineLength returns the length of the line through points.
+func lineLength(points []Point) float64 {
+	var l float64
+	for i := 0; i+1 < len(points); i++ {
+		l += math.Hypot(points[i+1].X-points[i].X, points[i+1].Y-points[i].Y)
+	}
+	return l
+}
+
+// Area returns the area of a polygon: the area of its outer rings, which are
+// clockwise, minus the area of its holes, which are counterclockwise. It is 0
+// for other shapes.
+func Area(s Shape) float64 {
+	a, ok := arraysOf(s)
+	if !ok || !a.rings {
+		return 0
+	}
+	var area float64
+	for i := range a.parts {
+		area -= signedArea(part(a.parts, a.points, i))
+	}
+	return area
+}
+
+// Perimeter returns the total length of the rings of a polygon, or 0 for other
+// shapes.
+func Perimeter(s Shape) float64 {
+	s = decoded(s)
+	if a, ok := arraysOf(s); !ok || !a.rings {
+		return 0
+	}
+	return Length(s)
+}
+
+// Length returns the total length of the parts of a polyline, the rings of a
+// polygon or the parts of a multipatch, and 0 for points and multipoints.
+func Length(s Shape) float64 {
+	a, ok := arraysOf(s)
+	if !ok {
+		return 0
+	}
+	var l float64
+	for i := range a.parts {
+		l += lineLength(part(a.parts, a.points, i))
+	}
+	return l
+}
+
+// NumVertices returns the number of points of s: 1 for points and 0 for Null
+// shapes.
+func NumVertices(s Shape) int {
+	s = decoded(s)
+	switch s.(type) {
+	case *Point, *PointZ, *PointM:
+		return 1
+	}
+	if a, ok := arraysOf(s); ok {
+		return len(a.points)
+	}
+	return 0
+}
+
+// Centroid returns the center of mass of s: of the area of a polygon, of the
+// length of a polyline and of the points of a multipoint. Polygons without
+// area fall back to the center of their rings and polylines without length to
+// the mean of their points. It returns false for Null shapes and shapes
+// without points.
+func Centroid(s Shape) (Point, bool) {
+	s = decoded(s)
+	switch p := s.(type) {
+	case *Point:
+		return *p, true
+	case *PointZ:
+		return Point{p.X, p.Y}, true
+	case *PointM:
+		return Point{p.X, p.Y}, true
+	}
+	a, ok := arraysOf(s)
+	if !ok || len(a.points) == 0 {
+		return Point{}, false
+	}
+
+	if a.rings {
+		var cx, cy, area float64
+		for i := range a.parts {
+			ring := part(a.parts, a.points, i)
+			for j := 0; j+1 < len(ring); j++ {
+				cross := ring[j].X*ring[j+1].Y - ring[j+1].X*ring[j].Y
+				cx += (ring[j].X + ring[j+1].X) * cross
+				cy += (ring[j].Y + ring[j+1].Y) * cross
+				area += cross / 2
+			}
+		}
+		if area != 0 {
+			return Point{cx / (6 * area), cy / (6 * area)}, true
+		}
+	}
+	if a.parts != nil {
+		var cx, cy, length float64
+		for i := range a.parts {
+			line := part(a.parts, a.points, i)
+			for j := 0; j+1 < len(line); j++ {
+				l := math.Hypot(line[j+1].X-line[j].X, line[j+1].Y-line[j].Y)
+				cx += (line[j].X + line[j+1].X) / 2 * l
+				cy += (line[j].Y + line[j+1].Y) / 2 * l
+				length += l
+			}
+		}
+		if length != 0 {
+			return Point{cx / length, cy / length}, true
+		}
+	}
+	var cx, cy float64
+	for _, pt := range a.points {
+		cx += pt.X
+		cy += pt.Y
+	}
+	n := float64(len(a.points))
+	return Point{cx / n, cy / n}, true
+}
+
+// PointInPolygon returns whether p is inside the polygon s, counting the
+// crossings of its rings so that points in holes are outside. Points on the
+// boundary may be reported either way. It returns false if s is not a
+// polygon.
+func PointInPolygon(p Point, s Shape) bool {
+	a, ok := arraysOf(s)
+	if !ok || !a.rings || !a.box.ContainsPoint(p) {
+		return false
+	}
+	inside := false
+	for i := range a.parts {
+		ring := part(a.parts, a.points, i)
+		for j := 0; j+1 < len(ring); j++ {
+			p1, p2 := ring[j], ring[j+1]
+			if (p1.Y > p.Y) != (p2.Y > p.Y) &&
+				p.X < p1.X+(p.Y-p1.Y)*(p2.X-p1.X)/(p2.Y-p1.Y) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// RepresentativePoint returns a point that is guaranteed to lie on s, which
+// is better suited than the centroid for placing labels: the centroid of a
+// polygon if it is inside, else the middle of the widest horizontal span of
+// the polygon near its centroid, the point halfway along a polyline and the
+// point of a multipoint closest to its centroid. It returns false for Null
+// shapes and shapes without points.
+func RepresentativePoint(s Shape) (Point, bool) {
+	s = decoded(s)
+	c, ok := Centroid(s)
+	if !ok {
+		return c, false
+	}
+	a, ok := arraysOf(s)
+	if !ok {
+		return c, true
+	}
+	switch {
+	case a.rings:
+		if PointInPolygon(c, s) {
+			return c, true
+		}
+		if p, ok := widestSpan(a, c.Y); ok {
+			return p, true
+		}
+	case a.parts != nil:
+		if l := Length(s); l > 0 {
+			return pointAlong(a, l/2), true
+		}
+	}
+	best, dist := a.points[0], math.Inf(1)
+	for _, p := range a.points {
+		if d := math.Hypot(p.X-c.X, p.Y-c.Y); d < dist {
+			best, dist = p, d
+		}
+	}
+	return best, true
+}
+
+// widestSpan returns the middle of the widest span inside the rings of a
+// along a horizontal line close to y. The line is placed between the Y
+// coordinates of two vertices so that it never passes through a vertex.
+func widestSpan(a shapeArrays, y float64) (Point, bool) {
+	below, above := math.Inf(-1), math.Inf(1)
+	for _, p := range a.points {
+		if p.Y <= y && p.Y > below {
+			below = p.Y
+		}
+		if p.Y > y && p.Y < above {
+			above = p.Y
+		}
+	}
+	if math.IsInf(above, 1) {
+		// y is at the top of the polygon, use the band below it
+		above = below
+		below = math.Inf(-1)
+		for _, p := range a.points {
+			if p.Y < above && p.Y > below {
+				below = p.Y
+			}
+		}
+	}
+	if math.IsInf(below, -1) || math.IsInf(above, 1) {
+		return Point{}, false
+	}
+	y = (below + above) / 2
+
+	var xs []float64
+	for i := range a.parts {
+		ring := part(a.parts, a.points, i)
+		for j := 0; j+1 < len(ring); j++ {
+			p1, p2 := ring[j], ring[j+1]
+			if (p1.Y > y) != (p2.Y > y) {
+				xs = append(xs, p1.X+(y-p1.Y)*(p2.X-p1.X)/(p2.Y-p1.Y))
+			}
+		}
+	}
+	sort.Float64s(xs)
+	var best Point
+	width := -1.0
+	for i := 0; i+1 < len(xs); i += 2 {
+		if w := xs[i+1] - xs[i]; w > width {
+			best, width = Point{(xs[i] + xs[i+1]) / 2, y}, w
+		}
+	}
+	return best, width >= 0
+}
+
+// pointAlong returns the point at distance d along the parts of a.
+func pointAlong(a shapeArrays, d float64) Point {
+	var last Point
+	for i := range a.parts {
+		line := part(a.parts, a.points, i)
+		for j := 0; j+1 < len(line); j++ {
+			l := math.Hypot(line[j+1].X-line[j].X, line[j+1].Y-line[j].Y)
+			if d <= l && l > 0 {
+				f := d / l
+				return Point{line[j].X + f*(line[j+1].X-line[j].X), line[j].Y + f*(line[j+1].Y-line[j].Y)}
+			}
+			d -= l
+			last = line[j+1]
+		}
+	}
+	return last
+}
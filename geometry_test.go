@@ -0,0 +1,135 @@
+package shp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeometry(t *testing.T) {
+	// a clockwise 4x4 square with a counterclockwise 2x2 hole
+	square := &Polygon{
+		Box:       Box{0, 0, 4, 4},
+		NumParts:  2,
+		NumPoints: 10,
+		Parts:     []int32{0, 5},
+		Points: []Point{
+			{0, 0}, {0, 4}, {4, 4}, {4, 0}, {0, 0},
+			{1, 1}, {3, 1}, {3, 3}, {1, 3}, {1, 1},
+		},
+	}
+	line := &PolyLine{
+		Box:       Box{0, 0, 3, 4},
+		NumParts:  1,
+		NumPoints: 3,
+		Parts:     []int32{0},
+		Points:    []Point{{0, 0}, {3, 4}, {3, 0}},
+	}
+
+	for _, c := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"area", Area(square), 12},
+		{"perimeter", Perimeter(square), 24},
+		{"length", Length(line), 9},
+		{"line area", Area(line), 0},
+		{"line perimeter", Perimeter(line), 0},
+		{"vertices", float64(NumVertices(square)), 10},
+		{"point vertices", float64(NumVertices(&Point{1, 2})), 1},
+	} {
+		if math.Abs(c.got-c.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	for _, c := range []struct {
+		s    Shape
+		want Point
+	}{
+		{square, Point{2, 2}},
+		{line, Point{(1.5*5 + 3*4) / 9, 2}},
+		{&MultiPoint{NumPoints: 2, Points: []Point{{0, 0}, {2, 4}}}, Point{1, 2}},
+		{&PointZ{X: 5, Y: 6}, Point{5, 6}},
+	} {
+		got, ok := Centroid(c.s)
+		if !ok || math.Abs(got.X-c.want.X) > 1e-9 || math.Abs(got.Y-c.want.Y) > 1e-9 {
+			t.Errorf("got centroid %v for %T, want %v", got, c.s, c.want)
+		}
+	}
+	if _, ok := Centroid(&Null{}); ok {
+		t.Error("got a centroid for a Null shape")
+	}
+}
+
+func TestRepresentativePoint(t *testing.T) {
+	// a clockwise U whose centroid lies in the gap between its arms
+	u := NewPolygon([][]Point{{
+		{0, 0}, {0, 3}, {1, 3}, {1, 1}, {2, 1}, {2, 3}, {3, 3}, {3, 0}, {0, 0},
+	}})
+	c, _ := Centroid(u)
+	if PointInPolygon(c, u) {
+		t.Fatalf("centroid %v of the U is inside", c)
+	}
+	if !PointInPolygon(Point{0.5, 2}, u) || PointInPolygon(Point{1.5, 2}, u) {
+		t.Error("PointInPolygon is wrong for the arms of the U")
+	}
+	p, ok := RepresentativePoint(u)
+	if !ok || !PointInPolygon(p, u) {
+		t.Errorf("got representative point %v outside of the U", p)
+	}
+
+	line := NewPolyLine([][]Point{{{0, 0}, {0, 2}, {2, 2}}})
+	if p, _ := RepresentativePoint(line); p != (Point{0, 2}) {
+		t.Errorf("got representative point %v, want the middle of the line", p)
+	}
+	mp := PointsToMultiPoint([]Point{{0, 0}, {1, 1}, {5, 5}})
+	if p, _ := RepresentativePoint(mp); p != (Point{1, 1}) {
+		t.Errorf("got representative point %v, want the closest point", p)
+	}
+}
+
+func TestGeometryReaderOptions(t *testing.T) {
+	read := func(opts ...ReaderOption) []Shape {
+		r, err := Open("test_files/polygon.shp", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		var shapes []Shape
+		for r.Next() {
+			_, s := r.Shape()
+			shapes = append(shapes, s)
+		}
+		return shapes
+	}
+	want := read()
+	for name, opts := range map[string][]ReaderOption{
+		"LazyDecoding":         {LazyDecoding()},
+		"Float32Coords":        {Float32Coords()},
+		"LazyDecoding/Float32": {LazyDecoding(), Float32Coords()},
+	} {
+		shapes := read(opts...)
+		if len(shapes) != len(want) {
+			t.Fatalf("%s: got %d shapes, want %d", name, len(shapes), len(want))
+		}
+		for i, s := range shapes {
+			if a := Area(s); a == 0 || a != Area(want[i]) {
+				t.Errorf("%s: got area %v, want %v", name, a, Area(want[i]))
+			}
+			if l := Perimeter(s); l == 0 || l != Perimeter(want[i]) {
+				t.Errorf("%s: got perimeter %v, want %v", name, l, Perimeter(want[i]))
+			}
+			if n := NumVertices(s); n != NumVertices(want[i]) {
+				t.Errorf("%s: got %d vertices, want %d", name, n, NumVertices(want[i]))
+			}
+			c, ok := Centroid(s)
+			if wc, _ := Centroid(want[i]); !ok || c != wc {
+				t.Errorf("%s: got centroid %v, %v, want %v", name, c, ok, wc)
+			}
+			if p, ok := RepresentativePoint(s); !ok || !PointInPolygon(p, s) {
+				t.Errorf("%s: got representative point %v, %v", name, p, ok)
+			}
+		}
+	}
+}
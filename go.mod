@@ -1 +1,3 @@
 module github.com/brianolson/go-shp
+
+go 1.21
@@ -0,0 +1,150 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// httpRange reads an object with HTTP range requests.
+type httpRange struct {
+	client *http.Client
+	url    string
+	size   int64
+	md5    []byte
+}
+
+// OpenHTTP returns a RangeReader for the object at url, which must support
+// range requests. Its size is taken from a HEAD request. If client is nil,
+// http.DefaultClient is used.
+func OpenHTTP(client *http.Client, url string) (RangeReader, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Head(url)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &os.PathError{Op: "open", Path: url, Err: os.ErrNotExist}
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("Unexpected status of %s: %s", url, resp.Status)
+	case resp.ContentLength < 0:
+		return nil, fmt.Errorf("Unknown size of %s", url)
+	}
+	return &httpRange{client: client, url: url, size: resp.ContentLength, md5: md5FromHeader(resp.Header)}, nil
+}
+
+func (h *httpRange) Size() int64 {
+	return h.size
+}
+
+// MD5 returns the digest of the object from the headers of the HEAD request.
+func (h *httpRange) MD5() []byte {
+	return h.md5
+}
+
+func (h *httpRange) ReadAt(p []byte, off int64) (int, error) {
+	if off >= h.size {
+		return 0, io.EOF
+	}
+	n := int64(len(p))
+	if off+n > h.size {
+		n = h.size - off
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, h.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+n-1))
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("Range request to %s failed: %s", h.url, resp.Status)
+	}
+	read, err := io.ReadFull(resp.Body, p[:n])
+	if err == nil && n < int64(len(p)) {
+		err = io.EOF
+	}
+	return read, err
+}
+
+// HTTPOpener returns a RangeOpener for the objects below baseURL, whose
+// names are escaped and appended as a path. If client is nil,
+// http.DefaultClient is used.
+func HTTPOpener(client *http.Client, baseURL string) RangeOpener {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return func(name string) (RangeReader, error) {
+		return OpenHTTP(client, baseURL+"/"+escapePath(name))
+	}
+}
+
+// S3Opener returns a RangeOpener for the objects of an AWS S3 bucket in
+// region, whose names are the object keys. Private buckets need a client
+// whose Transport signs the requests.
+func S3Opener(client *http.Client, bucket, region string) RangeOpener {
+	return HTTPOpener(client, fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region))
+}
+
+// GCSOpener returns a RangeOpener for the objects of a Google Cloud Storage
+// bucket, whose names are the object names. Private buckets need a client
+// that adds credentials, such as one from golang.org/x/oauth2/google.
+func GCSOpener(client *http.Client, bucket string) RangeOpener {
+	return HTTPOpener(client, "https://storage.googleapis.com/"+url.PathEscape(bucket))
+}
+
+// escapePath escapes each segment of the slash separated name.
+func escapePath(name string) string {
+	segments := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// md5FromHeader returns the MD5 digest of an object from the headers of a
+// response, or nil. GCS sends it in x-goog-hash, others in Content-MD5. The
+// ETag of S3 objects is the hex digest only if they were not uploaded in parts
+// and are not encrypted with SSE-KMS or SSE-C, so it is ignored if the
+// encryption headers show either. Other servers whose ETag happens to be 32
+// hex digits but not the MD5 digest make VerifyChecksums fail; ExpectMD5 can
+// be used for them instead.
+func md5FromHeader(h http.Header) []byte {
+	for _, v := range h.Values("X-Goog-Hash") {
+		for _, part := range strings.Split(v, ",") {
+			if b64, ok := strings.CutPrefix(strings.TrimSpace(part), "md5="); ok {
+				if sum, err := base64.StdEncoding.DecodeString(b64); err == nil && len(sum) == md5.Size {
+					return sum
+				}
+			}
+		}
+	}
+	if sum, err := base64.StdEncoding.DecodeString(h.Get("Content-MD5")); err == nil && len(sum) == md5.Size {
+		return sum
+	}
+	if strings.HasPrefix(h.Get("X-Amz-Server-Side-Encryption"), "aws:kms") ||
+		h.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm") != "" {
+		return nil
+	}
+	etag := strings.Trim(strings.TrimPrefix(h.Get("ETag"), "W/"), `"`)
+	if sum, err := hex.DecodeString(etag); err == nil && len(sum) == md5.Size && !strings.HasPrefix(h.Get("ETag"), "W/") {
+		return sum
+	}
+	return nil
+}
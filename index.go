@@ -0,0 +1,187 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// indexEntry locates a record in the SHP file, as stored in the SHX file.
+type indexEntry struct {
+	offset int64 // start of the record header
+	length int64 // length of the record content, excluding the header
+}
+
+// loadIndex reads the SHX file, or walks the record headers of the SHP file
+// if there is none, and opens the DBF file if there is one. It is run once
+// before the first call of ShapeAt or AttributesAt.
+func (r *Reader) loadIndex() {
+	r.indexOnce.Do(func() {
+		r.index, r.indexErr = r.readIndex()
+		if !r.skipAttributes && r.sidecars.DBF != "" {
+			r.openDbf()
+		}
+	})
+}
+
+func (r *Reader) readIndex() ([]indexEntry, error) {
+	if r.shp == nil {
+		return nil, errors.New("No SHP file to read shapes from")
+	}
+	if r.sidecars.SHX != "" {
+		data, err := r.readSidecar(r.sidecars.SHX)
+		if err != nil {
+			return nil, err
+		}
+		return parseIndex(data)
+	}
+
+	ra, ok := r.shp.(io.ReaderAt)
+	if !ok {
+		return nil, errors.New("SHP file does not support random access")
+	}
+	var index []indexEntry
+	header := make([]byte, 8)
+	for pos := int64(100); pos+8 <= r.filelength; {
+		if _, err := ra.ReadAt(header, pos); err != nil {
+			return nil, fmt.Errorf("Error when indexing records: %v", err)
+		}
+		length := int64(binary.BigEndian.Uint32(header[4:])) * 2
+		index = append(index, indexEntry{offset: pos, length: length})
+		pos += 8 + length
+	}
+	return index, nil
+}
+
+// parseIndex parses the contents of an SHX file.
+func parseIndex(data []byte) ([]indexEntry, error) {
+	if len(data) < 100 {
+		return nil, fmt.Errorf("SHX file of %d bytes is too short", len(data))
+	}
+	data = data[100:]
+	index := make([]indexEntry, len(data)/8)
+	for i := range index {
+		index[i].offset = int64(binary.BigEndian.Uint32(data[8*i:])) * 2
+		index[i].length = int64(binary.BigEndian.Uint32(data[8*i+4:])) * 2
+	}
+	return index, nil
+}
+
+// NumRecords returns the number of records in the SHP file according to its
+// SHX file. It returns 0 if the index cannot be read.
+func (r *Reader) NumRecords() int {
+	r.loadIndex()
+	return len(r.index)
+}
+
+// ShapeAt reads the i-th shape of the SHP file, located by the SHX file. It
+// reads with ReadAt and shares no mutable state between calls, so ShapeAt and
+// AttributesAt may be called concurrently from several goroutines, e.g. to
+// serve parallel requests from one opened shapefile. They must not be called
+// concurrently with Next or the other methods of r, however.
+func (r *Reader) ShapeAt(i int) (Shape, error) {
+	r.loadIndex()
+	if r.indexErr != nil {
+		return nil, r.indexErr
+	}
+	if i < 0 || i >= len(r.index) {
+		return nil, fmt.Errorf("Record index %d out of range [0, %d)", i, len(r.index))
+	}
+	ra, ok := r.shp.(io.ReaderAt)
+	if !ok {
+		return nil, errors.New("SHP file does not support random access")
+	}
+	e := r.index[i]
+	if err := r.limits.checkRecordSize(e.length); err != nil {
+		return nil, fmt.Errorf("Error when reading record %d: %w", i, err)
+	}
+	buf := make([]byte, 8+e.length)
+	if _, err := ra.ReadAt(buf, e.offset); err != nil {
+		return nil, fmt.Errorf("Error when reading record %d: %v", i, err)
+	}
+	t := ShapeType(binary.LittleEndian.Uint32(buf[8:]))
+	l, err := r.readLazy(bytes.NewReader(buf[12:]), t, e.length)
+	if err != nil {
+		return nil, fmt.Errorf("Error when reading record %d: %w", i, err)
+	}
+	if r.lazy {
+		return l, nil
+	}
+	return l.Decode()
+}
+
+// AttributesAt returns all attributes of the i-th row of the DBF file. Like
+// ShapeAt, it may be called concurrently.
+func (r *Reader) AttributesAt(i int) ([]string, error) {
+	r.loadIndex()
+	if r.dbf == nil {
+		return nil, errors.New("No DBF file to read attributes from")
+	}
+	if i < 0 || i >= int(r.dbfNumRecords) {
+		return nil, fmt.Errorf("Row index %d out of range [0, %d)", i, r.dbfNumRecords)
+	}
+	ra, ok := r.dbf.(io.ReaderAt)
+	if !ok {
+		return nil, errors.New("DBF file does not support random access")
+	}
+	buf := make([]byte, r.dbfRecordLength)
+	offset := int64(r.dbfHeaderLength) + int64(i)*int64(r.dbfRecordLength)
+	if _, err := ra.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("Error when reading row %d: %v", i, err)
+	}
+	attrs := make([]string, len(r.dbfSelected))
+	for n := range attrs {
+		attrs[n] = r.rowAttribute(buf, column(r.dbfColumns, n))
+	}
+	return attrs, nil
+}
+
+// rowAttribute returns the value of the field col of the DBF row buf.
+func (r *Reader) rowAttribute(buf []byte, col int) string {
+	f := r.dbfFields[col]
+	pos := int(r.dbfOffsets[col])
+	end := pos + int(f.Size)
+	if end > len(buf) {
+		return ""
+	}
+	if f.Fieldtype == 'Y' {
+		return decodeCurrency(buf[pos:end])
+	}
+	return strings.Trim(string(buf[pos:end]), " ")
+}
+
+// recordBox reads the bounding box of the i-th record from its header
+// without decoding the shape. It returns false for Null records.
+func (r *Reader) recordBox(i int) (Box, bool, error) {
+	ra, ok := r.shp.(io.ReaderAt)
+	if !ok {
+		return Box{}, false, errors.New("SHP file does not support random access")
+	}
+	e := r.index[i]
+	buf := make([]byte, 8+min(e.length, 36))
+	if _, err := ra.ReadAt(buf, e.offset); err != nil {
+		return Box{}, false, fmt.Errorf("Error when reading record %d: %v", i, err)
+	}
+	if len(buf) < 12 {
+		return Box{}, false, fmt.Errorf("Record %d of %d bytes is too short", i, e.length)
+	}
+	f := func(n int) float64 {
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf[12+8*n:]))
+	}
+	switch t := ShapeType(binary.LittleEndian.Uint32(buf[8:])); {
+	case t == NULL:
+		return Box{}, false, nil
+	case t == POINT || t == POINTZ || t == POINTM:
+		if len(buf) < 28 {
+			break
+		}
+		return Box{f(0), f(1), f(0), f(1)}, true, nil
+	case len(buf) == 44:
+		return Box{f(0), f(1), f(2), f(3)}, true, nil
+	}
+	return Box{}, false, fmt.Errorf("Record %d of %d bytes is too short", i, e.length)
+}
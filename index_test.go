@@ -0,0 +1,76 @@
+package shp
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestShapeAt(t *testing.T) {
+	filename, err := Generate(GenOptions{
+		Dir:     t.TempDir(),
+		Type:    POLYGONZ,
+		Records: 50,
+		Fields:  []Field{StringField("NAME", 10), NumberField("N", 5)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shapes []Shape
+	var attrs [][]string
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r.Next() {
+		_, s := r.Shape()
+		shapes = append(shapes, s)
+		attrs = append(attrs, []string{r.Attribute(0), r.Attribute(1)})
+	}
+	r.Close()
+
+	for _, shx := range []bool{true, false} {
+		if !shx {
+			os.Remove(strings.TrimSuffix(filename, ".shp") + ".shx")
+		}
+		r, err := Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := r.NumRecords(); n != len(shapes) {
+			t.Errorf("NumRecords() = %d, want %d", n, len(shapes))
+		}
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := len(shapes) - 1 - g; i >= 0; i-- {
+					s, err := r.ShapeAt(i)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if !equalShapes(s, shapes[i]) {
+						t.Errorf("shx %v: shape %d differs", shx, i)
+					}
+					a, err := r.AttributesAt(i)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if !reflect.DeepEqual(a, attrs[i]) {
+						t.Errorf("shx %v: attributes %d are %q, want %q", shx, i, a, attrs[i])
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+		if _, err := r.ShapeAt(len(shapes)); err == nil {
+			t.Error("expected error for index out of range")
+		}
+		r.Close()
+	}
+}
@@ -0,0 +1,223 @@
+package shp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// shapeArrays is a view of the fields of a multi-point shape that the
+// invariants are checked on.
+type shapeArrays struct {
+	box                 Box
+	numParts, numPoints int32
+	parts, partTypes    []int32
+	points              []Point
+	z, m                []float64
+	zRange, mRange      *[2]float64
+	rings               bool
+}
+
+// arraysOf returns the view of s. Lazy shapes are decoded and float32 shapes
+// converted first, so changes to the arrays do not reach them.
+func arraysOf(s Shape) (a shapeArrays, ok bool) {
+	switch p := decoded(s).(type) {
+	case *PolyLine:
+		a = shapeArrays{box: p.Box, numParts: p.NumParts, numPoints: p.NumPoints, parts: p.Parts, points: p.Points}
+	case *Polygon:
+		a = shapeArrays{box: p.Box, numParts: p.NumParts, numPoints: p.NumPoints, parts: p.Parts, points: p.Points, rings: true}
+	case *MultiPoint:
+		a = shapeArrays{box: p.Box, numPoints: p.NumPoints, points: p.Points}
+	case *PolyLineZ:
+		a = shapeArrays{box: p.Box, numParts: p.NumParts, numPoints: p.NumPoints, parts: p.Parts, points: p.Points,
+			z: p.ZArray, m: p.MArray, zRange: &p.ZRange, mRange: &p.MRange}
+	case *PolygonZ:
+		a = shapeArrays{box: p.Box, numParts: p.NumParts, numPoints: p.NumPoints, parts: p.Parts, points: p.Points,
+			z: p.ZArray, m: p.MArray, zRange: &p.ZRange, mRange: &p.MRange, rings: true}
+	case *MultiPointZ:
+		a = shapeArrays{box: p.Box, numPoints: p.NumPoints, points: p.Points,
+			z: p.ZArray, m: p.MArray, zRange: &p.ZRange, mRange: &p.MRange}
+	case *PolyLineM:
+		a = shapeArrays{box: p.Box, numParts: p.NumParts, numPoints: p.NumPoints, parts: p.Parts, points: p.Points,
+			m: p.MArray, mRange: &p.MRange}
+	case *PolygonM:
+		a = shapeArrays{box: p.Box, numParts: p.NumParts, numPoints: p.NumPoints, parts: p.Parts, points: p.Points,
+			m: p.MArray, mRange: &p.MRange, rings: true}
+	case *MultiPointM:
+		a = shapeArrays{box: p.Box, numPoints: p.NumPoints, points: p.Points, m: p.MArray, mRange: &p.MRange}
+	case *MultiPatch:
+		a = shapeArrays{box: p.Box, numParts: p.NumParts, numPoints: p.NumPoints, parts: p.Parts, points: p.Points,
+			partTypes: p.PartTypes, z: p.ZArray, m: p.MArray, zRange: &p.ZRange, mRange: &p.MRange}
+	default:
+		return a, false
+	}
+	return a, true
+}
+
+// decoded returns the regular shape that a *LazyShape or *Shape32 stands
+// for, and s itself for the other types. It returns nil if a lazy shape
+// cannot be decoded.
+func decoded(s Shape) Shape {
+	switch p := s.(type) {
+	case *LazyShape:
+		d, err := p.Decode()
+		if err != nil {
+			return nil
+		}
+		return decoded(d)
+	case *Shape32:
+		return p.Float64()
+	}
+	return s
+}
+
+// CheckShapeInvariants returns an error describing the first violation of
+// the structural rules of the shapefile specification by s, or nil. Shapes
+// decoded by a Reader and shapes written by a Writer satisfy them:
+//
+//   - NumParts and NumPoints match the lengths of Parts and Points
+//   - Parts start at 0, increase and index into Points
+//   - Z, M and part type arrays have one entry per point or part
+//   - Box contains all points and the Z and M ranges contain all values,
+//     ignoring "no data" measures
+//   - polygon rings are closed and have at least four points
+//   - coordinates are not NaN
+func CheckShapeInvariants(s Shape) error {
+	switch p := s.(type) {
+	case *Null:
+		return nil
+	case *Point:
+		return checkCoords(p.X, p.Y)
+	case *PointZ:
+		return checkCoords(p.X, p.Y, p.Z)
+	case *PointM:
+		return checkCoords(p.X, p.Y)
+	case *LazyShape:
+		d, err := p.Decode()
+		if err != nil {
+			return err
+		}
+		return CheckShapeInvariants(d)
+	case *Shape32:
+		return CheckShapeInvariants(p.Float64())
+	}
+	a, ok := arraysOf(s)
+	if !ok {
+		return fmt.Errorf("Unsupported shape type: %T", s)
+	}
+
+	if int(a.numPoints) != len(a.points) {
+		return fmt.Errorf("NumPoints %d does not match %d points", a.numPoints, len(a.points))
+	}
+	if int(a.numParts) != len(a.parts) {
+		return fmt.Errorf("NumParts %d does not match %d parts", a.numParts, len(a.parts))
+	}
+	for i, start := range a.parts {
+		switch {
+		case i == 0 && start != 0:
+			return fmt.Errorf("First part starts at %d instead of 0", start)
+		case i > 0 && start < a.parts[i-1]:
+			return fmt.Errorf("Part %d starts at %d before part %d", i, start, i-1)
+		case start > a.numPoints:
+			return fmt.Errorf("Part %d starts at %d beyond %d points", i, start, a.numPoints)
+		}
+	}
+	if a.partTypes != nil && len(a.partTypes) != len(a.parts) {
+		return fmt.Errorf("%d part types for %d parts", len(a.partTypes), len(a.parts))
+	}
+	if a.zRange != nil && len(a.z) != len(a.points) {
+		return fmt.Errorf("%d Z values for %d points", len(a.z), len(a.points))
+	}
+	// the M values of Z types are optional in the specification
+	if a.mRange != nil && len(a.m) != len(a.points) && (a.zRange == nil || len(a.m) != 0) {
+		return fmt.Errorf("%d M values for %d points", len(a.m), len(a.points))
+	}
+
+	for i, pt := range a.points {
+		if err := checkCoords(pt.X, pt.Y); err != nil {
+			return fmt.Errorf("Point %d: %v", i, err)
+		}
+		if !a.box.ContainsPoint(pt) {
+			return fmt.Errorf("Point %d (%v, %v) outside of box %v", i, pt.X, pt.Y, a.box)
+		}
+	}
+	for i, z := range a.z {
+		if err := checkCoords(z); err != nil {
+			return fmt.Errorf("Z value %d: %v", i, err)
+		}
+		if z < a.zRange[0] || z > a.zRange[1] {
+			return fmt.Errorf("Z value %d %v outside of range %v", i, z, *a.zRange)
+		}
+	}
+	for i, m := range a.m {
+		if IsNoData(m) || IsNoData(a.mRange[0]) {
+			continue
+		}
+		if m < a.mRange[0] || m > a.mRange[1] {
+			return fmt.Errorf("M value %d %v outside of range %v", i, m, *a.mRange)
+		}
+	}
+
+	if a.rings {
+		for i := range a.parts {
+			ring := part(a.parts, a.points, i)
+			if len(ring) < 4 {
+				return fmt.Errorf("Ring %d has %d points, at least 4 are required", i, len(ring))
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return fmt.Errorf("Ring %d is not closed", i)
+			}
+		}
+	}
+	return nil
+}
+
+func checkCoords(vs ...float64) error {
+	for _, v := range vs {
+		if math.IsNaN(v) {
+			return errors.New("Coordinate is NaN")
+		}
+	}
+	return nil
+}
+
+// withArrays returns a new shape of the type of s made of parts, partTypes,
+// points and the z and m values, which must be nil if s has none. The counts,
+// the bounding box and the ranges are computed from the arrays.
+func withArrays(s Shape, parts, partTypes []int32, points []Point, z, m []float64) Shape {
+	numParts, numPoints := int32(len(parts)), int32(len(points))
+	box := BBoxFromPoints(points)
+	var c Shape
+	switch s.(type) {
+	case *PolyLine:
+		c = &PolyLine{box, numParts, numPoints, parts, points}
+	case *Polygon:
+		c = &Polygon{box, numParts, numPoints, parts, points}
+	case *MultiPoint:
+		c = &MultiPoint{box, numPoints, points}
+	case *PolyLineZ:
+		c = &PolyLineZ{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: parts, Points: points, ZArray: z, MArray: m}
+	case *PolygonZ:
+		c = &PolygonZ{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: parts, Points: points, ZArray: z, MArray: m}
+	case *MultiPointZ:
+		c = &MultiPointZ{Box: box, NumPoints: numPoints, Points: points, ZArray: z, MArray: m}
+	case *PolyLineM:
+		c = &PolyLineM{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: parts, Points: points, MArray: m}
+	case *PolygonM:
+		c = &PolygonM{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: parts, Points: points, MArray: m}
+	case *MultiPointM:
+		c = &MultiPointM{Box: box, NumPoints: numPoints, Points: points, MArray: m}
+	case *MultiPatch:
+		c = &MultiPatch{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: parts, PartTypes: partTypes, Points: points, ZArray: z, MArray: m}
+	default:
+		return s
+	}
+	a, _ := arraysOf(c)
+	if a.zRange != nil && len(z) > 0 {
+		*a.zRange = valueRange(z)
+	}
+	if a.mRange != nil && len(m) > 0 {
+		*a.mRange = valueRange(m)
+	}
+	return c
+}
@@ -0,0 +1,129 @@
+package shp
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+)
+
+var quickShapeTypes = []ShapeType{
+	POINT, POLYLINE, POLYGON, MULTIPOINT,
+	POINTZ, POLYLINEZ, POLYGONZ, MULTIPOINTZ,
+	POINTM, POLYLINEM, POLYGONM, MULTIPOINTM, MULTIPATCH,
+}
+
+// TestQuickRoundTrip writes random shapes and checks that reading them back
+// returns equal shapes that satisfy the invariants.
+func TestQuickRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	roundTrip := func(seed int64, typ, parts, vertices uint8) bool {
+		opts := GenOptions{
+			Type:        quickShapeTypes[int(typ)%len(quickShapeTypes)],
+			Parts:       1 + int(parts)%4,
+			MaxVertices: int(vertices),
+			Extent:      Box{MinX: -1e6, MinY: -1e6, MaxX: 1e6, MaxY: 1e6},
+		}
+		g := generator{GenOptions: opts, rnd: rand.New(rand.NewSource(seed))}
+		shapes := make([]Shape, 1+g.rnd.Intn(10))
+		for i := range shapes {
+			shapes[i] = g.shape()
+			if err := CheckShapeInvariants(shapes[i]); err != nil {
+				t.Errorf("%v: generated shape %d: %v", opts.Type, i, err)
+				return false
+			}
+		}
+
+		filename := filepath.Join(dir, "quick.shp")
+		w, err := Create(filename, opts.Type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range shapes {
+			w.Write(s)
+		}
+		w.Close()
+
+		r, err := Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		n := 0
+		for ; r.Next(); n++ {
+			_, s := r.Shape()
+			if err := CheckShapeInvariants(s); err != nil {
+				t.Errorf("%v: read shape %d: %v", opts.Type, n, err)
+				return false
+			}
+			if n >= len(shapes) || !equalShapes(s, shapes[n]) {
+				t.Errorf("%v: shape %d differs after round trip", opts.Type, n)
+				return false
+			}
+		}
+		return r.Err() == nil && n == len(shapes)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckShapeInvariants(t *testing.T) {
+	square := []Point{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}
+	valid := func() *PolygonZ {
+		p := PolygonZ(*polyLineZ(NewPolygon([][]Point{square})))
+		return &p
+	}
+	tests := []struct {
+		name   string
+		modify func(p *PolygonZ)
+	}{
+		{"NumPoints", func(p *PolygonZ) { p.NumPoints++ }},
+		{"NumParts", func(p *PolygonZ) { p.NumParts = 2 }},
+		{"first part", func(p *PolygonZ) { p.Parts[0] = 1 }},
+		{"box", func(p *PolygonZ) { p.Box.MaxX = 0.5 }},
+		{"Z values", func(p *PolygonZ) { p.ZArray = p.ZArray[1:] }},
+		{"Z range", func(p *PolygonZ) { p.ZRange[1] = 0 }},
+		{"M range", func(p *PolygonZ) { p.MArray[2] = 10 }},
+		{"open ring", func(p *PolygonZ) { p.Points[4] = Point{1, 1}; p.Points[3] = Point{0, 1} }},
+		{"NaN", func(p *PolygonZ) { p.Points[1].X = math.NaN() }},
+	}
+	if err := CheckShapeInvariants(valid()); err != nil {
+		t.Fatalf("valid shape: %v", err)
+	}
+	for _, test := range tests {
+		p := valid()
+		test.modify(p)
+		if err := CheckShapeInvariants(p); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+// polyLineZ adds Z and M values of 1 to the points of p.
+func polyLineZ(p *Polygon) *PolyLineZ {
+	ones := make([]float64, len(p.Points))
+	for i := range ones {
+		ones[i] = 1
+	}
+	return &PolyLineZ{p.Box, p.NumParts, p.NumPoints, p.Parts, p.Points,
+		[2]float64{1, 1}, ones, [2]float64{1, 1}, append([]float64(nil), ones...)}
+}
+
+func TestTestFilesInvariants(t *testing.T) {
+	files, _ := filepath.Glob("test_files/*.shp")
+	for _, f := range files {
+		r, err := Open(f)
+		if err != nil {
+			continue
+		}
+		for r.Next() {
+			n, s := r.Shape()
+			if err := CheckShapeInvariants(s); err != nil {
+				t.Errorf("%s: shape %d: %v", f, n, err)
+			}
+		}
+		r.Close()
+	}
+}
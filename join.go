@@ -0,0 +1,351 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// JoinPredicate selects the features of the right side that SpatialJoin
+// joins to a feature of the left side.
+type JoinPredicate int
+
+const (
+	// JoinIntersects joins the right features that intersect the left one.
+	JoinIntersects JoinPredicate = iota
+	// JoinContains joins the right features inside the left one.
+	JoinContains
+	// JoinNearest joins the right feature closest to the left one, or the
+	// first of them for ties.
+	JoinNearest
+)
+
+func (p JoinPredicate) String() string {
+	switch p {
+	case JoinIntersects:
+		return "intersects"
+	case JoinContains:
+		return "contains"
+	case JoinNearest:
+		return "nearest"
+	}
+	return fmt.Sprintf("JoinPredicate(%d)", int(p))
+}
+
+// JoinOptions configures SpatialJoin.
+type JoinOptions struct {
+	// MaxDistance limits JoinNearest to right features within that distance
+	// of the left one. It is unlimited if 0.
+	MaxDistance float64
+	// KeepUnmatched also returns the left features without any match, with
+	// empty values for the attributes of the right side.
+	KeepUnmatched bool
+}
+
+// SpatialJoin returns a SequentialReader over the left features joined to the
+// right features that match predicate. Each record has the shape and the
+// number of the left feature and the attributes of both; a left feature that
+// matches several right features is returned once for each of them, in the
+// order of the right features. The fields of the right side follow those of
+// the left side, renamed with LaunderFieldNames where their names clash.
+//
+// The right side is read into memory and indexed with an R-tree before
+// SpatialJoin returns, so it may be closed then; the left side is read as the
+// result is read and closed with it. Distances and intersections are planar,
+// and boundaries within a billionth of the size of the coordinates touch.
+func SpatialJoin(left, right SequentialReader, predicate JoinPredicate, opts JoinOptions) (SequentialReader, error) {
+	j := &joinReader{SequentialReader: left, predicate: predicate, opts: opts, extent: EmptyBox()}
+	var entries []rtreeEntry
+	for right.Next() {
+		_, s := right.Shape()
+		if s == nil {
+			continue
+		}
+		if _, ok := s.(*Null); !ok {
+			entries = append(entries, rtreeEntry{box: s.BBox(), id: len(j.right)})
+			j.extent = j.extent.Union(s.BBox())
+		}
+		j.right = append(j.right, joinFeature{s, Attributes(right)})
+	}
+	if err := right.Err(); err != nil {
+		return nil, err
+	}
+	j.tree = newRTree(entries)
+
+	leftFields, rightFields := left.Fields(), right.Fields()
+	names := make([]string, 0, len(leftFields)+len(rightFields))
+	for _, f := range leftFields {
+		names = append(names, f.String())
+	}
+	for _, f := range rightFields {
+		names = append(names, f.String())
+	}
+	laundered, _ := LaunderFieldNames(names)
+	j.fields = append(append([]Field(nil), leftFields...), rightFields...)
+	for i := len(leftFields); i < len(j.fields); i++ {
+		if laundered[i] != names[i] {
+			j.fields[i].Name = [11]byte{}
+			copy(j.fields[i].Name[:], laundered[i])
+		}
+	}
+	j.numLeft, j.numRight = len(leftFields), len(rightFields)
+	return j, nil
+}
+
+type joinFeature struct {
+	shape      Shape
+	attributes []string
+}
+
+// joinReader implements SpatialJoin.
+type joinReader struct {
+	SequentialReader
+	predicate JoinPredicate
+	opts      JoinOptions
+
+	right    []joinFeature
+	tree     *rtree
+	extent   Box
+	fields   []Field
+	numLeft  int
+	numRight int
+
+	matches []int // of the current left feature, -1 for none
+	match   int
+}
+
+// Next implements a method of interface SequentialReader for joinReader.
+func (j *joinReader) Next() bool {
+	if j.match+1 < len(j.matches) {
+		j.match++
+		return true
+	}
+	for j.SequentialReader.Next() {
+		_, s := j.SequentialReader.Shape()
+		j.matches = j.find(s)
+		if len(j.matches) == 0 && j.opts.KeepUnmatched {
+			j.matches = []int{-1}
+		}
+		if len(j.matches) > 0 {
+			j.match = 0
+			return true
+		}
+	}
+	j.matches = nil
+	return false
+}
+
+// find returns the right features that match s.
+func (j *joinReader) find(s Shape) []int {
+	if s == nil || j.extent.IsEmpty() {
+		return nil
+	}
+	if _, ok := s.(*Null); ok {
+		return nil
+	}
+	box := s.BBox()
+	eps := epsilonFor(box.Union(j.extent))
+	var found []int
+	switch j.predicate {
+	case JoinIntersects:
+		for _, id := range j.tree.search(box.ExpandBy(eps)) {
+			if shapeDistance(s, j.right[id].shape) <= eps {
+				found = append(found, id)
+			}
+		}
+	case JoinContains:
+		for _, id := range j.tree.search(box.ExpandBy(eps)) {
+			if shapeContains(s, j.right[id].shape, eps) {
+				found = append(found, id)
+			}
+		}
+	case JoinNearest:
+		r := j.opts.MaxDistance
+		if r <= 0 {
+			// grow the search until it finds a candidate, whose distance
+			// then bounds the final search: a box within r may hold a
+			// shape farther away than one outside of it
+			r = math.Max(j.extent.MaxX-j.extent.MinX, j.extent.MaxY-j.extent.MinY) / math.Sqrt(float64(len(j.right))+1)
+			r = math.Max(r, eps)
+			candidates := j.tree.search(box.ExpandBy(r))
+			for len(candidates) == 0 {
+				r *= 2
+				candidates = j.tree.search(box.ExpandBy(r))
+			}
+			r = math.Inf(1)
+			for _, id := range candidates {
+				r = math.Min(r, shapeDistance(s, j.right[id].shape))
+			}
+			r = math.Max(r, eps)
+		}
+		best, dist := -1, math.Inf(1)
+		for _, id := range j.tree.search(box.ExpandBy(r)) {
+			d := shapeDistance(s, j.right[id].shape)
+			if d < dist && (j.opts.MaxDistance <= 0 || d <= r) {
+				best, dist = id, d
+			}
+		}
+		if best >= 0 {
+			found = []int{best}
+		}
+	}
+	return found
+}
+
+// Fields implements a method of interface SequentialReader for joinReader.
+func (j *joinReader) Fields() []Field {
+	return j.fields
+}
+
+// Attribute implements a method of interface SequentialReader for joinReader.
+func (j *joinReader) Attribute(n int) string {
+	if n < j.numLeft {
+		return j.SequentialReader.Attribute(n)
+	}
+	if j.match >= len(j.matches) || j.matches[j.match] < 0 {
+		return ""
+	}
+	if attrs := j.right[j.matches[j.match]].attributes; n-j.numLeft < len(attrs) {
+		return attrs[n-j.numLeft]
+	}
+	return ""
+}
+
+// NextBatch implements BatchReader for joinReader.
+func (j *joinReader) NextBatch(n int) ([]Record, error) {
+	var batch []Record
+	for len(batch) < n && j.Next() {
+		index, s := j.Shape()
+		batch = append(batch, Record{Index: index, Shape: s, Attributes: Attributes(j)})
+	}
+	if len(batch) == 0 {
+		if err := j.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return batch, nil
+}
+
+// shapeParts returns the parts of s as lines, with a single point for every
+// point of points and multipoints, and whether they are polygon rings.
+func shapeParts(s Shape) ([][]Point, bool) {
+	switch p := s.(type) {
+	case *Point:
+		return [][]Point{{*p}}, false
+	case *PointZ:
+		return [][]Point{{{p.X, p.Y}}}, false
+	case *PointM:
+		return [][]Point{{{p.X, p.Y}}}, false
+	}
+	a, ok := arraysOf(s)
+	if !ok {
+		return nil, false
+	}
+	if a.parts == nil {
+		parts := make([][]Point, len(a.points))
+		for i := range a.points {
+			parts[i] = a.points[i : i+1]
+		}
+		return parts, false
+	}
+	return splitParts(a.parts, a.points), a.rings
+}
+
+// shapeDistance returns the planar distance between a and b, which is 0 if
+// they intersect.
+func shapeDistance(a, b Shape) float64 {
+	pa, ra := shapeParts(a)
+	pb, rb := shapeParts(b)
+	if len(pa) == 0 || len(pb) == 0 {
+		return math.Inf(1)
+	}
+	// a part that does not cross the boundary of a polygon is either inside
+	// or outside of it
+	if ra {
+		for _, part := range pb {
+			if len(part) > 0 && PointInPolygon(part[0], a) {
+				return 0
+			}
+		}
+	}
+	if rb {
+		for _, part := range pa {
+			if len(part) > 0 && PointInPolygon(part[0], b) {
+				return 0
+			}
+		}
+	}
+	d := math.Inf(1)
+	for _, la := range pa {
+		for _, lb := range pb {
+			d = math.Min(d, lineDistance(la, lb))
+			if d == 0 {
+				return 0
+			}
+		}
+	}
+	return d
+}
+
+// lineDistance returns the distance between the lines a and b, either of
+// which may be a single point.
+func lineDistance(a, b []Point) float64 {
+	d := math.Inf(1)
+	for i := 0; i < max(len(a)-1, 1); i++ {
+		a1, a2 := a[i], a[min(i+1, len(a)-1)]
+		for k := 0; k < max(len(b)-1, 1); k++ {
+			b1, b2 := b[k], b[min(k+1, len(b)-1)]
+			d = math.Min(d, segmentsDistance(a1, a2, b1, b2))
+		}
+	}
+	return d
+}
+
+// segmentsDistance returns the distance between the segments from a1 to a2
+// and from b1 to b2, which is 0 if they intersect.
+func segmentsDistance(a1, a2, b1, b2 Point) float64 {
+	orient := func(p, q, r Point) float64 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+	d1, d2 := orient(b1, b2, a1), orient(b1, b2, a2)
+	d3, d4 := orient(a1, a2, b1), orient(a1, a2, b2)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return 0
+	}
+	return math.Min(
+		math.Min(segmentDistance(a1, b1, b2), segmentDistance(a2, b1, b2)),
+		math.Min(segmentDistance(b1, a1, a2), segmentDistance(b2, a1, a2)))
+}
+
+// shapeContains returns whether b lies inside a, including its boundary.
+// Polygons contain the shapes whose vertices are inside them or on their
+// boundary and whose edges do not cross theirs; other shapes contain the
+// shapes whose vertices lie on them.
+func shapeContains(a, b Shape, eps float64) bool {
+	pb, _ := shapeParts(b)
+	if len(pb) == 0 {
+		return false
+	}
+	aa, rings := arraysOf(a)
+	rings = rings && aa.rings
+	for _, part := range pb {
+		for _, p := range part {
+			if rings {
+				if !PointInPolygon(p, a) && boundaryDistance(p, aa) > eps {
+					return false
+				}
+			} else if shapeDistance(a, &p) > eps {
+				return false
+			}
+		}
+	}
+	if ba, ok := arraysOf(b); ok && rings && ba.parts != nil {
+		if _, cross := edgesCross(aa, ba, eps); cross {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpatialJoin(t *testing.T) {
+	cities := filenamePrefix + "cities"
+	areas := filenamePrefix + "join_areas"
+	defer removeShapefile(cities)
+	defer removeShapefile(areas)
+	writeCities(t, cities)
+
+	w, err := Create(areas+".shp", POLYGON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 5)})
+	square := func(x0, y0, x1, y1 float64) *Polygon {
+		return NewPolygon([][]Point{{{x0, y0}, {x0, y1}, {x1, y1}, {x1, y0}, {x0, y0}}})
+	}
+	w.WriteAttribute(int(w.Write(square(0, 0, 2, 3))), 0, "A")
+	w.WriteAttribute(int(w.Write(square(10, 10, 11, 11))), 0, "B")
+	w.WriteAttribute(int(w.Write(square(0, 0, 5, 5))), 0, "C")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	join := func(predicate JoinPredicate, opts JoinOptions) []string {
+		right := SequentialReaderFromExt(openFile(cities+".shp", t), openFile(cities+".dbf", t))
+		left := SequentialReaderFromExt(openFile(areas+".shp", t), openFile(areas+".dbf", t))
+		sr, err := SpatialJoin(left, right, predicate, opts)
+		right.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sr.Close()
+		if fields := sr.Fields(); len(fields) != 4 || fields[1].String() != "NAME_1" {
+			t.Fatalf("got fields %v", fields)
+		}
+		var got []string
+		for sr.Next() {
+			got = append(got, strings.TrimRight(sr.Attribute(0), "\x00")+"-"+strings.TrimRight(sr.Attribute(1), "\x00"))
+		}
+		if err := sr.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Fields(strings.Join(got, " "))
+	}
+
+	for _, c := range []struct {
+		predicate JoinPredicate
+		opts      JoinOptions
+		want      string
+	}{
+		{JoinContains, JoinOptions{}, "A-Springfield C-Springfield C-Shelbyville"},
+		{JoinIntersects, JoinOptions{KeepUnmatched: true}, "A-Springfield B- C-Springfield C-Shelbyville"},
+		{JoinNearest, JoinOptions{}, "A-Springfield B-Shelbyville C-Springfield"},
+		{JoinNearest, JoinOptions{MaxDistance: 1}, "A-Springfield C-Springfield"},
+	} {
+		if got := strings.Join(join(c.predicate, c.opts), " "); got != c.want {
+			t.Errorf("%v %+v: got %q, want %q", c.predicate, c.opts, got, c.want)
+		}
+	}
+
+	if d := shapeDistance(&Point{0, 0}, NewPolyLine([][]Point{{{3, 4}, {3, 10}}})); d != 5 {
+		t.Errorf("got distance %v, want 5", d)
+	}
+}
+
+func TestSpatialJoinNearestBeyondBoxes(t *testing.T) {
+	lines := filenamePrefix + "join_lines"
+	points := filenamePrefix + "join_points"
+	defer removeShapefile(lines)
+	defer removeShapefile(points)
+
+	w, err := Create(lines+".shp", POLYLINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 5)})
+	// the box of the diagonal reaches the point, the line itself does not
+	w.WriteAttribute(int(w.Write(NewPolyLine([][]Point{{{10, 0}, {0, 10}}}))), 0, "DIAG")
+	w.WriteAttribute(int(w.Write(NewPolyLine([][]Point{{{10, 10}, {10, 11}}}))), 0, "FAR")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err = Create(points+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 5)})
+	w.WriteAttribute(int(w.Write(&Point{0, 0})), 0, "O")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	right := SequentialReaderFromExt(openFile(lines+".shp", t), openFile(lines+".dbf", t))
+	left := SequentialReaderFromExt(openFile(points+".shp", t), openFile(points+".dbf", t))
+	sr, err := SpatialJoin(left, right, JoinNearest, JoinOptions{})
+	right.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Close()
+	var got []string
+	for sr.Next() {
+		got = append(got, strings.TrimRight(sr.Attribute(1), "\x00"))
+	}
+	if len(got) != 1 || got[0] != "DIAG" {
+		t.Errorf("got matches %q, want DIAG", got)
+	}
+}
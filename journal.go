@@ -0,0 +1,173 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Journal makes the Writer maintain a small journal file next to the
+// shapefile, named like it with the extension ".journal", while writing. After
+// every record, the journal stores how many records are complete and the
+// lengths of the files up to them. If the process is interrupted before
+// Close, which removes the journal, Recover uses it to truncate the files to
+// the last complete record and to write their headers, which turns them into
+// a valid shapefile that can be appended to. The journal is not synced to
+// disk, so it protects against crashes of the process, not of the system.
+func Journal() WriterOption {
+	return func(o *writerOptions) {
+		o.journal = true
+	}
+}
+
+const journalMagic = "SHPJ"
+
+// journalState is the fixed part of a journal. It is followed by the DBF
+// fields.
+type journalState struct {
+	Magic           [4]byte
+	GeometryType    ShapeType
+	Num             int32
+	_               int32
+	SHPLength       int64
+	SHXLength       int64
+	DBFHeaderLength int16
+	DBFRecordLength int16
+	_               int32
+}
+
+// startJournal creates the journal of w and writes the current state to it.
+func (w *Writer) startJournal() error {
+	f, err := os.Create(w.filename + ".journal")
+	if err != nil {
+		return err
+	}
+	w.journal = f
+	w.writeJournal(true)
+	return nil
+}
+
+// writeJournal writes the state of w to its journal, including the DBF
+// fields if fields is set.
+func (w *Writer) writeJournal(fields bool) {
+	if w.journal == nil {
+		return
+	}
+	s := journalState{
+		GeometryType:    w.GeometryType,
+		Num:             w.num,
+		DBFHeaderLength: w.dbfHeaderLength,
+		DBFRecordLength: w.dbfRecordLength,
+	}
+	copy(s.Magic[:], journalMagic)
+	s.SHPLength, _ = w.shp.Seek(0, io.SeekCurrent)
+	s.SHXLength, _ = w.shx.Seek(0, io.SeekCurrent)
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, s)
+	if fields {
+		binary.Write(&buf, binary.LittleEndian, w.dbfFields)
+	}
+	w.journal.WriteAt(buf.Bytes(), 0)
+}
+
+// closeJournal closes and, if the Writer was closed successfully, removes the
+// journal.
+func closeJournal(journal *os.File, err error) {
+	if journal == nil {
+		return
+	}
+	journal.Close()
+	if err == nil {
+		os.Remove(journal.Name())
+	}
+}
+
+// Recover restores the shapefile filename from its journal after an
+// interrupted write with the Journal option. It truncates the SHP, SHX and
+// DBF files to the last complete record, writes their headers and removes the
+// journal. If there is no journal, an error satisfying os.IsNotExist is
+// returned.
+func Recover(filename string) error {
+	basename := strings.TrimSuffix(filename, ".shp")
+	data, err := os.ReadFile(basename + ".journal")
+	if err != nil {
+		return err
+	}
+	var s journalState
+	r := bytes.NewReader(data)
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil || string(s.Magic[:]) != journalMagic {
+		return errors.New("Invalid journal")
+	}
+	fields := make([]Field, r.Len()/32)
+	binary.Read(r, binary.LittleEndian, fields)
+
+	w := &Writer{
+		filename:        basename,
+		GeometryType:    s.GeometryType,
+		num:             s.Num,
+		dbfFields:       fields,
+		dbfHeaderLength: s.DBFHeaderLength,
+		dbfRecordLength: s.DBFRecordLength,
+	}
+	files := []struct {
+		ext    string
+		length int64
+		f      *writeSeekCloser
+	}{
+		{".shp", s.SHPLength, &w.shp},
+		{".shx", s.SHXLength, &w.shx},
+		{".dbf", int64(s.DBFHeaderLength) + int64(s.Num)*int64(s.DBFRecordLength), &w.dbf},
+	}
+	for _, file := range files {
+		if file.ext == ".dbf" && s.DBFHeaderLength == 0 {
+			continue // SetFields was not called
+		}
+		f, err := os.OpenFile(basename+file.ext, os.O_RDWR, 0666)
+		if err != nil {
+			w.closeFiles()
+			return err
+		}
+		*file.f = f
+		if err := f.Truncate(file.length); err != nil {
+			w.closeFiles()
+			return fmt.Errorf("Cannot truncate %s: %v", f.Name(), err)
+		}
+		f.Seek(0, io.SeekEnd)
+	}
+
+	// the bounding box is computed from the remaining records, which can
+	// only be read once the header holds the file length
+	w.writeHeader(w.shp)
+	sr, err := Open(basename+".shp", SkipAttributes())
+	if err != nil {
+		w.closeFiles()
+		return err
+	}
+	for sr.Next() {
+		_, shape := sr.Shape()
+		w.extent.Add(shape)
+	}
+	sr.Close()
+	if err := sr.Err(); err != nil {
+		w.closeFiles()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.Remove(basename + ".journal")
+}
+
+// closeFiles closes the files of w that are open without writing headers.
+func (w *Writer) closeFiles() {
+	for _, f := range []writeSeekCloser{w.shp, w.shx, w.dbf} {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
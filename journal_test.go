@@ -0,0 +1,80 @@
+package shp
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalRecover(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "journal.shp")
+	w, err := Create(filename, POINT, Journal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 8)})
+	for i, name := range []string{"a", "b", "c"} {
+		w.Write(&Point{float64(i), float64(i)})
+		w.WriteAttribute(i, 0, name)
+	}
+	// simulate a crash in the middle of the fourth record
+	w.shp.Write([]byte{0, 0, 0, 4, 0, 0})
+	w.dbf.Seek(0, io.SeekEnd)
+	w.dbf.Write([]byte(" d"))
+	w.closeFiles()
+	w.journal.Close()
+
+	if err := Recover(filename); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(filename, ".shp") + ".journal"); !os.IsNotExist(err) {
+		t.Errorf("journal not removed: %v", err)
+	}
+
+	// resume writing
+	w, err = Append(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(&Point{3, 3})
+	w.WriteAttribute(3, 0, "d")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if want := (Box{0, 0, 3, 3}); r.BBox() != want {
+		t.Errorf("got box %v, want %v", r.BBox(), want)
+	}
+	var names []string
+	for r.Next() {
+		names = append(names, strings.TrimRight(r.Attribute(0), "\x00"))
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names, ""); got != "abcd" {
+		t.Errorf("got attributes %q, want %q", got, "abcd")
+	}
+}
+
+func TestJournalRemovedOnClose(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "journal.shp")
+	w, err := Create(filename, POINT, Journal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(&Point{1, 1})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Recover(filename); !os.IsNotExist(err) {
+		t.Errorf("got %v, want a missing journal", err)
+	}
+}
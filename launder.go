@@ -0,0 +1,47 @@
+package shp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LaunderFieldNames turns names, e.g. the property keys of GeoJSON features or
+// the header of a CSV file, into valid and unique DBF field names, similar to
+// the laundering of GDAL. Every character other than an ASCII letter, digit
+// or underscore is replaced with an underscore, and names are truncated to 10
+// characters. A name that equals an earlier one, ignoring case as DBF readers
+// do, gets the suffix "_1", "_2" and so on, replacing its last characters if
+// necessary. Empty names become "FIELD". The result only depends on the
+// order of names. It returns the laundered names in the order of names and a
+// mapping from each name to its laundered name; for duplicate names, the
+// mapping holds the first.
+func LaunderFieldNames(names []string) (laundered []string, mapping map[string]string) {
+	laundered = make([]string, len(names))
+	mapping = make(map[string]string, len(names))
+	taken := make(map[string]bool, len(names))
+	for i, name := range names {
+		base := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+				return r
+			}
+			return '_'
+		}, name)
+		if base == "" {
+			base = "FIELD"
+		}
+		if len(base) > 10 {
+			base = base[:10]
+		}
+		candidate := base
+		for n := 1; taken[strings.ToUpper(candidate)]; n++ {
+			suffix := "_" + strconv.Itoa(n)
+			candidate = base[:min(len(base), 10-len(suffix))] + suffix
+		}
+		taken[strings.ToUpper(candidate)] = true
+		laundered[i] = candidate
+		if _, ok := mapping[name]; !ok {
+			mapping[name] = candidate
+		}
+	}
+	return laundered, mapping
+}
@@ -0,0 +1,27 @@
+package shp
+
+import "testing"
+
+func TestLaunderFieldNames(t *testing.T) {
+	names := []string{"name", "population_2020", "population_2021", "NAME", "área", "", "a b", "population_2020", "populati_1"}
+	want := []string{"name", "population", "populati_1", "NAME_1", "_rea", "FIELD", "a_b", "populati_2", "populati_3"}
+	got, mapping := LaunderFieldNames(names)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("laundered %q to %q, want %q", names[i], got[i], want[i])
+		}
+	}
+	if mapping["population_2020"] != "population" || mapping["área"] != "_rea" {
+		t.Errorf("got mapping %v", mapping)
+	}
+
+	// names past the tenth duplicate get longer suffixes
+	var many []string
+	for i := 0; i < 12; i++ {
+		many = append(many, "identifier")
+	}
+	got, _ = LaunderFieldNames(many)
+	if got[0] != "identifier" || got[1] != "identifi_1" || got[11] != "identif_11" {
+		t.Errorf("got %v", got)
+	}
+}
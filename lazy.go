@@ -0,0 +1,135 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// LazyDecoding makes the reader return every shape as a *LazyShape, which
+// decodes its coordinates only when they are first needed. Pipelines that
+// reject most records based on their type or bounding box don't pay for
+// decoding the coordinates of the rejected records.
+func LazyDecoding() ReaderOption {
+	return func(o *readerOptions) {
+		o.lazy = true
+	}
+}
+
+// LazyShape holds the raw bytes of a record. Its type and bounding box are
+// available immediately, the shape itself is decoded on the first call to
+// Decode with the options of the reader that returned it.
+type LazyShape struct {
+	shapeType ShapeType
+	box       Box
+	raw       []byte // record content following the shape type
+	opts      readerOptions
+	warnings  *[]Warning // of the reader, if the shape is validated
+	index     int
+
+	shape Shape
+	err   error
+}
+
+// readLazy reads the content of a record of type t whose content length,
+// including the shape type, is size bytes.
+func (o readerOptions) readLazy(file io.Reader, t ShapeType, size int64) (*LazyShape, error) {
+	if _, err := newShape(t); err != nil {
+		return nil, err
+	}
+	if size < 4 {
+		return nil, fmt.Errorf("Record of %d bytes is too short", size)
+	}
+	l := &LazyShape{shapeType: t, raw: make([]byte, size-4), opts: o}
+	if _, err := io.ReadFull(file, l.raw); err != nil {
+		return nil, err
+	}
+	f := func(i int) float64 {
+		if len(l.raw) < 8*(i+1) {
+			return 0
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(l.raw[8*i:]))
+	}
+	switch t {
+	case NULL:
+	case POINT, POINTZ, POINTM:
+		l.box = Box{f(0), f(1), f(0), f(1)}
+	default:
+		l.box = Box{f(0), f(1), f(2), f(3)}
+	}
+	return l, nil
+}
+
+// reportTo makes Decode report the violations of the shape, the record at
+// index, to warnings.
+func (l *LazyShape) reportTo(warnings *[]Warning, index int) *LazyShape {
+	l.warnings, l.index = warnings, index
+	return l
+}
+
+// ShapeType returns the type of the shape.
+func (l *LazyShape) ShapeType() ShapeType {
+	return l.shapeType
+}
+
+// BBox returns the bounding box of the shape as stored in the record, or the
+// location of a point. It does not decode the shape, unless the reader
+// transforms coordinates.
+func (l *LazyShape) BBox() Box {
+	if l.opts.transformer != nil {
+		if s, err := l.Decode(); err == nil {
+			return s.BBox()
+		}
+	}
+	return l.box
+}
+
+// Decode returns the decoded shape. It is decoded on the first call only, so
+// later calls return the same Shape. The bytes of the record are released
+// after decoding. With ValidateShapes, the shape is checked like an eagerly
+// decoded one and violations are reported as warnings of the reader.
+func (l *LazyShape) Decode() (Shape, error) {
+	if l.shape != nil || l.err != nil {
+		return l.shape, l.err
+	}
+	s, _ := newShape(l.shapeType)
+	er := &errReader{Reader: bytes.NewReader(l.raw), size: int64(len(l.raw)), limits: l.opts.limits, pooled: l.opts.pooled}
+	s.read(er)
+	if er.e != nil && er.e != io.EOF {
+		l.err = fmt.Errorf("Error while decoding shape: %w", er.e)
+		return nil, l.err
+	}
+	l.opts.decodeMeasures(s)
+	if l.opts.transformer != nil {
+		TransformShape(s, l.opts.transformer)
+	}
+	if l.warnings != nil {
+		l.opts.validateShape(l.warnings, l.index, s)
+	}
+	if l.opts.float32Coords {
+		s = toShape32(s)
+	}
+	l.shape, l.raw = s, nil
+	return s, nil
+}
+
+func (l *LazyShape) read(file io.Reader) {
+	// the length of the record is unknown here, readers use readLazy
+	l.err = fmt.Errorf("Cannot read a LazyShape without its record length")
+}
+
+func (l *LazyShape) write(file io.Writer) {
+	if l.shape == nil && l.err == nil && l.opts.transformer != nil {
+		l.Decode()
+	}
+	if l.shape == nil && l.err == nil {
+		// write the bytes as read without decoding
+		file.Write(l.raw)
+		return
+	}
+	if l.shape != nil {
+		l.shape.write(file)
+	}
+}
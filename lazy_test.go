@@ -0,0 +1,81 @@
+package shp
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLazyDecoding(t *testing.T) {
+	for _, prefix := range []string{"test_files/point", "test_files/polygonm", "test_files/multipatch"} {
+		full := getShapesFromFile(prefix, t)
+		r, err := Open(prefix+".shp", LazyDecoding())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; r.Next(); i++ {
+			_, s := r.Shape()
+			l, ok := s.(*LazyShape)
+			if !ok {
+				t.Fatalf("%s: got %T, want *LazyShape", prefix, s)
+			}
+			if l.ShapeType() != shapeTypeOf(full[i]) || l.BBox() != full[i].BBox() {
+				t.Errorf("%s: got type %v and box %v", prefix, l.ShapeType(), l.BBox())
+			}
+			if l.shape != nil {
+				t.Errorf("%s: shape was decoded before Decode", prefix)
+			}
+			got, err := l.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalShapes(got, full[i]) {
+				t.Errorf("%s: got %+v, want %+v", prefix, got, full[i])
+			}
+			if again, _ := l.Decode(); again != got {
+				t.Errorf("%s: Decode returned a new shape", prefix)
+			}
+		}
+		if r.Err() != nil {
+			t.Error(r.Err())
+		}
+		r.Close()
+	}
+}
+
+func TestLazyDecodingSequential(t *testing.T) {
+	sr := SequentialReaderFromExt(openFile("test_files/polyline.shp", t), nil, LazyDecoding(), Float32Coords())
+	defer sr.Close()
+	n := 0
+	for sr.Next() {
+		_, s := sr.Shape()
+		d, err := s.(*LazyShape).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := d.(*Shape32); !ok {
+			t.Errorf("got %T, want *Shape32", d)
+		}
+		n++
+	}
+	if n != 2 || sr.Err() != nil {
+		t.Errorf("got %d shapes and error %v", n, sr.Err())
+	}
+}
+
+func TestLazyDecodingCorrupt(t *testing.T) {
+	b, err := os.ReadFile("test_files/polyline.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// claim a huge number of points in the first record
+	b[100+8+4+32+4] = 0xff
+	sr := SequentialReaderFromExt(newReadSeekCloser(b), nil, LazyDecoding())
+	defer sr.Close()
+	if !sr.Next() {
+		t.Fatal(sr.Err())
+	}
+	_, s := sr.Shape()
+	if _, err := s.(*LazyShape).Decode(); err == nil {
+		t.Error("decoded corrupt record without error")
+	}
+}
@@ -0,0 +1,95 @@
+package shp
+
+import (
+	"fmt"
+	"io"
+)
+
+// Limits restricts the sizes that are accepted when decoding shape records.
+// Independent of these values, the number of parts and points of a record is
+// always checked against the record length declared in its header, so corrupt
+// or hostile input is reported as an error instead of causing huge
+// allocations. A zero value for any of the fields disables that check.
+type Limits struct {
+	MaxRecordSize int64 // maximum size of a record's content in bytes
+	MaxNumParts   int32 // maximum number of parts in a shape
+	MaxNumPoints  int32 // maximum number of points in a shape
+}
+
+// DefaultLimits are the limits used by readers unless configured otherwise.
+var DefaultLimits = Limits{
+	MaxRecordSize: 1 << 30,
+	MaxNumParts:   1 << 24,
+	MaxNumPoints:  1 << 26,
+}
+
+// LimitError is returned when a record exceeds one of the Limits or declares
+// more parts or points than its record length can hold.
+type LimitError struct {
+	What  string // "record size", "parts" or "points"
+	Value int64
+	Limit int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s %d exceeds limit %d", e.What, e.Value, e.Limit)
+}
+
+// checkRecordSize validates the content length (in bytes) of a record as
+// declared in its header.
+func (l Limits) checkRecordSize(size int64) error {
+	if size < 4 {
+		return &LimitError{What: "record size", Value: size, Limit: 4}
+	}
+	if l.MaxRecordSize > 0 && size > l.MaxRecordSize {
+		return &LimitError{What: "record size", Value: size, Limit: l.MaxRecordSize}
+	}
+	return nil
+}
+
+// checkCounts validates the number of parts and points of a shape that is
+// read from file before anything is allocated for them. Every part takes at
+// least partSize bytes and every point at least 16 bytes in the record. If
+// the counts are invalid, the error is stored in the errReader so that no
+// further reads happen and false is returned.
+func checkCounts(file io.Reader, numParts, numPoints int32, partSize int64) bool {
+	er, ok := file.(*errReader)
+	if !ok {
+		return numParts >= 0 && numPoints >= 0
+	}
+	if er.e != nil {
+		return false
+	}
+	if err := er.checkCounts(numParts, numPoints, partSize); err != nil {
+		er.e = err
+		return false
+	}
+	return true
+}
+
+func (er *errReader) checkCounts(numParts, numPoints int32, partSize int64) error {
+	if numParts < 0 {
+		return &LimitError{What: "parts", Value: int64(numParts), Limit: 0}
+	}
+	if numPoints < 0 {
+		return &LimitError{What: "points", Value: int64(numPoints), Limit: 0}
+	}
+	if er.limits.MaxNumParts > 0 && numParts > er.limits.MaxNumParts {
+		return &LimitError{What: "parts", Value: int64(numParts), Limit: int64(er.limits.MaxNumParts)}
+	}
+	if er.limits.MaxNumPoints > 0 && numPoints > er.limits.MaxNumPoints {
+		return &LimitError{What: "points", Value: int64(numPoints), Limit: int64(er.limits.MaxNumPoints)}
+	}
+	if er.size <= 0 {
+		return nil
+	}
+	left := er.size - er.n
+	if need := int64(numParts) * partSize; numParts > 0 && need > left {
+		return &LimitError{What: "parts", Value: int64(numParts), Limit: left / partSize}
+	}
+	left -= int64(numParts) * partSize
+	if need := int64(numPoints) * 16; need > left {
+		return &LimitError{What: "points", Value: int64(numPoints), Limit: left / 16}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+// hugeRecord returns a polyline record whose header claims many more points
+// than the record length can hold.
+func hugeRecord() []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, []int32{1, 24})
+	binary.Write(buf, binary.LittleEndian, int32(POLYLINE))
+	binary.Write(buf, binary.LittleEndian, Box{})
+	binary.Write(buf, binary.LittleEndian, []int32{1, 1 << 30, 0})
+	return buf.Bytes()
+}
+
+func TestLimitsDerivedFromRecordLength(t *testing.T) {
+	record := hugeRecord()
+	tests := []struct {
+		r interface {
+			Next() bool
+			Err() error
+		}
+		name string
+	}{
+		{&Reader{shp: newReadSeekCloser(record), filelength: int64(len(record))}, "reader"},
+		{&seqReader{shp: newReadSeekCloser(record), filelength: int64(len(record))}, "seqReader"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.r.Next() {
+				t.Fatal("read shape with too many points without stopping")
+			}
+			var le *LimitError
+			if !errors.As(test.r.Err(), &le) {
+				t.Fatalf("got error %v, want a *LimitError", test.r.Err())
+			}
+			if le.What != "points" {
+				t.Errorf("got limit on %q, want points", le.What)
+			}
+		})
+	}
+}
+
+func TestLimitsMaxRecordSize(t *testing.T) {
+	record := hugeRecord()
+	r := &Reader{
+		shp:           newReadSeekCloser(record),
+		filelength:    int64(len(record)),
+		readerOptions: readerOptions{limits: Limits{MaxRecordSize: 16}},
+	}
+	if r.Next() {
+		t.Fatal("read record exceeding MaxRecordSize without stopping")
+	}
+	var le *LimitError
+	if !errors.As(r.Err(), &le) || le.What != "record size" {
+		t.Fatalf("got error %v, want record size *LimitError", r.Err())
+	}
+}
+
+func addSeeds(f *testing.F, ext string) {
+	for prefix := range dataForReadTests {
+		b, err := os.ReadFile(prefix + ext)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	addSeeds(f, ".shp")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r := &Reader{shp: newReadSeekCloser(b), readerOptions: newReaderOptions(nil)}
+		r.readHeaders()
+		for i := 0; r.Next() && i < 1000; i++ {
+			r.Shape()
+		}
+	})
+}
+
+func FuzzSequentialReader(f *testing.F) {
+	addSeeds(f, ".shp")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		if len(b) < 100 {
+			return
+		}
+		// skip the file header, shp.readHeaders would also want a DBF
+		sr := &seqReader{shp: newReadSeekCloser(b[100:]), readerOptions: newReaderOptions(nil)}
+		for i := 0; sr.Next() && i < 1000; i++ {
+			sr.Shape()
+		}
+	})
+}
+
+func FuzzDbf(f *testing.F) {
+	addSeeds(f, ".dbf")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r := &Reader{dbf: newReadSeekCloser(b)}
+		if err := r.readDbfHeader(); err != nil {
+			return
+		}
+		for row := 0; row < r.AttributeCount() && row < 100; row++ {
+			for field := range r.Fields() {
+				r.ReadAttribute(row, field)
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package shp
+
+import "os"
+
+// WithLock makes the Writer hold an exclusive advisory lock on the SHP file
+// from Create or Append until Close, so that two processes that both use
+// WithLock cannot interleave their writes and header patches and corrupt the
+// files. If another process holds the lock, Create and Append wait for it.
+// The lock uses flock on Unix and LockFileEx on Windows; on other platforms,
+// Create and Append fail with this option.
+//
+// With Atomic, Create locks a file named like the SHP file with the extra
+// extension ".lock" instead, so that no empty SHP file appears before Close.
+// The lock file is left in place.
+func WithLock() WriterOption {
+	return func(o *writerOptions) {
+		o.lock = true
+	}
+}
+
+// lockName returns the name of the file that WithLock locks for the
+// shapefile with basename filename.
+func lockName(filename string, atomic bool) string {
+	if atomic {
+		return filename + ".shp.lock"
+	}
+	return filename + ".shp"
+}
+
+// acquireLock opens filename, creating it if needed, and locks it. The lock
+// is held on a handle of its own, so that the file may be truncated and
+// written through other handles.
+func acquireLock(filename string) (*os.File, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "lock", Path: filename, Err: err}
+	}
+	return f, nil
+}
+
+// releaseLock unlocks and closes f if it is not nil.
+func releaseLock(f *os.File) {
+	if f != nil {
+		unlockFile(f)
+		f.Close()
+	}
+}
@@ -0,0 +1,87 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows
+
+package shp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithLock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "locked.shp")
+	w, err := Create(filename, POINT, WithLock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(&Point{1, 1})
+
+	appended := make(chan error)
+	go func() {
+		w2, err := Append(filename, WithLock())
+		if err == nil {
+			w2.Write(&Point{2, 2})
+			w2.Close()
+		}
+		appended <- err
+	}()
+	select {
+	case err := <-appended:
+		t.Fatalf("Append did not wait for the lock: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	w.Close()
+	if err := <-appended; err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	n := 0
+	for r.Next() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("read %d records, want 2", n)
+	}
+}
+
+func TestWithLockAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "locked.shp")
+	w, err := Create(filename, POINT, WithLock(), Atomic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(&Point{1, 1})
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("SHP file exists before Close: %v", err)
+	}
+
+	created := make(chan error)
+	go func() {
+		w2, err := Create(filename, POINT, WithLock(), Atomic())
+		if err == nil {
+			err = w2.Close()
+		}
+		created <- err
+	}()
+	select {
+	case err := <-created:
+		t.Fatalf("Create did not wait for the lock: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-created; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,25 @@
+package shp
+
+import "log/slog"
+
+// WithLogger makes the reader trace the parsing of headers, skipped records
+// and warnings to l at debug level.
+func WithLogger(l *slog.Logger) ReaderOption {
+	return func(o *readerOptions) {
+		o.logger = l
+	}
+}
+
+// debug logs msg with args at debug level if a logger is set.
+func (o readerOptions) debug(msg string, args ...any) {
+	if o.logger != nil {
+		o.logger.Debug(msg, args...)
+	}
+}
+
+// debug logs msg with args at debug level if w.Logger is set.
+func (w *Writer) debug(msg string, args ...any) {
+	if w.Logger != nil {
+		w.Logger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,43 @@
+package shp
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	filename := filepath.Join(t.TempDir(), "log.shp")
+	w, err := Create(filename, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Logger = logger
+	w.Write(&Point{1, 2})
+	w.Close()
+	for _, msg := range []string{`msg="patching header"`, `msg="patching DBF header"`} {
+		if !strings.Contains(buf.String(), msg) {
+			t.Errorf("writer log lacks %s:\n%s", msg, buf.String())
+		}
+	}
+
+	buf.Reset()
+	r, err := Open(writeMixed(t), WithLogger(logger), WithMixedTypes(MixedTypesSkip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for r.Next() {
+	}
+	r.Fields()
+	for _, msg := range []string{`msg="read SHP header"`, `msg="read DBF header"`, `msg=warning record=1`, `msg="skipping record" record=1`} {
+		if !strings.Contains(buf.String(), msg) {
+			t.Errorf("reader log lacks %s:\n%s", msg, buf.String())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// WriteAllOptions configures WriteAll.
+type WriteAllOptions struct {
+	// ShapeType is the geometry type of the new shapefile. If it is NULL, the
+	// type of the geometry of the first row is used.
+	ShapeType ShapeType
+}
+
+// WriteAll creates the shapefile filename (SHP, SHX and DBF) from rows, which
+// must be structs. The DBF schema is derived from the fields of T using the
+// same struct tags as Unmarshal: strings become character fields, integers
+// number fields, floats float fields, bools logical fields and time.Time date
+// fields. Unless set with the size and prec tag options, the width of every
+// column is the widest value in rows and floats use 6 decimals. The geometry
+// of each row is taken from the geometry field of T, which must not be nil.
+func WriteAll[T any](filename string, rows []T, opts *WriteAllOptions) (err error) {
+	var zero T
+	fields, err := structFieldsOf(reflect.TypeOf(zero))
+	if err != nil {
+		return err
+	}
+	if opts == nil {
+		opts = &WriteAllOptions{}
+	}
+
+	geometry := -1
+	var columns []structField
+	for i, f := range fields {
+		if f.geometry {
+			geometry = i
+		} else {
+			columns = append(columns, f)
+		}
+	}
+	if geometry < 0 {
+		return fmt.Errorf("%T has no geometry field", zero)
+	}
+
+	shapes := make([]Shape, len(rows))
+	values := make([][]string, len(rows))
+	for i := range rows {
+		v := reflect.ValueOf(&rows[i]).Elem()
+		s, _ := v.Field(fields[geometry].index).Interface().(Shape)
+		if s == nil || reflect.ValueOf(s).IsNil() {
+			return fmt.Errorf("row %d has no geometry", i)
+		}
+		if err := requireFloat64(s); err != nil {
+			return fmt.Errorf("row %d: %w", i, err)
+		}
+		shapes[i] = s
+		values[i] = make([]string, len(columns))
+		for j, f := range columns {
+			values[i][j], err = formatValue(v.Field(f.index), f)
+			if err != nil {
+				return fmt.Errorf("row %d: field %s: %v", i, f.name, err)
+			}
+		}
+	}
+
+	dbfFields := make([]Field, len(columns))
+	for j, f := range columns {
+		dbfFields[j], err = fieldFor(reflect.TypeOf(zero).Field(f.index).Type, f, values, j)
+		if err != nil {
+			return err
+		}
+	}
+
+	t := opts.ShapeType
+	if t == NULL && len(shapes) > 0 {
+		t = shapeTypeOf(shapes[0])
+	}
+	w, err := Create(filename, t)
+	if err != nil {
+		return err
+	}
+	// Close writes the headers and may fail to, which must not go unnoticed
+	defer func() {
+		if e := w.Close(); err == nil {
+			err = e
+		}
+	}()
+	if err := w.SetFields(dbfFields); err != nil {
+		return err
+	}
+	for i, s := range shapes {
+		if st := shapeTypeOf(s); st != t {
+			return fmt.Errorf("row %d: geometry is %v, want %v", i, st, t)
+		}
+		n := int(w.Write(s))
+		for j, v := range values[i] {
+			if err := w.WriteAttribute(n, j, v); err != nil {
+				return fmt.Errorf("row %d: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// formatValue returns the DBF representation of v.
+func formatValue(v reflect.Value, f structField) (string, error) {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format("20060102"), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "T", nil
+		}
+		return "F", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', floatPrecision(f), v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %v", v.Type())
+	}
+}
+
+func floatPrecision(f structField) int {
+	if f.prec > 0 {
+		return f.prec
+	}
+	return 6
+}
+
+// fieldFor returns the DBF field for the struct field f of type t. Column j
+// of values holds the formatted values of all rows.
+func fieldFor(t reflect.Type, f structField, values [][]string, j int) (Field, error) {
+	if len(f.name) > 10 {
+		return Field{}, fmt.Errorf("field name %s is longer than 10 characters", f.name)
+	}
+	size := f.size
+	if size == 0 {
+		size = 1
+		for _, row := range values {
+			if len(row[j]) > size {
+				size = len(row[j])
+			}
+		}
+	}
+	if size > 254 {
+		return Field{}, fmt.Errorf("field %s is %d bytes wide, the maximum is 254", f.name, size)
+	}
+	switch {
+	case t == timeType:
+		return DateField(f.name), nil
+	case t.Kind() == reflect.String:
+		return StringField(f.name, uint8(size)), nil
+	case t.Kind() == reflect.Bool:
+		field := Field{Fieldtype: 'L', Size: 1}
+		copy(field.Name[:], []byte(f.name))
+		return field, nil
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return FloatField(f.name, uint8(size), uint8(floatPrecision(f))), nil
+	default:
+		return NumberField(f.name, uint8(size)), nil
+	}
+}
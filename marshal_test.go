@@ -0,0 +1,61 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type station struct {
+	Location *PointZ `shp:",geometry"`
+	Name     string  `shp:"NAME,size=16"`
+	Height   float64 `shp:"HEIGHT,prec=1"`
+	Active   bool    `shp:"ACTIVE"`
+	Since    time.Time
+}
+
+func TestWriteAll(t *testing.T) {
+	filename := filenamePrefix + "stations"
+	defer removeShapefile(filename)
+
+	rows := []station{
+		{&PointZ{1, 2, 3, 0}, "Summit", 1234.5, true, time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{&PointZ{4, 5, 6, 0}, "Valley", 12, false, time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	if err := WriteAll(filename, rows, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+	defer sr.Close()
+	wantFields := []string{"NAME", "HEIGHT", "ACTIVE", "Since"}
+	fields := sr.Fields()
+	if len(fields) != len(wantFields) {
+		t.Fatalf("got %d fields, want %d", len(fields), len(wantFields))
+	}
+	for i, f := range fields {
+		if f.String() != wantFields[i] {
+			t.Errorf("field %d is %s, want %s", i, f, wantFields[i])
+		}
+	}
+	if fields[0].Size != 16 || fields[1].Precision != 1 {
+		t.Errorf("got fields %v, want size 16 and precision 1", fields)
+	}
+
+	got, err := ReadAll[station](sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("got %+v, want %+v", got, rows)
+	}
+}
+
+func TestWriteAllWithoutGeometry(t *testing.T) {
+	rows := []struct{ Name string }{{"a"}}
+	if err := WriteAll(filenamePrefix+"nogeom", rows, nil); err == nil {
+		t.Error("wrote rows without geometry field")
+	}
+}
@@ -0,0 +1,87 @@
+package shp
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// NoDataM is the value written for measures that are NaN. The shapefile
+// specification treats every measure smaller than -1e38 as "no data".
+const NoDataM = -1e39
+
+// IsNoData returns whether the measure m is "no data", either because it is
+// NaN or because it is a value smaller than -1e38 as read from a file.
+func IsNoData(m float64) bool {
+	return math.IsNaN(m) || m < -1e38
+}
+
+// WithNoDataM sets the value that "no data" measures are decoded to. By
+// default, they are decoded to NaN.
+func WithNoDataM(v float64) ReaderOption {
+	return func(o *readerOptions) {
+		o.noDataM = &v
+	}
+}
+
+func encodeMeasure(m float64) float64 {
+	if math.IsNaN(m) {
+		return NoDataM
+	}
+	return m
+}
+
+// writeMeasures writes ms to file, replacing NaN with NoDataM.
+func writeMeasures(file io.Writer, ms []float64) {
+	out := make([]float64, len(ms))
+	for i, m := range ms {
+		out[i] = encodeMeasure(m)
+	}
+	binary.Write(file, binary.LittleEndian, out)
+}
+
+// decodeMeasures replaces all "no data" measures of s in place.
+func (o readerOptions) decodeMeasures(s Shape) {
+	noData := math.NaN()
+	if o.noDataM != nil {
+		noData = *o.noDataM
+	}
+	decode := func(ms []float64) {
+		for i, m := range ms {
+			if m < -1e38 {
+				ms[i] = noData
+			}
+		}
+	}
+	switch p := s.(type) {
+	case *PointZ:
+		if p.M < -1e38 {
+			p.M = noData
+		}
+	case *PointM:
+		if p.M < -1e38 {
+			p.M = noData
+		}
+	case *PolyLineZ:
+		decode(p.MRange[:])
+		decode(p.MArray)
+	case *PolygonZ:
+		decode(p.MRange[:])
+		decode(p.MArray)
+	case *MultiPointZ:
+		decode(p.MRange[:])
+		decode(p.MArray)
+	case *PolyLineM:
+		decode(p.MRange[:])
+		decode(p.MArray)
+	case *PolygonM:
+		decode(p.MRange[:])
+		decode(p.MArray)
+	case *MultiPointM:
+		decode(p.MRange[:])
+		decode(p.MArray)
+	case *MultiPatch:
+		decode(p.MRange[:])
+		decode(p.MArray)
+	}
+}
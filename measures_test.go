@@ -0,0 +1,68 @@
+package shp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoDataMeasures(t *testing.T) {
+	filename := filenamePrefix + "polylinem"
+	defer removeShapefile(filename)
+
+	w, err := Create(filename+".shp", POLYLINEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewPolyLine([][]Point{{{0, 0}, {1, 1}, {2, 2}}})
+	lm := &PolyLineM{
+		Box:       l.Box,
+		NumParts:  l.NumParts,
+		NumPoints: l.NumPoints,
+		Parts:     l.Parts,
+		Points:    l.Points,
+		MRange:    [2]float64{1, 3},
+		MArray:    []float64{1, math.NaN(), 3},
+	}
+	w.Write(lm)
+	w.Close()
+	if !math.IsNaN(lm.MArray[1]) {
+		t.Error("Write modified the measures of its input")
+	}
+
+	tests := []struct {
+		name string
+		opts []ReaderOption
+		want func(float64) bool
+	}{
+		{"default", nil, math.IsNaN},
+		{"sentinel", []ReaderOption{WithNoDataM(-9999)}, func(m float64) bool { return m == -9999 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := Open(filename+".shp", test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if !r.Next() {
+				t.Fatal(r.Err())
+			}
+			_, s := r.Shape()
+			ms := s.(*PolyLineM).MArray
+			if ms[0] != 1 || !test.want(ms[1]) || ms[2] != 3 {
+				t.Errorf("got measures %v", ms)
+			}
+		})
+	}
+}
+
+func TestIsNoData(t *testing.T) {
+	for _, m := range []float64{math.NaN(), NoDataM, -math.MaxFloat64} {
+		if !IsNoData(m) {
+			t.Errorf("IsNoData(%v) = false", m)
+		}
+	}
+	if IsNoData(-1e37) {
+		t.Error("IsNoData(-1e37) = true")
+	}
+}
@@ -0,0 +1,43 @@
+package shp
+
+import (
+	"io"
+	"time"
+)
+
+// Metrics receives measurements from readers and writers, so that services
+// can export them, e.g. to Prometheus or OpenTelemetry, without wrapping
+// every call. Implementations shared by several readers or writers must be
+// safe for concurrent use.
+type Metrics interface {
+	// RecordRead is called for every record read with the number of bytes
+	// of the record in the SHP file and the time it took to read and
+	// decode it.
+	RecordRead(bytes int64, d time.Duration)
+	// RecordWritten is called for every record written with the number of
+	// bytes of the record in the SHP file.
+	RecordWritten(bytes int64)
+	// Error is called for every error that stops reading or writing. Op
+	// is "read" or "write".
+	Error(op string)
+}
+
+// WithMetrics makes the reader report to m.
+func WithMetrics(m Metrics) ReaderOption {
+	return func(o *readerOptions) {
+		o.metrics = m
+	}
+}
+
+// observeNext reports a call to Next that started at start and read bytes
+// from the SHP file. *reported is the last error reported, so that errors
+// that are returned repeatedly are only reported once.
+func (o readerOptions) observeNext(ok bool, err error, reported *error, start time.Time, bytes int64) {
+	switch {
+	case ok:
+		o.metrics.RecordRead(bytes, time.Since(start))
+	case err != nil && err != io.EOF && err != *reported:
+		*reported = err
+		o.metrics.Error("read")
+	}
+}
@@ -0,0 +1,83 @@
+package shp
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type countingMetrics struct {
+	read, written int
+	bytes         int64
+	errors        map[string]int
+}
+
+func (m *countingMetrics) RecordRead(bytes int64, d time.Duration) {
+	m.read++
+	m.bytes += bytes
+}
+
+func (m *countingMetrics) RecordWritten(bytes int64) {
+	m.written++
+	m.bytes += bytes
+}
+
+func (m *countingMetrics) Error(op string) {
+	if m.errors == nil {
+		m.errors = make(map[string]int)
+	}
+	m.errors[op]++
+}
+
+func TestMetrics(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "metrics.shp")
+	w, err := Create(filename, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm := &countingMetrics{}
+	w.Metrics = wm
+	w.SetFields([]Field{StringField("NAME", 4)})
+	for i := 0; i < 3; i++ {
+		w.Write(&Point{float64(i), float64(i)})
+	}
+	if w.WriteAttribute(0, 0, "too long") == nil {
+		t.Error("expected error for long attribute")
+	}
+	w.Close()
+	// a point record has an 8 byte header, a 4 byte type and 16 bytes of
+	// coordinates
+	if wm.written != 3 || wm.bytes != 3*28 || wm.errors["write"] != 1 {
+		t.Errorf("got writer metrics %+v", wm)
+	}
+
+	rm := &countingMetrics{}
+	r, err := Open(filename, WithMetrics(rm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r.Next() {
+	}
+	r.Close()
+	sm := &countingMetrics{}
+	sr := SequentialReaderFromExt(openFile(filename, t), nil, WithMetrics(sm))
+	for sr.Next() {
+	}
+	sr.Close()
+	for _, m := range []*countingMetrics{rm, sm} {
+		if m.read != 3 || m.bytes != 3*28 || len(m.errors) != 0 {
+			t.Errorf("got reader metrics %+v", m)
+		}
+	}
+}
+
+func TestMetricsError(t *testing.T) {
+	m := &countingMetrics{}
+	sr := SequentialReaderFromExt(newReadSeekCloser(make([]byte, 120)), nil, WithMetrics(m))
+	for i := 0; i < 3; i++ {
+		sr.Next()
+	}
+	if sr.Err() == nil || m.errors["read"] != 1 {
+		t.Errorf("got metrics %+v for error %v", m, sr.Err())
+	}
+}
@@ -0,0 +1,82 @@
+package shp
+
+import "fmt"
+
+// MixedTypesPolicy determines how readers handle records whose shape type
+// differs from the type in the header of the SHP file, as found in some
+// broken files. Null records are valid in every shapefile and never count as
+// different.
+type MixedTypesPolicy int
+
+const (
+	// MixedTypesDecode decodes every record as the type it declares. This
+	// is the default.
+	MixedTypesDecode MixedTypesPolicy = iota
+	// MixedTypesError stops reading with a *ShapeTypeError.
+	MixedTypesError
+	// MixedTypesSkip skips the record together with its attribute row.
+	MixedTypesSkip
+)
+
+// WithMixedTypes sets the policy for records whose shape type differs from
+// the header of the SHP file.
+func WithMixedTypes(p MixedTypesPolicy) ReaderOption {
+	return func(o *readerOptions) {
+		o.mixedTypes = p
+	}
+}
+
+// ShapeTypeError is the error of readers with MixedTypesError for a record
+// whose shape type differs from the header of the SHP file.
+type ShapeTypeError struct {
+	Index  int       // index of the record
+	Header ShapeType // type in the file header
+	Got    ShapeType // type of the record
+}
+
+func (e *ShapeTypeError) Error() string {
+	return fmt.Sprintf("record %d has shape type %v, but the file has %v", e.Index, e.Got, e.Header)
+}
+
+// checkShapeType applies the MixedTypesPolicy to the record index of type t
+// in a file of type header, counting it in *counts and warning about it if
+// the types differ. It returns whether the record is to be skipped.
+func (o readerOptions) checkShapeType(counts *map[ShapeType]int, warnings *[]Warning, header, t ShapeType, index int) (skip bool, err error) {
+	if t == header || t == NULL {
+		return false, nil
+	}
+	if *counts == nil {
+		*counts = make(map[ShapeType]int)
+	}
+	(*counts)[t]++
+	err = &ShapeTypeError{Index: index, Header: header, Got: t}
+	if o.mixedTypes == MixedTypesError {
+		return false, err
+	}
+	o.warn(warnings, Warning{Index: index, Err: err})
+	if o.mixedTypes == MixedTypesSkip {
+		o.debug("skipping record", "record", index, "type", t)
+		return true, nil
+	}
+	return false, nil
+}
+
+// copyCounts returns a copy of counts.
+func copyCounts(counts map[ShapeType]int) map[ShapeType]int {
+	out := make(map[ShapeType]int, len(counts))
+	for t, n := range counts {
+		out[t] = n
+	}
+	return out
+}
+
+// MixedTypes returns how many of the records read so far have each shape type
+// that differs from the header of the SHP file, including skipped records.
+func (r *Reader) MixedTypes() map[ShapeType]int {
+	return copyCounts(r.mixedTypeCounts)
+}
+
+// MixedTypes implements MixedTypesReporter for seqReader.
+func (sr *seqReader) MixedTypes() map[ShapeType]int {
+	return copyCounts(sr.mixedTypeCounts)
+}
@@ -0,0 +1,73 @@
+package shp
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeMixed writes a POINT shapefile whose second record is a PointZ and
+// whose third record is Null.
+func writeMixed(t *testing.T) string {
+	filename := filepath.Join(t.TempDir(), "mixed.shp")
+	w, err := Create(filename, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{NumberField("ID", 4)})
+	for i, s := range []Shape{&Point{1, 1}, &PointZ{2, 2, 2, 2}, &Null{}, &Point{4, 4}} {
+		// the Writer writes its GeometryType into every record
+		w.GeometryType = shapeTypeOf(s)
+		w.Write(s)
+		w.WriteAttribute(i, 0, i)
+	}
+	w.GeometryType = POINT
+	w.Close()
+	return filename
+}
+
+func TestMixedTypes(t *testing.T) {
+	filename := writeMixed(t)
+	tests := []struct {
+		policy MixedTypesPolicy
+		ids    []string
+		err    bool
+	}{
+		{MixedTypesDecode, []string{"0", "1", "2", "3"}, false},
+		{MixedTypesSkip, []string{"0", "2", "3"}, false},
+		{MixedTypesError, []string{"0"}, true},
+	}
+	for _, test := range tests {
+		r, err := Open(filename, WithMixedTypes(test.policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sr := SequentialReaderFromExt(openFile(filename, t),
+			openFile(filename[:len(filename)-4]+".dbf", t), WithMixedTypes(test.policy))
+		for _, rd := range []interface {
+			batchSource
+			MixedTypes() map[ShapeType]int
+		}{r, sr.(*seqReader)} {
+			var ids []string
+			for rd.Next() {
+				ids = append(ids, strings.TrimRight(rd.Attribute(0), " \x00"))
+			}
+			if !reflect.DeepEqual(ids, test.ids) {
+				t.Errorf("%T %v: read ids %v, want %v", rd, test.policy, ids, test.ids)
+			}
+			var te *ShapeTypeError
+			if errors.As(rd.Err(), &te) != test.err {
+				t.Errorf("%T %v: got error %v", rd, test.policy, rd.Err())
+			} else if test.err && (te.Index != 1 || te.Got != POINTZ || te.Header != POINT) {
+				t.Errorf("%T %v: got error %+v", rd, test.policy, te)
+			}
+			if got := rd.MixedTypes(); !reflect.DeepEqual(got, map[ShapeType]int{POINTZ: 1}) {
+				t.Errorf("%T %v: MixedTypes() = %v", rd, test.policy, got)
+			}
+		}
+		r.Close()
+		sr.Close()
+	}
+}
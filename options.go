@@ -0,0 +1,101 @@
+package shp
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ReaderOption configures how a shapefile is read. Options can be passed to
+// Open, SequentialReaderFromExt, OpenZip and OpenShapeFromZip.
+type ReaderOption func(*readerOptions)
+
+// readerOptions holds the settings shared by all readers.
+type readerOptions struct {
+	limits          Limits
+	skipAttributes  bool
+	skipGeometry    bool
+	noDataM         *float64 // nil means NaN
+	float32Coords   bool
+	lazy            bool
+	reuseBatches    bool
+	alignment       AlignmentPolicy
+	mixedTypes      MixedTypesPolicy
+	onWarning       func(Warning)
+	validate        bool
+	logger          *slog.Logger
+	metrics         Metrics
+	snapshot        bool
+	transformer     Transformer
+	checkCRS        bool
+	selectFields    []string // nil means all
+	virtualFields   []virtualField
+	readAhead       int
+	verifyChecksums bool
+	expectMD5       map[string]string // by extension
+	pooled          bool
+	sampling        samplingOptions
+	warnMu          *sync.Mutex // guards the warnings, which lazy shapes report to
+}
+
+func newReaderOptions(opts []ReaderOption) readerOptions {
+	o := readerOptions{limits: DefaultLimits, warnMu: new(sync.Mutex)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLimits replaces DefaultLimits with l when decoding shapes.
+func WithLimits(l Limits) ReaderOption {
+	return func(o *readerOptions) {
+		o.limits = l
+	}
+}
+
+// SkipAttributes makes the reader ignore the DBF file. It is not opened or
+// read at all, so Fields returns nil and every attribute is empty.
+func SkipAttributes() ReaderOption {
+	return func(o *readerOptions) {
+		o.skipAttributes = true
+	}
+}
+
+// SkipGeometry makes the reader ignore the SHP file. Next only advances
+// through the rows of the DBF file and Shape always returns a nil Shape. The
+// bounding box and geometry type of the shapefile are not available either.
+func SkipGeometry() ReaderOption {
+	return func(o *readerOptions) {
+		o.skipGeometry = true
+	}
+}
+
+// WriterOption configures how a shapefile is written. Options can be passed
+// to Create and Append.
+type WriterOption func(*writerOptions)
+
+// writerOptions holds the settings of a Writer.
+type writerOptions struct {
+	lock        bool
+	atomic      bool
+	journal     bool
+	transformer Transformer
+	overflow    OverflowPolicy
+
+	truncateStrings bool
+	lastUpdate      time.Time
+	prj, cpg        string
+	undo            io.Writer
+	deterministic   bool
+	style           *Style
+}
+
+func newWriterOptions(opts []WriterOption) writerOptions {
+	var o writerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.applyDeterministic()
+	return o
+}
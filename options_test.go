@@ -0,0 +1,71 @@
+package shp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSkipAttributes(t *testing.T) {
+	sr := SequentialReaderFromExt(openFile("test_files/point.shp", t), nil, SkipAttributes())
+	defer sr.Close()
+	n := 0
+	for sr.Next() {
+		if _, s := sr.Shape(); s == nil {
+			t.Error("got nil shape")
+		}
+		n++
+	}
+	if err := sr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d shapes, want 3", n)
+	}
+	if sr.Fields() != nil {
+		t.Errorf("got fields %v with SkipAttributes", sr.Fields())
+	}
+
+	r, err := Open("test_files/point.shp", SkipAttributes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Fields() != nil {
+		t.Errorf("got fields %v with SkipAttributes", r.Fields())
+	}
+}
+
+func TestSkipGeometry(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr := SequentialReaderFromExt(nil, openFile(filename+".dbf", t), SkipGeometry())
+	defer sr.Close()
+	var names []string
+	for sr.Next() {
+		if _, s := sr.Shape(); s != nil {
+			t.Errorf("got shape %v with SkipGeometry", s)
+		}
+		names = append(names, strings.TrimRight(sr.Attribute(0), "\x00"))
+	}
+	if err := sr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "Springfield" {
+		t.Errorf("got names %q", names)
+	}
+
+	r, err := Open(filename+".shp", SkipGeometry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	n := 0
+	for r.Next() {
+		n++
+	}
+	if n != 2 || r.GeometryType != NULL {
+		t.Errorf("got %d rows and geometry type %v, want 2 rows and no geometry", n, r.GeometryType)
+	}
+}
@@ -0,0 +1,101 @@
+package shp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// OverflowPolicy determines what WriteAttribute does with a number that does
+// not fit the size of its field.
+type OverflowPolicy int
+
+const (
+	// OverflowError makes WriteAttribute return an error. This is the
+	// default.
+	OverflowError OverflowPolicy = iota
+	// OverflowTruncate writes floats with fewer decimals than the precision
+	// of the field until they fit. Numbers whose integer digits do not fit
+	// are still an error.
+	OverflowTruncate
+	// OverflowWiden widens the field to fit the number, up to 255
+	// characters. The DBF file is rewritten with the new record layout,
+	// which takes time proportional to the records written so far.
+	OverflowWiden
+	// OverflowMarker fills the field with asterisks, as dBase does.
+	OverflowMarker
+)
+
+// WithOverflow sets the policy for numbers that do not fit their fields.
+// Strings that are too long are an error unless TruncateStrings is used.
+func WithOverflow(p OverflowPolicy) WriterOption {
+	return func(o *writerOptions) {
+		o.overflow = p
+	}
+}
+
+// fitNumber applies the overflow policy to the number v, formatted as buf,
+// which does not fit its field.
+func (w *Writer) fitNumber(field int, v interface{}, buf []byte) ([]byte, error) {
+	f := w.dbfFields[field]
+	err := fmt.Errorf("Unable to write field %v: %q exceeds field length %v", field, buf, f.Size)
+	switch w.overflow {
+	case OverflowTruncate:
+		if x, ok := v.(float64); ok {
+			for p := int(f.Precision) - 1; p >= 0; p-- {
+				if buf := strconv.FormatFloat(x, 'f', p, 64); len(buf) <= int(f.Size) {
+					return []byte(buf), nil
+				}
+			}
+		}
+	case OverflowWiden:
+		if len(buf) <= 255 {
+			if err := w.widenField(field, len(buf)); err != nil {
+				return nil, err
+			}
+			return buf, nil
+		}
+	case OverflowMarker:
+		return bytes.Repeat([]byte{'*'}, int(f.Size)), nil
+	}
+	return nil, err
+}
+
+// widenField changes the size of field to size and rewrites the records of
+// the DBF file with the new layout.
+func (w *Writer) widenField(field, size int) error {
+	ra, ok := w.dbf.(io.ReaderAt)
+	if !ok {
+		return fmt.Errorf("Cannot widen field %v: DBF file cannot be read back", field)
+	}
+	oldLength := int(w.dbfRecordLength)
+	data := make([]byte, int(w.num)*oldLength)
+	if _, err := ra.ReadAt(data, int64(w.dbfHeaderLength)); err != nil && err != io.EOF {
+		return fmt.Errorf("Cannot widen field %v: %v", field, err)
+	}
+	start := 1
+	for n := 0; n < field; n++ {
+		start += int(w.dbfFields[n].Size)
+	}
+	end := start + int(w.dbfFields[field].Size)
+	pad := bytes.Repeat([]byte{' '}, size-int(w.dbfFields[field].Size))
+	out := make([]byte, 0, int(w.num)*(oldLength+len(pad)))
+	for r := 0; r+oldLength <= len(data); r += oldLength {
+		rec := data[r : r+oldLength]
+		out = append(out, rec[:end]...)
+		out = append(out, pad...)
+		out = append(out, rec[end:]...)
+	}
+	w.debug("widening field", "field", field, "size", size, "records", w.num)
+	w.dbf.Seek(int64(w.dbfHeaderLength), io.SeekStart)
+	if _, err := w.dbf.Write(out); err != nil {
+		return fmt.Errorf("Cannot widen field %v: %v", field, err)
+	}
+	// the fields may be shared with the caller of SetFields
+	w.dbfFields = append([]Field(nil), w.dbfFields...)
+	w.dbfFields[field].Size = uint8(size)
+	w.dbfRecordLength += int16(len(pad))
+	w.writeJournal(true)
+	return nil
+}
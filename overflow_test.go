@@ -0,0 +1,78 @@
+package shp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOverflow(t *testing.T) {
+	for _, c := range []struct {
+		policy OverflowPolicy
+		want   []string // POP, AREA of the second row
+		err    bool
+	}{
+		{OverflowError, nil, true},
+		{OverflowTruncate, nil, true},
+		{OverflowMarker, []string{"****", "*****"}, false},
+		{OverflowWiden, []string{"123456", "12.345"}, false},
+	} {
+		filename := filepath.Join(t.TempDir(), "overflow.shp")
+		w, err := Create(filename, POINT, WithOverflow(c.policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.SetFields([]Field{StringField("NAME", 4), NumberField("POP", 4), FloatField("AREA", 5, 3)})
+		for row, values := range [][]interface{}{{"a", 1, 1.5}, {"b", 123456, 12.345}, {"c", 3, 0.25}} {
+			w.Write(&Point{float64(row), 0})
+			for i, v := range values {
+				if err := w.WriteAttribute(row, i, v); err != nil && !c.err {
+					t.Fatalf("policy %d: %v", c.policy, err)
+				} else if err == nil && c.err && row == 1 && i == 1 {
+					t.Errorf("policy %d: wrote %v", c.policy, v)
+				}
+			}
+		}
+		w.Close()
+		if c.err {
+			continue
+		}
+
+		r, err := Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rows []string
+		for r.Next() {
+			var attrs []string
+			for i := range r.Fields() {
+				attrs = append(attrs, strings.TrimRight(r.Attribute(i), "\x00 "))
+			}
+			rows = append(rows, strings.Join(attrs, ","))
+		}
+		r.Close()
+		if got, want := rows[1], "b,"+strings.Join(c.want, ","); got != want {
+			t.Errorf("policy %d: got row %q, want %q", c.policy, got, want)
+		}
+		if got := strings.Join([]string{rows[0], rows[2]}, ";"); got != "a,1,1.500;c,3,0.250" {
+			t.Errorf("policy %d: got other rows %q", c.policy, got)
+		}
+	}
+}
+
+func TestOverflowTruncate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "overflow.shp")
+	w, err := Create(filename, POINT, WithOverflow(OverflowTruncate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.SetFields([]Field{FloatField("AREA", 5, 3)})
+	w.Write(&Point{0, 0})
+	if err := w.WriteAttribute(0, 0, 12.345); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteAttribute(0, 0, 123456.0); err == nil {
+		t.Error("wrote a number whose integer digits do not fit")
+	}
+}
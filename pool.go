@@ -0,0 +1,143 @@
+package shp
+
+import (
+	"io"
+	"math/bits"
+	"sync"
+)
+
+// PooledShapes makes the reader take the Points, Parts and Z and M slices of
+// the shapes it decodes from pools of recycled slices instead of allocating
+// them. Shapes are returned to the pools with Release, so services that read
+// millions of shapes produce far less garbage. Without Release, the slices
+// are garbage collected as usual, so the option is safe, but useless.
+func PooledShapes() ReaderOption {
+	return func(o *readerOptions) {
+		o.pooled = true
+	}
+}
+
+// maxPooledClass is the largest size class of pooled slices, 2^24 elements.
+// Larger slices are allocated and collected as usual.
+const maxPooledClass = 24
+
+// slicePool keeps slices in size classes of powers of two.
+type slicePool[T any] struct {
+	classes [maxPooledClass + 1]sync.Pool // of *[]T
+}
+
+var (
+	pointPool slicePool[Point]
+	int32Pool slicePool[int32]
+	floatPool slicePool[float64]
+)
+
+// get returns a slice of n elements with undefined content.
+func (p *slicePool[T]) get(n int) []T {
+	if n <= 0 {
+		return make([]T, 0)
+	}
+	class := bits.Len(uint(n - 1))
+	if class > maxPooledClass {
+		return make([]T, n)
+	}
+	if v, ok := p.classes[class].Get().(*[]T); ok {
+		return (*v)[:n]
+	}
+	return make([]T, n, 1<<class)
+}
+
+// put returns s to the pool of its size class.
+func (p *slicePool[T]) put(s []T) {
+	c := cap(s)
+	if c == 0 {
+		return
+	}
+	class := bits.Len(uint(c)) - 1 // round down, so get never sees a short slice
+	if class > maxPooledClass {
+		return
+	}
+	s = s[:0]
+	p.classes[class].Put(&s)
+}
+
+// pooled returns whether file decodes shapes into pooled slices.
+func pooled(file io.Reader) bool {
+	er, ok := file.(*errReader)
+	return ok && er.pooled
+}
+
+func makePoints(file io.Reader, n int32) []Point {
+	if pooled(file) {
+		return pointPool.get(int(n))
+	}
+	return make([]Point, n)
+}
+
+func makeInt32s(file io.Reader, n int32) []int32 {
+	if pooled(file) {
+		return int32Pool.get(int(n))
+	}
+	return make([]int32, n)
+}
+
+func makeFloats(file io.Reader, n int32) []float64 {
+	if pooled(file) {
+		return floatPool.get(int(n))
+	}
+	return make([]float64, n)
+}
+
+// Release returns the slices of s to the pools that PooledShapes reads shapes
+// from and clears them. s must not be used afterwards, nor any slice taken
+// from it, such as a Part. Shapes from any source may be released, and
+// releasing a shape without slices, such as a Point, does nothing.
+func Release(s Shape) {
+	switch p := s.(type) {
+	case *PolyLine:
+		releaseParts(&p.Parts, &p.Points, &p.NumParts, &p.NumPoints)
+	case *Polygon:
+		releaseParts(&p.Parts, &p.Points, &p.NumParts, &p.NumPoints)
+	case *MultiPoint:
+		pointPool.put(p.Points)
+		p.Points, p.NumPoints = nil, 0
+	case *PolyLineZ:
+		releaseParts(&p.Parts, &p.Points, &p.NumParts, &p.NumPoints)
+		releaseFloats(&p.ZArray, &p.MArray)
+	case *PolygonZ:
+		releaseParts(&p.Parts, &p.Points, &p.NumParts, &p.NumPoints)
+		releaseFloats(&p.ZArray, &p.MArray)
+	case *MultiPointZ:
+		pointPool.put(p.Points)
+		p.Points, p.NumPoints = nil, 0
+		releaseFloats(&p.ZArray, &p.MArray)
+	case *PolyLineM:
+		releaseParts(&p.Parts, &p.Points, &p.NumParts, &p.NumPoints)
+		releaseFloats(&p.MArray)
+	case *PolygonM:
+		releaseParts(&p.Parts, &p.Points, &p.NumParts, &p.NumPoints)
+		releaseFloats(&p.MArray)
+	case *MultiPointM:
+		pointPool.put(p.Points)
+		p.Points, p.NumPoints = nil, 0
+		releaseFloats(&p.MArray)
+	case *MultiPatch:
+		releaseParts(&p.Parts, &p.Points, &p.NumParts, &p.NumPoints)
+		int32Pool.put(p.PartTypes)
+		p.PartTypes = nil
+		releaseFloats(&p.ZArray, &p.MArray)
+	}
+}
+
+func releaseParts(parts *[]int32, points *[]Point, numParts, numPoints *int32) {
+	int32Pool.put(*parts)
+	pointPool.put(*points)
+	*parts, *points, *numParts, *numPoints = nil, nil, 0, 0
+}
+
+func releaseFloats(arrays ...*[]float64) {
+	for _, a := range arrays {
+		floatPool.put(*a)
+		*a = nil
+	}
+}
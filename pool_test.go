@@ -0,0 +1,81 @@
+package shp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSlicePool(t *testing.T) {
+	var p slicePool[int32]
+	s := p.get(5)
+	if len(s) != 5 || cap(s) != 8 {
+		t.Errorf("get(5) has len %d and cap %d, want 5 and 8", len(s), cap(s))
+	}
+	p.put(s)
+	p.put(make([]int32, 3, 12)) // class of 8
+	for i := 0; i < 3; i++ {
+		if s := p.get(7); len(s) != 7 || cap(s) < 7 {
+			t.Errorf("get(7) has len %d and cap %d", len(s), cap(s))
+		}
+	}
+	if s := p.get(0); s == nil || len(s) != 0 {
+		t.Errorf("get(0) = %v", s)
+	}
+}
+
+func TestPooledShapes(t *testing.T) {
+	for _, name := range []string{"polygon", "polylinez", "multipointm", "multipatch"} {
+		want := readShapes(t, "test_files/"+name+".shp")
+		// decode every file twice, so that the second pass reuses the
+		// slices released by the first
+		for pass := 0; pass < 2; pass++ {
+			r, err := Open("test_files/"+name+".shp", PooledShapes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			i := 0
+			for r.Next() {
+				_, s := r.Shape()
+				// compared as text, as M values may be NaN
+				if fmt.Sprint(s) != fmt.Sprint(want[i]) {
+					t.Errorf("%s: shape %d = %+v, want %+v", name, i, s, want[i])
+				}
+				Release(s)
+				i++
+			}
+			if r.Err() != nil {
+				t.Fatal(r.Err())
+			}
+			r.Close()
+		}
+	}
+}
+
+func readShapes(t *testing.T, filename string) []Shape {
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var shapes []Shape
+	for r.Next() {
+		_, s := r.Shape()
+		shapes = append(shapes, s)
+	}
+	return shapes
+}
+
+func TestRelease(t *testing.T) {
+	p := NewPolyLine([][]Point{{{0, 0}, {1, 1}}})
+	Release(p)
+	if p.Parts != nil || p.Points != nil || p.NumParts != 0 || p.NumPoints != 0 {
+		t.Errorf("released PolyLine = %+v", p)
+	}
+	z := &PolygonZ{Points: []Point{{}}, ZArray: []float64{1}, MArray: []float64{2}}
+	Release(z)
+	if z.Points != nil || z.ZArray != nil || z.MArray != nil {
+		t.Errorf("released PolygonZ = %+v", z)
+	}
+	Release(&Point{1, 2})
+	Release(nil)
+}
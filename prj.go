@@ -0,0 +1,42 @@
+package shp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Conversion factors from common linear units to meters.
+const (
+	FootToMeter         = 0.3048
+	USSurveyFootToMeter = 1200.0 / 3937.0
+)
+
+// LinearUnit returns the name of the linear unit and its size in meters from
+// the WKT contents of a .prj file, e.g. "Foot_US" and 0.3048006096012192. It
+// returns false for geographic coordinate systems, whose only unit is an
+// angular one, and for WKT that cannot be parsed.
+func LinearUnit(wkt string) (name string, meters float64, ok bool) {
+	wkt = strings.TrimSpace(wkt)
+	if !strings.HasPrefix(strings.ToUpper(wkt), "PROJCS[") {
+		return "", 0, false
+	}
+	// the linear unit of a PROJCS follows its nested GEOGCS
+	i := strings.LastIndex(strings.ToUpper(wkt), "UNIT[")
+	if i < 0 {
+		return "", 0, false
+	}
+	rest := wkt[i+len("UNIT["):]
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return "", 0, false
+	}
+	fields := strings.Split(rest[:end], ",")
+	if len(fields) < 2 {
+		return "", 0, false
+	}
+	meters, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.Trim(strings.TrimSpace(fields[0]), `"`), meters, true
+}
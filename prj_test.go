@@ -0,0 +1,21 @@
+package shp
+
+import "testing"
+
+const wktStatePlaneFeet = `PROJCS["NAD_1983_StatePlane_California_III_FIPS_0403_Feet",` +
+	`GEOGCS["GCS_North_American_1983",DATUM["D_North_American_1983",SPHEROID["GRS_1980",6378137.0,298.257222101]],` +
+	`PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Lambert_Conformal_Conic"],` +
+	`PARAMETER["False_Easting",6561666.666666666],PARAMETER["Central_Meridian",-120.5],UNIT["Foot_US",0.3048006096012192]]`
+
+const wktWGS84 = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],` +
+	`PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+
+func TestLinearUnit(t *testing.T) {
+	name, meters, ok := LinearUnit(wktStatePlaneFeet)
+	if !ok || name != "Foot_US" || meters != 0.3048006096012192 {
+		t.Errorf("got %q, %v, %v", name, meters, ok)
+	}
+	if _, _, ok := LinearUnit(wktWGS84); ok {
+		t.Error("got linear unit for geographic coordinate system")
+	}
+}
@@ -0,0 +1,201 @@
+// Package proj converts coordinates between geodetic datums with Helmert
+// transformations, without depending on the PROJ library. A DatumShift
+// implements shp.Transformer, so shapefiles in longitude and latitude can be
+// moved to another datum while they are read or written:
+//
+//	shift := proj.NewDatumShift(proj.NAD27, proj.WGS84)
+//	r, err := shp.Open("parcels.shp", shp.WithTransformer(shift))
+//
+// The parameters of the common datums are averages for their whole area of
+// use, so results are accurate to a few meters, not to the centimeters that
+// national grid based transformations achieve.
+package proj
+
+import (
+	"math"
+	"strings"
+)
+
+// Ellipsoid is a reference ellipsoid given by its semi-major axis in meters
+// and its inverse flattening.
+type Ellipsoid struct {
+	A, InvF float64
+}
+
+// Common ellipsoids.
+var (
+	WGS84Ellipsoid = Ellipsoid{6378137.0, 298.257223563}
+	GRS80          = Ellipsoid{6378137.0, 298.257222101}
+	Clarke1866     = Ellipsoid{6378206.4, 294.9786982}
+	Airy1830       = Ellipsoid{6377563.396, 299.3249646}
+	Bessel1841     = Ellipsoid{6377397.155, 299.1528128}
+)
+
+// e2 returns the square of the first eccentricity.
+func (e Ellipsoid) e2() float64 {
+	f := 1 / e.InvF
+	return f * (2 - f)
+}
+
+// ToGeocentric converts longitude and latitude in degrees and the height
+// above the ellipsoid in meters to Earth-centered, Earth-fixed coordinates in
+// meters.
+func (e Ellipsoid) ToGeocentric(lon, lat, h float64) (x, y, z float64) {
+	lam, phi := lon*math.Pi/180, lat*math.Pi/180
+	sinPhi, cosPhi := math.Sincos(phi)
+	e2 := e.e2()
+	n := e.A / math.Sqrt(1-e2*sinPhi*sinPhi)
+	x = (n + h) * cosPhi * math.Cos(lam)
+	y = (n + h) * cosPhi * math.Sin(lam)
+	z = (n*(1-e2) + h) * sinPhi
+	return x, y, z
+}
+
+// FromGeocentric converts Earth-centered, Earth-fixed coordinates in meters
+// to longitude and latitude in degrees and the height above the ellipsoid in
+// meters.
+func (e Ellipsoid) FromGeocentric(x, y, z float64) (lon, lat, h float64) {
+	e2 := e.e2()
+	p := math.Hypot(x, y)
+	lam := math.Atan2(y, x)
+	phi := math.Atan2(z, p*(1-e2))
+	var n float64
+	for i := 0; i < 10; i++ {
+		sinPhi := math.Sin(phi)
+		n = e.A / math.Sqrt(1-e2*sinPhi*sinPhi)
+		next := math.Atan2(z+e2*n*sinPhi, p)
+		if math.Abs(next-phi) < 1e-12 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+	sinPhi, cosPhi := math.Sincos(phi)
+	n = e.A / math.Sqrt(1-e2*sinPhi*sinPhi)
+	if math.Abs(cosPhi) > 1e-10 {
+		h = p/cosPhi - n
+	} else {
+		h = math.Abs(z) - n*(1-e2)
+	}
+	return lam * 180 / math.Pi, phi * 180 / math.Pi, h
+}
+
+// Helmert is a 7-parameter similarity transformation of geocentric
+// coordinates in the position vector convention (EPSG method 9606). Leave
+// the rotations and the scale zero for a 3-parameter transformation. For
+// parameters published in the coordinate frame convention (EPSG method 9607),
+// negate the rotations.
+type Helmert struct {
+	Tx, Ty, Tz float64 // translations in meters
+	Rx, Ry, Rz float64 // rotations in arc seconds
+	S          float64 // scale difference in parts per million
+}
+
+// Apply transforms the geocentric coordinates x, y, z.
+func (t Helmert) Apply(x, y, z float64) (float64, float64, float64) {
+	const arcsec = math.Pi / (180 * 3600)
+	rx, ry, rz := t.Rx*arcsec, t.Ry*arcsec, t.Rz*arcsec
+	s := 1 + t.S*1e-6
+	return t.Tx + s*(x-rz*y+ry*z),
+		t.Ty + s*(rz*x+y-rx*z),
+		t.Tz + s*(-ry*x+rx*y+z)
+}
+
+// Inverse returns the transformation in the opposite direction. It negates
+// the parameters, which is accurate to a few centimeters for the small
+// rotations and scale differences between datums, like published reverse
+// transformations.
+func (t Helmert) Inverse() Helmert {
+	return Helmert{-t.Tx, -t.Ty, -t.Tz, -t.Rx, -t.Ry, -t.Rz, -t.S}
+}
+
+// Datum is a geodetic datum: an ellipsoid and its position relative to WGS 84.
+type Datum struct {
+	Name      string
+	Ellipsoid Ellipsoid
+	ToWGS84   Helmert
+}
+
+// Common datums. The transformations to WGS 84 are the ones of the EPSG
+// registry for the whole area of use of each datum. NAD83 and ETRS89 are
+// taken to be equal to WGS 84, which is accurate to about a meter.
+var (
+	WGS84  = Datum{"WGS84", WGS84Ellipsoid, Helmert{}}
+	NAD83  = Datum{"NAD83", GRS80, Helmert{}}
+	ETRS89 = Datum{"ETRS89", GRS80, Helmert{}}
+	// EPSG:1173, for the contiguous United States
+	NAD27 = Datum{"NAD27", Clarke1866, Helmert{Tx: -8, Ty: 160, Tz: 176}}
+	// EPSG:1314
+	OSGB36 = Datum{"OSGB36", Airy1830, Helmert{446.448, -125.157, 542.06, 0.1502, 0.247, 0.8421, -20.4894}}
+	// EPSG:1777, whose coordinate frame rotations are negated for Apply
+	DHDN = Datum{"DHDN", Bessel1841, Helmert{598.1, 73.7, 418.2, 0.202, 0.045, -2.455, 6.7}}
+	// EPSG:15739, whose rotations are negated likewise
+	Amersfoort = Datum{"Amersfoort", Bessel1841, Helmert{565.2369, 50.0087, 465.658, -0.406857, 0.350733, -1.87035, 4.0812}}
+)
+
+// datums maps the normalized names of the common datums, including the
+// names used in .prj files, to the datums.
+var datums = map[string]Datum{}
+
+func init() {
+	for _, d := range []struct {
+		datum Datum
+		names []string
+	}{
+		{WGS84, []string{"WGS_1984", "World Geodetic System 1984"}},
+		{NAD83, []string{"North_American_1983", "North American Datum 1983"}},
+		{NAD27, []string{"North_American_1927", "North American Datum 1927"}},
+		{ETRS89, []string{"ETRS_1989", "European_Terrestrial_Reference_System_1989"}},
+		{OSGB36, []string{"OSGB_1936", "OSGB 1936"}},
+		{DHDN, []string{"Deutsches_Hauptdreiecksnetz"}},
+		{Amersfoort, nil},
+	} {
+		datums[normalize(d.datum.Name)] = d.datum
+		for _, name := range d.names {
+			datums[normalize(name)] = d.datum
+		}
+	}
+}
+
+// normalize lowercases name and drops the "D_" prefix of .prj files and
+// everything but letters and digits.
+func normalize(name string) string {
+	name = strings.TrimPrefix(name, "D_")
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// LookupDatum returns the common datum with the name, e.g. "NAD27" or the
+// "D_North_American_1927" of a .prj file.
+func LookupDatum(name string) (Datum, bool) {
+	d, ok := datums[normalize(name)]
+	return d, ok
+}
+
+// DatumShift converts longitude, latitude and ellipsoidal height from one
+// datum to another through WGS 84.
+type DatumShift struct {
+	from, to Datum
+	helmert  Helmert // from -> WGS 84
+	inverse  Helmert // WGS 84 -> to
+}
+
+// NewDatumShift returns the conversion from datum from to datum to.
+func NewDatumShift(from, to Datum) *DatumShift {
+	return &DatumShift{from: from, to: to, helmert: from.ToWGS84, inverse: to.ToWGS84.Inverse()}
+}
+
+// Transform converts longitude x and latitude y in degrees and height z in
+// meters. M is returned unchanged. It implements shp.Transformer.
+func (s *DatumShift) Transform(x, y, z, m float64) (float64, float64, float64, float64) {
+	gx, gy, gz := s.from.Ellipsoid.ToGeocentric(x, y, z)
+	gx, gy, gz = s.helmert.Apply(gx, gy, gz)
+	gx, gy, gz = s.inverse.Apply(gx, gy, gz)
+	x, y, z = s.to.Ellipsoid.FromGeocentric(gx, gy, gz)
+	return x, y, z, m
+}
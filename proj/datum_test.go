@@ -0,0 +1,84 @@
+package proj
+
+import (
+	"math"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+var _ shp.Transformer = (*DatumShift)(nil)
+
+func TestGeocentricRoundTrip(t *testing.T) {
+	for _, c := range [][3]float64{{0, 0, 0}, {13.4, 52.5, 34}, {-122.4, 37.8, -20}, {170, -45, 1000}, {10, 89.9, 0}} {
+		x, y, z := GRS80.ToGeocentric(c[0], c[1], c[2])
+		lon, lat, h := GRS80.FromGeocentric(x, y, z)
+		if math.Abs(lon-c[0]) > 1e-9 || math.Abs(lat-c[1]) > 1e-9 || math.Abs(h-c[2]) > 1e-4 {
+			t.Errorf("got %v, %v, %v, want %v", lon, lat, h, c)
+		}
+	}
+}
+
+func TestHelmertInverse(t *testing.T) {
+	x, y, z := Airy1830.ToGeocentric(-1.5, 53, 0)
+	gx, gy, gz := OSGB36.ToWGS84.Apply(x, y, z)
+	rx, ry, rz := OSGB36.ToWGS84.Inverse().Apply(gx, gy, gz)
+	if d := math.Sqrt((rx-x)*(rx-x) + (ry-y)*(ry-y) + (rz-z)*(rz-z)); d > 0.05 {
+		t.Errorf("round trip is off by %v m", d)
+	}
+}
+
+func TestDatumShift(t *testing.T) {
+	// the Caister water tower example of the Ordnance Survey
+	const (
+		lonOSGB36, latOSGB36 = 1.0 + 43.0/60 + 4.5177/3600, 52.0 + 39.0/60 + 27.2531/3600
+		lonETRS89, latETRS89 = 1.0 + 42.0/60 + 57.8663/3600, 52.0 + 39.0/60 + 28.8282/3600
+	)
+	lon, lat, _, m := NewDatumShift(OSGB36, WGS84).Transform(lonOSGB36, latOSGB36, 0, 7)
+	// the Helmert transformation of OSGB36 is accurate to a few meters
+	if math.Abs(lon-lonETRS89) > 1e-4 || math.Abs(lat-latETRS89) > 1e-4 || m != 7 {
+		t.Errorf("got %v, %v, %v, want %v, %v", lon, lat, m, lonETRS89, latETRS89)
+	}
+
+	lon, lat, _, _ = NewDatumShift(WGS84, OSGB36).Transform(lonETRS89, latETRS89, 0, 0)
+	if math.Abs(lon-lonOSGB36) > 1e-4 || math.Abs(lat-latOSGB36) > 1e-4 {
+		t.Errorf("got %v, %v, want %v, %v", lon, lat, lonOSGB36, latOSGB36)
+	}
+}
+
+func TestDatumShiftReferencePoints(t *testing.T) {
+	// the origin of the Dutch RD grid, whose WGS 84 position is published by
+	// the Kadaster
+	lon, lat, _, _ := NewDatumShift(Amersfoort, WGS84).Transform(5.0+23.0/60+15.5/3600, 52.0+9.0/60+22.178/3600, 0, 0)
+	if math.Abs(lon-5.387206) > 2e-5 || math.Abs(lat-52.155174) > 2e-5 {
+		t.Errorf("Amersfoort: got %v, %v, want 5.387206, 52.155174", lon, lat)
+	}
+
+	// EPSG publishes the rotations of DHDN in the coordinate frame
+	// convention, the transpose of the one of Helmert.Apply
+	const arcsec = math.Pi / (180 * 3600)
+	rx, ry, rz := -0.202*arcsec, -0.045*arcsec, 2.455*arcsec
+	s := 1 + 6.7e-6
+	for _, p := range [][2]float64{{13.0 + 22.0/60 + 4.928/3600, 52.0 + 27.0/60 + 12.021/3600}, {7, 50}, {10, 54}} {
+		x, y, z := Bessel1841.ToGeocentric(p[0], p[1], 0)
+		wantLon, wantLat, _ := WGS84Ellipsoid.FromGeocentric(
+			598.1+s*(x+rz*y-ry*z),
+			73.7+s*(-rz*x+y+rx*z),
+			418.2+s*(ry*x-rx*y+z))
+		lon, lat, _, _ := NewDatumShift(DHDN, WGS84).Transform(p[0], p[1], 0, 0)
+		if math.Abs(lon-wantLon) > 1e-7 || math.Abs(lat-wantLat) > 1e-7 {
+			t.Errorf("DHDN %v: got %v, %v, want %v, %v", p, lon, lat, wantLon, wantLat)
+		}
+	}
+}
+
+func TestLookupDatum(t *testing.T) {
+	for _, name := range []string{"NAD27", "D_North_American_1927", "north american datum 1927"} {
+		if d, ok := LookupDatum(name); !ok || d.Name != "NAD27" {
+			t.Errorf("got %v, %v for %q", d, ok, name)
+		}
+	}
+	if _, ok := LookupDatum("D_Unknown"); ok {
+		t.Error("found unknown datum")
+	}
+}
@@ -0,0 +1,188 @@
+package proto
+
+import (
+	"fmt"
+	"strings"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// FromBox converts b to a Box message.
+func FromBox(b shp.Box) *Box {
+	return &Box{b.MinX, b.MinY, b.MaxX, b.MaxY}
+}
+
+// ToBox converts b to a shp.Box. A nil Box yields the zero shp.Box.
+func (b *Box) ToBox() shp.Box {
+	if b == nil {
+		return shp.Box{}
+	}
+	return shp.Box{MinX: b.MinX, MinY: b.MinY, MaxX: b.MaxX, MaxY: b.MaxY}
+}
+
+func fromPoints(ps []shp.Point) []Point {
+	out := make([]Point, len(ps))
+	for i, p := range ps {
+		out[i] = Point{p.X, p.Y}
+	}
+	return out
+}
+
+func toPoints(ps []Point) []shp.Point {
+	out := make([]shp.Point, len(ps))
+	for i, p := range ps {
+		out[i] = shp.Point{X: p.X, Y: p.Y}
+	}
+	return out
+}
+
+// fit returns a copy of vs with exactly n elements.
+func fit(vs []float64, n int) []float64 {
+	out := make([]float64, n)
+	copy(out, vs)
+	return out
+}
+
+func toRange(vs []float64) (r [2]float64) {
+	copy(r[:], vs)
+	return r
+}
+
+// FromShape converts s to a Shape message.
+func FromShape(s shp.Shape) *Shape {
+	switch p := s.(type) {
+	case *shp.Point:
+		return &Shape{Type: shp.POINT, Points: []Point{{p.X, p.Y}}}
+	case *shp.PointZ:
+		return &Shape{Type: shp.POINTZ, Points: []Point{{p.X, p.Y}}, Z: []float64{p.Z}, M: []float64{p.M}}
+	case *shp.PointM:
+		return &Shape{Type: shp.POINTM, Points: []Point{{p.X, p.Y}}, M: []float64{p.M}}
+	case *shp.PolyLine:
+		return &Shape{Type: shp.POLYLINE, Box: FromBox(p.Box), Parts: p.Parts, Points: fromPoints(p.Points)}
+	case *shp.Polygon:
+		return &Shape{Type: shp.POLYGON, Box: FromBox(p.Box), Parts: p.Parts, Points: fromPoints(p.Points)}
+	case *shp.MultiPoint:
+		return &Shape{Type: shp.MULTIPOINT, Box: FromBox(p.Box), Points: fromPoints(p.Points)}
+	case *shp.PolyLineZ:
+		return &Shape{Type: shp.POLYLINEZ, Box: FromBox(p.Box), Parts: p.Parts, Points: fromPoints(p.Points),
+			Z: p.ZArray, M: p.MArray, ZRange: p.ZRange[:], MRange: p.MRange[:]}
+	case *shp.PolygonZ:
+		return &Shape{Type: shp.POLYGONZ, Box: FromBox(p.Box), Parts: p.Parts, Points: fromPoints(p.Points),
+			Z: p.ZArray, M: p.MArray, ZRange: p.ZRange[:], MRange: p.MRange[:]}
+	case *shp.MultiPointZ:
+		return &Shape{Type: shp.MULTIPOINTZ, Box: FromBox(p.Box), Points: fromPoints(p.Points),
+			Z: p.ZArray, M: p.MArray, ZRange: p.ZRange[:], MRange: p.MRange[:]}
+	case *shp.PolyLineM:
+		return &Shape{Type: shp.POLYLINEM, Box: FromBox(p.Box), Parts: p.Parts, Points: fromPoints(p.Points),
+			M: p.MArray, MRange: p.MRange[:]}
+	case *shp.PolygonM:
+		return &Shape{Type: shp.POLYGONM, Box: FromBox(p.Box), Parts: p.Parts, Points: fromPoints(p.Points),
+			M: p.MArray, MRange: p.MRange[:]}
+	case *shp.MultiPointM:
+		return &Shape{Type: shp.MULTIPOINTM, Box: FromBox(p.Box), Points: fromPoints(p.Points),
+			M: p.MArray, MRange: p.MRange[:]}
+	case *shp.MultiPatch:
+		return &Shape{Type: shp.MULTIPATCH, Box: FromBox(p.Box), Parts: p.Parts, PartTypes: p.PartTypes,
+			Points: fromPoints(p.Points), Z: p.ZArray, M: p.MArray, ZRange: p.ZRange[:], MRange: p.MRange[:]}
+	default:
+		return &Shape{Type: shp.NULL}
+	}
+}
+
+// ToShape converts s to the shp.Shape of its type. The counts of parts and
+// points are taken from the lengths of the arrays, missing z and m values are
+// zero.
+func (s *Shape) ToShape() (shp.Shape, error) {
+	pts := toPoints(s.Points)
+	n := len(pts)
+	if n == 0 {
+		switch s.Type {
+		case shp.POINT, shp.POINTZ, shp.POINTM:
+			return nil, fmt.Errorf("Shape of type %v has no point", s.Type)
+		}
+	}
+	box := s.Box.ToBox()
+	if s.Box == nil {
+		box = shp.BBoxFromPoints(pts)
+	}
+	numParts, numPoints := int32(len(s.Parts)), int32(n)
+	z, m := fit(s.Z, n), fit(s.M, n)
+	switch s.Type {
+	case shp.NULL:
+		return &shp.Null{}, nil
+	case shp.POINT:
+		return &pts[0], nil
+	case shp.POINTZ:
+		return &shp.PointZ{X: pts[0].X, Y: pts[0].Y, Z: z[0], M: m[0]}, nil
+	case shp.POINTM:
+		return &shp.PointM{X: pts[0].X, Y: pts[0].Y, M: m[0]}, nil
+	case shp.POLYLINE:
+		return &shp.PolyLine{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, Points: pts}, nil
+	case shp.POLYGON:
+		return &shp.Polygon{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, Points: pts}, nil
+	case shp.MULTIPOINT:
+		return &shp.MultiPoint{Box: box, NumPoints: numPoints, Points: pts}, nil
+	case shp.POLYLINEZ:
+		return &shp.PolyLineZ{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, Points: pts,
+			ZRange: toRange(s.ZRange), ZArray: z, MRange: toRange(s.MRange), MArray: m}, nil
+	case shp.POLYGONZ:
+		return &shp.PolygonZ{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, Points: pts,
+			ZRange: toRange(s.ZRange), ZArray: z, MRange: toRange(s.MRange), MArray: m}, nil
+	case shp.MULTIPOINTZ:
+		return &shp.MultiPointZ{Box: box, NumPoints: numPoints, Points: pts,
+			ZRange: toRange(s.ZRange), ZArray: z, MRange: toRange(s.MRange), MArray: m}, nil
+	case shp.POLYLINEM:
+		return &shp.PolyLineM{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, Points: pts,
+			MRange: toRange(s.MRange), MArray: m}, nil
+	case shp.POLYGONM:
+		return &shp.PolygonM{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, Points: pts,
+			MRange: toRange(s.MRange), MArray: m}, nil
+	case shp.MULTIPOINTM:
+		return &shp.MultiPointM{Box: box, NumPoints: numPoints, Points: pts, MRange: toRange(s.MRange), MArray: m}, nil
+	case shp.MULTIPATCH:
+		partTypes := make([]int32, len(s.Parts))
+		copy(partTypes, s.PartTypes)
+		return &shp.MultiPatch{Box: box, NumParts: numParts, NumPoints: numPoints, Parts: s.Parts, PartTypes: partTypes,
+			Points: pts, ZRange: toRange(s.ZRange), ZArray: z, MRange: toRange(s.MRange), MArray: m}, nil
+	default:
+		return nil, fmt.Errorf("Unsupported shape type: %v", s.Type)
+	}
+}
+
+// FromField converts f to a Field message.
+func FromField(f shp.Field) Field {
+	return Field{Name: f.String(), Type: string(f.Fieldtype), Size: uint32(f.Size), Precision: uint32(f.Precision)}
+}
+
+// ToField converts f to a shp.Field.
+func (f Field) ToField() shp.Field {
+	out := shp.Field{Size: uint8(f.Size), Precision: uint8(f.Precision)}
+	if f.Type != "" {
+		out.Fieldtype = f.Type[0]
+	}
+	copy(out.Name[:], f.Name)
+	return out
+}
+
+// NewHeader returns the Header of a layer.
+func NewHeader(t shp.ShapeType, box shp.Box, fields []shp.Field) *Header {
+	h := &Header{Type: t, Box: FromBox(box), Fields: make([]Field, len(fields))}
+	for i, f := range fields {
+		h.Fields[i] = FromField(f)
+	}
+	return h
+}
+
+// FromReader returns the Record that sr was last advanced to. Trailing
+// padding is removed from the attributes.
+func FromReader(sr shp.SequentialReader) *Record {
+	n, s := sr.Shape()
+	r := &Record{Number: int32(n), Attributes: shp.Attributes(sr)}
+	if s != nil {
+		r.Shape = FromShape(s)
+	}
+	for i, a := range r.Attributes {
+		r.Attributes[i] = strings.TrimRight(a, " \x00")
+	}
+	return r
+}
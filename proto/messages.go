@@ -0,0 +1,280 @@
+// Package proto encodes and decodes the messages defined in shp.proto, the
+// canonical wire format for shapefile content, and converts them from and to
+// the types of package shp.
+//
+// It is a hand-written codec, not the output of protoc, and does not depend on
+// the protobuf runtime. Its types do not implement proto.Message, so they
+// cannot be used with gRPC or the protobuf reflection API directly. The
+// messages are encoded with the standard protocol buffer encoding, however,
+// so they are wire-compatible with code generated from shp.proto: a message
+// marshaled by one can be unmarshaled by the other.
+package proto
+
+import (
+	"fmt"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// Box is the message Box.
+type Box struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// Point is the message Point.
+type Point struct {
+	X, Y float64
+}
+
+// Shape is the message Shape.
+type Shape struct {
+	Type      shp.ShapeType
+	Box       *Box
+	Parts     []int32
+	PartTypes []int32
+	Points    []Point
+	Z         []float64
+	M         []float64
+	ZRange    []float64
+	MRange    []float64
+}
+
+// Field is the message Field.
+type Field struct {
+	Name      string
+	Type      string
+	Size      uint32
+	Precision uint32
+}
+
+// Header is the message Header.
+type Header struct {
+	Type   shp.ShapeType
+	Box    *Box
+	Fields []Field
+}
+
+// Record is the message Record.
+type Record struct {
+	Number     int32
+	Shape      *Shape
+	Attributes []string
+}
+
+// Marshal returns the encoding of b.
+func (b *Box) Marshal() []byte {
+	var out []byte
+	out = appendDouble(out, 1, b.MinX)
+	out = appendDouble(out, 2, b.MinY)
+	out = appendDouble(out, 3, b.MaxX)
+	return appendDouble(out, 4, b.MaxY)
+}
+
+// Unmarshal decodes data into b.
+func (b *Box) Unmarshal(data []byte) error {
+	*b = Box{}
+	d := decoder{data}
+	for {
+		f, ok, err := d.next()
+		if !ok {
+			return err
+		}
+		switch f.num {
+		case 1:
+			b.MinX = f.double()
+		case 2:
+			b.MinY = f.double()
+		case 3:
+			b.MaxX = f.double()
+		case 4:
+			b.MaxY = f.double()
+		}
+	}
+}
+
+// Marshal returns the encoding of p.
+func (p *Point) Marshal() []byte {
+	return appendDouble(appendDouble(nil, 1, p.X), 2, p.Y)
+}
+
+// Unmarshal decodes data into p.
+func (p *Point) Unmarshal(data []byte) error {
+	*p = Point{}
+	d := decoder{data}
+	for {
+		f, ok, err := d.next()
+		if !ok {
+			return err
+		}
+		switch f.num {
+		case 1:
+			p.X = f.double()
+		case 2:
+			p.Y = f.double()
+		}
+	}
+}
+
+// Marshal returns the encoding of s.
+func (s *Shape) Marshal() []byte {
+	var out []byte
+	out = appendVarint(out, 1, uint64(int64(s.Type)))
+	if s.Box != nil {
+		out = appendBytes(out, 2, s.Box.Marshal())
+	}
+	out = appendPackedInt32s(out, 3, s.Parts)
+	out = appendPackedInt32s(out, 4, s.PartTypes)
+	for i := range s.Points {
+		out = appendBytes(out, 5, s.Points[i].Marshal())
+	}
+	out = appendPackedDoubles(out, 6, s.Z)
+	out = appendPackedDoubles(out, 7, s.M)
+	out = appendPackedDoubles(out, 8, s.ZRange)
+	return appendPackedDoubles(out, 9, s.MRange)
+}
+
+// Unmarshal decodes data into s.
+func (s *Shape) Unmarshal(data []byte) error {
+	*s = Shape{}
+	d := decoder{data}
+	for {
+		f, ok, err := d.next()
+		if !ok {
+			return err
+		}
+		switch f.num {
+		case 1:
+			s.Type = shp.ShapeType(f.v)
+		case 2:
+			s.Box = new(Box)
+			err = s.Box.Unmarshal(f.data)
+		case 3:
+			s.Parts, err = f.int32s(s.Parts)
+		case 4:
+			s.PartTypes, err = f.int32s(s.PartTypes)
+		case 5:
+			var p Point
+			err = p.Unmarshal(f.data)
+			s.Points = append(s.Points, p)
+		case 6:
+			s.Z, err = f.doubles(s.Z)
+		case 7:
+			s.M, err = f.doubles(s.M)
+		case 8:
+			s.ZRange, err = f.doubles(s.ZRange)
+		case 9:
+			s.MRange, err = f.doubles(s.MRange)
+		}
+		if err != nil {
+			return fmt.Errorf("Error decoding Shape: %v", err)
+		}
+	}
+}
+
+// Marshal returns the encoding of f.
+func (f *Field) Marshal() []byte {
+	var out []byte
+	out = appendString(out, 1, f.Name)
+	out = appendString(out, 2, f.Type)
+	out = appendVarint(out, 3, uint64(f.Size))
+	return appendVarint(out, 4, uint64(f.Precision))
+}
+
+// Unmarshal decodes data into f.
+func (f *Field) Unmarshal(data []byte) error {
+	*f = Field{}
+	d := decoder{data}
+	for {
+		fd, ok, err := d.next()
+		if !ok {
+			return err
+		}
+		switch fd.num {
+		case 1:
+			f.Name = string(fd.data)
+		case 2:
+			f.Type = string(fd.data)
+		case 3:
+			f.Size = uint32(fd.v)
+		case 4:
+			f.Precision = uint32(fd.v)
+		}
+	}
+}
+
+// Marshal returns the encoding of h.
+func (h *Header) Marshal() []byte {
+	var out []byte
+	out = appendVarint(out, 1, uint64(int64(h.Type)))
+	if h.Box != nil {
+		out = appendBytes(out, 2, h.Box.Marshal())
+	}
+	for i := range h.Fields {
+		out = appendBytes(out, 3, h.Fields[i].Marshal())
+	}
+	return out
+}
+
+// Unmarshal decodes data into h.
+func (h *Header) Unmarshal(data []byte) error {
+	*h = Header{}
+	d := decoder{data}
+	for {
+		f, ok, err := d.next()
+		if !ok {
+			return err
+		}
+		switch f.num {
+		case 1:
+			h.Type = shp.ShapeType(f.v)
+		case 2:
+			h.Box = new(Box)
+			err = h.Box.Unmarshal(f.data)
+		case 3:
+			var fd Field
+			err = fd.Unmarshal(f.data)
+			h.Fields = append(h.Fields, fd)
+		}
+		if err != nil {
+			return fmt.Errorf("Error decoding Header: %v", err)
+		}
+	}
+}
+
+// Marshal returns the encoding of r.
+func (r *Record) Marshal() []byte {
+	var out []byte
+	out = appendVarint(out, 1, uint64(int64(r.Number)))
+	if r.Shape != nil {
+		out = appendBytes(out, 2, r.Shape.Marshal())
+	}
+	for _, a := range r.Attributes {
+		// repeated strings keep empty elements
+		out = appendBytes(out, 3, []byte(a))
+	}
+	return out
+}
+
+// Unmarshal decodes data into r.
+func (r *Record) Unmarshal(data []byte) error {
+	*r = Record{}
+	d := decoder{data}
+	for {
+		f, ok, err := d.next()
+		if !ok {
+			return err
+		}
+		switch f.num {
+		case 1:
+			r.Number = int32(f.v)
+		case 2:
+			r.Shape = new(Shape)
+			err = r.Shape.Unmarshal(f.data)
+		case 3:
+			r.Attributes = append(r.Attributes, string(f.data))
+		}
+		if err != nil {
+			return fmt.Errorf("Error decoding Record: %v", err)
+		}
+	}
+}
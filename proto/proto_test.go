@@ -0,0 +1,100 @@
+package proto
+
+import (
+	"bytes"
+	"encoding"
+	"os"
+	"reflect"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func openFile(name string, t *testing.T) *os.File {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	return f
+}
+
+// equalShapes compares shapes by their binary form, so that NaN measures are
+// equal.
+func equalShapes(a, b shp.Shape) bool {
+	ab, _ := a.(encoding.BinaryMarshaler).MarshalBinary()
+	bb, _ := b.(encoding.BinaryMarshaler).MarshalBinary()
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && bytes.Equal(ab, bb)
+}
+
+func TestShapeWireFormat(t *testing.T) {
+	s := FromShape(&shp.Point{X: 1, Y: 2})
+	want := []byte{
+		0x08, 0x01, // type: POINT
+		0x2a, 0x12, // points, 18 bytes
+		0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, // x: 1
+		0x11, 0, 0, 0, 0, 0, 0, 0, 0x40, // y: 2
+	}
+	if got := s.Marshal(); !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+func TestShapeRoundTrip(t *testing.T) {
+	for _, name := range []string{
+		"point", "polyline", "polygon", "multipoint", "pointz", "polylinez",
+		"polygonz", "multipointz", "pointm", "polylinem", "polygonm",
+		"multipointm", "multipatch",
+	} {
+		r, err := shp.Open("../test_files/" + name + ".shp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r.Next() {
+			_, want := r.Shape()
+			var m Shape
+			if err := m.Unmarshal(FromShape(want).Marshal()); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			got, err := m.ToShape()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !equalShapes(got, want) {
+				t.Errorf("%s: got %+v, want %+v", name, got, want)
+			}
+		}
+		r.Close()
+	}
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	f, err := shp.Open("../test_files/point.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	h := NewHeader(f.GeometryType, f.BBox(), f.Fields())
+	var gotHeader Header
+	if err := gotHeader.Unmarshal(h.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&gotHeader, h) {
+		t.Errorf("got header %+v, want %+v", gotHeader, h)
+	}
+	if got := gotHeader.Fields[0].ToField(); got != f.Fields()[0] {
+		t.Errorf("got field %+v, want %+v", got, f.Fields()[0])
+	}
+
+	sr := shp.SequentialReaderFromExt(openFile("../test_files/point.shp", t), openFile("../test_files/point.dbf", t))
+	defer sr.Close()
+	for sr.Next() {
+		want := FromReader(sr)
+		var got Record
+		if err := got.Unmarshal(want.Marshal()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("got record %+v, want %+v", got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package proto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Wire types of the protocol buffer encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated message")
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendTag(b []byte, num, wt int) []byte {
+	return appendUvarint(b, uint64(num)<<3|uint64(wt))
+}
+
+func appendVarint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendUvarint(appendTag(b, num, wireVarint), v)
+}
+
+func appendDouble(b []byte, num int, v float64) []byte {
+	if math.Float64bits(v) == 0 {
+		return b
+	}
+	return appendUint64(appendTag(b, num, wireFixed64), math.Float64bits(v))
+}
+
+func appendBytes(b []byte, num int, v []byte) []byte {
+	b = appendUvarint(appendTag(b, num, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendString(b []byte, num int, v string) []byte {
+	if v == "" {
+		return b
+	}
+	return appendBytes(b, num, []byte(v))
+}
+
+func appendPackedDoubles(b []byte, num int, vs []float64) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+	p := make([]byte, 0, 8*len(vs))
+	for _, v := range vs {
+		p = appendUint64(p, math.Float64bits(v))
+	}
+	return appendBytes(b, num, p)
+}
+
+func appendPackedInt32s(b []byte, num int, vs []int32) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+	var p []byte
+	for _, v := range vs {
+		p = appendUvarint(p, uint64(int64(v)))
+	}
+	return appendBytes(b, num, p)
+}
+
+// field is a decoded field of a message. v holds varint and fixed values,
+// data the content of length-delimited fields.
+type field struct {
+	num, wt int
+	v       uint64
+	data    []byte
+}
+
+// decoder iterates over the fields of a message.
+type decoder struct {
+	b []byte
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.b = d.b[n:]
+	return v, nil
+}
+
+func (d *decoder) fixed(n int) (uint64, error) {
+	if len(d.b) < n {
+		return 0, errTruncated
+	}
+	var v uint64
+	if n == 8 {
+		v = binary.LittleEndian.Uint64(d.b)
+	} else {
+		v = uint64(binary.LittleEndian.Uint32(d.b))
+	}
+	d.b = d.b[n:]
+	return v, nil
+}
+
+// next returns the next field. It returns false at the end of the message.
+func (d *decoder) next() (f field, ok bool, err error) {
+	if len(d.b) == 0 {
+		return f, false, nil
+	}
+	tag, err := d.uvarint()
+	if err != nil {
+		return f, false, err
+	}
+	f.num, f.wt = int(tag>>3), int(tag&7)
+	switch f.wt {
+	case wireVarint:
+		f.v, err = d.uvarint()
+	case wireFixed64:
+		f.v, err = d.fixed(8)
+	case wireFixed32:
+		f.v, err = d.fixed(4)
+	case wireBytes:
+		var n uint64
+		n, err = d.uvarint()
+		if err == nil && n > uint64(len(d.b)) {
+			err = errTruncated
+		}
+		if err == nil {
+			f.data, d.b = d.b[:n], d.b[n:]
+		}
+	default:
+		err = fmt.Errorf("unsupported wire type %d", f.wt)
+	}
+	return f, err == nil, err
+}
+
+// doubles appends the values of a repeated double field, which may be packed
+// or not.
+func (f field) doubles(vs []float64) ([]float64, error) {
+	if f.wt == wireFixed64 {
+		return append(vs, math.Float64frombits(f.v)), nil
+	}
+	if f.wt != wireBytes || len(f.data)%8 != 0 {
+		return vs, fmt.Errorf("invalid double field %d", f.num)
+	}
+	for i := 0; i < len(f.data); i += 8 {
+		vs = append(vs, math.Float64frombits(binary.LittleEndian.Uint64(f.data[i:])))
+	}
+	return vs, nil
+}
+
+// int32s appends the values of a repeated int32 field, which may be packed or
+// not.
+func (f field) int32s(vs []int32) ([]int32, error) {
+	if f.wt == wireVarint {
+		return append(vs, int32(f.v)), nil
+	}
+	if f.wt != wireBytes {
+		return vs, fmt.Errorf("invalid int32 field %d", f.num)
+	}
+	d := decoder{f.data}
+	for len(d.b) > 0 {
+		v, err := d.uvarint()
+		if err != nil {
+			return vs, err
+		}
+		vs = append(vs, int32(v))
+	}
+	return vs, nil
+}
+
+func (f field) double() float64 {
+	return math.Float64frombits(f.v)
+}
@@ -0,0 +1,160 @@
+package shp
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultReadAhead is the read-ahead of OpenRangeSequential if no ReadAhead
+// option is given.
+const DefaultReadAhead = 4 << 20
+
+// readAheadChunk is the size of the requests made by read-ahead.
+const readAheadChunk = 1 << 20
+
+// ReadAhead sets how many bytes of each file OpenRangeSequential requests
+// ahead of the reader. The bytes are fetched in requests of up to 1 MB that
+// run concurrently, so that reading remote files is limited by bandwidth
+// rather than by the latency of each request.
+func ReadAhead(bytes int) ReaderOption {
+	return func(o *readerOptions) {
+		o.readAhead = bytes
+	}
+}
+
+// OpenRangeSequential opens the shapefile name, with or without the ".shp"
+// extension, for sequential reading from files that are read with random
+// access, such as objects in cloud storage (see OpenRange). The SHP and DBF
+// files are read ahead of the records that are decoded, see ReadAhead.
+func OpenRangeSequential(open RangeOpener, name string, opts ...ReaderOption) (SequentialReader, error) {
+	o := newReaderOptions(opts)
+	ahead := o.readAhead
+	if ahead <= 0 {
+		ahead = DefaultReadAhead
+	}
+	base := trimSidecarExt(name)
+	var shp, dbf io.ReadCloser
+	if !o.skipGeometry {
+		rr, err := open(base + ".shp")
+		if err != nil {
+			return nil, err
+		}
+		if shp, err = o.verified(newReadAhead(rr, ahead), base+".shp", rr); err != nil {
+			return nil, err
+		}
+	}
+	if !o.skipAttributes {
+		rr, err := open(base + ".dbf")
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			if shp != nil {
+				shp.Close()
+			}
+			return nil, err
+		default:
+			if dbf, err = o.verified(newReadAhead(rr, ahead), base+".dbf", rr); err != nil {
+				if shp != nil {
+					shp.Close()
+				}
+				return nil, err
+			}
+		}
+	}
+	return SequentialReaderFromExt(shp, dbf, opts...), nil
+}
+
+// verified wraps rc, the content of rr, in a verifiedReader if it has an
+// expected digest.
+func (o readerOptions) verified(rc io.ReadCloser, name string, rr RangeReader) (io.ReadCloser, error) {
+	expected, err := o.expectedMD5(strings.ToLower(filepath.Ext(name)), rr)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	if expected == nil {
+		return rc, nil
+	}
+	return newVerifiedReader(rc, name, rr.Size(), expected), nil
+}
+
+// readAhead reads a RangeReader sequentially, with up to depth requests of
+// chunk bytes in flight.
+type readAhead struct {
+	rr      RangeReader
+	chunk   int64
+	depth   int
+	next    int64 // offset of the next request
+	pending []chan chunkResult
+	cur     []byte
+	err     error
+}
+
+type chunkResult struct {
+	data []byte
+	err  error
+}
+
+func newReadAhead(rr RangeReader, bytes int) *readAhead {
+	chunk := int64(min(bytes, readAheadChunk))
+	return &readAhead{rr: rr, chunk: chunk, depth: max(1, bytes/int(chunk))}
+}
+
+// fill starts requests until depth of them are pending.
+func (r *readAhead) fill() {
+	size := r.rr.Size()
+	for len(r.pending) < r.depth && r.next < size {
+		ch := make(chan chunkResult, 1)
+		off := r.next
+		buf := make([]byte, min(r.chunk, size-off))
+		go func() {
+			n, err := r.rr.ReadAt(buf, off)
+			if err == io.EOF && n == len(buf) {
+				err = nil
+			}
+			ch <- chunkResult{buf[:n], err}
+		}()
+		r.pending = append(r.pending, ch)
+		r.next += int64(len(buf))
+	}
+}
+
+func (r *readAhead) Read(p []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+		if len(r.pending) == 0 {
+			return 0, io.EOF
+		}
+		res := <-r.pending[0]
+		r.pending = r.pending[1:]
+		if res.err != nil {
+			r.err = res.err
+			return 0, r.err
+		}
+		r.cur = res.data
+	}
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	r.fill()
+	return n, nil
+}
+
+// Close waits for the pending requests and closes the RangeReader if it
+// implements io.Closer.
+func (r *readAhead) Close() error {
+	r.err = errors.New("Read after Close")
+	for _, ch := range r.pending {
+		<-ch
+	}
+	r.pending = nil
+	if c, ok := r.rr.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
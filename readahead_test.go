@@ -0,0 +1,61 @@
+package shp
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadAhead(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	src := &countingRange{Reader: bytes.NewReader(data)}
+	r := &readAhead{rr: src, chunk: 7, depth: 3}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("wrong data")
+	}
+	if src.reads.Load() != 15 {
+		t.Errorf("reads = %d, want 15", src.reads.Load())
+	}
+}
+
+// slowRange is a RangeReader whose reads take a while and fail once it is
+// closed.
+type slowRange struct {
+	*bytes.Reader
+	closed    atomic.Bool
+	lateReads atomic.Int64
+}
+
+func (r *slowRange) ReadAt(p []byte, off int64) (int, error) {
+	time.Sleep(10 * time.Millisecond)
+	if r.closed.Load() {
+		r.lateReads.Add(1)
+	}
+	return r.Reader.ReadAt(p, off)
+}
+
+func (r *slowRange) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+func TestReadAheadClose(t *testing.T) {
+	src := &slowRange{Reader: bytes.NewReader(make([]byte, 100))}
+	r := &readAhead{rr: src, chunk: 10, depth: 5}
+	if _, err := r.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if n := src.lateReads.Load(); n != 0 {
+		t.Errorf("%d reads after Close", n)
+	}
+}
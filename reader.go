@@ -2,12 +2,15 @@ package shp
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Reader provides a interface for reading Shapefiles. Calls
@@ -19,17 +22,38 @@ type Reader struct {
 	bbox         Box
 	err          error
 
-	shp        readSeekCloser
-	shape      Shape
-	num        int32
-	filename   string
-	filelength int64
+	shp             readSeekCloser
+	shape           Shape
+	num             int32
+	offset          int64
+	filename        string
+	filelength      int64
+	shpCount        int
+	sidecars        Sidecars
+	batch           []Record
+	mixedTypeCounts map[ShapeType]int
+	warnings        []Warning
+	reportedErr     error
+
+	remote *remoteFiles // of OpenRange
+
+	sampler *sampler // of SampleEveryN or RandomSample, if set
+
+	indexOnce sync.Once
+	index     []indexEntry
+	indexErr  error
+	readerOptions
 
 	dbf             readSeekCloser
 	dbfFields       []Field
+	dbfColumns      []int   // of SelectFields, nil for all
+	dbfOffsets      []int64 // of each field in a record
+	dbfSelected     []Field
 	dbfNumRecords   int32
 	dbfHeaderLength int16
 	dbfRecordLength int16
+	dbfVersion      byte
+	dbfLastUpdate   [3]byte
 }
 
 type readSeekCloser interface {
@@ -38,21 +62,88 @@ type readSeekCloser interface {
 	io.Closer
 }
 
-// Open opens a Shapefile for reading.
-func Open(filename string) (*Reader, error) {
+// Open opens a Shapefile for reading. The SHP file and its sidecars are
+// looked up case-insensitively (see FindSidecars). If the DBF file is missing,
+// only the geometry can be read. If the SHP file is missing but the DBF file
+// exists, the Reader is opened in table-only mode: Next iterates over the rows
+// of the DBF and Shape returns nil. The same happens if SkipGeometry is given.
+func Open(filename string, opts ...ReaderOption) (*Reader, error) {
 	ext := filepath.Ext(filename)
 	if strings.ToLower(ext) != ".shp" {
 		return nil, fmt.Errorf("Invalid file extension: %s", filename)
 	}
-	shp, err := os.Open(filename)
+	sidecars, err := FindSidecars(filename)
 	if err != nil {
 		return nil, err
 	}
-	s := &Reader{filename: strings.TrimSuffix(filename, ext), shp: shp}
-	s.readHeaders()
+	if sidecars.SHP == "" && sidecars.DBF == "" {
+		return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+	}
+	s := &Reader{
+		filename:      strings.TrimSuffix(filename, ext),
+		sidecars:      sidecars,
+		readerOptions: newReaderOptions(opts),
+	}
+	if err := s.init(); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
+// init opens the SHP file, unless geometry is skipped, and runs the checks
+// that were asked for.
+func (r *Reader) init() error {
+	r.sampler = r.sampling.newSampler()
+	if r.sidecars.SHP == "" || r.skipGeometry {
+		return nil
+	}
+	shp, err := r.openSidecar(r.sidecars.SHP)
+	if err != nil {
+		return err
+	}
+	r.shp = shp
+	r.readHeaders()
+	if err := r.checkAlignment(); err != nil {
+		r.Close()
+		return err
+	}
+	if r.checkCRS {
+		r.checkCoordinates()
+	}
+	if r.snapshot {
+		if err := r.takeSnapshot(); err != nil {
+			r.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// openSidecar opens one of the files of the shapefile, from the RangeOpener
+// if the Reader was created by OpenRange.
+func (r *Reader) openSidecar(name string) (readSeekCloser, error) {
+	if r.remote != nil {
+		return r.remote.open(name)
+	}
+	return os.Open(name)
+}
+
+// readSidecar returns the content of one of the files of the shapefile.
+func (r *Reader) readSidecar(name string) ([]byte, error) {
+	f, err := r.openSidecar(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// Sidecars returns the files of the shapefile that were found when the Reader
+// was opened.
+func (r *Reader) Sidecars() Sidecars {
+	return r.sidecars
+}
+
 // BBox returns the bounding box of the shapefile.
 func (r *Reader) BBox() Box {
 	return r.bbox
@@ -75,6 +166,8 @@ func (r *Reader) readHeaders() {
 	r.bbox.MaxX = readFloat64(r.shp)
 	r.bbox.MaxY = readFloat64(r.shp)
 	r.shp.Seek(100, 0)
+	r.debug("read SHP header", "file", r.sidecars.SHP, "type", r.GeometryType, "bbox", r.bbox,
+		"length", r.filelength, "declared length", int64(filelength)*2)
 }
 
 func readFloat64(r io.Reader) float64 {
@@ -86,10 +179,15 @@ func readFloat64(r io.Reader) float64 {
 // Close closes the Shapefile.
 func (r *Reader) Close() error {
 	if r.err == nil {
-		r.err = r.shp.Close()
+		if r.shp != nil {
+			r.err = r.shp.Close()
+		}
 		if r.dbf != nil {
 			r.dbf.Close()
 		}
+		if r.remote != nil {
+			r.remote.close()
+		}
 	}
 	return r.err
 }
@@ -102,9 +200,37 @@ func (r *Reader) Shape() (int, Shape) {
 	return int(r.num) - 1, r.shape
 }
 
+// Offset returns the byte offset in the SHP file of the most recent feature
+// that was read by a call to Next, or -1 if there is none.
+func (r *Reader) Offset() int64 {
+	if r.shape == nil {
+		return -1
+	}
+	return r.offset
+}
+
+// SeekRecord positions the Reader at the record starting at offset in the SHP
+// file, as returned by Offset of a Reader or a SequentialReader, such that the
+// next call to Next reads that record. This allows to resume reading at a
+// known position.
+func (r *Reader) SeekRecord(offset int64) error {
+	if r.shp == nil {
+		return errors.New("No SHP file to seek in")
+	}
+	if offset < 100 || offset >= r.filelength {
+		return fmt.Errorf("Offset %d is outside of the records in the SHP file", offset)
+	}
+	_, err := r.shp.Seek(offset, io.SeekStart)
+	return err
+}
+
 // Attribute returns value of the n-th attribute of the most recent feature
 // that was read by a call to Next.
 func (r *Reader) Attribute(n int) string {
+	if numFields := len(r.dbfFieldsOnly()); n >= numFields && len(r.virtualFields) > 0 {
+		index, s := r.Shape()
+		return r.virtualAttribute(n-numFields, index, s, numFields, r.Attribute)
+	}
 	return r.ReadAttribute(int(r.num)-1, n)
 }
 
@@ -144,49 +270,184 @@ func newShape(shapetype ShapeType) (Shape, error) {
 	}
 }
 
+// shapeTypeOf returns the ShapeType that corresponds to the type of s. This is
+// the inverse of newShape.
+func shapeTypeOf(s Shape) ShapeType {
+	switch p := s.(type) {
+	case *Point:
+		return POINT
+	case *PolyLine:
+		return POLYLINE
+	case *Polygon:
+		return POLYGON
+	case *MultiPoint:
+		return MULTIPOINT
+	case *PointZ:
+		return POINTZ
+	case *PolyLineZ:
+		return POLYLINEZ
+	case *PolygonZ:
+		return POLYGONZ
+	case *MultiPointZ:
+		return MULTIPOINTZ
+	case *PointM:
+		return POINTM
+	case *PolyLineM:
+		return POLYLINEM
+	case *PolygonM:
+		return POLYGONM
+	case *MultiPointM:
+		return MULTIPOINTM
+	case *MultiPatch:
+		return MULTIPATCH
+	case *Shape32:
+		return p.Type
+	case *LazyShape:
+		return p.shapeType
+	default:
+		return NULL
+	}
+}
+
 // Next reads in the next Shape in the Shapefile, which
 // will then be available through the Shape method. It
 // returns false when the reader has reached the end of the
 // file or encounters an error.
 func (r *Reader) Next() bool {
-	cur, _ := r.shp.Seek(0, io.SeekCurrent)
-	if cur >= r.filelength {
+	if r.metrics == nil || r.shp == nil {
+		return r.next()
+	}
+	start := time.Now()
+	ok := r.next()
+	var size int64
+	if ok && r.offset >= 0 {
+		end, _ := r.shp.Seek(0, io.SeekCurrent)
+		size = end - r.offset
+	}
+	r.observeNext(ok, r.err, &r.reportedErr, start, size)
+	return ok
+}
+
+// next implements Next.
+func (r *Reader) next() bool {
+	if r.shp == nil {
+		return r.nextRow()
+	}
+	if r.alignment == AlignTruncate && r.dbf != nil && r.shpCount > int(r.dbfNumRecords) &&
+		int(r.num) >= int(r.dbfNumRecords) {
+		r.debug("truncating records to DBF", "records", r.dbfNumRecords)
 		return false
 	}
+	var (
+		cur       int64
+		size      int32
+		shapetype ShapeType
+		er        *errReader
+	)
+	for {
+		if r.sampler != nil && !r.seekSample() {
+			return false
+		}
+		cur, _ = r.shp.Seek(0, io.SeekCurrent)
+		if cur >= r.filelength {
+			if r.alignment == AlignPad && r.dbf != nil && int(r.num) < int(r.dbfNumRecords) {
+				r.debug("padding record", "record", r.num)
+				r.num++
+				r.shape = &Null{}
+				r.offset = -1
+				return true
+			}
+			return false
+		}
 
-	var size int32
-	var shapetype ShapeType
-	er := &errReader{Reader: r.shp}
-	binary.Read(er, binary.BigEndian, &r.num)
-	binary.Read(er, binary.BigEndian, &size)
-	binary.Read(er, binary.LittleEndian, &shapetype)
-	if er.e != nil {
-		if er.e != io.EOF {
-			r.err = fmt.Errorf("Error when reading metadata of next shape: %v", er.e)
-		} else {
-			r.err = io.EOF
+		er = &errReader{Reader: r.shp}
+		binary.Read(er, binary.BigEndian, &r.num)
+		binary.Read(er, binary.BigEndian, &size)
+		binary.Read(er, binary.LittleEndian, &shapetype)
+		if er.e != nil {
+			if er.e != io.EOF {
+				r.err = fmt.Errorf("Error when reading metadata of next shape: %v", er.e)
+			} else {
+				r.err = io.EOF
+			}
+			return false
+		}
+		if err := r.limits.checkRecordSize(int64(size) * 2); err != nil {
+			r.err = fmt.Errorf("Error when reading metadata of next shape: %w", err)
+			return false
+		}
+		skip, err := r.checkShapeType(&r.mixedTypeCounts, &r.warnings, r.GeometryType, shapetype, int(r.num)-1)
+		if err != nil {
+			r.err = fmt.Errorf("Error when reading metadata of next shape: %w", err)
+			return false
+		}
+		if !skip {
+			break
+		}
+		r.shp.Seek(int64(size)*2+cur+8, 0)
+	}
+	er.size = int64(size)*2 + 8
+	er.limits = r.limits
+	er.pooled = r.pooled
+
+	if r.lazy {
+		l, err := r.readLazy(er, shapetype, int64(size)*2)
+		if err != nil {
+			r.err = fmt.Errorf("Error while reading next shape: %w", err)
+			return false
 		}
+		r.shape = l.reportTo(&r.warnings, int(r.num)-1)
+	} else if !r.decodeShape(er, shapetype) {
 		return false
 	}
 
+	r.offset = cur
+
+	// move to next object
+	r.shp.Seek(int64(size)*2+cur+8, 0)
+	return true
+}
+
+// decodeShape decodes a shape of type t from er into r.shape.
+func (r *Reader) decodeShape(er *errReader, t ShapeType) bool {
 	var err error
-	r.shape, err = newShape(shapetype)
+	r.shape, err = newShape(t)
 	if err != nil {
 		r.err = fmt.Errorf("Error decoding shape type: %v", err)
 		return false
 	}
 	r.shape.read(er)
 	if er.e != nil {
-		r.err = fmt.Errorf("Error while reading next shape: %v", er.e)
+		r.err = fmt.Errorf("Error while reading next shape: %w", er.e)
 		return false
 	}
+	r.decodeMeasures(r.shape)
+	if r.transformer != nil {
+		TransformShape(r.shape, r.transformer)
+	}
+	r.validateShape(&r.warnings, int(r.num)-1, r.shape)
+	if r.float32Coords {
+		r.shape = toShape32(r.shape)
+	}
+	return true
+}
 
-	// move to next object
-	r.shp.Seek(int64(size)*2+cur+8, 0)
+// nextRow advances to the next DBF row in table-only mode.
+func (r *Reader) nextRow() bool {
+	row := int(r.num)
+	if r.sampler != nil {
+		row = r.sampler.next
+		r.sampler.advance()
+	}
+	if row >= r.AttributeCount() {
+		return false
+	}
+	r.num = int32(row + 1)
+	r.shape = nil
 	return true
 }
 
-// Opens DBF file using r.filename + "dbf". This method
+// Opens the DBF file that was found next to the SHP file. This method
 // will parse the header and fill out all dbf* values int
 // the f object.
 func (r *Reader) openDbf() (err error) {
@@ -194,29 +455,71 @@ func (r *Reader) openDbf() (err error) {
 		return
 	}
 
-	r.dbf, err = os.Open(r.filename + ".dbf")
+	if r.skipAttributes {
+		return errors.New("Attributes are skipped")
+	}
+	if r.sidecars.DBF == "" {
+		return &os.PathError{Op: "open", Path: r.filename + ".dbf", Err: os.ErrNotExist}
+	}
+	dbf, err := r.openSidecar(r.sidecars.DBF)
 	if err != nil {
 		return
 	}
+	r.dbf = dbf
+	return r.readDbfHeader()
+}
 
-	// read header
-	r.dbf.Seek(4, io.SeekStart)
-	binary.Read(r.dbf, binary.LittleEndian, &r.dbfNumRecords)
-	binary.Read(r.dbf, binary.LittleEndian, &r.dbfHeaderLength)
-	binary.Read(r.dbf, binary.LittleEndian, &r.dbfRecordLength)
+// readDbfHeader parses the header of r.dbf and fills out all dbf* values.
+func (r *Reader) readDbfHeader() error {
+	r.dbf.Seek(0, io.SeekStart)
+	er := &errReader{Reader: r.dbf}
+	binary.Read(er, binary.LittleEndian, &r.dbfVersion)
+	binary.Read(er, binary.LittleEndian, &r.dbfLastUpdate)
+	binary.Read(er, binary.LittleEndian, &r.dbfNumRecords)
+	binary.Read(er, binary.LittleEndian, &r.dbfHeaderLength)
+	binary.Read(er, binary.LittleEndian, &r.dbfRecordLength)
+	if er.e != nil {
+		return fmt.Errorf("Error when reading DBF header: %v", er.e)
+	}
+	r.debug("read DBF header", "file", r.sidecars.DBF, "records", r.dbfNumRecords,
+		"header length", r.dbfHeaderLength, "record length", r.dbfRecordLength)
+	if r.dbfHeaderLength < 33 || r.dbfRecordLength < 1 || r.dbfNumRecords < 0 {
+		return fmt.Errorf("Invalid DBF header: header length %d, record length %d, %d records",
+			r.dbfHeaderLength, r.dbfRecordLength, r.dbfNumRecords)
+	}
 
 	r.dbf.Seek(20, io.SeekCurrent) // skip padding
 	numFields := int(math.Floor(float64(r.dbfHeaderLength-33) / 32.0))
 	r.dbfFields = make([]Field, numFields)
-	binary.Read(r.dbf, binary.LittleEndian, &r.dbfFields)
-	return
+	binary.Read(er, binary.LittleEndian, &r.dbfFields)
+	if er.e != nil {
+		return fmt.Errorf("Error when reading DBF fields: %v", er.e)
+	}
+	r.dbfOffsets = make([]int64, numFields)
+	offset := int64(1) // skip the deletion flag
+	for i, f := range r.dbfFields {
+		r.dbfOffsets[i] = offset
+		offset += int64(f.Size)
+	}
+	var err error
+	if r.dbfColumns, err = selectColumns(r.dbfFields, r.selectFields); err != nil {
+		return err
+	}
+	r.dbfSelected = selectedFields(r.dbfFields, r.dbfColumns)
+	return nil
 }
 
 // Fields returns a slice of Fields that are present in the
 // DBF table.
 func (r *Reader) Fields() []Field {
+	return r.withVirtual(r.dbfFieldsOnly())
+}
+
+// dbfFieldsOnly returns the selected fields of the DBF, without virtual
+// fields.
+func (r *Reader) dbfFieldsOnly() []Field {
 	r.openDbf() // make sure we have dbf file to read from
-	return r.dbfFields
+	return r.dbfSelected
 }
 
 // Err returns the last non-EOF error encountered.
@@ -236,13 +539,19 @@ func (r *Reader) AttributeCount() int {
 // ReadAttribute returns the attribute value at row for field in
 // the DBF table as a string. Both values starts at 0.
 func (r *Reader) ReadAttribute(row int, field int) string {
-	r.openDbf() // make sure we have a dbf file to read from
-	seekTo := 1 + int64(r.dbfHeaderLength) + (int64(row) * int64(r.dbfRecordLength))
-	for n := 0; n < field; n++ {
-		seekTo += int64(r.dbfFields[n].Size)
+	if r.openDbf() != nil {
+		return ""
 	}
+	field = column(r.dbfColumns, field)
+	if field < 0 || field >= len(r.dbfFields) || row >= int(r.dbfNumRecords) {
+		return ""
+	}
+	seekTo := int64(r.dbfHeaderLength) + (int64(row) * int64(r.dbfRecordLength)) + r.dbfOffsets[field]
 	r.dbf.Seek(seekTo, io.SeekStart)
 	buf := make([]byte, r.dbfFields[field].Size)
 	r.dbf.Read(buf)
+	if r.dbfFields[field].Fieldtype == 'Y' {
+		return decodeCurrency(buf)
+	}
 	return strings.Trim(string(buf[:]), " ")
 }
@@ -0,0 +1,38 @@
+package shp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// EncodeRecord returns the record of s with the record number num as stored
+// in a SHP file: the big-endian record number and content length in 16-bit
+// words, followed by the shape type and the content. Together with
+// DecodeRecord, it lets tools store shapefile records in their own containers
+// without the file-level machinery of Writer and Reader. Record numbers in SHP
+// files start at 1.
+func EncodeRecord(num int32, s Shape) []byte {
+	content, _ := marshalShape(s)
+	buf := make([]byte, 8, 8+len(content))
+	binary.BigEndian.PutUint32(buf, uint32(num))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(content)/2))
+	return append(buf, content...)
+}
+
+// DecodeRecord decodes a record as returned by EncodeRecord. data must hold
+// exactly one record. "No data" measures are decoded to NaN, like the Reader
+// does by default.
+func DecodeRecord(data []byte) (num int32, s Shape, err error) {
+	if len(data) < 12 {
+		return 0, nil, fmt.Errorf("Record of %d bytes is too short", len(data))
+	}
+	num = int32(binary.BigEndian.Uint32(data))
+	if length := int(binary.BigEndian.Uint32(data[4:])) * 2; length != len(data)-8 {
+		return num, nil, fmt.Errorf("Record %d has a content length of %d bytes but %d bytes follow", num, length, len(data)-8)
+	}
+	s, err = UnmarshalShape(data[8:])
+	if err != nil {
+		return num, nil, fmt.Errorf("Error when decoding record %d: %w", num, err)
+	}
+	return num, s, nil
+}
@@ -0,0 +1,45 @@
+package shp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeRecordMatchesWriter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "records.shp")
+	shapes := []Shape{
+		&Point{1, 2},
+		NewPolyLine([][]Point{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}}}),
+		&PointZ{1, 2, 3, 4},
+	}
+	for i, s := range shapes {
+		w, err := Create(filename, shapeTypeOf(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(s)
+		w.Close()
+		data, _ := os.ReadFile(filename)
+		want := data[100:]
+
+		got := EncodeRecord(1, s)
+		if !bytes.Equal(got, want) {
+			t.Errorf("shape %d: got record %x, want %x", i, got, want)
+		}
+		num, decoded, err := DecodeRecord(got)
+		if err != nil || num != 1 || !equalShapes(decoded, s) {
+			t.Errorf("shape %d: decoded %d, %+v, %v", i, num, decoded, err)
+		}
+	}
+}
+
+func TestDecodeRecordErrors(t *testing.T) {
+	rec := EncodeRecord(7, &Point{1, 2})
+	for _, data := range [][]byte{rec[:10], rec[:len(rec)-2], append(rec, 0, 0)} {
+		if _, _, err := DecodeRecord(data); err == nil {
+			t.Errorf("decoded %x", data)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package shp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Quirks are known deviations from the specification in the record headers
+// of SHP files written by broken producers. Reencode reads files with them
+// and writes spec-compliant ones.
+type Quirks int
+
+const (
+	// QuirkByteLengths means that the content length in record headers is
+	// counted in bytes instead of 16-bit words, so it is twice the correct
+	// value and readers skip half of the file.
+	QuirkByteLengths Quirks = 1 << iota
+	// QuirkLittleEndianHeaders means that the record number and the content
+	// length in record headers are little-endian instead of big-endian, as
+	// written by producers that use the byte order of the rest of the record
+	// for the header as well.
+	QuirkLittleEndianHeaders
+)
+
+// quirkCandidates are the combinations of quirks that DetectQuirks tries, in
+// order of preference.
+var quirkCandidates = []Quirks{0, QuirkByteLengths, QuirkLittleEndianHeaders, QuirkByteLengths | QuirkLittleEndianHeaders}
+
+// recordHeader decodes a record header with the quirks q.
+func (q Quirks) recordHeader(b []byte) (num int32, length int64) {
+	var order binary.ByteOrder = binary.BigEndian
+	if q&QuirkLittleEndianHeaders != 0 {
+		order = binary.LittleEndian
+	}
+	num = int32(order.Uint32(b))
+	length = int64(order.Uint32(b[4:]))
+	if q&QuirkByteLengths == 0 {
+		length *= 2
+	}
+	return num, length
+}
+
+// DetectQuirks guesses the quirks of the SHP file of size bytes read by r
+// from its first two records: the quirks are right if the second record
+// header follows the first record with number 2 and a valid shape type, or
+// if the file ends right after the first record. It returns an error if no
+// combination of quirks fits.
+func DetectQuirks(r io.ReaderAt, size int64) (Quirks, error) {
+	if size <= 100 {
+		return 0, nil // no records
+	}
+	first := make([]byte, 12)
+	if _, err := r.ReadAt(first, 100); err != nil {
+		return 0, fmt.Errorf("Error when reading first record: %v", err)
+	}
+	next := make([]byte, 12)
+	for _, q := range quirkCandidates {
+		_, length := q.recordHeader(first)
+		end := 100 + 8 + length
+		if length < 4 || end > size {
+			continue
+		}
+		if end == size {
+			return q, nil
+		}
+		if end+12 > size {
+			continue
+		}
+		if _, err := r.ReadAt(next, end); err != nil {
+			return 0, fmt.Errorf("Error when reading second record: %v", err)
+		}
+		num, _ := q.recordHeader(next)
+		if _, ok := _ShapeType_map[ShapeType(binary.LittleEndian.Uint32(next[8:]))]; ok && num == 2 {
+			return q, nil
+		}
+	}
+	return 0, errors.New("Record headers do not fit any known quirks")
+}
+
+// Reencode reads the SHP file src, whose record headers have the quirks q,
+// and writes a spec-compliant copy of it to shp and its index to shx. Records
+// are renumbered from 1 and the file lengths in the headers are recomputed;
+// the record contents are copied as they are. Records are streamed one by one
+// and only the headers are patched by seeking. It returns the number of
+// records written.
+func Reencode(src io.Reader, shp, shx io.WriteSeeker, q Quirks) (int, error) {
+	header := make([]byte, 100)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return 0, fmt.Errorf("Error when reading SHP header: %v", err)
+	}
+	if _, err := shp.Write(header); err != nil {
+		return 0, err
+	}
+	if _, err := shx.Write(header); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	offset := int64(100)
+	rec := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(src, rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return n, fmt.Errorf("Error when reading header of record %d: %v", n, err)
+		}
+		_, length := q.recordHeader(rec)
+		if length%2 != 0 {
+			return n, fmt.Errorf("Record %d has an odd length of %d bytes", n, length)
+		}
+		n++
+		binary.BigEndian.PutUint32(rec, uint32(n))
+		binary.BigEndian.PutUint32(rec[4:], uint32(length/2))
+		if _, err := shp.Write(rec); err != nil {
+			return n, err
+		}
+		if copied, err := io.CopyN(shp, src, length); err != nil {
+			return n, fmt.Errorf("Error when copying record %d after %d of %d bytes: %v", n-1, copied, length, err)
+		}
+		binary.BigEndian.PutUint32(rec, uint32(offset/2))
+		if _, err := shx.Write(rec); err != nil {
+			return n, err
+		}
+		offset += 8 + length
+	}
+
+	// patch the file lengths
+	for _, f := range []struct {
+		ws     io.WriteSeeker
+		length int64
+	}{{shp, offset}, {shx, 100 + 8*int64(n)}} {
+		if _, err := f.ws.Seek(24, io.SeekStart); err != nil {
+			return n, err
+		}
+		if err := binary.Write(f.ws, binary.BigEndian, int32(f.length/2)); err != nil {
+			return n, err
+		}
+		if _, err := f.ws.Seek(0, io.SeekEnd); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ReencodeFile re-encodes the SHP file of the shapefile src with Reencode
+// into the shapefile dst, detecting its quirks with DetectQuirks. Only the
+// SHP and SHX files of dst are written; the other files of src can be copied
+// as they are.
+func ReencodeFile(src, dst string) (Quirks, error) {
+	sidecars, err := FindSidecars(src)
+	if err != nil {
+		return 0, err
+	}
+	if sidecars.SHP == "" {
+		return 0, &os.PathError{Op: "open", Path: src, Err: os.ErrNotExist}
+	}
+	in, err := os.Open(sidecars.SHP)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+	q, err := DetectQuirks(in, fi.Size())
+	if err != nil {
+		return 0, err
+	}
+
+	base := trimSidecarExt(dst)
+	shp, err := os.Create(base + ".shp")
+	if err != nil {
+		return q, err
+	}
+	defer shp.Close()
+	shx, err := os.Create(base + ".shx")
+	if err != nil {
+		return q, err
+	}
+	defer shx.Close()
+	if _, err := Reencode(in, shp, shx, q); err != nil {
+		return q, err
+	}
+	if err := shp.Close(); err != nil {
+		return q, err
+	}
+	return q, shx.Close()
+}
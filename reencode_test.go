@@ -0,0 +1,67 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// breakRecordHeaders rewrites the record headers of the SHP file data with
+// the quirks q.
+func breakRecordHeaders(data []byte, q Quirks) []byte {
+	out := append([]byte(nil), data...)
+	for pos := 100; pos+8 <= len(out); {
+		num := binary.BigEndian.Uint32(out[pos:])
+		length := binary.BigEndian.Uint32(out[pos+4:]) * 2
+		broken := length
+		if q&QuirkByteLengths == 0 {
+			broken /= 2
+		}
+		var order binary.ByteOrder = binary.BigEndian
+		if q&QuirkLittleEndianHeaders != 0 {
+			order = binary.LittleEndian
+		}
+		order.PutUint32(out[pos:], num)
+		order.PutUint32(out[pos+4:], broken)
+		pos += 8 + int(length)
+	}
+	return out
+}
+
+func TestReencode(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "cities")
+	writeCities(t, filename)
+	shp, _ := os.ReadFile(filename + ".shp")
+	shx, _ := os.ReadFile(filename + ".shx")
+
+	for _, q := range quirkCandidates {
+		broken := filepath.Join(dir, "broken.shp")
+		if err := os.WriteFile(broken, breakRecordHeaders(shp, q), 0666); err != nil {
+			t.Fatal(err)
+		}
+		fixed := filepath.Join(dir, "fixed.shp")
+		got, err := ReencodeFile(broken, fixed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != q {
+			t.Errorf("detected quirks %v, want %v", got, q)
+		}
+		fixedSHP, _ := os.ReadFile(fixed)
+		fixedSHX, _ := os.ReadFile(filepath.Join(dir, "fixed.shx"))
+		if !bytes.Equal(fixedSHP, shp) || !bytes.Equal(fixedSHX, shx) {
+			t.Errorf("re-encoded files differ from the original with quirks %v", q)
+		}
+	}
+}
+
+func TestDetectQuirksUnknown(t *testing.T) {
+	data := make([]byte, 120)
+	binary.BigEndian.PutUint32(data[104:], 1000)
+	if _, err := DetectQuirks(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("detected quirks of garbage")
+	}
+}
@@ -0,0 +1,108 @@
+package shp
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// RangeReader is random access to a file of known size, such as an object
+// in cloud storage that is read with HTTP range requests. If it also
+// implements io.Closer, it is closed with the Reader.
+type RangeReader interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// RangeOpener opens the file called name for random access. It returns an
+// error that matches fs.ErrNotExist if there is no such file.
+type RangeOpener func(name string) (RangeReader, error)
+
+// remoteFiles are the files of a shapefile opened by OpenRange.
+type remoteFiles struct {
+	files map[string]RangeReader
+}
+
+// rangeFile reads a RangeReader sequentially. The RangeReader is closed
+// with the Reader.
+type rangeFile struct {
+	*io.SectionReader
+}
+
+func (rangeFile) Close() error {
+	return nil
+}
+
+func (rf *remoteFiles) open(name string) (readSeekCloser, error) {
+	rr, ok := rf.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return rangeFile{io.NewSectionReader(rr, 0, rr.Size())}, nil
+}
+
+// sidecarSize returns the size of one of the files of the shapefile.
+func (r *Reader) sidecarSize(name string) (int64, error) {
+	if r.remote != nil {
+		if rr, ok := r.remote.files[name]; ok {
+			return rr.Size(), nil
+		}
+		return 0, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// OpenRange opens the shapefile name, with or without the ".shp" extension,
+// from files that are read with random access, so that ShapeAt, AttributesAt
+// and the spatial index only fetch the bytes they need. The sidecars are
+// found by opening name with each of the lower case extensions.
+//
+// HTTPOpener, S3Opener and GCSOpener read objects with HTTP range requests.
+// Other storage is supported by implementing a RangeOpener.
+func OpenRange(open RangeOpener, name string, opts ...ReaderOption) (*Reader, error) {
+	base := trimSidecarExt(name)
+	remote := &remoteFiles{files: map[string]RangeReader{}}
+	var sidecars Sidecars
+	for _, ext := range sidecarExts {
+		rr, err := open(base + ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			remote.close()
+			return nil, err
+		}
+		remote.files[base+ext] = rr
+		sidecars.set(ext, base+ext)
+	}
+	if sidecars.SHP == "" && sidecars.DBF == "" {
+		return nil, &os.PathError{Op: "open", Path: base + ".shp", Err: os.ErrNotExist}
+	}
+	s := &Reader{
+		filename:      base,
+		sidecars:      sidecars,
+		remote:        remote,
+		readerOptions: newReaderOptions(opts),
+	}
+	if err := s.init(); err != nil {
+		remote.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// close closes the files that implement io.Closer. It may be called more
+// than once.
+func (rf *remoteFiles) close() {
+	for _, rr := range rf.files {
+		if c, ok := rr.(io.Closer); ok {
+			c.Close()
+		}
+	}
+	rf.files = nil
+}
@@ -0,0 +1,93 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenRange(t *testing.T) {
+	dir := t.TempDir()
+	writeCities(t, filepath.Join(dir, "cities"))
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	r, err := OpenRange(HTTPOpener(srv.Client(), srv.URL+"/"), "cities.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.GeometryType != POINT {
+		t.Errorf("type = %v, want POINT", r.GeometryType)
+	}
+	if r.NumRecords() != 2 {
+		t.Fatalf("records = %d, want 2", r.NumRecords())
+	}
+	s, err := r.ShapeAt(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := s.(*Point); p.X != 3 || p.Y != 4 {
+		t.Errorf("shape 1 = %v, want (3, 4)", p)
+	}
+	attrs, err := r.AttributesAt(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := strings.TrimRight(attrs[0], "\x00"); name != "Shelbyville" {
+		t.Errorf("name = %q, want Shelbyville", name)
+	}
+
+	n := 0
+	for r.Next() {
+		n++
+	}
+	if n != 2 || r.Err() != nil {
+		t.Errorf("read %d records, err %v", n, r.Err())
+	}
+}
+
+func TestOpenRangeMissing(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir(t.TempDir())))
+	defer srv.Close()
+
+	_, err := OpenRange(HTTPOpener(srv.Client(), srv.URL), "missing")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	if got := escapePath("data/my layer.shp"); got != "data/my%20layer.shp" {
+		t.Errorf("escapePath = %q", got)
+	}
+}
+
+func TestOpenRangeSequential(t *testing.T) {
+	dir := t.TempDir()
+	writeCities(t, filepath.Join(dir, "cities"))
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	sr, err := OpenRangeSequential(HTTPOpener(srv.Client(), srv.URL), "cities", ReadAhead(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Close()
+	var names []string
+	for sr.Next() {
+		names = append(names, strings.TrimRight(sr.Attribute(0), "\x00"))
+	}
+	if sr.Err() != nil {
+		t.Fatal(sr.Err())
+	}
+	if strings.Join(names, ",") != "Springfield,Shelbyville" {
+		t.Errorf("names = %v", names)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"math"
+	"sort"
+)
+
+// rtreeFanout is the number of children of each node of an rtree.
+const rtreeFanout = 16
+
+type rtreeEntry struct {
+	box Box
+	id  int
+}
+
+// rtree is a static R-tree over the bounding boxes of records, bulk loaded
+// with the Sort-Tile-Recursive algorithm. Level 0 holds the records; node i
+// of level k covers the nodes i*rtreeFanout up to (i+1)*rtreeFanout of level
+// k-1. It is not modified after it is built, so it may be searched
+// concurrently.
+type rtree struct {
+	levels [][]rtreeEntry
+}
+
+func newRTree(entries []rtreeEntry) *rtree {
+	strSort(entries)
+	t := &rtree{levels: [][]rtreeEntry{entries}}
+	for level := entries; len(level) > rtreeFanout; {
+		next := make([]rtreeEntry, (len(level)+rtreeFanout-1)/rtreeFanout)
+		for i := range next {
+			children := level[i*rtreeFanout : min((i+1)*rtreeFanout, len(level))]
+			next[i] = rtreeEntry{box: children[0].box, id: i}
+			for _, c := range children[1:] {
+				next[i].box = next[i].box.Union(c.box)
+			}
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t
+}
+
+// strSort orders entries into tiles: slices of similar X, sorted by Y within.
+func strSort(entries []rtreeEntry) {
+	cx := func(e rtreeEntry) float64 { return e.box.MinX + e.box.MaxX }
+	cy := func(e rtreeEntry) float64 { return e.box.MinY + e.box.MaxY }
+	sort.Slice(entries, func(i, j int) bool { return cx(entries[i]) < cx(entries[j]) })
+	leaves := (len(entries) + rtreeFanout - 1) / rtreeFanout
+	slice := int(math.Ceil(math.Sqrt(float64(leaves)))) * rtreeFanout
+	for start := 0; start < len(entries); start += slice {
+		tile := entries[start:min(start+slice, len(entries))]
+		sort.Slice(tile, func(i, j int) bool { return cy(tile[i]) < cy(tile[j]) })
+	}
+}
+
+// search returns the ids of the records whose box intersects b, in ascending
+// order.
+func (t *rtree) search(b Box) []int {
+	var ids []int
+	top := len(t.levels) - 1
+	for i := range t.levels[top] {
+		t.searchNode(top, i, b, &ids)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (t *rtree) searchNode(level, i int, b Box, ids *[]int) {
+	e := t.levels[level][i]
+	if !e.box.Intersects(b) {
+		return
+	}
+	if level == 0 {
+		*ids = append(*ids, e.id)
+		return
+	}
+	children := t.levels[level-1]
+	for c := i * rtreeFanout; c < min((i+1)*rtreeFanout, len(children)); c++ {
+		t.searchNode(level-1, c, b, ids)
+	}
+}
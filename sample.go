@@ -0,0 +1,120 @@
+package shp
+
+// SampleFunc returns the value at the location x, y, e.g. the elevation of a
+// digital elevation model or the band of a satellite image. It is how
+// SampleField attaches raster values to shapes without this package knowing
+// any raster format.
+type SampleFunc func(x, y float64) (any, error)
+
+// SampleField returns a SequentialReader that reads sr and adds the attribute
+// field with the value that f returns for each shape. Points are sampled at
+// their location and other shapes at the centroid of their vertices, their
+// mean; Null shapes get an empty value without calling f. Values are
+// formatted like those of AddComputedField. The first error that f returns
+// stops reading: Next returns false, NextBatch returns the records before it,
+// and Err returns the error.
+func SampleField(sr SequentialReader, field Field, f SampleFunc) SequentialReader {
+	return &sampleReader{SequentialReader: sr, field: field, sample: f}
+}
+
+// sampleLocation returns the location at which SampleField samples s.
+func sampleLocation(s Shape) (Point, bool) {
+	switch p := s.(type) {
+	case *Point:
+		return *p, true
+	case *PointZ:
+		return Point{p.X, p.Y}, true
+	case *PointM:
+		return Point{p.X, p.Y}, true
+	}
+	a, ok := arraysOf(s)
+	if !ok || len(a.points) == 0 {
+		return Point{}, false
+	}
+	var c Point
+	for _, p := range a.points {
+		c.X += p.X
+		c.Y += p.Y
+	}
+	n := float64(len(a.points))
+	return Point{c.X / n, c.Y / n}, true
+}
+
+// sampleReader adds a sampled attribute to the records of a
+// SequentialReader.
+type sampleReader struct {
+	SequentialReader
+	field  Field
+	sample SampleFunc
+
+	value string // of the current record
+	err   error
+}
+
+// valueOf returns the attribute value of s.
+func (r *sampleReader) valueOf(s Shape) (string, error) {
+	p, ok := sampleLocation(s)
+	if !ok {
+		return "", nil
+	}
+	v, err := r.sample(p.X, p.Y)
+	if err != nil {
+		return "", err
+	}
+	return formatComputed(v, r.field), nil
+}
+
+// Next implements a method of interface SequentialReader for sampleReader.
+func (r *sampleReader) Next() bool {
+	r.value = ""
+	if r.err != nil || !r.SequentialReader.Next() {
+		return false
+	}
+	_, s := r.SequentialReader.Shape()
+	r.value, r.err = r.valueOf(s)
+	return r.err == nil
+}
+
+// Err implements a method of interface SequentialReader for sampleReader.
+func (r *sampleReader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.SequentialReader.Err()
+}
+
+// Fields implements a method of interface SequentialReader for sampleReader.
+func (r *sampleReader) Fields() []Field {
+	fields := r.SequentialReader.Fields()
+	return append(fields[:len(fields):len(fields)], r.field)
+}
+
+// Attribute implements a method of interface SequentialReader for
+// sampleReader.
+func (r *sampleReader) Attribute(n int) string {
+	if n == len(r.SequentialReader.Fields()) {
+		return r.value
+	}
+	return r.SequentialReader.Attribute(n)
+}
+
+// NextBatch implements BatchReader for sampleReader.
+func (r *sampleReader) NextBatch(n int) ([]Record, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.value = ""
+	batch, err := NextBatch(r.SequentialReader, n)
+	for i := range batch {
+		v, serr := r.valueOf(batch[i].Shape)
+		if serr != nil {
+			r.err = serr
+			if i == 0 {
+				return nil, serr
+			}
+			return batch[:i], nil
+		}
+		batch[i].Attributes = append(batch[i].Attributes, v)
+	}
+	return batch, err
+}
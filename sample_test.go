@@ -0,0 +1,50 @@
+package shp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSampleField(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	elevation := func(x, y float64) (any, error) { return x*100 + y, nil }
+	sr := SampleField(SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t)),
+		FloatField("ELEV", 8, 1), elevation)
+	defer sr.Close()
+	if fields := sr.Fields(); len(fields) != 4 || fields[3].String() != "ELEV" {
+		t.Fatalf("got fields %v", fields)
+	}
+	if !sr.Next() {
+		t.Fatal(sr.Err())
+	}
+	if got := sr.Attribute(3); got != "102.0" {
+		t.Errorf("got ELEV %q, want 102.0", got)
+	}
+	batch, err := NextBatch(sr, 10)
+	if err != nil || len(batch) != 1 || batch[0].Attributes[3] != "304.0" {
+		t.Errorf("got batch %v, %v", batch, err)
+	}
+
+	errNoData := errors.New("outside of the raster")
+	sr = SampleField(SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t)),
+		NumberField("BAND", 3), func(x, y float64) (any, error) {
+			if x > 2 {
+				return nil, errNoData
+			}
+			return 7, nil
+		})
+	defer sr.Close()
+	if !sr.Next() || sr.Attribute(3) != "7" {
+		t.Fatalf("got %q, %v", sr.Attribute(3), sr.Err())
+	}
+	if sr.Next() || sr.Err() != errNoData {
+		t.Errorf("got error %v, want %v", sr.Err(), errNoData)
+	}
+
+	if p, ok := sampleLocation(NewPolyLine([][]Point{{{0, 0}, {2, 0}, {2, 2}, {0, 2}}})); !ok || p != (Point{1, 1}) {
+		t.Errorf("got location %v", p)
+	}
+}
@@ -0,0 +1,109 @@
+package shp
+
+import (
+	"io"
+	"math"
+	"math/rand"
+)
+
+// SampleEveryN makes the reader return only every n-th record, starting with
+// the first, e.g. to profile or preview a large shapefile without reading all
+// of it. Open seeks to the sampled records using the SHX file, or the record
+// headers if there is none, so the skipped records are not read;
+// SequentialReaders skip them without decoding. The indices returned by
+// Shape remain those of the records in the file.
+func SampleEveryN(n int) ReaderOption {
+	return func(o *readerOptions) {
+		o.sampling = samplingOptions{every: max(n, 1)}
+	}
+}
+
+// RandomSample makes the reader return a random sample of the records, each
+// record being included with probability fraction, like SampleEveryN. The
+// same seed selects the same records, so samples are reproducible.
+func RandomSample(fraction float64, seed int64) ReaderOption {
+	return func(o *readerOptions) {
+		o.sampling = samplingOptions{fraction: fraction, seed: seed, random: true}
+	}
+}
+
+// samplingOptions are the settings of SampleEveryN and RandomSample.
+type samplingOptions struct {
+	every    int
+	fraction float64
+	seed     int64
+	random   bool
+}
+
+// newSampler returns the sampler for o, or nil if all records are read.
+func (o samplingOptions) newSampler() *sampler {
+	if !o.random && o.every <= 1 {
+		return nil
+	}
+	s := &sampler{samplingOptions: o}
+	if o.random {
+		s.rng = rand.New(rand.NewSource(o.seed))
+		s.next = -1
+		s.advance()
+	}
+	return s
+}
+
+// sampler selects the indices of the sampled records in ascending order.
+type sampler struct {
+	samplingOptions
+	rng  *rand.Rand
+	next int // index of the next sampled record
+}
+
+// advance selects the next sampled record after s.next. The gaps between
+// random samples follow the geometric distribution, so that every record is
+// included with probability fraction without drawing a number per record.
+func (s *sampler) advance() {
+	switch {
+	case !s.random:
+		s.next += s.every
+	case s.fraction >= 1:
+		s.next++
+	case s.fraction <= 0:
+		s.next = math.MaxInt
+	default:
+		u := 1 - s.rng.Float64() // in (0, 1]
+		gap := math.Floor(math.Log(u) / math.Log1p(-s.fraction))
+		if gap >= float64(math.MaxInt-s.next-1) {
+			s.next = math.MaxInt
+		} else {
+			s.next += 1 + int(gap)
+		}
+	}
+}
+
+// take reports whether the record at index i, which must not be beyond the
+// next sampled one, is sampled, and then selects the next one.
+func (s *sampler) take(i int) bool {
+	if i != s.next {
+		return false
+	}
+	s.advance()
+	return true
+}
+
+// seekSample moves the SHP file of r to the next sampled record. It returns
+// false if there is none.
+func (r *Reader) seekSample() bool {
+	i := r.sampler.next
+	r.sampler.advance()
+	r.loadIndex()
+	if r.indexErr != nil {
+		r.err = r.indexErr
+		return false
+	}
+	if i >= len(r.index) {
+		return false
+	}
+	if _, err := r.shp.Seek(r.index[i].offset, io.SeekStart); err != nil {
+		r.err = err
+		return false
+	}
+	return true
+}
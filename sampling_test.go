@@ -0,0 +1,127 @@
+package shp
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeNumbered writes n points whose ID attribute is their index.
+func writeNumbered(t *testing.T, base string, n int) {
+	t.Helper()
+	w, err := Create(base+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{NumberField("ID", 6)})
+	for i := 0; i < n; i++ {
+		w.Write(&Point{X: float64(i), Y: 0})
+		w.WriteAttribute(i, 0, i)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sampled returns the indices of the records that next advances to,
+// checking that the shapes and attributes belong to them.
+func sampled(t *testing.T, next func() bool, shape func() (int, Shape), attribute func(int) string) []int {
+	t.Helper()
+	var indices []int
+	for next() {
+		i, s := shape()
+		if id := strings.TrimRight(strings.TrimSpace(attribute(0)), "\x00"); id != strconv.Itoa(i) {
+			t.Errorf("record %d has ID %s", i, id)
+		}
+		if p, ok := s.(*Point); s != nil && (!ok || p.X != float64(i)) {
+			t.Errorf("record %d has shape %v", i, s)
+		}
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+func TestSampleEveryN(t *testing.T) {
+	base := filenamePrefix + "sample_every"
+	defer removeShapefile(base)
+	writeNumbered(t, base, 95)
+	want := []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90}
+
+	for _, opts := range [][]ReaderOption{
+		{SampleEveryN(10)},
+		{SampleEveryN(10), SkipGeometry()},
+	} {
+		r, err := Open(base+".shp", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := sampled(t, r.Next, r.Shape, r.Attribute)
+		if err := r.Err(); err != nil {
+			t.Error(err)
+		}
+		r.Close()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Reader sampled %v, want %v", got, want)
+		}
+	}
+
+	sr := SequentialReaderFromExt(openFile(base+".shp", t), openFile(base+".dbf", t), SampleEveryN(10))
+	got := sampled(t, sr.Next, sr.Shape, sr.Attribute)
+	sr.Close()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SequentialReader sampled %v, want %v", got, want)
+	}
+
+	// without an SHX file, the record headers are walked
+	os.Remove(base + ".shx")
+	r, err := Open(base+".shp", SampleEveryN(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got := sampled(t, r.Next, r.Shape, r.Attribute); !reflect.DeepEqual(got, want) {
+		t.Errorf("Reader without SHX sampled %v, want %v", got, want)
+	}
+}
+
+func TestRandomSample(t *testing.T) {
+	base := filenamePrefix + "sample_random"
+	defer removeShapefile(base)
+	writeNumbered(t, base, 200)
+
+	read := func(seed int64) []int {
+		r, err := Open(base+".shp", RandomSample(0.25, seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		return sampled(t, r.Next, r.Shape, r.Attribute)
+	}
+	first := read(7)
+	if n := len(first); n < 25 || n > 75 {
+		t.Errorf("sampled %d of 200 records with fraction 0.25", n)
+	}
+	if again := read(7); !reflect.DeepEqual(again, first) {
+		t.Errorf("same seed sampled %v, then %v", first, again)
+	}
+	if other := read(8); reflect.DeepEqual(other, first) {
+		t.Error("different seeds sampled the same records")
+	}
+
+	sr := SequentialReaderFromExt(openFile(base+".shp", t), openFile(base+".dbf", t), RandomSample(0.25, 7))
+	defer sr.Close()
+	if got := sampled(t, sr.Next, sr.Shape, sr.Attribute); !reflect.DeepEqual(got, first) {
+		t.Errorf("SequentialReader sampled %v, Reader %v", got, first)
+	}
+
+	r, err := Open(base+".shp", RandomSample(0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Next() {
+		t.Error("fraction 0 sampled a record")
+	}
+}
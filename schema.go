@@ -0,0 +1,212 @@
+package shp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InferOptions configures InferSchema. The zero value uses the defaults.
+type InferOptions struct {
+	// MaxStringSize caps the width of character fields, 254 if zero. Longer
+	// strings must be truncated when they are written.
+	MaxStringSize int
+	// MaxPrecision caps the number of decimals of float fields, 8 if zero.
+	MaxPrecision int
+	// Padding is added to the width of character and number fields, so that
+	// records that were not sampled fit as well.
+	Padding int
+	// Sample limits the number of records examined. All records are examined
+	// if it is zero.
+	Sample int
+}
+
+// Schema is a DBF schema inferred by InferSchema.
+type Schema struct {
+	// Keys are the keys of the records in the order of Fields, so the value
+	// of Keys[i] is written to field i. The names of the fields may differ
+	// from the keys, see LaunderFieldNames.
+	Keys   []string
+	Fields []Field
+}
+
+// valueKind is the kind of values seen in a column. Kinds are ordered so that
+// combining two kinds of numbers yields the larger one.
+type valueKind int
+
+const (
+	kindNone valueKind = iota // only nil values
+	kindBool
+	kindInt
+	kindFloat
+	kindDate
+	kindString
+)
+
+// join returns the kind of a column holding values of kinds k and o.
+func (k valueKind) join(o valueKind) valueKind {
+	switch {
+	case k == o || o == kindNone:
+		return k
+	case k == kindNone:
+		return o
+	case (k == kindInt || k == kindFloat) && (o == kindInt || o == kindFloat):
+		return kindFloat
+	}
+	return kindString
+}
+
+// columnStats collects what InferSchema needs to know about a column.
+type columnStats struct {
+	kind      valueKind
+	maxLen    int // longest value as a string
+	intDigits int // longest integer part of numbers, including the sign
+	decimals  int // most decimals of numbers
+}
+
+func (c *columnStats) add(v any) error {
+	var kind valueKind
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		kind, s = kindBool, "T"
+	case int:
+		kind, s = kindInt, strconv.Itoa(v)
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		kind, s = kindInt, fmt.Sprint(v)
+	case float32:
+		kind, s = kindFloat, strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		kind, s = kindFloat, strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			kind, s = kindInt, v.String()
+		} else if f, err := v.Float64(); err == nil {
+			kind, s = kindFloat, strconv.FormatFloat(f, 'f', -1, 64)
+		} else {
+			kind, s = kindString, v.String()
+		}
+	case string:
+		kind, s = kindString, v
+	case time.Time:
+		kind, s = kindDate, v.Format("20060102")
+	default:
+		return fmt.Errorf("Unsupported value type: %T", v)
+	}
+	c.kind = c.kind.join(kind)
+	c.maxLen = max(c.maxLen, len(s))
+	if kind == kindInt || kind == kindFloat {
+		intPart, frac, _ := strings.Cut(s, ".")
+		c.intDigits = max(c.intDigits, len(intPart))
+		c.decimals = max(c.decimals, len(frac))
+	}
+	return nil
+}
+
+// field returns the field for the column named name.
+func (c *columnStats) field(name string, opts *InferOptions) (Field, error) {
+	switch c.kind {
+	case kindBool:
+		field := Field{Fieldtype: 'L', Size: 1}
+		copy(field.Name[:], name)
+		return field, nil
+	case kindDate:
+		return DateField(name), nil
+	case kindInt:
+		size := c.intDigits + opts.Padding
+		if size > 20 {
+			return Field{}, fmt.Errorf("Field %s needs %d digits, the maximum is 20", name, size)
+		}
+		return NumberField(name, uint8(size)), nil
+	case kindFloat:
+		precision := min(c.decimals, opts.MaxPrecision)
+		size := c.intDigits + opts.Padding
+		if precision > 0 {
+			size += precision + 1
+		}
+		if size > 254 {
+			return Field{}, fmt.Errorf("Field %s needs %d digits, the maximum is 254", name, size)
+		}
+		return FloatField(name, uint8(size), uint8(precision)), nil
+	}
+	// strings, mixed kinds and columns with only nil values
+	size := max(1, min(c.maxLen+opts.Padding, opts.MaxStringSize))
+	return StringField(name, uint8(size)), nil
+}
+
+// InferSchema chooses the DBF fields for records from the values they hold,
+// so that they can be written without authoring a schema by hand. records
+// yields maps from attribute names to values; an iter.Seq[map[string]any]
+// can be passed. Keys become fields in the order they first appear, sorted
+// among the keys that first appear in the same record, and their values
+// determine the type of field:
+//
+//   - bools become logical fields
+//   - integers become number fields wide enough for all values
+//   - floats become float fields with the most decimals seen, up to
+//     MaxPrecision; integers and floats in one column become floats
+//   - time.Time values become date fields
+//   - strings and columns with mixed kinds of values become character fields
+//     as wide as the longest value, up to MaxStringSize
+//
+// json.Number values are treated as integers or floats, and nil, NaN and
+// infinite values are ignored. The names of the fields are the keys
+// laundered with LaunderFieldNames.
+func InferSchema(records func(yield func(map[string]any) bool), opts *InferOptions) (*Schema, error) {
+	o := InferOptions{MaxStringSize: 254, MaxPrecision: 8}
+	if opts != nil {
+		o = *opts
+		if o.MaxStringSize <= 0 || o.MaxStringSize > 254 {
+			o.MaxStringSize = 254
+		}
+		if o.MaxPrecision <= 0 {
+			o.MaxPrecision = 8
+		}
+	}
+
+	schema := &Schema{}
+	stats := map[string]*columnStats{}
+	var err error
+	n := 0
+	records(func(rec map[string]any) bool {
+		var added []string
+		for k, v := range rec {
+			c, ok := stats[k]
+			if !ok {
+				c = &columnStats{}
+				stats[k] = c
+				added = append(added, k)
+			}
+			if err = c.add(v); err != nil {
+				err = fmt.Errorf("Record %d: key %s: %v", n, k, err)
+				return false
+			}
+		}
+		sort.Strings(added)
+		schema.Keys = append(schema.Keys, added...)
+		n++
+		return o.Sample == 0 || n < o.Sample
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names, _ := LaunderFieldNames(schema.Keys)
+	for i, k := range schema.Keys {
+		f, err := stats[k].field(names[i], &o)
+		if err != nil {
+			return nil, err
+		}
+		schema.Fields = append(schema.Fields, f)
+	}
+	return schema, nil
+}
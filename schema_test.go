@@ -0,0 +1,70 @@
+package shp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// recordsOf returns an iterator over records.
+func recordsOf(records ...map[string]any) func(func(map[string]any) bool) {
+	return func(yield func(map[string]any) bool) {
+		for _, r := range records {
+			if !yield(r) {
+				return
+			}
+		}
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	schema, err := InferSchema(recordsOf(
+		map[string]any{"name": "Springfield", "pop": 30720, "area": 12.5, "capital": false},
+		map[string]any{"name": "Ogdenville", "pop": -1200, "area": 3, "founded": time.Date(1880, 1, 1, 0, 0, 0, 0, time.UTC)},
+		map[string]any{"name": nil, "pop": json.Number("1000000"), "area": 0.125, "code": 7},
+		map[string]any{"code": "A7"},
+	), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Field{
+		FloatField("area", 6, 3),
+		{Name: [11]byte{'c', 'a', 'p', 'i', 't', 'a', 'l'}, Fieldtype: 'L', Size: 1},
+		StringField("name", 11),
+		NumberField("pop", 7),
+		DateField("founded"),
+		StringField("code", 2),
+	}
+	if len(schema.Fields) != len(want) {
+		t.Fatalf("got fields %v, want %v", schema.Fields, want)
+	}
+	for i, f := range want {
+		if schema.Fields[i] != f || schema.Keys[i] != f.String() {
+			t.Errorf("got field %+v for key %s, want %+v", schema.Fields[i], schema.Keys[i], f)
+		}
+	}
+}
+
+func TestInferSchemaOptions(t *testing.T) {
+	records := recordsOf(
+		map[string]any{"v": 1.0 / 3, "s": "abcdef"},
+		map[string]any{"v": "unsupported", "s": struct{}{}},
+	)
+	schema, err := InferSchema(records, &InferOptions{MaxStringSize: 4, MaxPrecision: 2, Padding: 1, Sample: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Field{StringField("s", 4), FloatField("v", 5, 2)}; schema.Fields[0] != want[0] || schema.Fields[1] != want[1] {
+		t.Errorf("got fields %+v, want %+v", schema.Fields, want)
+	}
+	if _, err := InferSchema(records, nil); err == nil {
+		t.Error("inferred a schema for an unsupported value")
+	}
+	schema, err = InferSchema(recordsOf(map[string]any{"population": 1, "populations": 2}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.Fields[1].String(); schema.Keys[1] != "populations" || got != "populati_1" {
+		t.Errorf("got field %s for key %s", got, schema.Keys[1])
+	}
+}
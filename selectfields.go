@@ -0,0 +1,71 @@
+package shp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SelectFields makes the reader return only the attribute fields named names,
+// in that order, as if the DBF had no other fields: Fields returns them and
+// the attribute indices of Attribute, ReadAttribute, AttributesAt and the
+// records of NextBatch refer to them. The values of the other fields are
+// skipped by their byte offset without being decoded, which saves the
+// allocations of wide tables of which only a few columns are needed. Names
+// are matched ignoring case, and opening the DBF fails if a name is not
+// found.
+func SelectFields(names ...string) ReaderOption {
+	return func(o *readerOptions) {
+		o.selectFields = append([]string{}, names...)
+	}
+}
+
+// selectColumns returns the indices of the fields named names in fields, or
+// nil if names is nil.
+func selectColumns(fields []Field, names []string) ([]int, error) {
+	if names == nil {
+		return nil, nil
+	}
+	columns := make([]int, len(names))
+	index := columnIndex(fields)
+	for i, name := range names {
+		col, ok := index[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("No DBF field named %s", name)
+		}
+		columns[i] = col
+	}
+	return columns, nil
+}
+
+// selectedFields returns the fields of columns, or fields if columns is nil.
+func selectedFields(fields []Field, columns []int) []Field {
+	if columns == nil {
+		return fields
+	}
+	out := make([]Field, len(columns))
+	for i, col := range columns {
+		out[i] = fields[col]
+	}
+	return out
+}
+
+// column returns the index in the DBF of the n-th selected field of
+// columns, or -1 if there is none.
+func column(columns []int, n int) int {
+	if columns == nil {
+		return n
+	}
+	if n < 0 || n >= len(columns) {
+		return -1
+	}
+	return columns[n]
+}
+
+// columnIndex maps upper-cased field names to their position in fields.
+func columnIndex(fields []Field) map[string]int {
+	m := make(map[string]int, len(fields))
+	for i, f := range fields {
+		m[strings.ToUpper(f.String())] = i
+	}
+	return m
+}
@@ -0,0 +1,52 @@
+package shp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectFields(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	check := func(name string, fields []Field, attrs []string) {
+		t.Helper()
+		if len(fields) != 2 || fields[0].String() != "AREA" || fields[1].String() != "NAME" {
+			t.Errorf("%s: got fields %v", name, fields)
+		}
+		if len(attrs) != 2 || strings.TrimRight(attrs[0], "\x00") != "12.50" || strings.TrimRight(attrs[1], "\x00") != "Springfield" {
+			t.Errorf("%s: got attributes %q", name, attrs)
+		}
+	}
+
+	r, err := Open(filename+".shp", SelectFields("area", "NAME"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Next()
+	check("Reader", r.Fields(), []string{r.Attribute(0), r.Attribute(1)})
+	if r.Attribute(2) != "" {
+		t.Errorf("got %q for an unselected field", r.Attribute(2))
+	}
+	attrs, err := r.AttributesAt(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("AttributesAt", r.Fields(), attrs)
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t), SelectFields("AREA", "name"))
+	defer sr.Close()
+	batch, err := NextBatch(sr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("SequentialReader", sr.Fields(), batch[0].Attributes)
+
+	sr = SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t), SelectFields("MISSING"))
+	defer sr.Close()
+	if sr.Next() || sr.Err() == nil {
+		t.Error("got no error for a missing field")
+	}
+}
@@ -1,10 +1,11 @@
 package shp
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"time"
 
 	dbf "github.com/brianolson/go-dbf"
 )
@@ -58,6 +59,59 @@ func AttributeCount(sr SequentialReader) int {
 	return len(sr.Fields())
 }
 
+// The following interfaces are implemented by the SequentialReaders of this
+// package, and may be implemented by others. Use a type assertion to check
+// whether a SequentialReader supports them.
+
+// Offsetter is implemented by SequentialReaders that know the position of
+// the current record.
+type Offsetter interface {
+	// Offset returns the byte offset of the current record in the SHP file,
+	// or -1 if no shape has been read.
+	Offset() int64
+}
+
+// Checkpointer is implemented by SequentialReaders that can be resumed with
+// ResumeFrom.
+type Checkpointer interface {
+	// Checkpoint returns the position after the current record, from which
+	// reading can be resumed with ResumeFrom.
+	Checkpoint() CheckpointToken
+}
+
+// BatchReader is implemented by SequentialReaders that read several records
+// at once.
+type BatchReader interface {
+	// NextBatch reads up to n records at once. It returns fewer records at
+	// the end of the file and io.EOF once there are no more records.
+	NextBatch(n int) ([]Record, error)
+}
+
+// MixedTypesReporter is implemented by SequentialReaders that count the
+// records whose shape type differs from the header of the SHP file.
+type MixedTypesReporter interface {
+	// MixedTypes returns how many of the records read so far have each
+	// shape type that differs from the header of the SHP file.
+	MixedTypes() map[ShapeType]int
+}
+
+// WarningReporter is implemented by SequentialReaders that collect
+// non-fatal issues.
+type WarningReporter interface {
+	// Warnings returns the non-fatal issues found so far, unless the
+	// OnWarning option is used.
+	Warnings() []Warning
+}
+
+// NextBatch reads up to n records of sr at once, with its NextBatch method
+// if sr is a BatchReader and by calling Next otherwise.
+func NextBatch(sr SequentialReader, n int) ([]Record, error) {
+	if br, ok := sr.(BatchReader); ok {
+		return br.NextBatch(n)
+	}
+	return readerOptions{}.nextBatch(nil, n, sr, len(sr.Fields()))
+}
+
 // seqReader implements SequentialReader based on external io.ReadCloser
 // instances
 type seqReader struct {
@@ -71,65 +125,191 @@ type seqReader struct {
 	shapetype  ShapeType
 	num        int32
 	filelength int64
+	seekable   bool
+	pos        int64    // bytes consumed from shp
+	offset     int64    // start of the current record
+	records    int32    // number of records read
+	sampler    *sampler // of SampleEveryN or RandomSample, if set
+	batch      []Record
+
+	mixedTypeCounts map[ShapeType]int
+	warnings        []Warning
+	reportedErr     error
+	readerOptions
 
-	db *dbf.Dbf
+	db            *dbf.Dbf
+	dbfNumRecords int32
+	dbfColumns    []int // of SelectFields, nil for all
+
+	// the raw rows are only kept if there are currency fields, whose binary
+	// values the DBF package does not preserve
+	rows            *rowRecorder
+	row             []byte // raw content of the current row
+	dbfRows         int64  // number of rows read
+	dbfHeaderLength int64  // from the DBF header
+	dbfRecordLength int    // from the DBF header
+	dbfOffsets      []int  // of the fields in a raw row
 }
 
 // Read and parse headers in the Shapefile. This will fill out GeometryType,
 // filelength and bbox.
 func (sr *seqReader) readHeaders() {
+	if sr.err != nil {
+		return
+	}
+	if !sr.skipGeometry {
+		sr.readShpHeader()
+		if sr.err != nil {
+			return
+		}
+	}
+
+	// dbf header, a missing DBF means that only the geometry is read
+	if sr.dbf == nil || sr.skipAttributes {
+		return
+	}
+	// peek at the number of records and the lengths of the header and the
+	// records before handing the header to dbf
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(sr.dbf, header); err != nil {
+		sr.err = fmt.Errorf("Error reading dbf: %v", err)
+		return
+	}
+	sr.dbfNumRecords = int32(binary.LittleEndian.Uint32(header[4:]))
+	sr.dbfHeaderLength = int64(binary.LittleEndian.Uint16(header[8:]))
+	sr.dbfRecordLength = int(binary.LittleEndian.Uint16(header[10:]))
+	sr.debug("read DBF header", "records", sr.dbfNumRecords)
+	sr.rows = &rowRecorder{r: sr.dbf, start: int64(len(header))}
+	var err error
+	sr.db, err = dbf.NewDbf(io.MultiReader(bytes.NewReader(header), sr.rows))
+	if err != nil {
+		sr.err = fmt.Errorf("Error reading dbf: %v", err)
+		return
+	}
+	sr.recordCurrency()
+	if sr.dbfColumns, err = selectColumns(sr.allFields(), sr.selectFields); err != nil {
+		sr.err = err
+	}
+}
+
+// readShpHeader reads the header of the SHP file.
+func (sr *seqReader) readShpHeader() {
 	// contrary to Reader.readHeaders we cannot seek with the ReadCloser, so we
 	// need to trust the filelength in the header
 
 	er := &errReader{Reader: sr.shp}
 	// shp headers
-	io.CopyN(ioutil.Discard, er, 24)
+	io.CopyN(io.Discard, er, 24)
 	var l int32
 	binary.Read(er, binary.BigEndian, &l)
 	sr.filelength = int64(l) * 2
-	io.CopyN(ioutil.Discard, er, 4)
+	io.CopyN(io.Discard, er, 4)
 	binary.Read(er, binary.LittleEndian, &sr.geometryType)
 	sr.bbox.MinX = readFloat64(er)
 	sr.bbox.MinY = readFloat64(er)
 	sr.bbox.MaxX = readFloat64(er)
 	sr.bbox.MaxY = readFloat64(er)
-	io.CopyN(ioutil.Discard, er, 32) // skip four float64: Zmin, Zmax, Mmin, Max
+	io.CopyN(io.Discard, er, 32) // skip four float64: Zmin, Zmax, Mmin, Max
 	if er.e != nil {
 		sr.err = fmt.Errorf("Error when reading SHP header: %v", er.e)
-		return
-	}
-
-	// dbf header
-	var err error
-	sr.db, err = dbf.NewDbf(sr.dbf)
-	if err != nil {
-		sr.err = fmt.Errorf("Error reading dbf: %v", err)
-		return
 	}
+	sr.pos = er.n
+	sr.debug("read SHP header", "type", sr.geometryType, "bbox", sr.bbox, "length", sr.filelength)
 }
 
 // Next implements a method of interface SequentialReader for seqReader.
 func (sr *seqReader) Next() bool {
+	if sr.metrics == nil {
+		return sr.next()
+	}
+	start, pos := time.Now(), sr.pos
+	ok := sr.next()
+	sr.observeNext(ok, sr.err, &sr.reportedErr, start, sr.pos-pos)
+	return ok
+}
+
+// next implements Next.
+func (sr *seqReader) next() bool {
 	if sr.err != nil {
 		return false
 	}
+	if sr.skipGeometry {
+		return sr.nextRow()
+	}
 	var num, size int32
+	var er *errReader
+	for {
+		// read shape
+		er = &errReader{Reader: sr.shp}
+		binary.Read(er, binary.BigEndian, &num)
+		binary.Read(er, binary.BigEndian, &size)
+		binary.Read(er, binary.LittleEndian, &sr.shapetype)
 
-	// read shape
-	er := &errReader{Reader: sr.shp}
-	binary.Read(er, binary.BigEndian, &num)
-	binary.Read(er, binary.BigEndian, &size)
-	binary.Read(er, binary.LittleEndian, &sr.shapetype)
-
-	if er.e != nil {
-		if er.e != io.EOF {
-			sr.err = fmt.Errorf("Error when reading shapefile header: %v", er.e)
-		} else {
-			sr.err = io.EOF
+		if er.e != nil {
+			if er.e != io.EOF {
+				sr.err = fmt.Errorf("Error when reading shapefile header: %w", er.e)
+			} else {
+				sr.err = io.EOF
+			}
+			return false
+		}
+		if err := sr.limits.checkRecordSize(int64(size) * 2); err != nil {
+			sr.err = fmt.Errorf("Error when reading shapefile header: %w", err)
+			return false
+		}
+		if sr.sampler != nil && !sr.sampler.take(int(sr.records)) {
+			if !sr.skipRecord(int64(size)*2+8, er.n) {
+				return false
+			}
+			continue
+		}
+		skip, err := sr.checkShapeType(&sr.mixedTypeCounts, &sr.warnings, sr.geometryType, sr.shapetype, int(num)-1)
+		if err != nil {
+			sr.err = fmt.Errorf("Error when reading shapefile header: %w", err)
+			return false
+		}
+		if !skip {
+			break
+		}
+		if !sr.skipRecord(int64(size)*2+8, er.n) {
+			return false
 		}
-		return false
 	}
+	er.size = int64(size)*2 + 8
+	er.limits = sr.limits
+	er.pooled = sr.pooled
 	sr.num = num
+	if sr.lazy {
+		l, err := sr.readLazy(er, sr.shapetype, int64(size)*2)
+		if err != nil {
+			sr.err = fmt.Errorf("Error while reading next shape: %w", err)
+			return false
+		}
+		sr.shape = l.reportTo(&sr.warnings, int(sr.num)-1)
+	} else if !sr.decodeShape(er) {
+		return false
+	}
+	skipBytes := int64(size)*2 + 8 - er.n
+	if ce := sr.skip(skipBytes); ce != nil {
+		sr.err = fmt.Errorf("Error when discarding bytes on sequential read: %v", ce)
+		return false
+	}
+	sr.offset = sr.pos
+	sr.pos += int64(size)*2 + 8
+	sr.records++
+	if sr.db != nil {
+		err := sr.nextDbfRow()
+		if err != nil {
+			sr.err = fmt.Errorf("Error when reading DBF row: %v", err)
+			return false
+		}
+	}
+	return sr.err == nil
+}
+
+// decodeShape decodes a shape of the current shape type from er into
+// sr.shape.
+func (sr *seqReader) decodeShape(er *errReader) bool {
 	var err error
 	sr.shape, err = newShape(sr.shapetype)
 	if err != nil {
@@ -144,27 +324,130 @@ func (sr *seqReader) Next() bool {
 		// iterating over all shapes.
 		er.e = nil
 	case er.e != nil:
-		sr.err = fmt.Errorf("Error while reading next shape: %v", er.e)
+		sr.err = fmt.Errorf("Error while reading next shape: %w", er.e)
 		return false
 	}
-	skipBytes := int64(size)*2 + 8 - er.n
-	_, ce := io.CopyN(ioutil.Discard, er, skipBytes)
-	if er.e != nil {
-		sr.err = er.e
-		return false
+	sr.decodeMeasures(sr.shape)
+	if sr.transformer != nil {
+		TransformShape(sr.shape, sr.transformer)
 	}
-	if ce != nil {
-		sr.err = fmt.Errorf("Error when discarding bytes on sequential read: %v", ce)
+	sr.validateShape(&sr.warnings, int(sr.num)-1, sr.shape)
+	if sr.float32Coords {
+		sr.shape = toShape32(sr.shape)
+	}
+	return true
+}
+
+// skipRecord discards the rest of the current record of size bytes, of which
+// consumed bytes have been read, together with its DBF row.
+func (sr *seqReader) skipRecord(size, consumed int64) bool {
+	if err := sr.skip(size - consumed); err != nil {
+		sr.err = fmt.Errorf("Error when discarding bytes on sequential read: %v", err)
 		return false
 	}
+	sr.pos += size
+	sr.records++
 	if sr.db != nil {
-		err := sr.db.Next()
-		if err != nil {
+		if err := sr.nextDbfRow(); err != nil {
 			sr.err = fmt.Errorf("Error when reading DBF row: %v", err)
 			return false
 		}
 	}
-	return sr.err == nil
+	return true
+}
+
+// nextRow advances to the next DBF row without reading any shape.
+func (sr *seqReader) nextRow() bool {
+	for {
+		if sr.db == nil || sr.num >= sr.dbfNumRecords {
+			sr.err = io.EOF
+			return false
+		}
+		if err := sr.nextDbfRow(); err != nil {
+			sr.err = fmt.Errorf("Error when reading DBF row: %v", err)
+			return false
+		}
+		sr.num++
+		sr.records++
+		if sr.sampler == nil || sr.sampler.take(int(sr.num)-1) {
+			return true
+		}
+	}
+}
+
+// recordCurrency keeps recording the raw DBF rows if there are currency
+// fields, and stops otherwise.
+func (sr *seqReader) recordCurrency() {
+	currency := false
+	pos := 1 // after the deletion flag
+	for _, f := range sr.db.Fields {
+		sr.dbfOffsets = append(sr.dbfOffsets, pos)
+		pos += int(f.Length)
+		currency = currency || f.Type == 'Y'
+	}
+	if !currency {
+		sr.rows.off, sr.rows.buf = true, nil
+		sr.rows = nil
+	}
+}
+
+// nextDbfRow advances the DBF file by one row.
+func (sr *seqReader) nextDbfRow() error {
+	if err := sr.db.Next(); err != nil {
+		return err
+	}
+	if sr.rows != nil {
+		offset := sr.dbfHeaderLength + sr.dbfRows*int64(sr.dbfRecordLength)
+		sr.row = sr.rows.row(offset, sr.dbfRecordLength)
+	}
+	sr.dbfRows++
+	return nil
+}
+
+// rowRecorder records the bytes that the DBF package reads from r, so that
+// the raw rows are available even if it reads ahead. The bytes before the
+// last row returned by row are discarded.
+type rowRecorder struct {
+	r     io.Reader
+	buf   []byte
+	start int64 // offset of buf[0] in the DBF file
+	off   bool  // stops recording
+}
+
+func (rr *rowRecorder) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if !rr.off {
+		rr.buf = append(rr.buf, p[:n]...)
+	}
+	return n, err
+}
+
+// row returns the length bytes at offset in the DBF file, or nil if they
+// were not read.
+func (rr *rowRecorder) row(offset int64, length int) []byte {
+	skip := offset - rr.start
+	if skip < 0 || skip > int64(len(rr.buf)) {
+		return nil
+	}
+	rr.buf, rr.start = rr.buf[skip:], offset
+	if len(rr.buf) < length {
+		return nil
+	}
+	return rr.buf[:length]
+}
+
+// skip discards the next n bytes of the SHP file. If the file supports
+// seeking, this is done without reading the bytes.
+func (sr *seqReader) skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if sr.seekable {
+		_, err := sr.shp.(io.Seeker).Seek(n, io.SeekCurrent)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, sr.shp, n)
+	return err
 }
 
 // Shape implements a method of interface SequentialReader for seqReader.
@@ -183,9 +466,35 @@ func (sr *seqReader) Attribute(n int) string {
 	if sr.err != nil {
 		return ""
 	}
+	if numFields := sr.numFields(); n >= numFields && len(sr.virtualFields) > 0 {
+		index, s := sr.Shape()
+		return sr.virtualAttribute(n-numFields, index, s, numFields, sr.Attribute)
+	}
+	if sr.db == nil {
+		return ""
+	}
+	if n = column(sr.dbfColumns, n); n < 0 || n >= len(sr.db.Fields) {
+		return ""
+	}
+	if sr.db.Fields[n].Type == 'Y' {
+		// the value is binary, which the DBF package may have trimmed
+		pos := sr.dbfOffsets[n]
+		if pos+8 > len(sr.row) {
+			return ""
+		}
+		return decodeCurrency(sr.row[pos : pos+8])
+	}
 	return sr.db.Fields[n].StringValue()
 }
 
+// Offset implements Offsetter for seqReader.
+func (sr *seqReader) Offset() int64 {
+	if sr.shape == nil {
+		return -1
+	}
+	return sr.offset
+}
+
 // Err returns the first non-EOF error that was encountered.
 func (sr *seqReader) Err() error {
 	if sr.err == io.EOF {
@@ -196,8 +505,13 @@ func (sr *seqReader) Err() error {
 
 // Close closes the seqReader and free all the allocated resources.
 func (sr *seqReader) Close() error {
-	if err := sr.shp.Close(); err != nil {
-		return err
+	if sr.shp != nil {
+		if err := sr.shp.Close(); err != nil {
+			return err
+		}
+	}
+	if sr.dbf == nil {
+		return nil
 	}
 	if err := sr.dbf.Close(); err != nil {
 		return err
@@ -207,6 +521,25 @@ func (sr *seqReader) Close() error {
 
 // Fields returns a slice of the fields that are present in the DBF table.
 func (sr *seqReader) Fields() []Field {
+	if sr.db == nil {
+		return sr.withVirtual(nil)
+	}
+	return sr.withVirtual(selectedFields(sr.allFields(), sr.dbfColumns))
+}
+
+// numFields returns the number of selected fields of the DBF table.
+func (sr *seqReader) numFields() int {
+	switch {
+	case sr.db == nil:
+		return 0
+	case sr.dbfColumns != nil:
+		return len(sr.dbfColumns)
+	}
+	return len(sr.db.Fields)
+}
+
+// allFields returns all fields of the DBF table, regardless of SelectFields.
+func (sr *seqReader) allFields() []Field {
 	out := make([]Field, len(sr.db.Fields))
 	for i, field := range sr.db.Fields {
 		out[i] = Field{
@@ -224,9 +557,33 @@ func (sr *seqReader) Db() *dbf.Dbf {
 }
 
 // SequentialReaderFromExt returns a new SequentialReader that interprets shp
-// as a source of shapes whose attributes can be retrieved from dbf.
-func SequentialReaderFromExt(shp, dbf io.ReadCloser) SequentialReader {
-	sr := &seqReader{shp: shp, dbf: dbf}
+// as a source of shapes whose attributes can be retrieved from dbf. If dbf is
+// nil, only the shapes are read and there are no attributes. With
+// SkipGeometry, shp may be nil. Files compressed with gzip, or a format added
+// with RegisterDecompressor, are decompressed transparently.
+func SequentialReaderFromExt(shp, dbf io.ReadCloser, opts ...ReaderOption) SequentialReader {
+	sr := newSeqReader(shp, dbf, opts)
 	sr.readHeaders()
 	return sr
 }
+
+func newSeqReader(shp, dbf io.ReadCloser, opts []ReaderOption) *seqReader {
+	sr := &seqReader{shp: shp, dbf: dbf, readerOptions: newReaderOptions(opts)}
+	sr.sampler = sr.sampling.newSampler()
+	if shp != nil {
+		if sr.shp, sr.err = decompress(shp); sr.err != nil {
+			sr.shp = shp
+			return sr
+		}
+	}
+	if dbf != nil {
+		if sr.dbf, sr.err = decompress(dbf); sr.err != nil {
+			sr.dbf = dbf
+			return sr
+		}
+	}
+	if s, ok := sr.shp.(io.Seeker); ok {
+		sr.seekable = isSeekable(s)
+	}
+	return sr
+}
@@ -1,7 +1,9 @@
 package shp
 
 import (
+	"io"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -41,3 +43,96 @@ func TestSequentialReader(t *testing.T) {
 		testshapeIdentity(t, prefix, getShapesSequentially)
 	}
 }
+
+// onlyReadCloser hides any Seek method of the wrapped file.
+type onlyReadCloser struct {
+	io.ReadCloser
+}
+
+func getShapesSequentiallyNoSeek(prefix string, t *testing.T) (shapes []Shape) {
+	shp := onlyReadCloser{openFile(prefix+".shp", t)}
+	dbf := onlyReadCloser{openFile(prefix+".dbf", t)}
+
+	sr := SequentialReaderFromExt(shp, dbf)
+	if sr.(*seqReader).seekable {
+		t.Fatal("reader without Seek method is considered seekable")
+	}
+	for sr.Next() {
+		_, shape := sr.Shape()
+		shapes = append(shapes, shape)
+	}
+	if err := sr.Err(); err != nil {
+		t.Errorf("Error when iterating over the shapes: %v", err)
+	}
+	sr.Close()
+	return shapes
+}
+
+func TestSequentialReaderNoSeek(t *testing.T) {
+	for prefix := range dataForReadTests {
+		testshapeIdentity(t, prefix, getShapesSequentiallyNoSeek)
+	}
+}
+
+func TestSequentialReaderSkipPadding(t *testing.T) {
+	// a point record whose declared length includes 8 bytes of padding
+	record := []byte{
+		0, 0, 0, 1, // number
+		0, 0, 0, 14, // length in 16-bit words
+		1, 0, 0, 0, // shape type
+		0, 0, 0, 0, 0, 0, 0xf0, 0x3f, // X = 1
+		0, 0, 0, 0, 0, 0, 0, 0x40, // Y = 2
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // padding
+	}
+	record = append(record, record...)
+	record[len(record)/2+3] = 2
+	for _, seekable := range []bool{true, false} {
+		sr := &seqReader{shp: newReadSeekCloser(record), seekable: seekable}
+		n := 0
+		for sr.Next() {
+			if _, s := sr.Shape(); *s.(*Point) != (Point{1, 2}) {
+				t.Errorf("got %v, want {1 2}", s)
+			}
+			n++
+		}
+		if n != 2 || sr.Err() != nil {
+			t.Errorf("seekable=%v: got %d shapes, error %v", seekable, n, sr.Err())
+		}
+	}
+}
+
+func TestSequentialReaderOffset(t *testing.T) {
+	sr := SequentialReaderFromExt(openFile("test_files/point.shp", t), openFile("test_files/point.dbf", t))
+	defer sr.Close()
+	o, ok := sr.(Offsetter)
+	if !ok {
+		t.Fatalf("%T does not implement Offsetter", sr)
+	}
+	if o.Offset() != -1 {
+		t.Errorf("got offset %d before first record, want -1", o.Offset())
+	}
+	var offsets []int64
+	for sr.Next() {
+		offsets = append(offsets, o.Offset())
+	}
+	want := []int64{100, 128, 156}
+	if !reflect.DeepEqual(offsets, want) {
+		t.Fatalf("got offsets %v, want %v", offsets, want)
+	}
+
+	r, err := Open("test_files/point.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.SeekRecord(offsets[2]); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Next() {
+		t.Fatal(r.Err())
+	}
+	n, s := r.Shape()
+	if n != 2 || *s.(*Point) != (Point{0, 10}) || r.Offset() != offsets[2] {
+		t.Errorf("got shape %d %v at offset %d after SeekRecord", n, s, r.Offset())
+	}
+}
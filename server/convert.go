@@ -0,0 +1,331 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// DefaultMaxUploadSize is the MaxUploadSize of a ConvertHandler if none is
+// set.
+const DefaultMaxUploadSize = 512 << 20
+
+// ConvertHandler is an http.Handler that converts a zipped shapefile, POSTed
+// as the request body or as the first file of a multipart form, to GeoJSON or
+// CSV. The format is chosen by the f query parameter, "geojson" or "csv", or
+// else negotiated from the Accept header, GeoJSON being the default. If the
+// archive holds several shapefiles, the layer query parameter names the one
+// to convert. The locale query parameter, a language tag like "de-DE",
+// formats numbers and dates by the conventions of that locale, see Locales;
+// otherwise Format is used.
+//
+// The upload is spooled to a temporary file, as ZIP archives need random
+// access, and the converted features are streamed while they are read. An
+// error after the first feature was written can only be signalled by
+// truncating the response.
+//
+// A converter service is:
+//
+//	http.Handle("/convert", &server.ConvertHandler{})
+//	log.Fatal(http.ListenAndServe(":8080", nil))
+type ConvertHandler struct {
+	// MaxUploadSize limits the size of uploads in bytes. If zero,
+	// DefaultMaxUploadSize is used.
+	MaxUploadSize int64
+	// Format formats numbers and dates when no locale is requested.
+	Format Format
+	// GeoJSON tunes the GeoJSON output.
+	GeoJSON GeoJSONOptions
+}
+
+const (
+	formatGeoJSON = "geojson"
+	formatCSV     = "csv"
+)
+
+// ServeHTTP implements http.Handler.
+func (h *ConvertHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format := negotiate(r)
+	if format == "" {
+		http.Error(w, "GeoJSON or CSV must be acceptable", http.StatusNotAcceptable)
+		return
+	}
+	nf := h.Format
+	if name := r.URL.Query().Get("locale"); name != "" {
+		var ok bool
+		if nf, ok = LookupLocale(name); !ok {
+			http.Error(w, fmt.Sprintf("unknown locale %q", name), http.StatusBadRequest)
+			return
+		}
+	}
+	path, err := h.spool(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer os.Remove(path)
+
+	var zr *shp.ZipReader
+	if layer := r.URL.Query().Get("layer"); layer != "" {
+		zr, err = shp.OpenShapeFromZip(path, layer)
+	} else {
+		zr, err = shp.OpenZip(path)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	defer zr.Close()
+
+	if format == formatCSV {
+		writeCSV(w, zr, &nf)
+	} else {
+		writeFeatureCollection(w, zr, &nf, &h.GeoJSON)
+	}
+}
+
+// spool copies the uploaded archive to a temporary file and returns its path.
+func (h *ConvertHandler) spool(w http.ResponseWriter, r *http.Request) (string, error) {
+	limit := h.MaxUploadSize
+	if limit <= 0 {
+		limit = DefaultMaxUploadSize
+	}
+	// wrap the body itself, which MultipartReader reads from
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	var body io.Reader = r.Body
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return "", err
+		}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return "", fmt.Errorf("No file in upload: %v", err)
+			}
+			if part.FileName() != "" {
+				body = part
+				break
+			}
+		}
+	}
+	f, err := os.CreateTemp("", "shp-upload-*.zip")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, body)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("Failed to read upload: %v", err)
+	}
+	return f.Name(), nil
+}
+
+// negotiate returns the format of the response, or "" if neither is
+// acceptable.
+func negotiate(r *http.Request) string {
+	switch f := r.URL.Query().Get("f"); f {
+	case formatGeoJSON, formatCSV:
+		return f
+	case "":
+	default:
+		return ""
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatGeoJSON
+	}
+	format, best := "", 0.0
+	for _, item := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		var f string
+		switch mt {
+		case "application/geo+json", "application/json", "application/*", "*/*":
+			f = formatGeoJSON
+		case "text/csv", "text/*":
+			f = formatCSV
+		default:
+			continue
+		}
+		if q > best {
+			format, best = f, q
+		}
+	}
+	return format
+}
+
+// records are read sequentially, by a shp.ZipReader for example.
+type records interface {
+	record
+	Next() bool
+	Fields() []shp.Field
+	Err() error
+}
+
+// writeFeatureCollection streams the records of sr as a GeoJSON feature
+// collection, with dates formatted by nf and the features tuned by opts.
+func writeFeatureCollection(w http.ResponseWriter, sr records, nf *Format, opts *GeoJSONOptions) {
+	fields := sr.Fields()
+	idIndex, err := opts.idIndex(fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	io.WriteString(w, `{"type":"FeatureCollection",`)
+	if opts.CRS != "" && !opts.RFC7946 {
+		name, _ := json.Marshal(opts.CRS)
+		fmt.Fprintf(w, `"crs":{"type":"name","properties":{"name":%s}},`, name)
+	}
+	io.WriteString(w, `"features":[`)
+	for n := 0; sr.Next(); n++ {
+		f := toFeature(sr, fields)
+		for _, fd := range fields {
+			if v, ok := f.Properties[fd.String()].(string); ok && fd.Fieldtype == 'D' {
+				f.Properties[fd.String()] = nf.date(v)
+			}
+		}
+		data, err := json.Marshal(opts.apply(f, fields, idIndex))
+		if err != nil {
+			return
+		}
+		if n > 0 {
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "\n")
+		if _, err := w.Write(data); err != nil {
+			return
+		}
+	}
+	if sr.Err() != nil {
+		return
+	}
+	io.WriteString(w, "\n]}\n")
+}
+
+// writeCSV streams the records of sr as CSV with a column for every field and
+// the geometry as WKT in the last column. Numbers and dates are formatted by
+// nf.
+func writeCSV(w http.ResponseWriter, sr records, nf *Format) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	fields := sr.Fields()
+	cw := csv.NewWriter(w)
+	cw.Comma = nf.delimiter()
+	row := make([]string, len(fields)+1)
+	for i, f := range fields {
+		row[i] = f.String()
+	}
+	row[len(fields)] = "WKT"
+	cw.Write(row)
+	for sr.Next() {
+		for i := range fields {
+			v := strings.TrimRight(strings.TrimSpace(sr.Attribute(i)), "\x00")
+			row[i] = nf.attribute(fields[i].Fieldtype, v)
+		}
+		_, shape := sr.Shape()
+		row[len(fields)] = wkt(toGeometry(shape))
+		if err := cw.Write(row); err != nil {
+			return
+		}
+	}
+	if sr.Err() == nil {
+		cw.Flush()
+	}
+}
+
+// wkt returns the well-known text of g, or "" if g is nil.
+func wkt(g *geometry) string {
+	if g == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(g.Type))
+	if hasZ(g.Coordinates) {
+		b.WriteString(" Z")
+	}
+	b.WriteString(" ")
+	if p, ok := g.Coordinates.([]float64); ok {
+		b.WriteString("(")
+		writeCoords(&b, p)
+		b.WriteString(")")
+	} else {
+		writeCoords(&b, g.Coordinates)
+	}
+	return b.String()
+}
+
+// writeCoords writes the nested coordinates c in WKT notation.
+func writeCoords(b *strings.Builder, c interface{}) {
+	var items []interface{}
+	switch c := c.(type) {
+	case []float64:
+		for i, v := range c {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return
+	case [][]float64:
+		for _, v := range c {
+			items = append(items, v)
+		}
+	case [][][]float64:
+		for _, v := range c {
+			items = append(items, v)
+		}
+	case [][][][]float64:
+		for _, v := range c {
+			items = append(items, v)
+		}
+	}
+	b.WriteString("(")
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeCoords(b, item)
+	}
+	b.WriteString(")")
+}
+
+// hasZ returns whether the first position of c has a z value.
+func hasZ(c interface{}) bool {
+	switch c := c.(type) {
+	case []float64:
+		return len(c) > 2
+	case [][]float64:
+		return len(c) > 0 && hasZ(c[0])
+	case [][][]float64:
+		return len(c) > 0 && hasZ(c[0])
+	case [][][][]float64:
+		return len(c) > 0 && hasZ(c[0])
+	}
+	return false
+}
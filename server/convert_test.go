@@ -0,0 +1,222 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func zippedCities(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w, err := shp.CreateZip(&buf, "cities", shp.POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]shp.Field{shp.StringField("NAME", 20), shp.NumberField("POP", 10)})
+	w.Write(&shp.Point{X: 1, Y: 2})
+	w.WriteAttribute(0, 0, "Springfield")
+	w.WriteAttribute(0, 1, 30000)
+	w.Write(&shp.Point{X: 3.5, Y: 4})
+	w.WriteAttribute(1, 0, "Shelbyville")
+	w.WriteAttribute(1, 1, 25000)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func convert(t *testing.T, url, accept string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/zip")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	(&ConvertHandler{}).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConvertGeoJSON(t *testing.T) {
+	rec := convert(t, "/convert", "", zippedCities(t))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/geo+json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var fc featureCollection
+	if err := json.Unmarshal(rec.Body.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 2 {
+		t.Fatalf("got %+v", fc)
+	}
+	if name := fc.Features[1].Properties["NAME"]; name != "Shelbyville" {
+		t.Errorf("NAME = %v", name)
+	}
+}
+
+func TestConvertCSV(t *testing.T) {
+	for _, c := range []struct{ url, accept string }{
+		{"/convert", "application/xml, text/csv;q=0.9, application/json;q=0.5"},
+		{"/convert?f=csv", "application/geo+json"},
+	} {
+		rec := convert(t, c.url, c.accept, zippedCities(t))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		rows, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [][]string{
+			{"NAME", "POP", "WKT"},
+			{"Springfield", "30000", "POINT (1 2)"},
+			{"Shelbyville", "25000", "POINT (3.5 4)"},
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("%s, %s: got %q", c.url, c.accept, rows)
+		}
+	}
+}
+
+func TestConvertMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("comment", "ignored")
+	fw, _ := mw.CreateFormFile("file", "cities.zip")
+	fw.Write(zippedCities(t))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/convert?f=geojson", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	(&ConvertHandler{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	if rec := convert(t, "/convert", "image/png", zippedCities(t)); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("image/png: status %d", rec.Code)
+	}
+	if rec := convert(t, "/convert", "", []byte("not a zip")); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid archive: status %d", rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	(&ConvertHandler{MaxUploadSize: 10}).ServeHTTP(rec, httptest.NewRequest("POST", "/convert", bytes.NewReader(zippedCities(t))))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("too large: status %d", rec.Code)
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "cities.zip")
+	fw.Write(zippedCities(t))
+	mw.Close()
+	req := httptest.NewRequest("POST", "/convert", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec = httptest.NewRecorder()
+	(&ConvertHandler{MaxUploadSize: 100}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "too large") {
+		t.Errorf("too large multipart: status %d: %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	(&ConvertHandler{}).ServeHTTP(rec, httptest.NewRequest("GET", "/convert", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d", rec.Code)
+	}
+}
+
+func TestWKT(t *testing.T) {
+	for _, c := range []struct {
+		shape shp.Shape
+		want  string
+	}{
+		{&shp.PointZ{X: 1, Y: 2, Z: 3}, "POINT Z (1 2 3)"},
+		{shp.NewPolyLine([][]shp.Point{{{X: 0, Y: 0}, {X: 1, Y: 1}}}), "LINESTRING (0 0, 1 1)"},
+		{&shp.MultiPoint{Points: []shp.Point{{X: 0, Y: 0}, {X: 1, Y: 1}}}, "MULTIPOINT (0 0, 1 1)"},
+		{shp.NewPolygon([][]shp.Point{{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 0, Y: 0}}}), "POLYGON ((0 0, 0 1, 1 1, 0 0))"},
+		{&shp.Null{}, ""},
+	} {
+		if got := wkt(toGeometry(c.shape)); got != c.want {
+			t.Errorf("wkt = %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestConvertGeoJSONOptions(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := shp.CreateZip(&buf, "roads", shp.POLYLINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]shp.Field{shp.StringField("NAME", 20), shp.NumberField("ROAD_ID", 10)})
+	w.Write(shp.NewPolyLine([][]shp.Point{{{X: 1.23456, Y: 2}, {X: 3, Y: -4.98765}}}))
+	w.WriteAttribute(0, 0, "Main Street")
+	w.WriteAttribute(0, 1, 17)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &ConvertHandler{GeoJSON: GeoJSONOptions{
+		Precision: 2,
+		BBox:      true,
+		IDField:   "road_id",
+		CRS:       "urn:ogc:def:crs:EPSG::3857",
+	}}
+	req := httptest.NewRequest("POST", "/convert", bytes.NewReader(buf.Bytes()))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var fc struct {
+		CRS struct {
+			Properties struct{ Name string }
+		}
+		Features []struct {
+			ID       interface{}
+			BBox     []float64
+			Geometry geometry
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	if fc.CRS.Properties.Name != "urn:ogc:def:crs:EPSG::3857" {
+		t.Errorf("crs = %+v", fc.CRS)
+	}
+	if len(fc.Features) != 1 {
+		t.Fatalf("got %d features", len(fc.Features))
+	}
+	f := fc.Features[0]
+	if f.ID != 17.0 {
+		t.Errorf("id = %v, want 17", f.ID)
+	}
+	if want := []float64{1.23, -4.99, 3, 2}; !reflect.DeepEqual(f.BBox, want) {
+		t.Errorf("bbox = %v, want %v", f.BBox, want)
+	}
+	want := []interface{}{[]interface{}{1.23, 2.0}, []interface{}{3.0, -4.99}}
+	if !reflect.DeepEqual(f.Geometry.Coordinates, want) {
+		t.Errorf("coordinates = %v, want %v", f.Geometry.Coordinates, want)
+	}
+
+	h.GeoJSON = GeoJSONOptions{IDField: "MISSING"}
+	req = httptest.NewRequest("POST", "/convert", bytes.NewReader(buf.Bytes()))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("missing id field: status %d", rec.Code)
+	}
+}
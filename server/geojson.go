@@ -0,0 +1,262 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// geometry is a GeoJSON geometry object.
+type geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// feature is a GeoJSON feature object.
+type feature struct {
+	Type       string                 `json:"type"`
+	ID         int                    `json:"id"`
+	BBox       []float64              `json:"bbox,omitempty"`
+	Geometry   *geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+func coord(p shp.Point, z []float64, i int) []float64 {
+	if z != nil && i < len(z) {
+		return []float64{p.X, p.Y, z[i]}
+	}
+	return []float64{p.X, p.Y}
+}
+
+// rings splits points into its parts, keeping the z values if z is not nil.
+func rings(parts []int32, points []shp.Point, z []float64) [][][]float64 {
+	out := make([][][]float64, 0, len(parts))
+	for i := range parts {
+		start, end := int(parts[i]), len(points)
+		if i+1 < len(parts) {
+			end = int(parts[i+1])
+		}
+		if start < 0 || end > len(points) || start > end {
+			continue
+		}
+		ring := make([][]float64, 0, end-start)
+		for j := start; j < end; j++ {
+			ring = append(ring, coord(points[j], z, j))
+		}
+		out = append(out, ring)
+	}
+	return out
+}
+
+// clockwise returns whether ring is an outer ring of a shapefile polygon.
+func clockwise(ring [][]float64) bool {
+	var area float64
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area < 0
+}
+
+func lines(parts []int32, points []shp.Point, z []float64) *geometry {
+	rs := rings(parts, points, z)
+	if len(rs) == 1 {
+		return &geometry{"LineString", rs[0]}
+	}
+	return &geometry{"MultiLineString", rs}
+}
+
+// polygons groups the rings of a shapefile polygon into GeoJSON polygons.
+// Every clockwise ring starts a new polygon, the following counterclockwise
+// rings are its holes.
+func polygons(parts []int32, points []shp.Point, z []float64) *geometry {
+	var polys [][][][]float64
+	for _, ring := range rings(parts, points, z) {
+		if clockwise(ring) || len(polys) == 0 {
+			polys = append(polys, [][][]float64{ring})
+		} else {
+			polys[len(polys)-1] = append(polys[len(polys)-1], ring)
+		}
+	}
+	if len(polys) == 1 {
+		return &geometry{"Polygon", polys[0]}
+	}
+	return &geometry{"MultiPolygon", polys}
+}
+
+func multiPoint(points []shp.Point, z []float64) *geometry {
+	coords := make([][]float64, len(points))
+	for i, p := range points {
+		coords[i] = coord(p, z, i)
+	}
+	return &geometry{"MultiPoint", coords}
+}
+
+// toGeometry converts s to a GeoJSON geometry. Null shapes and multipatches
+// have no GeoJSON equivalent and yield nil.
+func toGeometry(s shp.Shape) *geometry {
+	switch p := s.(type) {
+	case *shp.Point:
+		return &geometry{"Point", []float64{p.X, p.Y}}
+	case *shp.PointZ:
+		return &geometry{"Point", []float64{p.X, p.Y, p.Z}}
+	case *shp.PointM:
+		return &geometry{"Point", []float64{p.X, p.Y}}
+	case *shp.MultiPoint:
+		return multiPoint(p.Points, nil)
+	case *shp.MultiPointZ:
+		return multiPoint(p.Points, p.ZArray)
+	case *shp.MultiPointM:
+		return multiPoint(p.Points, nil)
+	case *shp.PolyLine:
+		return lines(p.Parts, p.Points, nil)
+	case *shp.PolyLineZ:
+		return lines(p.Parts, p.Points, p.ZArray)
+	case *shp.PolyLineM:
+		return lines(p.Parts, p.Points, nil)
+	case *shp.Polygon:
+		return polygons(p.Parts, p.Points, nil)
+	case *shp.PolygonZ:
+		return polygons(p.Parts, p.Points, p.ZArray)
+	case *shp.PolygonM:
+		return polygons(p.Parts, p.Points, nil)
+	default:
+		return nil
+	}
+}
+
+// property converts the DBF value v of field f to a JSON value. Numbers and
+// logicals that cannot be parsed are kept as strings, empty values are null.
+func property(f shp.Field, v string) interface{} {
+	v = strings.TrimRight(strings.TrimSpace(v), "\x00")
+	if v == "" {
+		return nil
+	}
+	switch f.Fieldtype {
+	case 'N', 'F':
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case 'L':
+		switch v {
+		case "T", "t", "Y", "y":
+			return true
+		case "F", "f", "N", "n":
+			return false
+		}
+	}
+	return v
+}
+
+// GeoJSONOptions tune the GeoJSON written by a ConvertHandler, as consumers
+// are picky about different details. The zero value writes coordinates with
+// full precision, no bounding boxes, the record numbers as feature ids and no
+// crs member.
+type GeoJSONOptions struct {
+	// Precision is the number of decimal places that coordinates are
+	// rounded to, if it is positive.
+	Precision int
+	// BBox adds the bounding box of its geometry to every feature.
+	BBox bool
+	// IDField names the field, matched case-insensitively, whose values are
+	// the ids of the features instead of the record numbers. Values of
+	// numeric fields are numbers, others strings.
+	IDField string
+	// CRS is the name of the coordinate reference system, like
+	// "urn:ogc:def:crs:EPSG::3857", for the crs member of the feature
+	// collection. The member was dropped by RFC 7946, which only allows
+	// WGS 84, but older clients need it for other systems. It is omitted
+	// if CRS is "" or RFC7946 is set.
+	CRS string
+	// RFC7946 splits lines and polygons that cross the antimeridian and
+	// orders the rings of polygons by the right-hand rule, exteriors
+	// counterclockwise, so that the output passes strict RFC 7946
+	// validators. The coordinates must be WGS 84 longitudes and latitudes.
+	RFC7946 bool
+}
+
+// keyedFeature is a feature whose id is the value of a field.
+type keyedFeature struct {
+	feature
+	ID interface{} `json:"id"`
+}
+
+// idIndex returns the index of the IDField of o in fields, or -1 if it is
+// not set.
+func (o *GeoJSONOptions) idIndex(fields []shp.Field) (int, error) {
+	if o.IDField == "" {
+		return -1, nil
+	}
+	for i, f := range fields {
+		if strings.EqualFold(f.String(), o.IDField) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("No field %s for the feature ids", o.IDField)
+}
+
+// apply changes f as set by o, with its id taken from the idIndex-th field.
+func (o *GeoJSONOptions) apply(f feature, fields []shp.Field, idIndex int) interface{} {
+	if o.RFC7946 {
+		f.Geometry = rfc7946(f.Geometry)
+	}
+	if f.Geometry != nil {
+		if o.Precision > 0 {
+			scale := math.Pow(10, float64(o.Precision))
+			eachPosition(f.Geometry.Coordinates, func(p []float64) {
+				for i, v := range p {
+					p[i] = math.Round(v*scale) / scale
+				}
+			})
+		}
+		if o.BBox {
+			f.BBox = bbox(f.Geometry.Coordinates)
+		}
+	}
+	if idIndex < 0 {
+		return f
+	}
+	return keyedFeature{feature: f, ID: f.Properties[fields[idIndex].String()]}
+}
+
+// eachPosition calls fn for every position in the coordinates c.
+func eachPosition(c interface{}, fn func([]float64)) {
+	switch c := c.(type) {
+	case []float64:
+		fn(c)
+	case [][]float64:
+		for _, v := range c {
+			fn(v)
+		}
+	case [][][]float64:
+		for _, v := range c {
+			eachPosition(v, fn)
+		}
+	case [][][][]float64:
+		for _, v := range c {
+			eachPosition(v, fn)
+		}
+	}
+}
+
+// bbox returns the bounding box of the coordinates c, with the minima of all
+// axes followed by their maxima, or nil if c has no positions.
+func bbox(c interface{}) []float64 {
+	var lo, hi []float64
+	eachPosition(c, func(p []float64) {
+		if lo == nil {
+			lo = append([]float64(nil), p...)
+			hi = append([]float64(nil), p...)
+			return
+		}
+		for i := 0; i < len(p) && i < len(lo); i++ {
+			lo[i] = math.Min(lo[i], p[i])
+			hi[i] = math.Max(hi[i], p[i])
+		}
+	})
+	return append(lo, hi...)
+}
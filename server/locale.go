@@ -0,0 +1,131 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format controls how a ConvertHandler formats numbers and dates, so that
+// exports open correctly in spreadsheets set up for other conventions, like
+// the European decimal comma. The zero value keeps the values as they are
+// stored in the DBF file.
+//
+// Number formatting only applies to CSV, as JSON numbers always use a
+// decimal point. Coordinates in the WKT column are never localized.
+type Format struct {
+	// Decimal is the decimal separator of numbers, '.' if zero.
+	Decimal rune
+	// Thousands separates groups of three integer digits of numbers. They
+	// are not grouped if it is zero.
+	Thousands rune
+	// Delimiter separates the columns of CSV. If zero, it is ';' when
+	// Decimal is ',' and ',' otherwise.
+	Delimiter rune
+	// DateLayout is the layout of dates, as for time.Time.Format. Dates are
+	// kept as YYYYMMDD if it is "".
+	DateLayout string
+}
+
+// Locales are the Formats selected by the locale query parameter of a
+// ConvertHandler, keyed by lowercase language tags. A tag that is not found
+// falls back to its language, "de-AT" to "de" for example.
+var Locales = map[string]Format{
+	"iso":   {DateLayout: "2006-01-02"},
+	"en":    {DateLayout: "2006-01-02"},
+	"en-us": {DateLayout: "01/02/2006"},
+	"en-gb": {DateLayout: "02/01/2006"},
+	"de":    {Decimal: ',', Thousands: '.', DateLayout: "02.01.2006"},
+	"de-ch": {Decimal: '.', Thousands: '\'', DateLayout: "02.01.2006"},
+	"fr":    {Decimal: ',', Thousands: ' ', DateLayout: "02/01/2006"},
+	"es":    {Decimal: ',', Thousands: '.', DateLayout: "02/01/2006"},
+	"it":    {Decimal: ',', Thousands: '.', DateLayout: "02/01/2006"},
+	"nl":    {Decimal: ',', Thousands: '.', DateLayout: "02-01-2006"},
+	"pt":    {Decimal: ',', Thousands: '.', DateLayout: "02/01/2006"},
+	"pl":    {Decimal: ',', Thousands: ' ', DateLayout: "02.01.2006"},
+	"sv":    {Decimal: ',', Thousands: ' ', DateLayout: "2006-01-02"},
+}
+
+// LookupLocale returns the Format in Locales for the language tag name.
+func LookupLocale(name string) (Format, bool) {
+	name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	if f, ok := Locales[name]; ok {
+		return f, true
+	}
+	if lang, _, found := strings.Cut(name, "-"); found {
+		f, ok := Locales[lang]
+		return f, ok
+	}
+	return Format{}, false
+}
+
+// delimiter returns the CSV delimiter of f.
+func (f *Format) delimiter() rune {
+	switch {
+	case f.Delimiter != 0:
+		return f.Delimiter
+	case f.Decimal == ',':
+		return ';'
+	}
+	return ','
+}
+
+// number formats the DBF number v. Values that are not numbers are returned
+// unchanged.
+func (f *Format) number(v string) string {
+	if f.Decimal == 0 && f.Thousands == 0 {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err != nil || strings.ContainsAny(v, "eEnN") {
+		return v
+	}
+	sign := ""
+	if v[0] == '-' || v[0] == '+' {
+		sign, v = v[:1], v[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(v, ".")
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range integer {
+		if i > 0 && f.Thousands != 0 && (len(integer)-i)%3 == 0 {
+			b.WriteRune(f.Thousands)
+		}
+		b.WriteRune(c)
+	}
+	if hasFraction {
+		if f.Decimal != 0 {
+			b.WriteRune(f.Decimal)
+		} else {
+			b.WriteByte('.')
+		}
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// date formats the DBF date v, which is YYYYMMDD. Values that are not dates
+// are returned unchanged.
+func (f *Format) date(v string) string {
+	if f.DateLayout == "" {
+		return v
+	}
+	t, err := time.Parse("20060102", v)
+	if err != nil {
+		return v
+	}
+	return t.Format(f.DateLayout)
+}
+
+// attribute formats the trimmed DBF value v of a field of type fieldtype for
+// CSV.
+func (f *Format) attribute(fieldtype byte, v string) string {
+	switch fieldtype {
+	case 'N', 'F':
+		return f.number(v)
+	case 'D':
+		return f.date(v)
+	}
+	return v
+}
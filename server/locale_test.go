@@ -0,0 +1,92 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func TestFormatNumber(t *testing.T) {
+	de, _ := LookupLocale("de-DE")
+	for _, c := range []struct {
+		f       Format
+		v, want string
+	}{
+		{Format{}, "1234567.50", "1234567.50"},
+		{de, "1234567.50", "1.234.567,50"},
+		{de, "-123456", "-123.456"},
+		{de, "123", "123"},
+		{de, "abc", "abc"},
+		{Format{Decimal: ','}, "1234.5", "1234,5"},
+		{Format{Thousands: ','}, "1234.5", "1,234.5"},
+	} {
+		if got := c.f.number(c.v); got != c.want {
+			t.Errorf("%+v.number(%q) = %q, want %q", c.f, c.v, got, c.want)
+		}
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	if f, ok := LookupLocale("de_AT"); !ok || f.Decimal != ',' {
+		t.Errorf("LookupLocale(de_AT) = %+v, %v", f, ok)
+	}
+	if f, ok := LookupLocale("en-US"); !ok || f.DateLayout != "01/02/2006" {
+		t.Errorf("LookupLocale(en-US) = %+v, %v", f, ok)
+	}
+	if _, ok := LookupLocale("xx"); ok {
+		t.Error("LookupLocale(xx) found a locale")
+	}
+}
+
+func zippedMeasurements(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w, err := shp.CreateZip(&buf, "measurements", shp.POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]shp.Field{shp.FloatField("LEVEL", 12, 2), shp.DateField("TAKEN")})
+	w.Write(&shp.Point{X: 1.5, Y: 2})
+	w.WriteAttribute(0, 0, 12345.5)
+	w.WriteAttribute(0, 1, "20240503")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestConvertLocale(t *testing.T) {
+	rec := convert(t, "/convert?f=csv&locale=de-DE", "", zippedMeasurements(t))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	r := csv.NewReader(rec.Body)
+	r.Comma = ';'
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"LEVEL", "TAKEN", "WKT"}, {"12.345,50", "03.05.2024", "POINT (1.5 2)"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	rec = convert(t, "/convert?locale=de", "", zippedMeasurements(t))
+	var fc featureCollection
+	if err := json.Unmarshal(rec.Body.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	if p := fc.Features[0].Properties; p["LEVEL"] != 12345.5 || p["TAKEN"] != "03.05.2024" {
+		t.Errorf("properties = %v", p)
+	}
+
+	if rec := convert(t, "/convert?locale=xx", "", zippedMeasurements(t)); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown locale: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,368 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// The Server also implements the core, GeoJSON and OpenAPI 3.0 conformance
+// classes of OGC API - Features at the following endpoints:
+//
+//	GET /                                   landing page
+//	GET /api                                API definition (OpenAPI 3.0)
+//	GET /conformance                        conformance declaration
+//	GET /collections                        all layers as collections
+//	GET /collections/{name}                 a single collection
+//	GET /collections/{name}/items           a page of features
+//	GET /collections/{name}/items/{id}      a single feature
+//
+// Items support the parameters limit (default 10, at most 10000), offset and
+// bbox as well as the where parameter of /layers. Records are read by their
+// index, so pages without where conditions are read directly. Features are
+// served in the coordinates of the shapefile without reprojection, so only
+// layers in longitude and latitude, as declared by their PRJ file or, without
+// one, judged by their extent, are served as collections, in CRS84.
+
+// CRS84 is the URI of WGS 84 longitude/latitude, the default CRS of OGC API -
+// Features.
+const CRS84 = "http://www.opengis.net/def/crs/OGC/1.3/CRS84"
+
+var conformance = []string{
+	"http://www.opengis.net/spec/ogcapi-features-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-features-1/1.0/conf/geojson",
+	"http://www.opengis.net/spec/ogcapi-features-1/1.0/conf/oas30",
+}
+
+const (
+	defaultLimit = 10
+	maxLimit     = 10000
+)
+
+type link struct {
+	Href  string `json:"href"`
+	Rel   string `json:"rel"`
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+type collection struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Extent     extent   `json:"extent"`
+	ItemType   string   `json:"itemType"`
+	CRS        []string `json:"crs"`
+	StorageCRS string   `json:"storageCrs"`
+	Links      []link   `json:"links"`
+}
+
+type extent struct {
+	Spatial struct {
+		BBox [][4]float64 `json:"bbox"`
+		CRS  string       `json:"crs"`
+	} `json:"spatial"`
+}
+
+type featureCollection struct {
+	Type           string    `json:"type"`
+	Features       []feature `json:"features"`
+	TimeStamp      string    `json:"timeStamp"`
+	NumberReturned int       `json:"numberReturned"`
+	Links          []link    `json:"links"`
+}
+
+// baseURL returns the URL that links are relative to, without a trailing
+// slash.
+func (s *Server) baseURL(r *http.Request) string {
+	if s.BaseURL != "" {
+		return s.BaseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, v interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	json.NewEncoder(w).Encode(v)
+}
+
+// checkCRS reports an error for every CRS parameter that is not CRS84.
+func checkCRS(w http.ResponseWriter, q url.Values) bool {
+	for _, p := range []string{"crs", "bbox-crs"} {
+		if v := q.Get(p); v != "" && v != CRS84 {
+			http.Error(w, fmt.Sprintf("Unsupported %s: %s", p, v), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) serveLanding(w http.ResponseWriter, r *http.Request) {
+	base := s.baseURL(r)
+	writeJSON(w, "application/json", map[string]interface{}{
+		"title": "Shapefiles",
+		"links": []link{
+			{Href: base + "/", Rel: "self", Type: "application/json"},
+			{Href: base + "/api", Rel: "service-desc", Type: "application/vnd.oai.openapi+json;version=3.0"},
+			{Href: base + "/conformance", Rel: "conformance", Type: "application/json"},
+			{Href: base + "/collections", Rel: "data", Type: "application/json"},
+		},
+	})
+}
+
+// openAPI is the API definition of the OGC API - Features endpoints, without
+// its servers, which are added for each request.
+const openAPI = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Shapefiles", "version": "1.0.0"},
+  "paths": {
+    "/": {"get": {"operationId": "getLandingPage", "responses": {"200": {"description": "The landing page"}}}},
+    "/api": {"get": {"operationId": "getAPI", "responses": {"200": {"description": "This API definition"}}}},
+    "/conformance": {"get": {"operationId": "getConformance", "responses": {"200": {"description": "The conformance classes"}}}},
+    "/collections": {"get": {"operationId": "getCollections", "responses": {"200": {"description": "The layers"}}}},
+    "/collections/{collectionId}": {"get": {
+      "operationId": "getCollection",
+      "parameters": [{"$ref": "#/components/parameters/collectionId"}],
+      "responses": {"200": {"description": "The layer"}, "404": {"description": "No such layer"}}
+    }},
+    "/collections/{collectionId}/items": {"get": {
+      "operationId": "getFeatures",
+      "parameters": [
+        {"$ref": "#/components/parameters/collectionId"},
+        {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 10000, "default": 10}},
+        {"name": "offset", "in": "query", "schema": {"type": "integer", "minimum": 0, "default": 0}},
+        {"name": "bbox", "in": "query", "style": "form", "explode": false,
+          "schema": {"type": "array", "minItems": 4, "maxItems": 6, "items": {"type": "number"}}},
+        {"name": "where", "in": "query", "description": "A condition FIELD=value or FIELD!=value",
+          "schema": {"type": "array", "items": {"type": "string"}}}
+      ],
+      "responses": {"200": {"description": "A page of features", "content": {"application/geo+json": {}}}, "400": {"description": "Invalid parameter"}}
+    }},
+    "/collections/{collectionId}/items/{featureId}": {"get": {
+      "operationId": "getFeature",
+      "parameters": [
+        {"$ref": "#/components/parameters/collectionId"},
+        {"name": "featureId", "in": "path", "required": true, "schema": {"type": "integer", "minimum": 0}}
+      ],
+      "responses": {"200": {"description": "A feature", "content": {"application/geo+json": {}}}, "404": {"description": "No such feature"}}
+    }}
+  },
+  "components": {
+    "parameters": {
+      "collectionId": {"name": "collectionId", "in": "path", "required": true, "schema": {"type": "string"}}
+    }
+  }
+}`
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	var api map[string]interface{}
+	if err := json.Unmarshal([]byte(openAPI), &api); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api["servers"] = []map[string]string{{"url": s.baseURL(r)}}
+	writeJSON(w, "application/vnd.oai.openapi+json;version=3.0", api)
+}
+
+func (s *Server) serveConformance(w http.ResponseWriter) {
+	writeJSON(w, "application/json", map[string]interface{}{"conformsTo": conformance})
+}
+
+func (s *Server) collection(base string, schema shp.LayerSchema) collection {
+	b := schema.BBox
+	c := collection{
+		ID:         schema.Layer,
+		Title:      schema.Layer,
+		ItemType:   "feature",
+		CRS:        []string{CRS84},
+		StorageCRS: CRS84,
+		Links: []link{
+			{Href: base + "/collections/" + schema.Layer, Rel: "self", Type: "application/json"},
+			{Href: base + "/collections/" + schema.Layer + "/items", Rel: "items", Type: "application/geo+json"},
+		},
+	}
+	c.Extent.Spatial.BBox = [][4]float64{{b.MinX, b.MinY, b.MaxX, b.MaxY}}
+	c.Extent.Spatial.CRS = CRS84
+	return c
+}
+
+// isCollection returns whether the layer called name, whose extent is box,
+// is served as a collection, which requires longitude and latitude.
+func (s *Server) isCollection(name string, box shp.Box) (bool, error) {
+	path, err := s.ds.LayerPath(name)
+	if err != nil {
+		return false, err
+	}
+	geographic, _, err := detectCRS(path, box)
+	return geographic, err
+}
+
+// openCollection opens the layer called name if it is served as a
+// collection, and otherwise responds with an error and returns nil.
+func (s *Server) openCollection(w http.ResponseWriter, r *http.Request, name string) *shp.CachedReader {
+	layer, err := s.openLayer(r.Context(), name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	switch ok, err := s.isCollection(name, layer.BBox()); {
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case !ok:
+		http.NotFound(w, r)
+	default:
+		return layer
+	}
+	layer.Release()
+	return nil
+}
+
+func (s *Server) serveCollections(w http.ResponseWriter, r *http.Request, name string) {
+	schemas, err := s.schemas()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	base := s.baseURL(r)
+	cs := []collection{}
+	for _, schema := range schemas {
+		ok, err := s.isCollection(schema.Layer, schema.BBox)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			continue
+		}
+		if name != "" && schema.Layer == name {
+			writeJSON(w, "application/json", s.collection(base, schema))
+			return
+		}
+		cs = append(cs, s.collection(base, schema))
+	}
+	if name != "" {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, "application/json", map[string]interface{}{
+		"links":       []link{{Href: base + "/collections", Rel: "self", Type: "application/json"}},
+		"collections": cs,
+	})
+}
+
+func (s *Server) serveItems(w http.ResponseWriter, r *http.Request, name string) {
+	q := r.URL.Query()
+	if !checkCRS(w, q) {
+		return
+	}
+	layer := s.openCollection(w, r, name)
+	if layer == nil {
+		return
+	}
+	defer layer.Release()
+	fields := layer.Fields()
+	filter, err := parseFilter(q, fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset := defaultLimit, 0
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			http.Error(w, "Invalid limit: "+v, http.StatusBadRequest)
+			return
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			http.Error(w, "Invalid offset: "+v, http.StatusBadRequest)
+			return
+		}
+	}
+
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}, TimeStamp: time.Now().UTC().Format(time.RFC3339)}
+	more := false
+	span := startScan(r.Context(), name)
+	read, err := filter.scan(layer, offset, func(rec *indexedRecord) bool {
+		if len(fc.Features) == limit {
+			more = true
+			return false
+		}
+		fc.Features = append(fc.Features, toFeature(rec, fields))
+		return true
+	})
+	endScan(span, read, len(fc.Features), err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fc.NumberReturned = len(fc.Features)
+
+	items := s.baseURL(r) + "/collections/" + name + "/items"
+	self := items
+	if r.URL.RawQuery != "" {
+		self += "?" + r.URL.RawQuery
+	}
+	fc.Links = []link{{Href: self, Rel: "self", Type: "application/geo+json"}}
+	if more {
+		q.Set("offset", strconv.Itoa(offset+limit))
+		q.Set("limit", strconv.Itoa(limit))
+		fc.Links = append(fc.Links, link{Href: items + "?" + q.Encode(), Rel: "next", Type: "application/geo+json"})
+	}
+	w.Header().Set("Content-Crs", "<"+CRS84+">")
+	writeJSON(w, "application/geo+json", fc)
+}
+
+func (s *Server) serveItem(w http.ResponseWriter, r *http.Request, name, id string) {
+	if !checkCRS(w, r.URL.Query()) {
+		return
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	layer := s.openCollection(w, r, name)
+	if layer == nil {
+		return
+	}
+	defer layer.Release()
+	if n >= layer.NumRecords() {
+		http.NotFound(w, r)
+		return
+	}
+	span := startScan(r.Context(), name)
+	var f feature
+	// without conditions, scan reads record n directly
+	read, err := filter{}.scan(layer, n, func(rec *indexedRecord) bool {
+		f = toFeature(rec, layer.Fields())
+		return false
+	})
+	endScan(span, read, 1, err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	base := s.baseURL(r) + "/collections/" + name
+	w.Header().Set("Content-Crs", "<"+CRS84+">")
+	writeJSON(w, "application/geo+json", struct {
+		feature
+		Links []link `json:"links"`
+	}{f, []link{
+		{Href: base + "/items/" + id, Rel: "self", Type: "application/geo+json"},
+		{Href: base, Rel: "collection", Type: "application/json"},
+	}})
+}
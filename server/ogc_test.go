@@ -0,0 +1,179 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func getJSON(t *testing.T, srv http.Handler, url string, v interface{}) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: got status %d: %s", url, rec.Code, rec.Body)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("%s: %v", url, err)
+	}
+	return rec
+}
+
+func TestOGCCollections(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.BaseURL = "https://example.com/ogc"
+
+	var conf struct{ ConformsTo []string }
+	getJSON(t, srv, "/conformance", &conf)
+	if !reflect.DeepEqual(conf.ConformsTo, conformance) {
+		t.Errorf("got conformance %v", conf.ConformsTo)
+	}
+
+	var cs struct{ Collections []collection }
+	getJSON(t, srv, "/collections", &cs)
+	if len(cs.Collections) != 2 || cs.Collections[0].ID != "cities" {
+		t.Fatalf("got collections %+v", cs.Collections)
+	}
+	var c collection
+	getJSON(t, srv, "/collections/cities", &c)
+	if c.Extent.Spatial.BBox[0] != [4]float64{1, 1, 9, 9} {
+		t.Errorf("got extent %v", c.Extent.Spatial.BBox)
+	}
+	if c.Links[1].Href != "https://example.com/ogc/collections/cities/items" {
+		t.Errorf("got items link %s", c.Links[1].Href)
+	}
+}
+
+func TestOGCItems(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.BaseURL = "https://example.com"
+
+	var fc featureCollection
+	rec := getJSON(t, srv, "/collections/cities/items?limit=2", &fc)
+	if fc.NumberReturned != 2 || fc.Features[1].ID != 1 {
+		t.Errorf("got features %+v", fc.Features)
+	}
+	if len(fc.Links) != 2 || fc.Links[1].Href != "https://example.com/collections/cities/items?limit=2&offset=2" {
+		t.Fatalf("got links %+v", fc.Links)
+	}
+	if got := rec.Header().Get("Content-Crs"); got != "<"+CRS84+">" {
+		t.Errorf("got Content-Crs %s", got)
+	}
+
+	fc = featureCollection{}
+	getJSON(t, srv, "/collections/cities/items?limit=2&offset=2", &fc)
+	if fc.NumberReturned != 1 || fc.Features[0].ID != 2 || len(fc.Links) != 1 {
+		t.Errorf("got second page %+v", fc)
+	}
+
+	fc = featureCollection{}
+	getJSON(t, srv, "/collections/cities/items?bbox=0,0,0,6,6,0&crs="+CRS84, &fc)
+	if fc.NumberReturned != 2 {
+		t.Errorf("got %d features in bbox, want 2", fc.NumberReturned)
+	}
+
+	var f feature
+	getJSON(t, srv, "/collections/cities/items/2", &f)
+	if f.ID != 2 || f.Properties["NAME"] != "Capital City" {
+		t.Errorf("got feature %+v", f)
+	}
+
+	for url, code := range map[string]int{
+		"/collections/cities/items/3": http.StatusNotFound,
+		"/collections/cities/items?crs=http://www.opengis.net/def/crs/EPSG/0/3857": http.StatusBadRequest,
+		"/collections/towns/items": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		if rec.Code != code {
+			t.Errorf("%s: got status %d, want %d", url, rec.Code, code)
+		}
+	}
+}
+
+func TestOGCProjectedLayer(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	w, err := shp.Create(filepath.Join(dir, "utm.shp"), shp.POINT,
+		shp.WithPRJ(`PROJCS["WGS 84 / UTM zone 33N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],UNIT["metre",1]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(&shp.Point{X: 500000, Y: 5000000})
+	w.Close()
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cs struct{ Collections []collection }
+	getJSON(t, srv, "/collections", &cs)
+	for _, c := range cs.Collections {
+		if c.ID == "utm" {
+			t.Error("projected layer served as a collection")
+		}
+	}
+	for _, url := range []string{"/collections/utm", "/collections/utm/items", "/collections/utm/items/0"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: got status %d, want %d", url, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestOGCAPIDefinition(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.BaseURL = "https://example.com/ogc"
+
+	var landing struct{ Links []link }
+	getJSON(t, srv, "/", &landing)
+	var desc *link
+	for i, l := range landing.Links {
+		if l.Rel == "service-desc" {
+			desc = &landing.Links[i]
+		}
+	}
+	if desc == nil || desc.Href != "https://example.com/ogc/api" {
+		t.Fatalf("got links %+v", landing.Links)
+	}
+
+	var api struct {
+		OpenAPI string
+		Servers []struct{ URL string }
+		Paths   map[string]interface{}
+	}
+	rec := getJSON(t, srv, "/api", &api)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/vnd.oai.openapi+json") {
+		t.Errorf("got content type %s", ct)
+	}
+	if api.OpenAPI != "3.0.3" || len(api.Servers) != 1 || api.Servers[0].URL != "https://example.com/ogc" {
+		t.Errorf("got API definition %+v", api)
+	}
+	if _, ok := api.Paths["/collections/{collectionId}/items"]; !ok {
+		t.Errorf("got paths %v", api.Paths)
+	}
+}
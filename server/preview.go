@@ -0,0 +1,218 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// previewDetail is the fraction of the extent of a layer below which the
+// vertices of its lines and polygons are simplified away in a preview.
+const previewDetail = 1.0 / 2000
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<style>html, body, #map { height: 100%; margin: 0; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+var data = {{.Data}};
+var geographic = {{.Geographic}};
+var map = L.map('map', geographic ? {} : {crs: L.CRS.Simple});
+if (geographic) {
+  L.tileLayer('https://tile.openstreetmap.org/{z}/{x}/{y}.png', {
+    maxZoom: 19,
+    attribution: '&copy; OpenStreetMap contributors'
+  }).addTo(map);
+}
+var layer = L.geoJSON(data, {
+  pointToLayer: function (f, latlng) {
+    return L.circleMarker(latlng, {radius: 4});
+  },
+  onEachFeature: function (f, l) {
+    var table = document.createElement('table');
+    for (var k in f.properties) {
+      var row = table.insertRow();
+      row.insertCell().textContent = k;
+      row.insertCell().textContent = f.properties[k];
+    }
+    l.bindPopup(table);
+  }
+}).addTo(map);
+if (layer.getBounds().isValid()) {
+  map.fitBounds(layer.getBounds());
+} else {
+  map.setView([0, 0], 1);
+}
+</script>
+</body>
+</html>
+`))
+
+// Preview writes a self-contained HTML file out with a Leaflet map of the
+// shapefile path, as a quick visual check of its contents. The features are
+// embedded as GeoJSON, with their lines and polygons simplified to the detail
+// visible at the scale of the whole layer, and their attributes are shown in
+// popups. Only Leaflet itself and the OpenStreetMap background are loaded
+// from the web.
+//
+// Coordinates in longitude and latitude and in Web Mercator are shown on the
+// background map. Other coordinate systems cannot be converted and are shown
+// as they are on a blank plane.
+func Preview(path, out string) error {
+	r, err := shp.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	box := r.BBox()
+	geographic, mercator, err := detectCRS(path, box)
+	if err != nil {
+		return err
+	}
+	tolerance := math.Max(box.MaxX-box.MinX, box.MaxY-box.MinY) * previewDetail
+
+	fields := r.Fields()
+	var features []feature
+	for r.Next() {
+		f := toFeature(r, fields)
+		if f.Geometry != nil {
+			f.Geometry.Coordinates = simplify(f.Geometry.Type, f.Geometry.Coordinates, tolerance)
+			if mercator {
+				eachPosition(f.Geometry.Coordinates, func(p []float64) {
+					p[0], p[1] = shp.MercatorToLonLat(p[0], p[1])
+				})
+			}
+		}
+		features = append(features, f)
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(map[string]interface{}{"type": "FeatureCollection", "features": features})
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	err = previewTemplate.Execute(&b, struct {
+		Title      string
+		Data       template.JS
+		Geographic bool
+	}{filepath.Base(path), template.JS(data), geographic || mercator})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, b.Bytes(), 0666)
+}
+
+// detectCRS returns whether the coordinates of the shapefile path, whose
+// extent is box, are longitudes and latitudes or Web Mercator meters, as
+// declared by its PRJ file. Without a PRJ file, coordinates within the
+// ranges of longitudes and latitudes are taken to be such.
+func detectCRS(path string, box shp.Box) (geographic, mercator bool, err error) {
+	sidecars, err := shp.FindSidecars(path)
+	if err != nil {
+		return false, false, err
+	}
+	if sidecars.PRJ == "" {
+		return box.MinX >= -180 && box.MaxX <= 180 && box.MinY >= -90 && box.MaxY <= 90, false, nil
+	}
+	wkt, err := os.ReadFile(sidecars.PRJ)
+	if err != nil {
+		return false, false, err
+	}
+	if code, ok := shp.IdentifyEPSG(string(wkt)); ok && code == 3857 {
+		return false, true, nil
+	}
+	return shp.IsGeographic(string(wkt)), false, nil
+}
+
+// simplify returns the coordinates c of a geometry of type t with the
+// vertices of its lines and rings removed that deviate less than tolerance
+// from the simplified shape, by the Douglas-Peucker algorithm. Rings keep at
+// least four positions.
+func simplify(t string, c interface{}, tolerance float64) interface{} {
+	if tolerance <= 0 {
+		return c
+	}
+	switch t {
+	case "LineString":
+		return simplifyLine(c.([][]float64), tolerance, 2)
+	case "MultiLineString", "Polygon":
+		minLen := 2
+		if t == "Polygon" {
+			minLen = 4
+		}
+		lines := c.([][][]float64)
+		for i, line := range lines {
+			lines[i] = simplifyLine(line, tolerance, minLen)
+		}
+		return lines
+	case "MultiPolygon":
+		polys := c.([][][][]float64)
+		for i, poly := range polys {
+			polys[i] = simplify("Polygon", poly, tolerance).([][][]float64)
+		}
+		return polys
+	}
+	return c
+}
+
+// simplifyLine simplifies line, keeping it if less than minLen positions
+// would be left.
+func simplifyLine(line [][]float64, tolerance float64, minLen int) [][]float64 {
+	if len(line) <= minLen {
+		return line
+	}
+	keep := make([]bool, len(line))
+	keep[0], keep[len(line)-1] = true, true
+	var mark func(i, j int)
+	mark = func(i, j int) {
+		farthest, dist := -1, tolerance
+		for k := i + 1; k < j; k++ {
+			if d := segmentDistance(line[k], line[i], line[j]); d > dist {
+				farthest, dist = k, d
+			}
+		}
+		if farthest >= 0 {
+			keep[farthest] = true
+			mark(i, farthest)
+			mark(farthest, j)
+		}
+	}
+	mark(0, len(line)-1)
+	var out [][]float64
+	for i, p := range line {
+		if keep[i] {
+			out = append(out, p)
+		}
+	}
+	if len(out) < minLen {
+		return line
+	}
+	return out
+}
+
+// segmentDistance returns the distance of p from the segment from a to b.
+func segmentDistance(p, a, b []float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/l))
+	}
+	return math.Hypot(p[0]-a[0]-t*dx, p[1]-a[1]-t*dy)
+}
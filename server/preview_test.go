@@ -0,0 +1,75 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func TestPreview(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "roads.shp")
+	mercator, _ := shp.LookupEPSG(3857)
+	w, err := shp.Create(name, shp.POLYLINE, shp.WithPRJ(mercator.WKT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]shp.Field{shp.StringField("NAME", 20)})
+	w.Write(shp.NewPolyLine([][]shp.Point{{{X: 0, Y: 0}, {X: 500000, Y: 1}, {X: 1000000, Y: 0}}}))
+	w.WriteAttribute(0, 0, "</script><b>x")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "roads.html")
+	if err := Preview(name, out); err != nil {
+		t.Fatal(err)
+	}
+	page, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regexp.MustCompile(`</script><b>x`).Match(page) {
+		t.Error("attribute is not escaped")
+	}
+	m := regexp.MustCompile(`var data = (.*);\n`).FindSubmatch(page)
+	if m == nil {
+		t.Fatalf("no data in\n%s", page)
+	}
+	var fc struct{ Features []feature }
+	if err := json.Unmarshal(m[1], &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 1 || fc.Features[0].Properties["NAME"] != "</script><b>x" {
+		t.Fatalf("features = %+v", fc.Features)
+	}
+	// the middle vertex is simplified away and the Web Mercator meters are
+	// converted to degrees
+	coords, _ := fc.Features[0].Geometry.Coordinates.([]interface{})
+	if len(coords) != 2 || math.Abs(coords[1].([]interface{})[0].(float64)-8.98315284) > 1e-6 {
+		t.Errorf("coordinates = %v, want [[0 0] [8.98315284 0]]", coords)
+	}
+	if !regexp.MustCompile(`var geographic = *true *;`).Match(page) {
+		t.Error("map has no background")
+	}
+}
+
+func TestSimplifyLine(t *testing.T) {
+	line := [][]float64{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {4, 6}, {5, 7}}
+	want := [][]float64{{0, 0}, {2, -0.1}, {3, 5}, {5, 7}}
+	if got := simplifyLine(line, 0.5, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("simplifyLine() = %v, want %v", got, want)
+	}
+	ring := [][]float64{{0, 0}, {0, 1}, {0.01, 1}, {0, 0}}
+	if got := simplifyLine(ring, 10, 4); !reflect.DeepEqual(got, ring) {
+		t.Errorf("ring simplified to %v", got)
+	}
+}
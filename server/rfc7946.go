@@ -0,0 +1,218 @@
+//go:build !shp_minimal
+
+package server
+
+import "math"
+
+// rfc7946 returns g in the form RFC 7946 demands: lines and polygons that
+// cross the antimeridian are split into parts on either side of it, and the
+// exterior rings of polygons are counterclockwise and their holes clockwise.
+// Edges longer than 180 degrees of longitude are taken to cross the
+// antimeridian, as the shortest way between their ends does.
+func rfc7946(g *geometry) *geometry {
+	if g == nil {
+		return nil
+	}
+	switch c := g.Coordinates.(type) {
+	case [][]float64:
+		if g.Type != "LineString" {
+			return g
+		}
+		return lineGeometry(splitLine(c))
+	case [][][]float64:
+		if g.Type == "MultiLineString" {
+			var out [][][]float64
+			for _, line := range c {
+				out = append(out, splitLine(line)...)
+			}
+			return lineGeometry(out)
+		}
+		return polygonGeometry(splitPolygon(c))
+	case [][][][]float64:
+		var out [][][][]float64
+		for _, poly := range c {
+			out = append(out, splitPolygon(poly)...)
+		}
+		return polygonGeometry(out)
+	}
+	return g
+}
+
+func lineGeometry(lines [][][]float64) *geometry {
+	if len(lines) == 1 {
+		return &geometry{"LineString", lines[0]}
+	}
+	return &geometry{"MultiLineString", lines}
+}
+
+func polygonGeometry(polys [][][][]float64) *geometry {
+	if len(polys) == 1 {
+		return &geometry{"Polygon", polys[0]}
+	}
+	return &geometry{"MultiPolygon", polys}
+}
+
+// splitLine splits line where it crosses the antimeridian.
+func splitLine(line [][]float64) [][][]float64 {
+	if len(line) == 0 {
+		return nil
+	}
+	var out [][][]float64
+	part := [][]float64{line[0]}
+	for i := 1; i < len(line); i++ {
+		a, b := line[i-1], line[i]
+		d := b[0] - a[0]
+		if math.Abs(d) <= 180 {
+			part = append(part, b)
+			continue
+		}
+		edge, shift := 180.0, 360.0
+		if d > 0 {
+			edge, shift = -180, -360
+		}
+		p := intersect(a, shifted(b, shift), edge)
+		out = append(out, append(part, p))
+		part = [][]float64{shifted(p, -2*edge), b}
+	}
+	return append(out, part)
+}
+
+// splitPolygon splits the polygon poly, its exterior ring followed by its
+// holes, where it crosses the antimeridian, and orders the rings of the
+// parts by the right-hand rule.
+func splitPolygon(poly [][][]float64) [][][][]float64 {
+	if len(poly) == 0 {
+		return nil
+	}
+	// make the rings continuous, so that the crossing parts extend beyond
+	// the antimeridian
+	ref := 0.0
+	if len(poly[0]) > 0 {
+		ref = poly[0][0][0]
+	}
+	unwrapped := make([][][]float64, len(poly))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, ring := range poly {
+		unwrapped[i] = unwrap(ring, ref)
+		for _, p := range unwrapped[i] {
+			lo, hi = math.Min(lo, p[0]), math.Max(hi, p[0])
+		}
+	}
+
+	var parts [][][][]float64
+	switch {
+	case hi > 180:
+		parts = append(parts, clipPolygon(unwrapped, 180, true, 0), clipPolygon(unwrapped, 180, false, -360))
+	case lo < -180:
+		parts = append(parts, clipPolygon(unwrapped, -180, true, 360), clipPolygon(unwrapped, -180, false, 0))
+	default:
+		parts = append(parts, poly)
+	}
+	var out [][][][]float64
+	for _, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		for i, ring := range part {
+			// shapefile exteriors are clockwise, GeoJSON ones the reverse
+			if clockwise(ring) == (i == 0) {
+				part[i] = reversed(ring)
+			}
+		}
+		out = append(out, part)
+	}
+	return out
+}
+
+// unwrap returns ring with its longitudes shifted by multiples of 360
+// degrees so that no edge is longer than 180 degrees and the first position
+// is within 180 degrees of ref.
+func unwrap(ring [][]float64, ref float64) [][]float64 {
+	out := make([][]float64, len(ring))
+	prev := ref
+	for i, p := range ring {
+		shift := 360 * math.Round((prev-p[0])/360)
+		out[i] = shifted(p, shift)
+		prev = out[i][0]
+	}
+	return out
+}
+
+// clipPolygon clips the rings of poly to the longitudes below x, if below is
+// set, or else above x, and shifts the longitudes of the result by shift. It
+// returns nil if nothing of the exterior ring is left.
+func clipPolygon(poly [][][]float64, x float64, below bool, shift float64) [][][]float64 {
+	var out [][][]float64
+	for i, ring := range poly {
+		clipped := clipRing(ring, x, below)
+		if clipped == nil {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		for j, p := range clipped {
+			clipped[j] = shifted(p, shift)
+		}
+		out = append(out, clipped)
+	}
+	return out
+}
+
+// clipRing clips the closed ring to the longitudes below x, if below is set,
+// or else above x, by the Sutherland-Hodgman algorithm. It returns nil if
+// less than a triangle is left.
+func clipRing(ring [][]float64, x float64, below bool) [][]float64 {
+	inside := func(p []float64) bool {
+		if below {
+			return p[0] <= x
+		}
+		return p[0] >= x
+	}
+	var out [][]float64
+	add := func(p []float64) {
+		if n := len(out); n == 0 || out[n-1][0] != p[0] || out[n-1][1] != p[1] {
+			out = append(out, p)
+		}
+	}
+	for i := 0; i+1 < len(ring); i++ {
+		a, b := ring[i], ring[i+1]
+		if inside(a) {
+			add(a)
+		}
+		if inside(a) != inside(b) {
+			add(intersect(a, b, x))
+		}
+	}
+	if len(out) < 3 {
+		return nil
+	}
+	return append(out, out[0])
+}
+
+// intersect returns the position where the edge from a to b meets the
+// longitude x.
+func intersect(a, b []float64, x float64) []float64 {
+	t := (x - a[0]) / (b[0] - a[0])
+	p := make([]float64, len(a))
+	p[0] = x
+	for i := 1; i < len(a) && i < len(b); i++ {
+		p[i] = a[i] + t*(b[i]-a[i])
+	}
+	return p
+}
+
+// shifted returns a copy of p with its longitude shifted by shift.
+func shifted(p []float64, shift float64) []float64 {
+	q := append([]float64(nil), p...)
+	q[0] += shift
+	return q
+}
+
+func reversed(ring [][]float64) [][]float64 {
+	out := make([][]float64, len(ring))
+	for i, p := range ring {
+		out[len(ring)-1-i] = p
+	}
+	return out
+}
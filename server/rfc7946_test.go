@@ -0,0 +1,46 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRFC7946Line(t *testing.T) {
+	g := rfc7946(&geometry{"LineString", [][]float64{{170, 0}, {179, 10}, {-179, 20}, {-170, 30}}})
+	want := &geometry{"MultiLineString", [][][]float64{
+		{{170, 0}, {179, 10}, {180, 15}},
+		{{-180, 15}, {-179, 20}, {-170, 30}},
+	}}
+	if !reflect.DeepEqual(g, want) {
+		t.Errorf("got %v, want %v", g, want)
+	}
+
+	line := &geometry{"LineString", [][]float64{{0, 0}, {10, 10}}}
+	if g := rfc7946(line); !reflect.DeepEqual(g, line) {
+		t.Errorf("line that does not cross changed to %v", g)
+	}
+}
+
+func TestRFC7946Polygon(t *testing.T) {
+	// a clockwise shapefile exterior ring from 170 to -170
+	g := rfc7946(&geometry{"Polygon", [][][]float64{{{170, 0}, {170, 10}, {-170, 10}, {-170, 0}, {170, 0}}}})
+	want := &geometry{"MultiPolygon", [][][][]float64{
+		{{{170, 0}, {180, 0}, {180, 10}, {170, 10}, {170, 0}}},
+		{{{-180, 10}, {-180, 0}, {-170, 0}, {-170, 10}, {-180, 10}}},
+	}}
+	if !reflect.DeepEqual(g, want) {
+		t.Errorf("got %v, want %v", g, want)
+	}
+
+	// a clockwise exterior with a counterclockwise hole
+	g = rfc7946(&geometry{"Polygon", [][][]float64{
+		{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {0, 0}},
+		{{2, 2}, {8, 2}, {8, 8}, {2, 8}, {2, 2}},
+	}})
+	rings := g.Coordinates.([][][]float64)
+	if clockwise(rings[0]) || !clockwise(rings[1]) {
+		t.Errorf("rings are not ordered by the right-hand rule: %v", rings)
+	}
+}
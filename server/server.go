@@ -0,0 +1,459 @@
+//go:build !shp_minimal
+
+// Package server exposes the shapefiles of a directory as an HTTP API. It
+// serves the following endpoints:
+//
+//	GET /layers
+//		a JSON array describing every layer
+//	GET /layers/{name}/features?bbox=minx,miny,maxx,maxy&where=FIELD=value
+//		the features of a layer as a GeoJSON text sequence (RFC 8142)
+//
+// The bbox parameter selects the features whose bounding box intersects the
+// given box. Each where parameter is a condition FIELD=value or FIELD!=value
+// on an attribute, field names are matched case-insensitively, and a feature
+// must fulfill all conditions. The limit parameter caps the number of
+// features. Layers are kept open in a shp.Cache with an R-tree of their
+// records, which selects the features in the bbox, and features are read by
+// their index while they are streamed, so large layers are not loaded into
+// memory.
+//
+// The same layers are also served as the collections of an OGC API - Features
+// service below /collections.
+//
+// ConvertHandler is a separate http.Handler that converts uploaded zipped
+// shapefiles to GeoJSON or CSV, and Preview writes an HTML page with a map
+// of a shapefile.
+//
+// With a Tracer, opening a layer and scanning its features are traced as the
+// spans "shp.open" and "shp.scan" below the context of the request.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// Server is an http.Handler serving the layers of a dataset.
+type Server struct {
+	// BaseURL is the URL the Server is reachable at, used for links. If
+	// empty, it is derived from the requests.
+	BaseURL string
+	// Tracer, if set, traces opening and scanning layers.
+	Tracer shp.Tracer
+
+	ds     *shp.Dataset
+	layers []string
+	cache  *shp.Cache
+}
+
+// cacheSize is the number of layers a Server keeps open and indexed.
+const cacheSize = 16
+
+// New returns a Server for all layers of ds. The layers are opened and
+// indexed once and kept open in a shp.Cache of the most recently used ones,
+// which Close closes.
+func New(ds *shp.Dataset) *Server {
+	return newServer(ds, ds.Layers())
+}
+
+func newServer(ds *shp.Dataset, layers []string) *Server {
+	return &Server{ds: ds, layers: layers, cache: shp.NewCache(cacheSize)}
+}
+
+// Close closes the layers kept open by s.
+func (s *Server) Close() error {
+	return s.cache.Close()
+}
+
+// Open returns a Server for path, which is either a directory of shapefiles
+// or a single shapefile.
+func Open(path string) (*Server, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		ds, err := shp.OpenDir(path)
+		if err != nil {
+			return nil, err
+		}
+		return New(ds), nil
+	}
+	ds, err := shp.OpenDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, l := range ds.Layers() {
+		if l == name {
+			return newServer(ds, []string{name}), nil
+		}
+	}
+	return nil, fmt.Errorf("Not a shapefile: %s", path)
+}
+
+func (s *Server) hasLayer(name string) bool {
+	for _, l := range s.layers {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Tracer != nil {
+		r = r.WithContext(shp.ContextWithTracer(r.Context(), s.Tracer))
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "":
+		s.serveLanding(w, r)
+	case path == "api":
+		s.serveAPI(w, r)
+	case path == "conformance":
+		s.serveConformance(w)
+	case path == "collections":
+		s.serveCollections(w, r, "")
+	case len(parts) == 2 && parts[0] == "collections" && s.hasLayer(parts[1]):
+		s.serveCollections(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "collections" && parts[2] == "items" && s.hasLayer(parts[1]):
+		s.serveItems(w, r, parts[1])
+	case len(parts) == 4 && parts[0] == "collections" && parts[2] == "items" && s.hasLayer(parts[1]):
+		s.serveItem(w, r, parts[1], parts[3])
+	case path == "layers":
+		s.serveLayers(w)
+	case len(parts) == 3 && parts[0] == "layers" && parts[2] == "features" && s.hasLayer(parts[1]):
+		s.serveFeatures(w, r, parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// layer is the description of a layer returned by /layers.
+type layer struct {
+	Name         string     `json:"name"`
+	GeometryType string     `json:"geometryType"`
+	BBox         [4]float64 `json:"bbox"`
+	Fields       []field    `json:"fields"`
+}
+
+type field struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Size      uint8  `json:"size"`
+	Precision uint8  `json:"precision"`
+}
+
+// schemas returns the schemas of the layers of s from the cache.
+func (s *Server) schemas() ([]shp.LayerSchema, error) {
+	schemas := make([]shp.LayerSchema, 0, len(s.layers))
+	for _, name := range s.layers {
+		path, err := s.ds.LayerPath(name)
+		if err != nil {
+			return nil, err
+		}
+		r, err := s.cache.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, shp.LayerSchema{
+			Layer:        name,
+			GeometryType: r.GeometryType,
+			BBox:         r.BBox(),
+			Fields:       r.Fields(),
+		})
+		r.Release()
+	}
+	return schemas, nil
+}
+
+func (s *Server) serveLayers(w http.ResponseWriter) {
+	schemas, err := s.schemas()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out := []layer{}
+	for _, schema := range schemas {
+		b := schema.BBox
+		l := layer{
+			Name:         schema.Layer,
+			GeometryType: schema.GeometryType.String(),
+			BBox:         [4]float64{b.MinX, b.MinY, b.MaxX, b.MaxY},
+			Fields:       []field{},
+		}
+		for _, f := range schema.Fields {
+			l.Fields = append(l.Fields, field{f.String(), string(f.Fieldtype), f.Size, f.Precision})
+		}
+		out = append(out, l)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// condition is a parsed where parameter.
+type condition struct {
+	field  int
+	value  string
+	negate bool
+}
+
+func (c condition) match(r record) bool {
+	v := strings.TrimRight(strings.TrimSpace(r.Attribute(c.field)), "\x00")
+	return (v == c.value) != c.negate
+}
+
+// parseBBox parses a bbox of four numbers, or of six numbers including the
+// height, which is ignored.
+func parseBBox(s string) (shp.Box, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 && len(parts) != 6 {
+		return shp.Box{}, fmt.Errorf("Invalid bbox: %s", s)
+	}
+	v := make([]float64, len(parts))
+	for i, p := range parts {
+		var err error
+		if v[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64); err != nil {
+			return shp.Box{}, fmt.Errorf("Invalid bbox: %s", s)
+		}
+	}
+	if len(v) == 6 {
+		return shp.Box{MinX: v[0], MinY: v[1], MaxX: v[3], MaxY: v[4]}, nil
+	}
+	return shp.Box{MinX: v[0], MinY: v[1], MaxX: v[2], MaxY: v[3]}, nil
+}
+
+func parseWhere(s string, fields []shp.Field) (condition, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return condition{}, fmt.Errorf("Invalid condition: %s", s)
+	}
+	c := condition{value: s[i+1:]}
+	name := s[:i]
+	if strings.HasSuffix(name, "!") {
+		c.negate = true
+		name = name[:len(name)-1]
+	}
+	for j, f := range fields {
+		if strings.EqualFold(f.String(), name) {
+			c.field = j
+			return c, nil
+		}
+	}
+	return condition{}, fmt.Errorf("No such field: %s", name)
+}
+
+// filter selects features by the bbox and where parameters of a request.
+type filter struct {
+	bbox  *shp.Box
+	conds []condition
+}
+
+func parseFilter(q url.Values, fields []shp.Field) (filter, error) {
+	var f filter
+	if v := q.Get("bbox"); v != "" {
+		b, err := parseBBox(v)
+		if err != nil {
+			return f, err
+		}
+		f.bbox = &b
+	}
+	for _, v := range q["where"] {
+		c, err := parseWhere(v, fields)
+		if err != nil {
+			return f, err
+		}
+		f.conds = append(f.conds, c)
+	}
+	return f, nil
+}
+
+// match returns whether r fulfills the where conditions of f. The bbox is
+// matched by the R-tree of the layer in scan.
+func (f filter) match(r record) bool {
+	for _, c := range f.conds {
+		if !c.match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// record is the current record of a shp.Reader or shp.SequentialReader.
+type record interface {
+	Shape() (int, shp.Shape)
+	Attribute(n int) string
+}
+
+// indexedRecord is a record read by its index.
+type indexedRecord struct {
+	n     int
+	shape shp.Shape
+	attrs []string
+}
+
+func (r *indexedRecord) Shape() (int, shp.Shape) {
+	return r.n, r.shape
+}
+
+func (r *indexedRecord) Attribute(n int) string {
+	if n < len(r.attrs) {
+		return r.attrs[n]
+	}
+	return ""
+}
+
+// scan calls fn with the records of layer that match f in ascending order
+// until fn returns false, skipping the first skip matching records. The
+// records within the bbox are looked up in the R-tree of layer and all
+// records are read by their index: the attributes only of records that are
+// tested against conditions and the shapes only of those passed to fn. It
+// returns the number of records read.
+func (f filter) scan(layer *shp.CachedReader, skip int, fn func(*indexedRecord) bool) (int, error) {
+	var ids []int
+	n := layer.NumRecords()
+	if f.bbox != nil {
+		ids = layer.Search(*f.bbox)
+		n = len(ids)
+	}
+	rows := 0
+	if len(layer.Fields()) > 0 {
+		rows = layer.AttributeCount()
+	}
+	k, read := 0, 0
+	if len(f.conds) == 0 {
+		k, skip = min(skip, n), 0
+	}
+	for ; k < n; k++ {
+		rec := &indexedRecord{n: k}
+		if ids != nil {
+			rec.n = ids[k]
+		}
+		var err error
+		if rec.n < rows {
+			if rec.attrs, err = layer.AttributesAt(rec.n); err != nil {
+				return read, err
+			}
+		}
+		read++
+		if !f.match(rec) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if rec.shape, err = layer.ShapeAt(rec.n); err != nil {
+			return read, err
+		}
+		if !fn(rec) {
+			break
+		}
+	}
+	return read, nil
+}
+
+// toFeature converts the current record of r to a GeoJSON feature.
+func toFeature(r record, fields []shp.Field) feature {
+	n, shape := r.Shape()
+	f := feature{Type: "Feature", ID: n, Geometry: toGeometry(shape), Properties: map[string]interface{}{}}
+	for i, fd := range fields {
+		f.Properties[fd.String()] = property(fd, r.Attribute(i))
+	}
+	return f
+}
+
+// openLayer opens the layer called name from the cache in a "shp.open" span.
+// The returned reader must be released.
+func (s *Server) openLayer(ctx context.Context, name string) (*shp.CachedReader, error) {
+	_, span := shp.StartSpan(ctx, "shp.open")
+	defer span.End()
+	span.SetAttribute("layer", name)
+	path, err := s.ds.LayerPath(name)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	layer, err := s.cache.Open(path)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return layer, err
+}
+
+// startScan starts a "shp.scan" span for reading layer.
+func startScan(ctx context.Context, name string) shp.Span {
+	_, span := shp.StartSpan(ctx, "shp.scan")
+	span.SetAttribute("layer", name)
+	return span
+}
+
+// endScan ends the span of a scan that read records and returned count
+// features.
+func endScan(span shp.Span, records, count int, err error) {
+	span.SetAttribute("records", records)
+	span.SetAttribute("features", count)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+func (s *Server) serveFeatures(w http.ResponseWriter, r *http.Request, name string) {
+	layer, err := s.openLayer(r.Context(), name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer layer.Release()
+	fields := layer.Fields()
+
+	q := r.URL.Query()
+	filter, err := parseFilter(q, fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := -1
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit: "+v, http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json-seq")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	count, read := 0, 0
+	span := startScan(r.Context(), name)
+	if limit != 0 {
+		read, err = filter.scan(layer, 0, func(rec *indexedRecord) bool {
+			w.Write([]byte{0x1e})
+			if err := enc.Encode(toFeature(rec, fields)); err != nil {
+				return false
+			}
+			if count++; flusher != nil && count%100 == 0 {
+				flusher.Flush()
+			}
+			return count != limit
+		})
+	}
+	endScan(span, read, count, err)
+}
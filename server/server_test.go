@@ -0,0 +1,177 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func writeTestLayers(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "go-shp-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := shp.Create(filepath.Join(dir, "cities.shp"), shp.POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]shp.Field{shp.StringField("NAME", 20), shp.NumberField("POP", 10)})
+	for i, c := range []struct {
+		p    shp.Point
+		name string
+		pop  int
+	}{
+		{shp.Point{X: 1, Y: 1}, "Springfield", 30000},
+		{shp.Point{X: 5, Y: 5}, "Shelbyville", 25000},
+		{shp.Point{X: 9, Y: 9}, "Capital City", 1000000},
+	} {
+		w.Write(&c.p)
+		w.WriteAttribute(i, 0, c.name)
+		w.WriteAttribute(i, 1, c.pop)
+	}
+	w.Close()
+
+	w, err = shp.Create(filepath.Join(dir, "parks.shp"), shp.POLYGON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(shp.NewPolyLine([][]shp.Point{
+		{{X: 0, Y: 0}, {X: 0, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 0}, {X: 0, Y: 0}},
+		{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 1, Y: 2}, {X: 1, Y: 1}},
+	}))
+	w.Close()
+	return dir
+}
+
+// getFeatures returns the features of a GeoJSON text sequence response.
+func getFeatures(t *testing.T, srv http.Handler, url string) []feature {
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: got status %d: %s", url, rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/geo+json-seq" {
+		t.Errorf("got content type %s", ct)
+	}
+	var out []feature
+	s := bufio.NewScanner(rec.Body)
+	for s.Scan() {
+		var f feature
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(s.Text(), "\x1e")), &f); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func TestLayers(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/layers", nil))
+	var layers []layer
+	if err := json.NewDecoder(rec.Body).Decode(&layers); err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 2 || layers[0].Name != "cities" || layers[1].GeometryType != "POLYGON" {
+		t.Fatalf("got layers %+v", layers)
+	}
+	if !reflect.DeepEqual(layers[0].BBox, [4]float64{1, 1, 9, 9}) || len(layers[0].Fields) != 2 {
+		t.Errorf("got layer %+v", layers[0])
+	}
+
+	srv, err = Open(filepath.Join(dir, "parks.shp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/layers/cities/features", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status %d for layer outside of the server", rec.Code)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		ids   []int
+	}{
+		{"", []int{0, 1, 2}},
+		{"?bbox=4,4,10,10", []int{1, 2}},
+		{"?where=name=Shelbyville", []int{1}},
+		{"?where=NAME!=Shelbyville&bbox=0,0,6,6", []int{0}},
+		{"?limit=2", []int{0, 1}},
+	}
+	for _, test := range tests {
+		var ids []int
+		for _, f := range getFeatures(t, srv, "/layers/cities/features"+test.query) {
+			ids = append(ids, f.ID)
+		}
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("%q: got features %v, want %v", test.query, ids, test.ids)
+		}
+	}
+
+	fs := getFeatures(t, srv, "/layers/cities/features?limit=1")
+	if fs[0].Properties["NAME"] != "Springfield" || fs[0].Properties["POP"] != 30000.0 {
+		t.Errorf("got properties %v", fs[0].Properties)
+	}
+	if fs[0].Geometry.Type != "Point" {
+		t.Errorf("got geometry %+v", fs[0].Geometry)
+	}
+
+	fs = getFeatures(t, srv, "/layers/parks/features")
+	if g := fs[0].Geometry; g.Type != "Polygon" || len(g.Coordinates.([]interface{})) != 2 {
+		t.Errorf("got geometry %+v, want polygon with hole", g)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/layers/cities/features?where=AREA=1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for unknown field", rec.Code)
+	}
+}
+
+func TestLayerCache(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	if fs := getFeatures(t, srv, "/layers/cities/features?bbox=4,4,10,10"); len(fs) != 2 {
+		t.Fatalf("got %d features, want 2", len(fs))
+	}
+	// the cached layer is neither reopened nor reindexed
+	for _, ext := range []string{".shp", ".shx", ".dbf"} {
+		if err := os.Remove(filepath.Join(dir, "cities"+ext)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fs := getFeatures(t, srv, "/layers/cities/features?bbox=4,4,10,10"); len(fs) != 2 || fs[1].ID != 2 {
+		t.Errorf("got features %+v from the cache", fs)
+	}
+}
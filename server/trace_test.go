@@ -0,0 +1,64 @@
+//go:build !shp_minimal
+
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.attrs["error"] = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer []*testSpan
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, shp.Span) {
+	s := &testSpan{name: name, attrs: make(map[string]interface{})}
+	*t = append(*t, s)
+	return ctx, s
+}
+
+func TestTracer(t *testing.T) {
+	dir := writeTestLayers(t)
+	defer os.RemoveAll(dir)
+	srv, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tracer testTracer
+	srv.Tracer = &tracer
+
+	getFeatures(t, srv, "/layers/cities/features?limit=2")
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/collections/cities/items?limit=1", nil))
+	if len(tracer) != 4 {
+		t.Fatalf("got %d spans, want 4", len(tracer))
+	}
+	for i, want := range []struct {
+		name              string
+		records, features int
+	}{
+		{"shp.open", 0, 0},
+		{"shp.scan", 2, 2},
+		{"shp.open", 0, 0},
+		{"shp.scan", 2, 1},
+	} {
+		s := tracer[i]
+		if s.name != want.name || !s.ended || s.attrs["layer"] != "cities" {
+			t.Errorf("span %d: got %+v", i, s)
+		}
+		if want.name == "shp.scan" && (s.attrs["records"] != want.records || s.attrs["features"] != want.features) {
+			t.Errorf("span %d: got %+v", i, s)
+		}
+	}
+}
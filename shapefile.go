@@ -3,6 +3,7 @@ package shp
 import (
 	"encoding/binary"
 	"io"
+	"math"
 	"strings"
 )
 
@@ -60,6 +61,71 @@ func (b *Box) ExtendWithPoint(p Point) {
 	}
 }
 
+// EmptyBox returns a box that contains nothing. Extending it with a point or
+// taking its union with another box yields that point's or box's extent.
+func EmptyBox() Box {
+	return Box{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+// IsEmpty returns true if the box contains no points at all, i.e. if a
+// minimum is larger than its maximum or any coordinate is NaN. A box of zero
+// width or height is not empty.
+func (b Box) IsEmpty() bool {
+	return !(b.MinX <= b.MaxX && b.MinY <= b.MaxY)
+}
+
+// Union returns the smallest box that contains both b and o. Empty boxes are
+// ignored.
+func (b Box) Union(o Box) Box {
+	if b.IsEmpty() {
+		return o
+	}
+	if o.IsEmpty() {
+		return b
+	}
+	return Box{
+		math.Min(b.MinX, o.MinX), math.Min(b.MinY, o.MinY),
+		math.Max(b.MaxX, o.MaxX), math.Max(b.MaxY, o.MaxY),
+	}
+}
+
+// Intersect returns the area that b and o have in common and whether they
+// intersect at all. Boxes that only touch intersect in a box of zero width or
+// height.
+func (b Box) Intersect(o Box) (Box, bool) {
+	i := Box{
+		math.Max(b.MinX, o.MinX), math.Max(b.MinY, o.MinY),
+		math.Min(b.MaxX, o.MaxX), math.Min(b.MaxY, o.MaxY),
+	}
+	if i.IsEmpty() {
+		return EmptyBox(), false
+	}
+	return i, true
+}
+
+// Intersects returns whether b and o have at least one point in common.
+func (b Box) Intersects(o Box) bool {
+	_, ok := b.Intersect(o)
+	return ok
+}
+
+// ExpandBy returns b grown by d on every side. A negative d shrinks the box.
+func (b Box) ExpandBy(d float64) Box {
+	return Box{b.MinX - d, b.MinY - d, b.MaxX + d, b.MaxY + d}
+}
+
+// ContainsBox returns whether o lies completely inside b, including its
+// boundary.
+func (b Box) ContainsBox(o Box) bool {
+	return !b.IsEmpty() && !o.IsEmpty() &&
+		o.MinX >= b.MinX && o.MinY >= b.MinY && o.MaxX <= b.MaxX && o.MaxY <= b.MaxY
+}
+
+// ContainsPoint returns whether p lies inside b, including its boundary.
+func (b Box) ContainsPoint(p Point) bool {
+	return p.X >= b.MinX && p.Y >= b.MinY && p.X <= b.MaxX && p.Y <= b.MaxY
+}
+
 // BBoxFromPoints returns the bounding box calculated
 // from points.
 func BBoxFromPoints(points []Point) (box Box) {
@@ -132,6 +198,35 @@ func flatten(points [][]Point) []Point {
 	return r
 }
 
+// part returns the points of the i-th part from points, where parts holds
+// the index of the first point of every part. Offsets that are out of range
+// are clamped, so corrupt shapes yield empty or short parts instead of a
+// panic.
+func part(parts []int32, points []Point, i int) []Point {
+	if i < 0 || i >= len(parts) {
+		return nil
+	}
+	start := clampIndex(parts[i], len(points))
+	end := len(points)
+	if i+1 < len(parts) {
+		end = clampIndex(parts[i+1], len(points))
+	}
+	if end < start {
+		end = start
+	}
+	return points[start:end]
+}
+
+func clampIndex(i int32, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if int(i) > n {
+		return n
+	}
+	return int(i)
+}
+
 // PolyLine is a shape type that consists of an ordered set of vertices that
 // consists of one or more parts. A part is a connected sequence of two ore
 // more points. Parts may or may not be connected to another and may or may not
@@ -170,14 +265,23 @@ func (p PolyLine) BBox() Box {
 	return BBoxFromPoints(p.Points)
 }
 
+// Part returns the points of the i-th part of the PolyLine. The returned slice
+// shares its memory with p.Points.
+func (p PolyLine) Part(i int) []Point {
+	return part(p.Parts, p.Points, i)
+}
+
 func (p *PolyLine) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumParts)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Parts = make([]int32, p.NumParts)
-	p.Points = make([]Point, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Parts)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, p.NumParts, p.NumPoints, 4) {
+		return
+	}
+	p.Parts = makeInt32s(file, p.NumParts)
+	p.Points = makePoints(file, p.NumPoints)
+	readInt32s(file, p.Parts)
+	readPoints(file, p.Points)
 }
 
 func (p *PolyLine) write(file io.Writer) {
@@ -197,14 +301,23 @@ func (p Polygon) BBox() Box {
 	return BBoxFromPoints(p.Points)
 }
 
+// Part returns the points of the i-th part of the Polygon. The returned slice
+// shares its memory with p.Points.
+func (p Polygon) Part(i int) []Point {
+	return part(p.Parts, p.Points, i)
+}
+
 func (p *Polygon) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumParts)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Parts = make([]int32, p.NumParts)
-	p.Points = make([]Point, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Parts)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, p.NumParts, p.NumPoints, 4) {
+		return
+	}
+	p.Parts = makeInt32s(file, p.NumParts)
+	p.Points = makePoints(file, p.NumPoints)
+	readInt32s(file, p.Parts)
+	readPoints(file, p.Points)
 }
 
 func (p *Polygon) write(file io.Writer) {
@@ -230,8 +343,11 @@ func (p MultiPoint) BBox() Box {
 func (p *MultiPoint) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Points = make([]Point, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, 0, p.NumPoints, 0) {
+		return
+	}
+	p.Points = makePoints(file, p.NumPoints)
+	readPoints(file, p.Points)
 }
 
 func (p *MultiPoint) write(file io.Writer) {
@@ -259,7 +375,7 @@ func (p *PointZ) read(file io.Reader) {
 }
 
 func (p *PointZ) write(file io.Writer) {
-	binary.Write(file, binary.LittleEndian, p)
+	binary.Write(file, binary.LittleEndian, []float64{p.X, p.Y, p.Z, encodeMeasure(p.M)})
 }
 
 // PolyLineZ is a shape which consists of one or more parts. A part is a
@@ -282,20 +398,29 @@ func (p PolyLineZ) BBox() Box {
 	return BBoxFromPoints(p.Points)
 }
 
+// Part returns the points of the i-th part of the PolyLineZ. The returned slice
+// shares its memory with p.Points.
+func (p PolyLineZ) Part(i int) []Point {
+	return part(p.Parts, p.Points, i)
+}
+
 func (p *PolyLineZ) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumParts)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Parts = make([]int32, p.NumParts)
-	p.Points = make([]Point, p.NumPoints)
-	p.ZArray = make([]float64, p.NumPoints)
-	p.MArray = make([]float64, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Parts)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, p.NumParts, p.NumPoints, 4) {
+		return
+	}
+	p.Parts = makeInt32s(file, p.NumParts)
+	p.Points = makePoints(file, p.NumPoints)
+	p.ZArray = makeFloats(file, p.NumPoints)
+	p.MArray = makeFloats(file, p.NumPoints)
+	readInt32s(file, p.Parts)
+	readPoints(file, p.Points)
 	binary.Read(file, binary.LittleEndian, &p.ZRange)
-	binary.Read(file, binary.LittleEndian, &p.ZArray)
+	readFloats(file, p.ZArray)
 	binary.Read(file, binary.LittleEndian, &p.MRange)
-	binary.Read(file, binary.LittleEndian, &p.MArray)
+	readFloats(file, p.MArray)
 }
 
 func (p *PolyLineZ) write(file io.Writer) {
@@ -306,8 +431,8 @@ func (p *PolyLineZ) write(file io.Writer) {
 	binary.Write(file, binary.LittleEndian, p.Points)
 	binary.Write(file, binary.LittleEndian, p.ZRange)
 	binary.Write(file, binary.LittleEndian, p.ZArray)
-	binary.Write(file, binary.LittleEndian, p.MRange)
-	binary.Write(file, binary.LittleEndian, p.MArray)
+	writeMeasures(file, p.MRange[:])
+	writeMeasures(file, p.MArray)
 }
 
 // PolygonZ structure is identical to the PolyLineZ structure.
@@ -318,20 +443,29 @@ func (p PolygonZ) BBox() Box {
 	return BBoxFromPoints(p.Points)
 }
 
+// Part returns the points of the i-th part of the PolygonZ. The returned slice
+// shares its memory with p.Points.
+func (p PolygonZ) Part(i int) []Point {
+	return part(p.Parts, p.Points, i)
+}
+
 func (p *PolygonZ) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumParts)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Parts = make([]int32, p.NumParts)
-	p.Points = make([]Point, p.NumPoints)
-	p.ZArray = make([]float64, p.NumPoints)
-	p.MArray = make([]float64, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Parts)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, p.NumParts, p.NumPoints, 4) {
+		return
+	}
+	p.Parts = makeInt32s(file, p.NumParts)
+	p.Points = makePoints(file, p.NumPoints)
+	p.ZArray = makeFloats(file, p.NumPoints)
+	p.MArray = makeFloats(file, p.NumPoints)
+	readInt32s(file, p.Parts)
+	readPoints(file, p.Points)
 	binary.Read(file, binary.LittleEndian, &p.ZRange)
-	binary.Read(file, binary.LittleEndian, &p.ZArray)
+	readFloats(file, p.ZArray)
 	binary.Read(file, binary.LittleEndian, &p.MRange)
-	binary.Read(file, binary.LittleEndian, &p.MArray)
+	readFloats(file, p.MArray)
 }
 
 func (p *PolygonZ) write(file io.Writer) {
@@ -342,8 +476,8 @@ func (p *PolygonZ) write(file io.Writer) {
 	binary.Write(file, binary.LittleEndian, p.Points)
 	binary.Write(file, binary.LittleEndian, p.ZRange)
 	binary.Write(file, binary.LittleEndian, p.ZArray)
-	binary.Write(file, binary.LittleEndian, p.MRange)
-	binary.Write(file, binary.LittleEndian, p.MArray)
+	writeMeasures(file, p.MRange[:])
+	writeMeasures(file, p.MArray)
 }
 
 // MultiPointZ consists of one ore more PointZ.
@@ -365,14 +499,17 @@ func (p MultiPointZ) BBox() Box {
 func (p *MultiPointZ) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Points = make([]Point, p.NumPoints)
-	p.ZArray = make([]float64, p.NumPoints)
-	p.MArray = make([]float64, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, 0, p.NumPoints, 0) {
+		return
+	}
+	p.Points = makePoints(file, p.NumPoints)
+	p.ZArray = makeFloats(file, p.NumPoints)
+	p.MArray = makeFloats(file, p.NumPoints)
+	readPoints(file, p.Points)
 	binary.Read(file, binary.LittleEndian, &p.ZRange)
-	binary.Read(file, binary.LittleEndian, &p.ZArray)
+	readFloats(file, p.ZArray)
 	binary.Read(file, binary.LittleEndian, &p.MRange)
-	binary.Read(file, binary.LittleEndian, &p.MArray)
+	readFloats(file, p.MArray)
 }
 
 func (p *MultiPointZ) write(file io.Writer) {
@@ -381,8 +518,8 @@ func (p *MultiPointZ) write(file io.Writer) {
 	binary.Write(file, binary.LittleEndian, p.Points)
 	binary.Write(file, binary.LittleEndian, p.ZRange)
 	binary.Write(file, binary.LittleEndian, p.ZArray)
-	binary.Write(file, binary.LittleEndian, p.MRange)
-	binary.Write(file, binary.LittleEndian, p.MArray)
+	writeMeasures(file, p.MRange[:])
+	writeMeasures(file, p.MArray)
 }
 
 // PointM is a point with a measure.
@@ -403,7 +540,7 @@ func (p *PointM) read(file io.Reader) {
 }
 
 func (p *PointM) write(file io.Writer) {
-	binary.Write(file, binary.LittleEndian, p)
+	binary.Write(file, binary.LittleEndian, []float64{p.X, p.Y, encodeMeasure(p.M)})
 }
 
 // PolyLineM is the polyline in which each point also has a measure.
@@ -422,17 +559,26 @@ func (p PolyLineM) BBox() Box {
 	return BBoxFromPoints(p.Points)
 }
 
+// Part returns the points of the i-th part of the PolyLineM. The returned slice
+// shares its memory with p.Points.
+func (p PolyLineM) Part(i int) []Point {
+	return part(p.Parts, p.Points, i)
+}
+
 func (p *PolyLineM) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumParts)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Parts = make([]int32, p.NumParts)
-	p.Points = make([]Point, p.NumPoints)
-	p.MArray = make([]float64, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Parts)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, p.NumParts, p.NumPoints, 4) {
+		return
+	}
+	p.Parts = makeInt32s(file, p.NumParts)
+	p.Points = makePoints(file, p.NumPoints)
+	p.MArray = makeFloats(file, p.NumPoints)
+	readInt32s(file, p.Parts)
+	readPoints(file, p.Points)
 	binary.Read(file, binary.LittleEndian, &p.MRange)
-	binary.Read(file, binary.LittleEndian, &p.MArray)
+	readFloats(file, p.MArray)
 }
 
 func (p *PolyLineM) write(file io.Writer) {
@@ -441,8 +587,8 @@ func (p *PolyLineM) write(file io.Writer) {
 	binary.Write(file, binary.LittleEndian, p.NumPoints)
 	binary.Write(file, binary.LittleEndian, p.Parts)
 	binary.Write(file, binary.LittleEndian, p.Points)
-	binary.Write(file, binary.LittleEndian, p.MRange)
-	binary.Write(file, binary.LittleEndian, p.MArray)
+	writeMeasures(file, p.MRange[:])
+	writeMeasures(file, p.MArray)
 }
 
 // PolygonM structure is identical to the PolyLineZ structure.
@@ -453,17 +599,26 @@ func (p PolygonM) BBox() Box {
 	return BBoxFromPoints(p.Points)
 }
 
+// Part returns the points of the i-th part of the PolygonM. The returned slice
+// shares its memory with p.Points.
+func (p PolygonM) Part(i int) []Point {
+	return part(p.Parts, p.Points, i)
+}
+
 func (p *PolygonM) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumParts)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Parts = make([]int32, p.NumParts)
-	p.Points = make([]Point, p.NumPoints)
-	p.MArray = make([]float64, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Parts)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, p.NumParts, p.NumPoints, 4) {
+		return
+	}
+	p.Parts = makeInt32s(file, p.NumParts)
+	p.Points = makePoints(file, p.NumPoints)
+	p.MArray = makeFloats(file, p.NumPoints)
+	readInt32s(file, p.Parts)
+	readPoints(file, p.Points)
 	binary.Read(file, binary.LittleEndian, &p.MRange)
-	binary.Read(file, binary.LittleEndian, &p.MArray)
+	readFloats(file, p.MArray)
 }
 
 func (p *PolygonM) write(file io.Writer) {
@@ -472,8 +627,8 @@ func (p *PolygonM) write(file io.Writer) {
 	binary.Write(file, binary.LittleEndian, p.NumPoints)
 	binary.Write(file, binary.LittleEndian, p.Parts)
 	binary.Write(file, binary.LittleEndian, p.Points)
-	binary.Write(file, binary.LittleEndian, p.MRange)
-	binary.Write(file, binary.LittleEndian, p.MArray)
+	writeMeasures(file, p.MRange[:])
+	writeMeasures(file, p.MArray)
 }
 
 // MultiPointM is the collection of multiple points with measures.
@@ -493,19 +648,22 @@ func (p MultiPointM) BBox() Box {
 func (p *MultiPointM) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Points = make([]Point, p.NumPoints)
-	p.MArray = make([]float64, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, 0, p.NumPoints, 0) {
+		return
+	}
+	p.Points = makePoints(file, p.NumPoints)
+	p.MArray = makeFloats(file, p.NumPoints)
+	readPoints(file, p.Points)
 	binary.Read(file, binary.LittleEndian, &p.MRange)
-	binary.Read(file, binary.LittleEndian, &p.MArray)
+	readFloats(file, p.MArray)
 }
 
 func (p *MultiPointM) write(file io.Writer) {
 	binary.Write(file, binary.LittleEndian, p.Box)
 	binary.Write(file, binary.LittleEndian, p.NumPoints)
 	binary.Write(file, binary.LittleEndian, p.Points)
-	binary.Write(file, binary.LittleEndian, p.MRange)
-	binary.Write(file, binary.LittleEndian, p.MArray)
+	writeMeasures(file, p.MRange[:])
+	writeMeasures(file, p.MArray)
 }
 
 // MultiPatch consists of a number of surfaces patches. Each surface path
@@ -530,22 +688,31 @@ func (p MultiPatch) BBox() Box {
 	return BBoxFromPoints(p.Points)
 }
 
+// Part returns the points of the i-th part of the MultiPatch. The returned slice
+// shares its memory with p.Points.
+func (p MultiPatch) Part(i int) []Point {
+	return part(p.Parts, p.Points, i)
+}
+
 func (p *MultiPatch) read(file io.Reader) {
 	binary.Read(file, binary.LittleEndian, &p.Box)
 	binary.Read(file, binary.LittleEndian, &p.NumParts)
 	binary.Read(file, binary.LittleEndian, &p.NumPoints)
-	p.Parts = make([]int32, p.NumParts)
-	p.PartTypes = make([]int32, p.NumParts)
-	p.Points = make([]Point, p.NumPoints)
-	p.ZArray = make([]float64, p.NumPoints)
-	p.MArray = make([]float64, p.NumPoints)
-	binary.Read(file, binary.LittleEndian, &p.Parts)
-	binary.Read(file, binary.LittleEndian, &p.PartTypes)
-	binary.Read(file, binary.LittleEndian, &p.Points)
+	if !checkCounts(file, p.NumParts, p.NumPoints, 8) {
+		return
+	}
+	p.Parts = makeInt32s(file, p.NumParts)
+	p.PartTypes = makeInt32s(file, p.NumParts)
+	p.Points = makePoints(file, p.NumPoints)
+	p.ZArray = makeFloats(file, p.NumPoints)
+	p.MArray = makeFloats(file, p.NumPoints)
+	readInt32s(file, p.Parts)
+	readInt32s(file, p.PartTypes)
+	readPoints(file, p.Points)
 	binary.Read(file, binary.LittleEndian, &p.ZRange)
-	binary.Read(file, binary.LittleEndian, &p.ZArray)
+	readFloats(file, p.ZArray)
 	binary.Read(file, binary.LittleEndian, &p.MRange)
-	binary.Read(file, binary.LittleEndian, &p.MArray)
+	readFloats(file, p.MArray)
 }
 
 func (p *MultiPatch) write(file io.Writer) {
@@ -557,8 +724,8 @@ func (p *MultiPatch) write(file io.Writer) {
 	binary.Write(file, binary.LittleEndian, p.Points)
 	binary.Write(file, binary.LittleEndian, p.ZRange)
 	binary.Write(file, binary.LittleEndian, p.ZArray)
-	binary.Write(file, binary.LittleEndian, p.MRange)
-	binary.Write(file, binary.LittleEndian, p.MArray)
+	writeMeasures(file, p.MRange[:])
+	writeMeasures(file, p.MArray)
 }
 
 // Field representation of a field object in the DBF file
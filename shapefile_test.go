@@ -20,3 +20,57 @@ func TestBoxExtend(t *testing.T) {
 		t.Errorf("a.MaxY = %v, want %v", a.MaxY, c.MaxY)
 	}
 }
+
+func TestPart(t *testing.T) {
+	p := NewPolyLine([][]Point{
+		{{0, 0}, {1, 1}},
+		{{2, 2}, {3, 3}, {4, 4}},
+	})
+	if got := p.Part(0); len(got) != 2 || got[1] != (Point{1, 1}) {
+		t.Errorf("got part 0 = %v", got)
+	}
+	if got := p.Part(1); len(got) != 3 || got[0] != (Point{2, 2}) {
+		t.Errorf("got part 1 = %v", got)
+	}
+	if got := p.Part(2); got != nil {
+		t.Errorf("got part 2 = %v, want nil", got)
+	}
+
+	// corrupt offsets must not panic
+	p.Parts = []int32{0, 10, -1}
+	if got := (*Polygon)(p).Part(1); len(got) != 0 {
+		t.Errorf("got part 1 = %v, want empty", got)
+	}
+}
+
+func TestBoxOperations(t *testing.T) {
+	a := Box{0, 0, 10, 10}
+	b := Box{5, 5, 15, 20}
+	if got, want := a.Union(b), (Box{0, 0, 15, 20}); got != want {
+		t.Errorf("Union = %v, want %v", got, want)
+	}
+	if got := EmptyBox().Union(a); got != a {
+		t.Errorf("Union with empty box = %v, want %v", got, a)
+	}
+	if got, ok := a.Intersect(b); !ok || got != (Box{5, 5, 10, 10}) {
+		t.Errorf("Intersect = %v, %v", got, ok)
+	}
+	if _, ok := a.Intersect(Box{11, 11, 12, 12}); ok {
+		t.Error("disjoint boxes intersect")
+	}
+	if got, ok := a.Intersect(Box{10, 0, 20, 10}); !ok || got != (Box{10, 0, 10, 10}) {
+		t.Errorf("Intersect of touching boxes = %v, %v", got, ok)
+	}
+	if got := a.ExpandBy(1); got != (Box{-1, -1, 11, 11}) {
+		t.Errorf("ExpandBy = %v", got)
+	}
+	if !EmptyBox().IsEmpty() || a.IsEmpty() || (Box{1, 1, 1, 1}).IsEmpty() {
+		t.Error("IsEmpty is wrong")
+	}
+	if !a.ContainsBox(Box{1, 1, 10, 10}) || a.ContainsBox(b) {
+		t.Error("ContainsBox is wrong")
+	}
+	if !a.ContainsPoint(Point{10, 0}) || a.ContainsPoint(Point{10.5, 0}) {
+		t.Error("ContainsPoint is wrong")
+	}
+}
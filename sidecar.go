@@ -0,0 +1,92 @@
+package shp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sidecars lists the files that make up a shapefile. Files that were not found
+// are empty strings.
+type Sidecars struct {
+	SHP string
+	SHX string
+	DBF string
+	PRJ string
+	CPG string
+}
+
+// sidecarExts are the extensions that FindSidecars looks for.
+var sidecarExts = []string{".shp", ".shx", ".dbf", ".prj", ".cpg"}
+
+func (s *Sidecars) set(ext, filename string) {
+	switch ext {
+	case ".shp":
+		s.SHP = filename
+	case ".shx":
+		s.SHX = filename
+	case ".dbf":
+		s.DBF = filename
+	case ".prj":
+		s.PRJ = filename
+	case ".cpg":
+		s.CPG = filename
+	}
+}
+
+func (s *Sidecars) get(ext string) string {
+	switch ext {
+	case ".shp":
+		return s.SHP
+	case ".shx":
+		return s.SHX
+	case ".dbf":
+		return s.DBF
+	case ".prj":
+		return s.PRJ
+	case ".cpg":
+		return s.CPG
+	}
+	return ""
+}
+
+// trimSidecarExt removes any of the shapefile extensions from filename.
+func trimSidecarExt(filename string) string {
+	ext := filepath.Ext(filename)
+	for _, e := range sidecarExts {
+		if strings.EqualFold(ext, e) {
+			return strings.TrimSuffix(filename, ext)
+		}
+	}
+	return filename
+}
+
+// FindSidecars looks for the files belonging to the shapefile filename, which
+// may carry any of the shapefile extensions or none at all. Both the basename
+// and the extensions are matched case-insensitively, as shapefiles coming from
+// Windows often have names like "ROADS.SHP" and "Roads.dbf". If there are
+// several candidates, the one matching the case of filename is preferred.
+func FindSidecars(filename string) (Sidecars, error) {
+	var s Sidecars
+	base := trimSidecarExt(filename)
+	dir, name := filepath.Split(base)
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return s, err
+	}
+	for _, fi := range entries {
+		if fi.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(fi.Name())
+		if !strings.EqualFold(strings.TrimSuffix(fi.Name(), ext), name) {
+			continue
+		}
+		lower := strings.ToLower(ext)
+		exact := strings.TrimSuffix(fi.Name(), ext) == name
+		if s.get(lower) == "" || exact {
+			s.set(lower, filepath.Join(dir, fi.Name()))
+		}
+	}
+	return s, nil
+}
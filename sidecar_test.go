@@ -0,0 +1,110 @@
+package shp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// copyToTempDir copies the files src[i] to the names dst[i] in a new
+// temporary directory.
+func copyToTempDir(t *testing.T, src, dst []string) string {
+	dir, err := os.MkdirTemp("", "go-shp-test")
+	if err != nil {
+		t.Fatalf("Could not create temporary directory: %v", err)
+	}
+	for i := range src {
+		b, err := os.ReadFile(src[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, dst[i]), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFindSidecarsMixedCase(t *testing.T) {
+	dir := copyToTempDir(t,
+		[]string{"test_files/point.shp", "test_files/point.shx", "test_files/point.dbf"},
+		[]string{"POINT.SHP", "point.shx", "Point.Dbf"})
+	defer os.RemoveAll(dir)
+
+	s, err := FindSidecars(filepath.Join(dir, "POINT.SHP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Sidecars{
+		SHP: filepath.Join(dir, "POINT.SHP"),
+		SHX: filepath.Join(dir, "point.shx"),
+		DBF: filepath.Join(dir, "Point.Dbf"),
+	}
+	if s != want {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+
+	r, err := Open(filepath.Join(dir, "POINT.SHP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Sidecars() != want {
+		t.Errorf("got %+v from Reader, want %+v", r.Sidecars(), want)
+	}
+	if len(r.Fields()) != 1 {
+		t.Errorf("got %d fields, want 1", len(r.Fields()))
+	}
+}
+
+func TestOpenGeometryOnly(t *testing.T) {
+	dir := copyToTempDir(t, []string{"test_files/point.shp"}, []string{"point.shp"})
+	defer os.RemoveAll(dir)
+
+	r, err := Open(filepath.Join(dir, "point.shp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	n := 0
+	for r.Next() {
+		if r.Attribute(0) != "" {
+			t.Error("got attribute without DBF")
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("got %d shapes, want 3", n)
+	}
+	if r.Fields() != nil {
+		t.Errorf("got fields %v without DBF", r.Fields())
+	}
+}
+
+func TestOpenTableOnly(t *testing.T) {
+	dir := copyToTempDir(t, []string{"test_files/point.dbf"}, []string{"point.dbf"})
+	defer os.RemoveAll(dir)
+
+	r, err := Open(filepath.Join(dir, "point.shp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	n := 0
+	for r.Next() {
+		if _, s := r.Shape(); s != nil {
+			t.Errorf("got shape %v in table-only mode", s)
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("got %d rows, want 3", n)
+	}
+}
+
+func TestOpenMissing(t *testing.T) {
+	_, err := Open("test_files/missing.shp")
+	if !os.IsNotExist(err) {
+		t.Errorf("got error %v, want not-exist error", err)
+	}
+}
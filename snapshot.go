@@ -0,0 +1,30 @@
+package shp
+
+// Snapshot makes Open capture the records of the shapefile when it is opened,
+// so that a Reader keeps seeing the same records while a single Writer
+// appends to the files (see Append). The SHX file is read into memory, and
+// Next, NumRecords and ShapeAt are limited to the records it lists. As a
+// Writer adds the SHX entry of a record only after the record itself, these
+// are complete records. The DBF header is read at the same time, so its
+// record count is captured as well; rows appended later are not visible.
+func Snapshot() ReaderOption {
+	return func(o *readerOptions) {
+		o.snapshot = true
+	}
+}
+
+// takeSnapshot loads the index and the DBF header and limits reading to the
+// records listed in the index.
+func (r *Reader) takeSnapshot() error {
+	r.loadIndex()
+	if r.indexErr != nil {
+		return r.indexErr
+	}
+	r.filelength = 100
+	if n := len(r.index); n > 0 {
+		last := r.index[n-1]
+		r.filelength = last.offset + 8 + last.length
+	}
+	r.debug("took snapshot", "records", len(r.index), "length", r.filelength)
+	return nil
+}
@@ -0,0 +1,54 @@
+package shp
+
+import (
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	filename, err := Generate(GenOptions{Dir: t.TempDir(), Records: 5, Fields: []Field{NumberField("ID", 4)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(filename, Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	w, err := Append(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		n := int(w.Write(&Point{float64(i), float64(i)}))
+		w.WriteAttribute(n, 0, n)
+	}
+	w.Close()
+
+	if n := r.NumRecords(); n != 5 {
+		t.Errorf("NumRecords() = %d, want 5", n)
+	}
+	if n := r.AttributeCount(); n != 5 {
+		t.Errorf("AttributeCount() = %d, want 5", n)
+	}
+	if _, err := r.ShapeAt(5); err == nil {
+		t.Error("appended record is visible to ShapeAt")
+	}
+	n := 0
+	for r.Next() {
+		n++
+	}
+	if n != 5 || r.Err() != nil {
+		t.Errorf("read %d records, want 5: %v", n, r.Err())
+	}
+
+	// a new Reader sees the appended records
+	r2, err := Open(filename, Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r2.Close()
+	if n := r2.NumRecords(); n != 8 {
+		t.Errorf("NumRecords() = %d after append, want 8", n)
+	}
+}
@@ -0,0 +1,289 @@
+// Package snapshots stores versions of shapefiles in a directory, addressed
+// by the hashes of their contents, which gives lightweight versioning of
+// datasets without a version control system for large files:
+//
+//	store, err := snapshots.Open("parcels.versions")
+//	v1, err := store.Save("parcels.shp")
+//	// ... edit parcels.shp ...
+//	v2, err := store.Save("parcels.shp")
+//	changes, err := store.Diff(v1, v2, "PARCEL_ID")
+//	err = store.Materialize(v1, "parcels_v1.shp")
+//
+// Every file of a shapefile is stored once, compressed with gzip, under the
+// hash of its contents, so files that did not change between versions, like
+// the PRJ file, take no additional space. A version lists its files and is
+// identified by the hash of that list.
+package snapshots
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+// Store is a directory of snapshots.
+type Store struct {
+	dir string
+}
+
+// Version is a snapshot in a Store.
+type Version struct {
+	ID   string
+	Time time.Time // when it was first saved
+}
+
+// Open opens the Store in the directory dir, creating it if needed.
+func Open(dir string) (*Store, error) {
+	for _, sub := range []string{"objects", "versions"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0777); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{dir: dir}, nil
+}
+
+// file is a file of a version.
+type file struct {
+	ext  string
+	hash string
+	size int64
+}
+
+// Save stores the shapefile filename, with the sidecar files found by
+// shp.FindSidecars, and returns the ID of the version. Saving a version that
+// is already stored only returns its ID.
+func (s *Store) Save(filename string) (string, error) {
+	sidecars, err := shp.FindSidecars(filename)
+	if err != nil {
+		return "", err
+	}
+	if sidecars.SHP == "" {
+		return "", fmt.Errorf("No SHP file found for %s", filename)
+	}
+	var files []file
+	for _, name := range []string{sidecars.SHP, sidecars.SHX, sidecars.DBF, sidecars.PRJ, sidecars.CPG} {
+		if name == "" {
+			continue
+		}
+		f, err := s.saveObject(name)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, f)
+	}
+
+	var list strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&list, "%s %s %d\n", f.ext, f.hash, f.size)
+	}
+	sum := sha256.Sum256([]byte(list.String()))
+	id := hex.EncodeToString(sum[:])
+	path := filepath.Join(s.dir, "versions", id)
+	if _, err := os.Stat(path); err == nil {
+		return id, nil
+	}
+	return id, writeFile(path, strings.NewReader(list.String()), false)
+}
+
+// saveObject stores the file name compressed under the hash of its contents,
+// unless it is already stored.
+func (s *Store) saveObject(name string) (file, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return file{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return file{}, err
+	}
+	obj := file{ext: strings.ToLower(filepath.Ext(name)), hash: hex.EncodeToString(h.Sum(nil)), size: size}
+	path := s.objectPath(obj.hash)
+	if _, err := os.Stat(path); err == nil {
+		return obj, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return file{}, err
+	}
+	return obj, writeFile(path, f, true)
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash+".gz")
+}
+
+// writeFile writes the contents of r to path, compressed if compress is
+// set. It writes to a temporary file first, so that path is either complete
+// or missing.
+func writeFile(path string, r io.Reader, compress bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	var w io.Writer = tmp
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(tmp)
+		w = zw
+	}
+	_, err = io.Copy(w, r)
+	if zw != nil {
+		if e := zw.Close(); err == nil {
+			err = e
+		}
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// files reads the list of files of the version id.
+func (s *Store) files(id string) ([]file, error) {
+	if len(id) != 2*sha256.Size || strings.Trim(id, "0123456789abcdef") != "" {
+		return nil, fmt.Errorf("Invalid version ID %q", id)
+	}
+	f, err := os.Open(filepath.Join(s.dir, "versions", id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("Version %s not found", id)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var files []file
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var v file
+		if _, err := fmt.Sscanf(scanner.Text(), "%s %s %d", &v.ext, &v.hash, &v.size); err != nil {
+			return nil, fmt.Errorf("Invalid version %s: %v", id, err)
+		}
+		files = append(files, v)
+	}
+	return files, scanner.Err()
+}
+
+// Versions returns the versions in the Store in the order they were saved.
+func (s *Store) Versions() ([]Version, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "versions"))
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, Version{ID: e.Name(), Time: info.ModTime()})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Time.Before(versions[j].Time)
+	})
+	return versions, nil
+}
+
+// Materialize writes the version id as the shapefile filename, with its
+// sidecar files named alike. The contents are checked against their hashes.
+func (s *Store) Materialize(id, filename string) error {
+	files, err := s.files(id)
+	if err != nil {
+		return err
+	}
+	basename := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for _, f := range files {
+		if err := s.materializeObject(f, basename+f.ext); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// materializeObject decompresses the object of f to the file name.
+func (s *Store) materializeObject(f file, name string) error {
+	obj, err := os.Open(s.objectPath(f.hash))
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	zr, err := gzip.NewReader(obj)
+	if err != nil {
+		return fmt.Errorf("Invalid object %s: %v", f.hash, err)
+	}
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), zr)
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != f.hash || n != f.size {
+		return fmt.Errorf("Object %s is corrupt: got %d bytes with hash %s", f.hash, n, sum)
+	}
+	return nil
+}
+
+// Diff returns the records that were added, changed or deleted from version
+// a to version b, as shp.Reader.DiffSince does, identified by the values of
+// keyField or, if it is "", by their index.
+func (s *Store) Diff(a, b, keyField string) ([]shp.Change, error) {
+	dir, err := os.MkdirTemp("", "snapshots-diff-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	var readers []*shp.Reader
+	defer func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}()
+	for i, id := range []string{a, b} {
+		name := filepath.Join(dir, fmt.Sprintf("v%d.shp", i))
+		if err := s.Materialize(id, name); err != nil {
+			return nil, err
+		}
+		r, err := shp.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, r)
+	}
+
+	m, err := readers[0].Manifest(keyField)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := readers[1].DiffSince(m)
+	if err != nil {
+		return nil, err
+	}
+	var changes []shp.Change
+	for stream.Next() {
+		changes = append(changes, stream.Change())
+	}
+	return changes, stream.Err()
+}
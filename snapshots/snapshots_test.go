@@ -0,0 +1,133 @@
+package snapshots
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	shp "github.com/brianolson/go-shp"
+)
+
+func writeShapefile(t *testing.T, name string) {
+	t.Helper()
+	w, err := shp.Create(name, shp.POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]shp.Field{shp.StringField("NAME", 20), shp.NumberField("POP", 10)})
+	for i, c := range []string{"Springfield", "Shelbyville"} {
+		n := int(w.Write(&shp.Point{X: float64(i), Y: float64(i)}))
+		w.WriteAttribute(n, 0, c)
+		w.WriteAttribute(n, 1, 1000*(i+1))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "cities.shp")
+	writeShapefile(t, name)
+	original, err := os.ReadFile(filepath.Join(dir, "cities.dbf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := store.Save(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := shp.Edit(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.UpdateAttr(1, 1, 2500)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	v2, err := store.Save(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1 == v2 {
+		t.Fatal("edited shapefile has the same version ID")
+	}
+	if again, err := store.Save(name); err != nil || again != v2 {
+		t.Errorf("Save of an unchanged shapefile = %s, %v, want %s", again, err, v2)
+	}
+
+	versions, err := store.Versions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].ID != v1 || versions[1].ID != v2 {
+		t.Errorf("Versions() = %v, want %s and %s", versions, v1, v2)
+	}
+	// only the DBF file changed
+	objects, err := os.ReadDir(filepath.Join(dir, "store", "objects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 4 {
+		t.Errorf("store has %d objects, want 4", len(objects))
+	}
+
+	restored := filepath.Join(dir, "restored.shp")
+	if err := store.Materialize(v1, restored); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "restored.dbf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("materialized DBF file differs from the saved one")
+	}
+
+	changes, err := store.Diff(v1, v2, "NAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Kind != shp.Changed || changes[0].Key != "Shelbyville" {
+		t.Errorf("Diff() = %+v, want Shelbyville changed", changes)
+	}
+
+	if err := store.Materialize("1234", restored); err == nil {
+		t.Error("Materialize of an invalid ID succeeded")
+	}
+}
+
+func TestStoreCorruptObject(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "cities.shp")
+	writeShapefile(t, name)
+	store, err := Open(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := store.Save(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := store.files(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// replace the SHP object with the DBF object
+	dbf, err := os.ReadFile(store.objectPath(files[2].hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(store.objectPath(files[0].hash), dbf, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Materialize(id, filepath.Join(dir, "restored.shp")); err == nil {
+		t.Error("Materialize of a corrupt object succeeded")
+	}
+}
@@ -0,0 +1,228 @@
+package shp
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Style colors the features of a shapefile by the value of one of its
+// fields. It is written as an OGC SLD file, for GeoServer and other map
+// servers, and as a QGIS QML file, which QGIS loads automatically when it is
+// next to the shapefile and named alike.
+type Style struct {
+	// Field is the name of the field that selects the class of a feature.
+	Field string
+	// Graduated selects the classes by the ranges [Min, Max) of numeric
+	// values instead of by Value.
+	Graduated bool
+	Classes   []StyleClass
+}
+
+// StyleClass is a class of features that are drawn in the same color.
+type StyleClass struct {
+	// Value is the attribute value of the features of the class, unless the
+	// Style is Graduated.
+	Value string
+	// Min and Max are the range of the attribute values of the features of
+	// the class, Min included, if the Style is Graduated.
+	Min, Max float64
+	// Color is the color of the features as "#rrggbb".
+	Color string
+	// Label describes the class in legends. If empty, the value or range
+	// is used.
+	Label string
+}
+
+// WithStyle makes Create and CreateZip write s for the shapefile to its SLD
+// and QML files on Close.
+func WithStyle(s *Style) WriterOption {
+	return func(o *writerOptions) {
+		o.style = s
+	}
+}
+
+// styleExts are the extensions of the files written for WithStyle.
+var styleExts = []string{".sld", ".qml"}
+
+// check returns an error if s has no field or an invalid color.
+func (s *Style) check() error {
+	if s.Field == "" {
+		return errors.New("Style has no field")
+	}
+	for _, c := range s.Classes {
+		if _, err := parseColor(c.Color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseColor parses a color "#rrggbb".
+func parseColor(c string) ([3]uint8, error) {
+	var rgb [3]uint8
+	if len(c) != 7 || c[0] != '#' {
+		return rgb, fmt.Errorf("Invalid color %q, want #rrggbb", c)
+	}
+	for i := range rgb {
+		v, err := strconv.ParseUint(c[1+2*i:3+2*i], 16, 8)
+		if err != nil {
+			return rgb, fmt.Errorf("Invalid color %q, want #rrggbb", c)
+		}
+		rgb[i] = uint8(v)
+	}
+	return rgb, nil
+}
+
+// label returns the label of c.
+func (s *Style) label(c StyleClass) string {
+	switch {
+	case c.Label != "":
+		return c.Label
+	case s.Graduated:
+		return fmt.Sprintf("%g - %g", c.Min, c.Max)
+	}
+	return c.Value
+}
+
+// escape escapes s for XML text and attributes.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// geometryKind returns whether shapes of type t are drawn as points, lines
+// or polygons.
+func geometryKind(t ShapeType) string {
+	switch t {
+	case POLYLINE, POLYLINEZ, POLYLINEM:
+		return "line"
+	case POLYGON, POLYGONZ, POLYGONM, MULTIPATCH:
+		return "polygon"
+	}
+	return "point"
+}
+
+// WriteSLD writes s as an OGC Styled Layer Descriptor 1.0 for the layer
+// called name whose shapes are of type t.
+func (s *Style) WriteSLD(w io.Writer, name string, t ShapeType) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<StyledLayerDescriptor version="1.0.0" xmlns="http://www.opengis.net/sld" xmlns:ogc="http://www.opengis.net/ogc" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.opengis.net/sld http://schemas.opengis.net/sld/1.0.0/StyledLayerDescriptor.xsd">
+`)
+	fmt.Fprintf(&b, "  <NamedLayer>\n    <Name>%s</Name>\n    <UserStyle>\n      <Title>%s</Title>\n      <FeatureTypeStyle>\n", escape(name), escape(name))
+	field := escape(s.Field)
+	for _, c := range s.Classes {
+		label := escape(s.label(c))
+		fmt.Fprintf(&b, "        <Rule>\n          <Name>%s</Name>\n          <Title>%s</Title>\n          <ogc:Filter>\n", label, label)
+		if s.Graduated {
+			fmt.Fprintf(&b, "            <ogc:And>\n"+
+				"              <ogc:PropertyIsGreaterThanOrEqualTo><ogc:PropertyName>%s</ogc:PropertyName><ogc:Literal>%g</ogc:Literal></ogc:PropertyIsGreaterThanOrEqualTo>\n"+
+				"              <ogc:PropertyIsLessThan><ogc:PropertyName>%s</ogc:PropertyName><ogc:Literal>%g</ogc:Literal></ogc:PropertyIsLessThan>\n"+
+				"            </ogc:And>\n", field, c.Min, field, c.Max)
+		} else {
+			fmt.Fprintf(&b, "            <ogc:PropertyIsEqualTo><ogc:PropertyName>%s</ogc:PropertyName><ogc:Literal>%s</ogc:Literal></ogc:PropertyIsEqualTo>\n", field, escape(c.Value))
+		}
+		b.WriteString("          </ogc:Filter>\n")
+		switch geometryKind(t) {
+		case "polygon":
+			fmt.Fprintf(&b, "          <PolygonSymbolizer>\n"+
+				"            <Fill><CssParameter name=\"fill\">%s</CssParameter></Fill>\n"+
+				"            <Stroke><CssParameter name=\"stroke\">#000000</CssParameter><CssParameter name=\"stroke-width\">0.5</CssParameter></Stroke>\n"+
+				"          </PolygonSymbolizer>\n", c.Color)
+		case "line":
+			fmt.Fprintf(&b, "          <LineSymbolizer>\n"+
+				"            <Stroke><CssParameter name=\"stroke\">%s</CssParameter><CssParameter name=\"stroke-width\">1</CssParameter></Stroke>\n"+
+				"          </LineSymbolizer>\n", c.Color)
+		default:
+			fmt.Fprintf(&b, "          <PointSymbolizer>\n"+
+				"            <Graphic><Mark><WellKnownName>circle</WellKnownName><Fill><CssParameter name=\"fill\">%s</CssParameter></Fill></Mark><Size>6</Size></Graphic>\n"+
+				"          </PointSymbolizer>\n", c.Color)
+		}
+		b.WriteString("        </Rule>\n")
+	}
+	b.WriteString("      </FeatureTypeStyle>\n    </UserStyle>\n  </NamedLayer>\n</StyledLayerDescriptor>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteQML writes s as a QGIS layer style for shapes of type t.
+func (s *Style) WriteQML(w io.Writer, t ShapeType) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE qgis PUBLIC 'http://mrcc.com/qgis.dtd' 'SYSTEM'>\n<qgis version=\"3.22\" styleCategories=\"Symbology\">\n")
+	if s.Graduated {
+		fmt.Fprintf(&b, "  <renderer-v2 type=\"graduatedSymbol\" attr=\"%s\" graduatedMethod=\"GraduatedColor\" symbollevels=\"0\" enableorderby=\"0\" forceraster=\"0\">\n    <ranges>\n", escape(s.Field))
+		for i, c := range s.Classes {
+			fmt.Fprintf(&b, "      <range lower=\"%g\" upper=\"%g\" label=\"%s\" symbol=\"%d\" render=\"true\"/>\n", c.Min, c.Max, escape(s.label(c)), i)
+		}
+		b.WriteString("    </ranges>\n")
+	} else {
+		fmt.Fprintf(&b, "  <renderer-v2 type=\"categorizedSymbol\" attr=\"%s\" symbollevels=\"0\" enableorderby=\"0\" forceraster=\"0\">\n    <categories>\n", escape(s.Field))
+		for i, c := range s.Classes {
+			fmt.Fprintf(&b, "      <category value=\"%s\" label=\"%s\" symbol=\"%d\" render=\"true\"/>\n", escape(c.Value), escape(s.label(c)), i)
+		}
+		b.WriteString("    </categories>\n")
+	}
+	b.WriteString("    <symbols>\n")
+	for i, c := range s.Classes {
+		rgb, _ := parseColor(c.Color)
+		color := fmt.Sprintf("%d,%d,%d,255", rgb[0], rgb[1], rgb[2])
+		switch geometryKind(t) {
+		case "polygon":
+			fmt.Fprintf(&b, "      <symbol type=\"fill\" name=\"%d\" alpha=\"1\" clip_to_extent=\"1\" force_rhr=\"0\">\n"+
+				"        <layer class=\"SimpleFill\" enabled=\"1\" locked=\"0\" pass=\"0\">\n"+
+				"          <prop k=\"color\" v=\"%s\"/>\n"+
+				"          <prop k=\"outline_color\" v=\"0,0,0,255\"/>\n"+
+				"          <prop k=\"outline_width\" v=\"0.26\"/>\n", i, color)
+		case "line":
+			fmt.Fprintf(&b, "      <symbol type=\"line\" name=\"%d\" alpha=\"1\" clip_to_extent=\"1\" force_rhr=\"0\">\n"+
+				"        <layer class=\"SimpleLine\" enabled=\"1\" locked=\"0\" pass=\"0\">\n"+
+				"          <prop k=\"line_color\" v=\"%s\"/>\n"+
+				"          <prop k=\"line_width\" v=\"0.5\"/>\n", i, color)
+		default:
+			fmt.Fprintf(&b, "      <symbol type=\"marker\" name=\"%d\" alpha=\"1\" clip_to_extent=\"1\" force_rhr=\"0\">\n"+
+				"        <layer class=\"SimpleMarker\" enabled=\"1\" locked=\"0\" pass=\"0\">\n"+
+				"          <prop k=\"color\" v=\"%s\"/>\n"+
+				"          <prop k=\"name\" v=\"circle\"/>\n"+
+				"          <prop k=\"size\" v=\"2\"/>\n", i, color)
+		}
+		b.WriteString("        </layer>\n      </symbol>\n")
+	}
+	b.WriteString("    </symbols>\n  </renderer-v2>\n</qgis>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeStyle writes the SLD and QML files of the style of w, if set.
+func (w *Writer) writeStyle() error {
+	if w.style == nil {
+		return nil
+	}
+	name := w.filename
+	if w.target != "" {
+		name = w.target
+	}
+	var sld, qml strings.Builder
+	if err := w.style.WriteSLD(&sld, filepath.Base(name), w.GeometryType); err != nil {
+		return err
+	}
+	if err := w.style.WriteQML(&qml, w.GeometryType); err != nil {
+		return err
+	}
+	if err := os.WriteFile(w.filename+".sld", []byte(sld.String()), 0666); err != nil {
+		return err
+	}
+	return os.WriteFile(w.filename+".qml", []byte(qml.String()), 0666)
+}
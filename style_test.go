@@ -0,0 +1,127 @@
+package shp
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+var landUse = &Style{
+	Field: "USE",
+	Classes: []StyleClass{
+		{Value: "farm", Color: "#33a02c", Label: "Farmland"},
+		{Value: "R&D", Color: "#1f78b4"},
+	},
+}
+
+// checkXML fails t if data is not well-formed XML.
+func checkXML(t *testing.T, data []byte) {
+	t.Helper()
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := d.Token()
+		if err != nil {
+			if err != io.EOF {
+				t.Errorf("invalid XML: %v\n%s", err, data)
+			}
+			return
+		}
+	}
+}
+
+func TestWithStyle(t *testing.T) {
+	name := filenamePrefix + "style"
+	defer removeShapefile(name)
+	defer os.Remove(name + ".sld")
+	defer os.Remove(name + ".qml")
+
+	w, err := Create(name+".shp", POLYGON, WithStyle(landUse), Atomic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("USE", 10)})
+	w.Write(NewPolygon([][]Point{{{0, 0}, {0, 1}, {1, 1}, {0, 0}}}))
+	w.WriteAttribute(0, 0, "farm")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sld, err := os.ReadFile(name + ".sld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkXML(t, sld)
+	for _, s := range []string{
+		"<Name>write_style</Name>",
+		"<ogc:PropertyName>USE</ogc:PropertyName><ogc:Literal>R&amp;D</ogc:Literal>",
+		`<CssParameter name="fill">#33a02c</CssParameter>`,
+		"<Title>Farmland</Title>",
+	} {
+		if !strings.Contains(string(sld), s) {
+			t.Errorf("SLD lacks %s:\n%s", s, sld)
+		}
+	}
+	qml, err := os.ReadFile(name + ".qml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkXML(t, qml)
+	for _, s := range []string{
+		`<renderer-v2 type="categorizedSymbol" attr="USE"`,
+		`<category value="R&amp;D" label="R&amp;D" symbol="1" render="true"/>`,
+		`<layer class="SimpleFill"`,
+		`<prop k="color" v="51,160,44,255"/>`,
+	} {
+		if !strings.Contains(string(qml), s) {
+			t.Errorf("QML lacks %s:\n%s", s, qml)
+		}
+	}
+}
+
+func TestWriteQMLGraduated(t *testing.T) {
+	s := &Style{Field: "POP", Graduated: true, Classes: []StyleClass{
+		{Min: 0, Max: 1000, Color: "#ffffb2"},
+		{Min: 1000, Max: 1e6, Color: "#bd0026"},
+	}}
+	var b bytes.Buffer
+	if err := s.WriteQML(&b, POINT); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<range lower="1000" upper="1e+06" label="1000 - 1e+06" symbol="1" render="true"/>`; !strings.Contains(b.String(), want) {
+		t.Errorf("QML lacks %s:\n%s", want, b.String())
+	}
+	if !strings.Contains(b.String(), `<layer class="SimpleMarker"`) {
+		t.Errorf("points are not drawn as markers:\n%s", b.String())
+	}
+
+	s.Classes[0].Color = "red"
+	if err := s.WriteSLD(&b, "cities", POINT); err == nil {
+		t.Error("invalid color accepted")
+	}
+}
+
+func TestCreateZipStyle(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := CreateZip(&buf, "parcels", POLYGON, WithStyle(landUse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, " "); got != "parcels.shp parcels.shx parcels.dbf parcels.sld parcels.qml" {
+		t.Errorf("archive has %s", got)
+	}
+}
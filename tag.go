@@ -0,0 +1,116 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// TagPoints returns a SequentialReader that reads points and adds the
+// attribute field of the polygon of polygons that contains each point, e.g.
+// the code of the county of every address. The added field has the
+// definition of field in polygons, renamed with LaunderFieldNames if points
+// already has a field of that name. Points outside of all polygons get an
+// empty value, and points inside several polygons the value of the first one.
+// Shapes other than points are tagged by their RepresentativePoint.
+//
+// The polygons are read into memory and indexed with an R-tree before
+// TagPoints returns, so polygons may be closed then. Batches read with
+// NextBatch are tagged by parallel workers, one for each CPU, which makes it
+// the fastest way to tag many points.
+func TagPoints(points, polygons SequentialReader, field string) (SequentialReader, error) {
+	fields := polygons.Fields()
+	column := -1
+	for i, f := range fields {
+		if f.String() == field {
+			column = i
+			break
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("No field %s in polygons", field)
+	}
+
+	t := &tagger{}
+	var entries []rtreeEntry
+	for polygons.Next() {
+		// decode lazy and float32 polygons once, not for every point
+		_, s := polygons.Shape()
+		s = decoded(s)
+		if a, ok := arraysOf(s); ok && a.rings && len(a.points) > 0 {
+			entries = append(entries, rtreeEntry{box: s.BBox(), id: len(t.polygons)})
+			t.polygons = append(t.polygons, s)
+			t.values = append(t.values, polygons.Attribute(column))
+		}
+	}
+	if err := polygons.Err(); err != nil {
+		return nil, err
+	}
+	t.tree = newRTree(entries)
+
+	tagField := fields[column]
+	names := make([]string, 0, len(points.Fields())+1)
+	for _, f := range points.Fields() {
+		names = append(names, f.String())
+	}
+	laundered, _ := LaunderFieldNames(append(names, field))
+	if name := laundered[len(names)]; name != field {
+		tagField.Name = [11]byte{}
+		copy(tagField.Name[:], name)
+	}
+	c := &computedReader{SequentialReader: points, field: tagField, compute: t.tag}
+	return &tagReader{computedReader: c, tagger: t}, nil
+}
+
+// tagger looks up the polygons that contain points.
+type tagger struct {
+	polygons []Shape
+	values   []string
+	tree     *rtree
+}
+
+// tag returns the value of the first polygon that contains the shape of r.
+func (t *tagger) tag(r Record) any {
+	p, ok := RepresentativePoint(r.Shape)
+	if !ok {
+		return ""
+	}
+	for _, id := range t.tree.search(Box{p.X, p.Y, p.X, p.Y}) {
+		if PointInPolygon(p, t.polygons[id]) {
+			return t.values[id]
+		}
+	}
+	return ""
+}
+
+// tagReader tags the batches of points in parallel; single records are
+// tagged by the computed field it wraps.
+type tagReader struct {
+	*computedReader
+	tagger *tagger
+}
+
+// NextBatch implements BatchReader for tagReader.
+func (t *tagReader) NextBatch(n int) ([]Record, error) {
+	batch, err := NextBatch(t.computedReader.SequentialReader, n)
+	t.computed = false
+	values := make([]string, len(batch))
+	workers := min(runtime.GOMAXPROCS(0), len(batch))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(batch); i += workers {
+				values[i] = t.tagger.tag(batch[i]).(string)
+			}
+		}(w)
+	}
+	wg.Wait()
+	for i := range batch {
+		batch[i].Attributes = append(batch[i].Attributes, values[i])
+	}
+	return batch, err
+}
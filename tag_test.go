@@ -0,0 +1,101 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTagPoints(t *testing.T) {
+	points := filenamePrefix + "tag_points"
+	counties := filenamePrefix + "tag_counties"
+	defer removeShapefile(points)
+	defer removeShapefile(counties)
+
+	w, err := Create(counties+".shp", POLYGON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 10), StringField("CODE", 3)})
+	for i := 0; i < 4; i++ {
+		x := float64(i)
+		row := int(w.Write(NewPolygon([][]Point{{{x, 0}, {x, 1}, {x + 1, 1}, {x + 1, 0}, {x, 0}}})))
+		w.WriteAttribute(row, 0, fmt.Sprint("county ", i))
+		w.WriteAttribute(row, 1, fmt.Sprint("C", i))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = Create(points+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{NumberField("ID", 5)})
+	const n = 100
+	for i := 0; i < n; i++ {
+		// the last points are outside of all counties
+		w.WriteAttribute(int(w.Write(&Point{float64(i)*0.045 + 0.01, 0.5})), 0, i)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	open := func(opts ...ReaderOption) SequentialReader {
+		polygons := SequentialReaderFromExt(openFile(counties+".shp", t), openFile(counties+".dbf", t), opts...)
+		defer polygons.Close()
+		sr, err := TagPoints(SequentialReaderFromExt(openFile(points+".shp", t), openFile(points+".dbf", t), opts...), polygons, "CODE")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sr
+	}
+	want := func(i int) string {
+		if x := float64(i)*0.045 + 0.01; x < 4 {
+			return fmt.Sprint("C", int(x))
+		}
+		return ""
+	}
+
+	for name, opts := range map[string][]ReaderOption{
+		"default":       nil,
+		"LazyDecoding":  {LazyDecoding()},
+		"Float32Coords": {Float32Coords()},
+	} {
+		sr := open(opts...)
+		if fields := sr.Fields(); len(fields) != 2 || fields[1].String() != "CODE" || fields[1].Size != 3 {
+			t.Fatalf("%s: got fields %v", name, fields)
+		}
+		for i := 0; sr.Next(); i++ {
+			if got := strings.TrimRight(sr.Attribute(1), "\x00"); got != want(i) {
+				t.Errorf("%s: point %d: got %q, want %q", name, i, got, want(i))
+			}
+		}
+		sr.Close()
+	}
+
+	sr := open()
+	defer sr.Close()
+	var count int
+	for {
+		batch, err := NextBatch(sr, 32)
+		for _, r := range batch {
+			if got := strings.TrimRight(r.Attributes[1], "\x00"); got != want(r.Index) {
+				t.Errorf("point %d: got %q, want %q", r.Index, got, want(r.Index))
+			}
+			count++
+		}
+		if err != nil {
+			break
+		}
+	}
+	if count != n {
+		t.Errorf("got %d points, want %d", count, n)
+	}
+
+	if _, err := TagPoints(sr, sr, "MISSING"); err == nil {
+		t.Error("got no error for a missing field")
+	}
+}
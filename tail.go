@@ -0,0 +1,160 @@
+package shp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// TailReader reads a shapefile that is still being appended to, like the
+// output of a logger. Instead of stopping at the end of the file, Next waits
+// for the SHP and DBF files to grow and then returns the new records. The
+// files are polled, so new records are seen after at most PollInterval.
+//
+// TailReader implements SequentialReader.
+type TailReader struct {
+	// PollInterval is the time to wait before looking for new records again.
+	PollInterval time.Duration
+
+	*seqReader
+	ctx      context.Context
+	shp, dbf *os.File
+	err      error
+
+	dbfHeaderLength, dbfRecordLength int64
+}
+
+// OpenTail opens the shapefile filename for tailing. Next blocks until a new
+// record is available or ctx is done. The option SkipGeometry is not
+// supported, as the DBF file alone does not tell when a row is complete, and
+// neither is MixedTypesSkip, which would read past the last complete record.
+func OpenTail(ctx context.Context, filename string, opts ...ReaderOption) (*TailReader, error) {
+	o := newReaderOptions(opts)
+	if o.skipGeometry {
+		return nil, errors.New("Cannot tail a shapefile without geometry")
+	}
+	if o.mixedTypes == MixedTypesSkip {
+		return nil, errors.New("Cannot tail a shapefile skipping mixed shape types")
+	}
+	sidecars, err := FindSidecars(filename)
+	if err != nil {
+		return nil, err
+	}
+	if sidecars.SHP == "" {
+		return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+	}
+	t := &TailReader{PollInterval: time.Second, ctx: ctx}
+	t.shp, err = os.Open(sidecars.SHP)
+	if err != nil {
+		return nil, err
+	}
+	var dbf io.ReadCloser
+	if sidecars.DBF != "" && !o.skipAttributes {
+		t.dbf, err = os.Open(sidecars.DBF)
+		if err != nil {
+			t.shp.Close()
+			return nil, err
+		}
+		header := make([]byte, 12)
+		if _, err := t.dbf.ReadAt(header, 0); err != nil {
+			t.shp.Close()
+			t.dbf.Close()
+			return nil, fmt.Errorf("Error reading dbf: %v", err)
+		}
+		t.dbfHeaderLength = int64(binary.LittleEndian.Uint16(header[8:]))
+		t.dbfRecordLength = int64(binary.LittleEndian.Uint16(header[10:]))
+		dbf = t.dbf
+	}
+	t.seqReader = newSeqReader(t.shp, dbf, opts)
+	t.readHeaders()
+	if t.seqReader.err != nil {
+		t.Close()
+		return nil, t.seqReader.err
+	}
+	return t, nil
+}
+
+// Next implements a method of interface SequentialReader for TailReader. It
+// blocks until the next record has been written completely, and returns false
+// once the context passed to OpenTail is done.
+func (t *TailReader) Next() bool {
+	for t.err == nil {
+		ok, err := t.available()
+		if err != nil {
+			t.err = err
+			return false
+		}
+		if ok {
+			if t.seqReader.Next() {
+				return true
+			}
+			t.err = t.seqReader.err
+			return false
+		}
+		select {
+		case <-t.ctx.Done():
+			t.err = t.ctx.Err()
+		case <-time.After(t.PollInterval):
+		}
+	}
+	return false
+}
+
+// NextBatch implements BatchReader for TailReader. It blocks until n records are available or the context passed
+// to OpenTail is done.
+func (t *TailReader) NextBatch(n int) ([]Record, error) {
+	numFields := 0
+	if t.db != nil {
+		numFields = len(t.db.Fields)
+	}
+	return t.nextBatch(&t.batch, n, t, numFields)
+}
+
+// available returns whether the next record is completely present in both
+// the SHP and the DBF file.
+func (t *TailReader) available() (bool, error) {
+	fi, err := t.shp.Stat()
+	if err != nil {
+		return false, err
+	}
+	if fi.Size() < t.pos+8 {
+		return false, nil
+	}
+	header := make([]byte, 8)
+	if _, err := t.shp.ReadAt(header, t.pos); err != nil {
+		return false, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[4:])) * 2
+	// writers fill in the length after the content, so a record without
+	// room for its shape type is still being written
+	if length < 4 {
+		return false, nil
+	}
+	if err := t.limits.checkRecordSize(length); err != nil {
+		return false, fmt.Errorf("Error when reading shapefile header: %w", err)
+	}
+	if fi.Size() < t.pos+8+length {
+		return false, nil
+	}
+	if t.db == nil {
+		return true, nil
+	}
+	fi, err = t.dbf.Stat()
+	if err != nil {
+		return false, err
+	}
+	return fi.Size() >= t.dbfHeaderLength+int64(t.records+1)*t.dbfRecordLength, nil
+}
+
+// Err returns the first non-EOF error that was encountered, including the
+// error of the context passed to OpenTail once it is done.
+func (t *TailReader) Err() error {
+	if t.err == io.EOF {
+		return nil
+	}
+	return t.err
+}
@@ -0,0 +1,89 @@
+package shp
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailReader(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tr, err := OpenTail(ctx, filename+".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+	tr.PollInterval = 10 * time.Millisecond
+	for i := 0; i < 2; i++ {
+		if !tr.Next() {
+			t.Fatal(tr.Err())
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(50 * time.Millisecond)
+		w, err := Append(filename + ".shp")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		n := int(w.Write(&Point{5, 6}))
+		w.WriteAttribute(n, 0, "Capital City")
+		w.Close()
+	}()
+	if !tr.Next() {
+		t.Fatal(tr.Err())
+	}
+	<-done
+	n, s := tr.Shape()
+	if n != 2 || *s.(*Point) != (Point{5, 6}) {
+		t.Errorf("got shape %d %v, want 2 {5 6}", n, s)
+	}
+	if name := strings.TrimRight(tr.Attribute(0), "\x00"); name != "Capital City" {
+		t.Errorf("got name %q, want Capital City", name)
+	}
+
+	cancel()
+	if tr.Next() {
+		t.Error("got record after cancel")
+	}
+	if tr.Err() != context.Canceled {
+		t.Errorf("got error %v, want %v", tr.Err(), context.Canceled)
+	}
+}
+
+func TestTailReaderPartialHeader(t *testing.T) {
+	filename := filenamePrefix + "cities_partial"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+	// a record header whose length the writer has not filled in yet
+	f, err := os.OpenFile(filename+".shp", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 4, 0, 0, 0, 0})
+	f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	tr, err := OpenTail(ctx, filename+".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+	tr.PollInterval = 10 * time.Millisecond
+	for tr.Next() {
+	}
+	if tr.Err() != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", tr.Err(), context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,149 @@
+package shp
+
+import (
+	"fmt"
+	"math"
+)
+
+// Web Mercator (EPSG:3857) constants.
+const (
+	// MaxMercatorLatitude is the latitude at which Web Mercator tiles end,
+	// which makes the world a square.
+	MaxMercatorLatitude = 85.0511287798066
+	// mercatorRadius is the radius of the sphere of Web Mercator.
+	mercatorRadius = 6378137.0
+	// mercatorExtent is half the width of the world in Web Mercator meters.
+	mercatorExtent = math.Pi * mercatorRadius
+)
+
+// LonLatToMercator converts longitude and latitude in degrees to Web
+// Mercator meters. Latitudes beyond MaxMercatorLatitude are clamped.
+func LonLatToMercator(lon, lat float64) (x, y float64) {
+	lat = math.Max(-MaxMercatorLatitude, math.Min(MaxMercatorLatitude, lat))
+	x = lon * math.Pi / 180 * mercatorRadius
+	y = math.Log(math.Tan(math.Pi/4+lat*math.Pi/360)) * mercatorRadius
+	return x, y
+}
+
+// MercatorToLonLat converts Web Mercator meters to longitude and latitude in
+// degrees.
+func MercatorToLonLat(x, y float64) (lon, lat float64) {
+	lon = x / mercatorRadius * 180 / math.Pi
+	lat = (2*math.Atan(math.Exp(y/mercatorRadius)) - math.Pi/2) * 180 / math.Pi
+	return lon, lat
+}
+
+// WebMercator is a Transformer from longitude and latitude in degrees to Web
+// Mercator meters, e.g. for WithTransformer or TransformOutput.
+var WebMercator Transformer = TransformerFunc(func(x, y, z, m float64) (float64, float64, float64, float64) {
+	x, y = LonLatToMercator(x, y)
+	return x, y, z, m
+})
+
+// Tile is a tile of the Web Mercator tiling scheme used by web maps: at zoom
+// level Z, the world is divided into 2^Z by 2^Z tiles, numbered from the
+// north-west corner.
+type Tile struct {
+	Z, X, Y int
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+// MercatorBounds returns the box covered by t in Web Mercator meters.
+func (t Tile) MercatorBounds() Box {
+	size := 2 * mercatorExtent / float64(int(1)<<t.Z)
+	return Box{
+		MinX: -mercatorExtent + float64(t.X)*size,
+		MinY: mercatorExtent - float64(t.Y+1)*size,
+		MaxX: -mercatorExtent + float64(t.X+1)*size,
+		MaxY: mercatorExtent - float64(t.Y)*size,
+	}
+}
+
+// Bounds returns the box covered by t in degrees of longitude and latitude.
+func (t Tile) Bounds() Box {
+	b := t.MercatorBounds()
+	minLon, minLat := MercatorToLonLat(b.MinX, b.MinY)
+	maxLon, maxLat := MercatorToLonLat(b.MaxX, b.MaxY)
+	return Box{minLon, minLat, maxLon, maxLat}
+}
+
+// TileAt returns the tile at zoom level z that contains the point at
+// longitude lon and latitude lat in degrees.
+func TileAt(lon, lat float64, z int) Tile {
+	x, y := LonLatToMercator(lon, lat)
+	return Tile{Z: z, X: tileIndex(x, z), Y: tileIndex(-y, z)}
+}
+
+// tileIndex returns the index of the column containing x, or of the row
+// containing -y, at zoom level z, clamped to the tiles that exist.
+func tileIndex(v float64, z int) int {
+	n := int(1) << z
+	i := int(math.Floor((v + mercatorExtent) / (2 * mercatorExtent) * float64(n)))
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+// TilesCovering returns the tiles at zoom level z that intersect box, which
+// is in degrees of longitude and latitude, row by row from the north-west. A
+// box whose MinX is greater than its MaxX crosses the antimeridian and is
+// covered from MinX eastwards to 180 degrees and on from -180 degrees to
+// MaxX.
+func TilesCovering(box Box, z int) []Tile {
+	cols, minY, maxY := tileRange(box, z)
+	tiles := make([]Tile, 0, tileCount(cols, minY, maxY))
+	for y := minY; y <= maxY; y++ {
+		for _, c := range cols {
+			for x := c[0]; x <= c[1]; x++ {
+				tiles = append(tiles, Tile{Z: z, X: x, Y: y})
+			}
+		}
+	}
+	return tiles
+}
+
+// tileRange returns the ranges of the columns, from west to east, and the
+// range of the rows of the tiles at zoom level z that intersect box.
+func tileRange(box Box, z int) (cols [][2]int, minY, maxY int) {
+	nw := TileAt(box.MinX, box.MaxY, z)
+	se := TileAt(box.MaxX, box.MinY, z)
+	switch {
+	case box.MinX <= box.MaxX:
+		cols = [][2]int{{nw.X, se.X}}
+	case nw.X <= se.X:
+		// the parts on either side of the antimeridian share a column
+		cols = [][2]int{{0, (1 << z) - 1}}
+	default:
+		cols = [][2]int{{nw.X, (1 << z) - 1}, {0, se.X}}
+	}
+	return cols, nw.Y, se.Y
+}
+
+// tileCount returns the number of tiles in the ranges returned by tileRange.
+func tileCount(cols [][2]int, minY, maxY int) int {
+	n := 0
+	for _, c := range cols {
+		n += max(0, c[1]-c[0]+1)
+	}
+	return n * max(0, maxY-minY+1)
+}
+
+// ZoomForBox returns the highest zoom level up to maxZoom at which box, in
+// degrees of longitude and latitude, is covered by at most n tiles, e.g. to
+// choose the zoom level of a preview or of a split into tiles. Boxes may
+// cross the antimeridian as for TilesCovering.
+func ZoomForBox(box Box, n, maxZoom int) int {
+	for z := maxZoom; z > 0; z-- {
+		if tileCount(tileRange(box, z)) <= n {
+			return z
+		}
+	}
+	return 0
+}
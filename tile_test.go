@@ -0,0 +1,73 @@
+package shp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMercatorRoundTrip(t *testing.T) {
+	x, y := LonLatToMercator(180, MaxMercatorLatitude)
+	if math.Abs(x-mercatorExtent) > 1e-6 || math.Abs(y-mercatorExtent) > 1e-3 {
+		t.Errorf("got corner %v, %v, want %v", x, y, mercatorExtent)
+	}
+	lon, lat := MercatorToLonLat(LonLatToMercator(13.4, 52.5))
+	if math.Abs(lon-13.4) > 1e-9 || math.Abs(lat-52.5) > 1e-9 {
+		t.Errorf("got %v, %v", lon, lat)
+	}
+}
+
+func TestTileAt(t *testing.T) {
+	// the tile of Berlin in the OpenStreetMap tile numbering
+	if got, want := TileAt(13.4, 52.5, 10), (Tile{10, 550, 335}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := TileAt(-180, 90, 3); got != (Tile{3, 0, 0}) {
+		t.Errorf("got %v for the north-west corner", got)
+	}
+	if got := TileAt(180, -90, 3); got != (Tile{3, 7, 7}) {
+		t.Errorf("got %v for the south-east corner", got)
+	}
+	b := TileAt(13.4, 52.5, 10).Bounds()
+	if !b.ContainsPoint(Point{13.4, 52.5}) {
+		t.Errorf("tile bounds %v do not contain the point", b)
+	}
+}
+
+func TestTilesCovering(t *testing.T) {
+	tiles := TilesCovering(Box{-1, -1, 1, 1}, 1)
+	want := []Tile{{1, 0, 0}, {1, 1, 0}, {1, 0, 1}, {1, 1, 1}}
+	if len(tiles) != len(want) {
+		t.Fatalf("got %v, want %v", tiles, want)
+	}
+	for i := range want {
+		if tiles[i] != want[i] {
+			t.Errorf("got %v, want %v", tiles, want)
+		}
+	}
+	if z := ZoomForBox(Box{-1, -1, 1, 1}, 4, 20); z != 8 {
+		t.Errorf("got zoom %d, want 8", z)
+	}
+	if z := ZoomForBox(Box{13.40, 52.50, 13.41, 52.51}, 1, 10); z != 10 {
+		t.Errorf("got zoom %d, want 10", z)
+	}
+}
+
+func TestTilesCoveringAntimeridian(t *testing.T) {
+	box := Box{MinX: 170, MinY: -10, MaxX: -170, MaxY: 10}
+	tiles := TilesCovering(box, 3)
+	want := []Tile{{3, 7, 3}, {3, 0, 3}, {3, 7, 4}, {3, 0, 4}}
+	if len(tiles) != len(want) {
+		t.Fatalf("got %v, want %v", tiles, want)
+	}
+	for i := range want {
+		if tiles[i] != want[i] {
+			t.Errorf("got %v, want %v", tiles, want)
+		}
+	}
+	if tiles := TilesCovering(box, 0); len(tiles) != 1 {
+		t.Errorf("got %v at zoom 0, want one tile", tiles)
+	}
+	if z, want := ZoomForBox(box, 4, 20), ZoomForBox(Box{-10, -10, 10, 10}, 4, 20); z != want {
+		t.Errorf("got zoom %d, want %d", z, want)
+	}
+}
@@ -0,0 +1,217 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"math"
+)
+
+// TopologyKind is the kind of a TopologyIssue.
+type TopologyKind int
+
+const (
+	// TopologyOverlap is reported for polygons whose interiors overlap.
+	TopologyOverlap TopologyKind = iota
+	// TopologyGap is reported for polygons whose boundaries come within the
+	// tolerance of each other without meeting, leaving a sliver between
+	// them.
+	TopologyGap
+)
+
+func (k TopologyKind) String() string {
+	switch k {
+	case TopologyOverlap:
+		return "overlap"
+	case TopologyGap:
+		return "gap"
+	}
+	return fmt.Sprintf("TopologyKind(%d)", int(k))
+}
+
+// TopologyIssue is a problem between the polygons with the record numbers A
+// and B, with A < B, found by CheckTopology at Point.
+type TopologyIssue struct {
+	Kind  TopologyKind
+	A, B  int
+	Point Point
+}
+
+func (i TopologyIssue) String() string {
+	return fmt.Sprintf("%v between records %d and %d at %v", i.Kind, i.A, i.B, i.Point)
+}
+
+// TopologyOptions configures CheckTopology.
+type TopologyOptions struct {
+	// Tolerance is the distance within which the boundaries of adjacent
+	// polygons are expected to coincide. Vertices closer than Tolerance to
+	// the boundary of another polygon, without being on it, are reported as
+	// gaps.
+	Tolerance float64
+}
+
+// CheckTopology reads the polygons of r and reports every pair of polygons
+// that overlap or that leave a gap between them, as expected to be absent
+// from layers that partition an area such as administrative boundaries. The
+// candidate pairs are the polygons whose bounding boxes, grown by the
+// tolerance, intersect in an R-tree. Shapes that are not polygons are ignored.
+// The issues are returned in the order of their first record; at most one
+// issue of each kind is reported for a pair.
+//
+// The checks compare vertices and edges rather than computing the
+// intersections of the polygons: an overlap is found where edges cross or a
+// point of one polygon lies inside the other, away from its boundary, and a
+// gap where a vertex of one polygon lies outside the other but within the
+// tolerance of its boundary. Vertices are on a boundary if they are within
+// a billionth of the size of the coordinates of it.
+func CheckTopology(r SequentialReader, opts TopologyOptions) ([]TopologyIssue, error) {
+	var shapes []Shape
+	var entries []rtreeEntry
+	for r.Next() {
+		n, s := r.Shape()
+		if a, ok := arraysOf(s); ok && a.rings && len(a.points) > 0 {
+			for len(shapes) <= n {
+				shapes = append(shapes, nil)
+			}
+			shapes[n] = s
+			entries = append(entries, rtreeEntry{box: s.BBox().ExpandBy(opts.Tolerance), id: n})
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	tree := newRTree(append([]rtreeEntry(nil), entries...))
+	var issues []TopologyIssue
+	for n, s := range shapes {
+		if s == nil {
+			continue
+		}
+		for _, m := range tree.search(s.BBox().ExpandBy(opts.Tolerance)) {
+			if m <= n {
+				continue
+			}
+			issues = append(issues, checkPair(n, m, s, shapes[m], opts.Tolerance)...)
+		}
+	}
+	return issues, nil
+}
+
+// checkPair returns the issues between the polygons a and b.
+func checkPair(n, m int, a, b Shape, tolerance float64) []TopologyIssue {
+	var overlap, gap *TopologyIssue
+	aa, _ := arraysOf(a)
+	ba, _ := arraysOf(b)
+	eps := epsilonFor(aa.box.Union(ba.box))
+
+	// classify checks the point p of one polygon against the other one
+	classify := func(p Point, other Shape, oa shapeArrays) {
+		d := boundaryDistance(p, oa)
+		switch {
+		case d <= eps:
+		case PointInPolygon(p, other):
+			if overlap == nil {
+				overlap = &TopologyIssue{TopologyOverlap, n, m, p}
+			}
+		case d <= tolerance:
+			if gap == nil {
+				gap = &TopologyIssue{TopologyGap, n, m, p}
+			}
+		}
+	}
+	for _, p := range aa.points {
+		classify(p, b, ba)
+	}
+	for _, p := range ba.points {
+		classify(p, a, aa)
+	}
+	if overlap == nil {
+		if p, ok := edgesCross(aa, ba, eps); ok {
+			overlap = &TopologyIssue{TopologyOverlap, n, m, p}
+		}
+	}
+	if overlap == nil {
+		// polygons with the same vertices, or one inside the other sharing
+		// all its vertices, have no vertex inside the other one
+		if p, ok := RepresentativePoint(a); ok {
+			classify(p, b, ba)
+		}
+		if p, ok := RepresentativePoint(b); ok {
+			classify(p, a, aa)
+		}
+	}
+
+	var issues []TopologyIssue
+	if overlap != nil {
+		issues = append(issues, *overlap)
+	}
+	if gap != nil {
+		issues = append(issues, *gap)
+	}
+	return issues
+}
+
+// epsilonFor returns the distance below which points in box are considered
+// equal: a billionth of the size of their coordinates.
+func epsilonFor(box Box) float64 {
+	return 1e-9 * math.Max(1, math.Max(math.Max(math.Abs(box.MinX), math.Abs(box.MaxX)), math.Max(math.Abs(box.MinY), math.Abs(box.MaxY))))
+}
+
+// segmentDistance returns the distance from p to the segment from a to b.
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	if l := dx*dx + dy*dy; l > 0 {
+		f := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/l))
+		a = Point{a.X + f*dx, a.Y + f*dy}
+	}
+	return math.Hypot(p.X-a.X, p.Y-a.Y)
+}
+
+// boundaryDistance returns the distance from p to the closest part or ring
+// of a.
+func boundaryDistance(p Point, a shapeArrays) float64 {
+	d := math.Inf(1)
+	for i := range a.parts {
+		line := part(a.parts, a.points, i)
+		for j := 0; j+1 < len(line); j++ {
+			d = math.Min(d, segmentDistance(p, line[j], line[j+1]))
+		}
+	}
+	return d
+}
+
+// edgesCross returns the intersection of the first edges of a and b that
+// cross each other at a point inside both of them, with the ends of each edge
+// farther than eps from the line through the other one so that shared edges
+// do not cross.
+func edgesCross(a, b shapeArrays, eps float64) (Point, bool) {
+	orient := func(p, q, r Point) float64 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+	for i := range a.parts {
+		ra := part(a.parts, a.points, i)
+		for j := 0; j+1 < len(ra); j++ {
+			p1, p2 := ra[j], ra[j+1]
+			eb := Box{math.Min(p1.X, p2.X), math.Min(p1.Y, p2.Y), math.Max(p1.X, p2.X), math.Max(p1.Y, p2.Y)}
+			if !eb.Intersects(b.box) {
+				continue
+			}
+			for k := range b.parts {
+				rb := part(b.parts, b.points, k)
+				for l := 0; l+1 < len(rb); l++ {
+					q1, q2 := rb[l], rb[l+1]
+					d1, d2 := orient(q1, q2, p1), orient(q1, q2, p2)
+					d3, d4 := orient(p1, p2, q1), orient(p1, p2, q2)
+					lp, lq := math.Hypot(p2.X-p1.X, p2.Y-p1.Y), math.Hypot(q2.X-q1.X, q2.Y-q1.Y)
+					if d1*d2 < 0 && d3*d4 < 0 &&
+						math.Min(math.Abs(d1), math.Abs(d2)) > eps*lq &&
+						math.Min(math.Abs(d3), math.Abs(d4)) > eps*lp {
+						f := d1 / (d1 - d2)
+						return Point{p1.X + f*(p2.X-p1.X), p1.Y + f*(p2.Y-p1.Y)}, true
+					}
+				}
+			}
+		}
+	}
+	return Point{}, false
+}
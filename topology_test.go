@@ -0,0 +1,57 @@
+//go:build !shp_minimal
+
+package shp
+
+import "testing"
+
+func TestCheckTopology(t *testing.T) {
+	filename := filenamePrefix + "topology"
+	defer removeShapefile(filename)
+
+	square := func(x0, y0, x1, y1 float64) *Polygon {
+		return NewPolygon([][]Point{{{x0, y0}, {x0, y1}, {x1, y1}, {x1, y0}, {x0, y0}}})
+	}
+	shapes := []Shape{
+		square(0, 0, 1, 1),
+		square(1, 0, 2, 1),           // shares an edge with 0
+		square(2.001, 0, 3, 1),       // leaves a gap to 1
+		square(0.5, 0.25, 1.5, 0.75), // crosses 0 and 1 without any vertex inside them
+		square(10, 10, 11, 11),
+		square(10, 10, 11, 11), // a duplicate of 4
+	}
+	w, err := Create(filename+".shp", POLYGON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range shapes {
+		w.Write(s)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+	defer sr.Close()
+	issues, err := CheckTopology(sr, TopologyOptions{Tolerance: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TopologyIssue{
+		{Kind: TopologyOverlap, A: 0, B: 3},
+		{Kind: TopologyOverlap, A: 1, B: 3},
+		{Kind: TopologyGap, A: 1, B: 2},
+		{Kind: TopologyOverlap, A: 4, B: 5},
+	}
+	found := map[[3]int]bool{}
+	for _, i := range issues {
+		found[[3]int{int(i.Kind), i.A, i.B}] = true
+	}
+	for _, i := range want {
+		if !found[[3]int{int(i.Kind), i.A, i.B}] {
+			t.Errorf("missing %v", i)
+		}
+	}
+	if len(issues) != len(want) {
+		t.Errorf("got issues %v, want %d", issues, len(want))
+	}
+}
@@ -0,0 +1,41 @@
+package shp
+
+import "context"
+
+// Tracer starts spans around long running operations such as opening layers,
+// full scans and conversions. It mirrors the part of the OpenTelemetry trace
+// API that is used here, so an adapter for an OpenTelemetry tracer takes a few
+// lines while this package does not depend on OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced operation started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns a copy of ctx that carries t, which StartSpan
+// uses for the spans of operations given that context.
+func ContextWithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// StartSpan starts a span called name with the Tracer of ctx. If ctx carries
+// no Tracer, it returns ctx and a Span that does nothing.
+func StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	if t, ok := ctx.Value(tracerKey{}).(Tracer); ok && t != nil {
+		return t.Start(ctx, name)
+	}
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) RecordError(error)                {}
+func (noopSpan) End()                             {}
@@ -0,0 +1,39 @@
+package shp
+
+import (
+	"context"
+	"testing"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.attrs["error"] = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer []*testSpan
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &testSpan{name: name, attrs: make(map[string]interface{})}
+	*t = append(*t, s)
+	return ctx, s
+}
+
+func TestStartSpan(t *testing.T) {
+	_, span := StartSpan(context.Background(), "noop")
+	span.SetAttribute("key", 1)
+	span.End()
+
+	var tracer testTracer
+	ctx := ContextWithTracer(context.Background(), &tracer)
+	_, span = StartSpan(ctx, "traced")
+	span.SetAttribute("key", 1)
+	span.End()
+	if len(tracer) != 1 || tracer[0].name != "traced" || tracer[0].attrs["key"] != 1 || !tracer[0].ended {
+		t.Errorf("got spans %+v", tracer)
+	}
+}
@@ -0,0 +1,125 @@
+package shp
+
+import (
+	"bytes"
+	"math"
+)
+
+// Transformer converts coordinates, e.g. from one coordinate reference system
+// to another. Z and M are 0 for shapes without them, and "no data" measures
+// are passed as NaN. Wrap PROJ bindings or a pure Go projection library in a
+// Transformer to reproject shapefiles while they are read or written.
+type Transformer interface {
+	Transform(x, y, z, m float64) (float64, float64, float64, float64)
+}
+
+// TransformerFunc is an adapter to use an ordinary function as a Transformer.
+type TransformerFunc func(x, y, z, m float64) (float64, float64, float64, float64)
+
+// Transform calls f(x, y, z, m).
+func (f TransformerFunc) Transform(x, y, z, m float64) (float64, float64, float64, float64) {
+	return f(x, y, z, m)
+}
+
+// WithTransformer makes the reader transform the coordinates of every shape
+// with t. The bounding boxes and Z and M ranges of the shapes are updated to
+// match, but the bounding box of the shapefile is returned as stored in its
+// header. Lazily decoded shapes are decoded when their box is requested.
+func WithTransformer(t Transformer) ReaderOption {
+	return func(o *readerOptions) {
+		o.transformer = t
+	}
+}
+
+// TransformOutput makes the Writer transform the coordinates of every shape
+// with t before writing it. The shapes passed to Write are not modified.
+func TransformOutput(t Transformer) WriterOption {
+	return func(o *writerOptions) {
+		o.transformer = t
+	}
+}
+
+// TransformShape transforms the coordinates of s with t in place and updates
+// its bounding box and Z and M ranges.
+func TransformShape(s Shape, t Transformer) {
+	switch p := s.(type) {
+	case *Point:
+		p.X, p.Y, _, _ = t.Transform(p.X, p.Y, 0, 0)
+		return
+	case *PointZ:
+		p.X, p.Y, p.Z, p.M = t.Transform(p.X, p.Y, p.Z, p.M)
+		return
+	case *PointM:
+		p.X, p.Y, _, p.M = t.Transform(p.X, p.Y, 0, p.M)
+		return
+	case *LazyShape:
+		if d, err := p.Decode(); err == nil {
+			TransformShape(d, t)
+		}
+		return
+	case *Shape32:
+		d := p.Float64()
+		TransformShape(d, t)
+		*p = *toShape32(d)
+		return
+	}
+	a, ok := arraysOf(s)
+	if !ok {
+		return
+	}
+	for i := range a.points {
+		var z, m float64
+		if i < len(a.z) {
+			z = a.z[i]
+		}
+		if i < len(a.m) {
+			m = a.m[i]
+		}
+		pt := &a.points[i]
+		pt.X, pt.Y, z, m = t.Transform(pt.X, pt.Y, z, m)
+		if i < len(a.z) {
+			a.z[i] = z
+		}
+		if i < len(a.m) {
+			a.m[i] = m
+		}
+	}
+	updateBBox(s)
+	if a.zRange != nil && len(a.z) > 0 {
+		*a.zRange = valueRange(a.z)
+	}
+	if a.mRange != nil && len(a.m) > 0 {
+		*a.mRange = valueRange(a.m)
+	}
+}
+
+// valueRange returns the minimum and maximum of vs, ignoring "no data"
+// measures. It is NaN if all values are "no data".
+func valueRange(vs []float64) [2]float64 {
+	r := [2]float64{math.NaN(), math.NaN()}
+	for _, v := range vs {
+		if IsNoData(v) {
+			continue
+		}
+		if !(v >= r[0]) {
+			r[0] = v
+		}
+		if !(v <= r[1]) {
+			r[1] = v
+		}
+	}
+	return r
+}
+
+// cloneShape returns a deep copy of s, decoded like a Reader does.
+func cloneShape(s Shape) Shape {
+	c, err := newShape(shapeTypeOf(s))
+	if err != nil {
+		return s
+	}
+	var buf bytes.Buffer
+	s.write(&buf)
+	c.read(&buf)
+	readerOptions{}.decodeMeasures(c)
+	return c
+}
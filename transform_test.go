@@ -0,0 +1,85 @@
+package shp
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// shift moves coordinates by (1, 2) and scales Z and M.
+var shift = TransformerFunc(func(x, y, z, m float64) (float64, float64, float64, float64) {
+	return x + 1, y + 2, z * 10, m * 10
+})
+
+func TestTransformShape(t *testing.T) {
+	p := &PolyLineZ{
+		Parts:  []int32{0},
+		Points: []Point{{0, 0}, {1, 1}},
+		ZArray: []float64{1, 2},
+		MArray: []float64{3, math.NaN()},
+	}
+	TransformShape(p, shift)
+	if want := []Point{{1, 2}, {2, 3}}; p.Points[0] != want[0] || p.Points[1] != want[1] {
+		t.Errorf("got points %v, want %v", p.Points, want)
+	}
+	if want := (Box{1, 2, 2, 3}); p.Box != want {
+		t.Errorf("got box %v, want %v", p.Box, want)
+	}
+	if want := [2]float64{10, 20}; p.ZRange != want {
+		t.Errorf("got Z range %v, want %v", p.ZRange, want)
+	}
+	if want := [2]float64{30, 30}; p.MRange != want || !math.IsNaN(p.MArray[1]) {
+		t.Errorf("got M range %v and values %v, want %v", p.MRange, p.MArray, want)
+	}
+
+	s := toShape32(&PolyLineZ{
+		Parts:  []int32{0},
+		Points: []Point{{0, 0}, {1, 1}},
+		ZArray: []float64{1, 2},
+		MArray: []float64{3, 4},
+	})
+	TransformShape(s, shift)
+	if s.Point(1) != (Point{2, 3}) || s.Box != (Box{1, 2, 2, 3}) {
+		t.Errorf("got float32 point %v and box %v", s.Point(1), s.Box)
+	}
+	if s.ZRange != [2]float64{10, 20} || s.M[1] != 40 {
+		t.Errorf("got float32 Z range %v and M values %v", s.ZRange, s.M)
+	}
+}
+
+func TestTransformReadWrite(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "transformed.shp")
+	w, err := Create(filename, POLYGON, TransformOutput(shift))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := NewPolygon([][]Point{{{0, 0}, {0, 1}, {1, 1}, {0, 0}}})
+	w.Write(in)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if in.Points[1] != (Point{0, 1}) {
+		t.Errorf("Write modified its argument: %v", in.Points)
+	}
+
+	inverse := TransformerFunc(func(x, y, z, m float64) (float64, float64, float64, float64) {
+		return x - 1, y - 2, z, m
+	})
+	for _, opts := range [][]ReaderOption{{}, {WithTransformer(inverse)}, {WithTransformer(inverse), LazyDecoding()}} {
+		r, err := Open(filename, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Box{1, 2, 2, 3}
+		if len(opts) > 0 {
+			want = in.Box
+		}
+		if !r.Next() {
+			t.Fatal(r.Err())
+		}
+		if _, s := r.Shape(); s.BBox() != want {
+			t.Errorf("got box %v with %d options, want %v", s.BBox(), len(opts), want)
+		}
+		r.Close()
+	}
+}
@@ -0,0 +1,66 @@
+package shp
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// TruncateStrings makes WriteAttribute truncate strings that are longer than
+// their field instead of returning an error. The size of a field counts
+// bytes, not characters, so a string is cut after the last complete UTF-8
+// character that fits, never in the middle of one. Every truncation is
+// reported as a Warning with a *TruncatedError, see Writer.Warnings.
+func TruncateStrings() WriterOption {
+	return func(o *writerOptions) {
+		o.truncateStrings = true
+	}
+}
+
+// TruncatedError describes a string that was truncated to fit its field.
+type TruncatedError struct {
+	Field int    // index of the field
+	Value string // the string before truncation
+	Size  int    // size of the field in bytes
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("Truncated %q of %d bytes to field %d of %d bytes", e.Value, len(e.Value), e.Field, e.Size)
+}
+
+// truncateUTF8 returns the longest prefix of b with at most n bytes that does
+// not end in an incomplete UTF-8 character.
+func truncateUTF8(b []byte, n int) []byte {
+	if len(b) <= n {
+		return b
+	}
+	b = b[:n]
+	// drop the start of a character whose last bytes were cut off
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				b = b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// fitString truncates the string buf, which does not fit its field, if
+// TruncateStrings is used.
+func (w *Writer) fitString(row, field int, buf []byte) ([]byte, error) {
+	size := int(w.dbfFields[field].Size)
+	if !w.truncateStrings {
+		return nil, fmt.Errorf("Unable to write field %v: %q exceeds field length %v", field, buf, size)
+	}
+	err := &TruncatedError{Field: field, Value: string(buf), Size: size}
+	w.debug("warning", "record", row, "error", err)
+	w.warnings = append(w.warnings, Warning{Index: row, Err: err})
+	return truncateUTF8(buf, size), nil
+}
+
+// Warnings returns the strings truncated so far by a Writer created with
+// TruncateStrings. The Index of a Warning is the row.
+func (w *Writer) Warnings() []Warning {
+	return w.warnings
+}
@@ -0,0 +1,76 @@
+package shp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"abc", 5, "abc"},
+		{"abcdef", 3, "abc"},
+		{"Zürich", 2, "Z"},
+		{"Zürich", 3, "Zü"},
+		{"東京都", 5, "東"},
+		{"東京都", 6, "東京"},
+		{"😀x", 3, ""},
+	} {
+		got := string(truncateUTF8([]byte(c.s), c.n))
+		if got != c.want || !utf8.ValidString(got) {
+			t.Errorf("truncated %q to %d bytes: got %q, want %q", c.s, c.n, got, c.want)
+		}
+	}
+}
+
+func TestTruncateStrings(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "utf8.shp")
+	w, err := Create(filename, POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 5)})
+	w.Write(&Point{0, 0})
+	if err := w.WriteAttribute(0, 0, "Genève"); err == nil {
+		t.Error("wrote a string that does not fit without TruncateStrings")
+	}
+	w.Close()
+
+	w, err = Create(filename, POINT, TruncateStrings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 5)})
+	w.Write(&Point{0, 0})
+	w.Write(&Point{1, 1})
+	if err := w.WriteAttribute(0, 0, "Bern"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteAttribute(1, 0, "Genève"); err != nil {
+		t.Fatal(err)
+	}
+	warnings := w.Warnings()
+	w.Close()
+	if len(warnings) != 1 || warnings[0].Index != 1 {
+		t.Fatalf("got warnings %v", warnings)
+	}
+	if e, ok := warnings[0].Err.(*TruncatedError); !ok || e.Value != "Genève" || e.Size != 5 {
+		t.Errorf("got warning %v", warnings[0])
+	}
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Next()
+	r.Next()
+	if got := strings.TrimRight(r.Attribute(0), "\x00"); got != "Genè" {
+		t.Errorf("got %q, want %q", got, "Genè")
+	}
+}
@@ -0,0 +1,299 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Tx collects edits of a shapefile and applies them all at once on Commit.
+// Records are identified by their index in the shapefile as opened, also
+// after earlier records are deleted.
+type Tx struct {
+	filename string
+	opts     []WriterOption
+	src      *Reader
+	attrs    []attrUpdate
+	shapes   map[int]Shape
+	deleted  map[int]bool
+	done     bool
+}
+
+type attrUpdate struct {
+	row, field int
+	value      interface{}
+}
+
+// Edit opens the shapefile filename and starts a transaction to edit it. The
+// options are those of Create, and Atomic and Journal are ignored. Commit or
+// Rollback must be called when done.
+func Edit(filename string, opts ...WriterOption) (*Tx, error) {
+	r, err := Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	r.loadIndex()
+	if r.indexErr != nil {
+		r.Close()
+		return nil, r.indexErr
+	}
+	return &Tx{
+		filename: filename,
+		opts:     opts,
+		src:      r,
+		shapes:   make(map[int]Shape),
+		deleted:  make(map[int]bool),
+	}, nil
+}
+
+// check returns an error if the transaction is done or the i-th record does
+// not exist or is deleted.
+func (tx *Tx) check(i int) error {
+	if tx.done {
+		return errors.New("Transaction is already done")
+	}
+	if i < 0 || i >= len(tx.src.index) {
+		return fmt.Errorf("Record %d out of range [0, %d)", i, len(tx.src.index))
+	}
+	if tx.deleted[i] {
+		return fmt.Errorf("Record %d is deleted", i)
+	}
+	return nil
+}
+
+// UpdateAttr sets the value of field in row, like Writer.WriteAttribute.
+// Values of unsupported types or that are too long for the field make Commit
+// fail.
+func (tx *Tx) UpdateAttr(row, field int, value interface{}) error {
+	if err := tx.check(row); err != nil {
+		return err
+	}
+	if row >= int(tx.src.dbfNumRecords) {
+		return fmt.Errorf("Row %d out of range [0, %d)", row, tx.src.dbfNumRecords)
+	}
+	if field < 0 || field >= len(tx.src.dbfFields) {
+		return fmt.Errorf("Field %d out of range [0, %d)", field, len(tx.src.dbfFields))
+	}
+	tx.attrs = append(tx.attrs, attrUpdate{row: row, field: field, value: value})
+	return nil
+}
+
+// ReplaceShape replaces the i-th shape with s.
+func (tx *Tx) ReplaceShape(i int, s Shape) error {
+	if err := tx.check(i); err != nil {
+		return err
+	}
+	if t := shapeTypeOf(s); t != NULL && t != tx.src.GeometryType {
+		return fmt.Errorf("Cannot write a %v to a %v shapefile", t, tx.src.GeometryType)
+	}
+	tx.shapes[i] = s
+	return nil
+}
+
+// Delete deletes the i-th record, with its shape and attributes.
+func (tx *Tx) Delete(i int) error {
+	if err := tx.check(i); err != nil {
+		return err
+	}
+	tx.deleted[i] = true
+	return nil
+}
+
+// Rollback discards the edits.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	return tx.src.Close()
+}
+
+// Commit applies the edits. If no records are deleted and every replaced
+// shape fits in the place of the old one, the edits are written in place,
+// after all of them were encoded without error, and only an I/O error can
+// leave them partly applied. Otherwise the shapefile is rewritten like with
+// Atomic, and if any edit fails, it is left untouched.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return errors.New("Transaction is already done")
+	}
+	tx.done = true
+	defer tx.src.Close()
+	if len(tx.deleted) == 0 && newWriterOptions(tx.opts).overflow != OverflowWiden {
+		if done, err := tx.commitInPlace(); done {
+			return err
+		}
+	}
+	return tx.rewrite()
+}
+
+// commitInPlace applies the edits with an Editor. It returns false, without
+// writing anything, if a shape does not fit in place.
+func (tx *Tx) commitInPlace() (bool, error) {
+	e, err := OpenEditor(tx.filename, tx.opts...)
+	if err != nil {
+		return true, err
+	}
+	bufs := make([][]byte, len(tx.attrs))
+	for n, u := range tx.attrs {
+		if bufs[n], err = e.w.encodeAttribute(u.row, u.field, u.value); err != nil {
+			e.close()
+			return true, err
+		}
+	}
+	indexes := tx.replaced()
+	shapes := make([]Shape, len(indexes))
+	contents := make([][]byte, len(indexes))
+	for n, i := range indexes {
+		if shapes[n], contents[n], err = e.encodeShape(tx.shapes[i]); err != nil {
+			e.close()
+			return true, err
+		}
+		if !e.fits(i, contents[n]) {
+			e.close()
+			return false, nil
+		}
+	}
+
+	for n, u := range tx.attrs {
+		if err := e.writeField(u.row, u.field, bufs[n]); err != nil {
+			e.close()
+			return true, err
+		}
+	}
+	for n, i := range indexes {
+		if err := e.replace(i, shapes[n], contents[n]); err != nil {
+			e.close()
+			return true, err
+		}
+	}
+	return true, e.Close()
+}
+
+// replaced returns the indexes of the replaced shapes in order.
+func (tx *Tx) replaced() []int {
+	indexes := make([]int, 0, len(tx.shapes))
+	for i := range tx.shapes {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// rewrite applies the edits by writing a new shapefile with the records that
+// are not deleted and renaming it into place.
+func (tx *Tx) rewrite() error {
+	r := tx.src
+	shp, ok := r.shp.(io.ReaderAt)
+	if !ok {
+		return errors.New("SHP file does not support random access")
+	}
+	var dbf io.ReaderAt
+	if r.dbf != nil {
+		if dbf, ok = r.dbf.(io.ReaderAt); !ok {
+			return errors.New("DBF file does not support random access")
+		}
+	}
+	w, err := Create(tx.filename, r.GeometryType, append(tx.opts, Atomic())...)
+	if err != nil {
+		return err
+	}
+	// only the replaced shapes are transformed
+	transformer := w.transformer
+	w.transformer = nil
+	if dbf != nil {
+		if err := w.SetFields(append([]Field(nil), r.dbfFields...)); err != nil {
+			return w.abort(err)
+		}
+	}
+	updates := make(map[int][]attrUpdate)
+	for _, u := range tx.attrs {
+		updates[u.row] = append(updates[u.row], u)
+	}
+
+	for i, entry := range r.index {
+		if tx.deleted[i] {
+			continue
+		}
+		var shape Shape
+		if s, ok := tx.shapes[i]; ok {
+			if transformer != nil {
+				s = cloneShape(s)
+				TransformShape(s, transformer)
+			}
+			shape = s
+		} else {
+			buf := make([]byte, 8+entry.length)
+			if _, err := shp.ReadAt(buf, entry.offset); err != nil {
+				return w.abort(fmt.Errorf("Error when reading record %d: %v", i, err))
+			}
+			t := ShapeType(binary.LittleEndian.Uint32(buf[8:]))
+			l, err := r.readLazy(bytes.NewReader(buf[12:]), t, entry.length)
+			if err != nil {
+				return w.abort(fmt.Errorf("Error when reading record %d: %w", i, err))
+			}
+			shape = l
+		}
+		n := int(w.Write(shape))
+
+		if dbf == nil || i >= int(r.dbfNumRecords) {
+			continue
+		}
+		row := make([]byte, r.dbfRecordLength)
+		offset := int64(r.dbfHeaderLength) + int64(i)*int64(r.dbfRecordLength)
+		if _, err := dbf.ReadAt(row, offset); err != nil {
+			return w.abort(fmt.Errorf("Error when reading row %d: %v", i, err))
+		}
+		if sameFields(r.dbfFields, w.dbfFields) {
+			err = w.writeRow(n, row)
+		} else {
+			// a field was widened for an update
+			for j := range r.dbfFields {
+				if err = w.WriteAttribute(n, j, r.rowAttribute(row, j)); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return w.abort(err)
+		}
+		for _, u := range updates[i] {
+			if err := w.WriteAttribute(n, u.field, u.value); err != nil {
+				return w.abort(fmt.Errorf("Error when updating row %d: %v", i, err))
+			}
+		}
+	}
+	if newWriterOptions(tx.opts).undo == nil {
+		return w.Close()
+	}
+	// the old files stay readable through open handles after the rename
+	var files []patchFile
+	for _, name := range []string{r.sidecars.SHP, r.sidecars.SHX, r.sidecars.DBF} {
+		if name == "" {
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return w.abort(err)
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return w.abort(err)
+		}
+		files = append(files, patchFile{
+			ext:    filepath.Ext(name),
+			length: fi.Size(),
+			ranges: []patchRange{{ByteRange{0, fi.Size()}, f}},
+		})
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return writePatch(newWriterOptions(tx.opts).undo, files)
+}
@@ -0,0 +1,167 @@
+package shp
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readCities returns the points and names of the shapefile written by
+// writeCities.
+func readCities(t *testing.T, filename string) ([]Point, []string) {
+	t.Helper()
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var points []Point
+	var names []string
+	for r.Next() {
+		_, s := r.Shape()
+		points = append(points, *s.(*Point))
+		names = append(names, strings.TrimRight(r.Attribute(0), "\x00"))
+	}
+	if r.Err() != nil {
+		t.Fatal(r.Err())
+	}
+	return points, names
+}
+
+func TestTxInPlace(t *testing.T) {
+	filename := filenamePrefix + "tx_inplace"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+	before, err := os.Stat(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Edit(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.UpdateAttr(0, 0, "Ogden"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.ReplaceShape(1, &Point{7, 8}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Error("second Commit succeeded")
+	}
+
+	after, err := os.Stat(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("SHP file was replaced, want it edited in place")
+	}
+	points, names := readCities(t, filename)
+	if want := []Point{{1, 2}, {7, 8}}; !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+	if want := []string{"Ogden", "Shelbyville"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+}
+
+func TestTxRewrite(t *testing.T) {
+	filename := filenamePrefix + "tx_rewrite"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	tx, err := Edit(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.UpdateAttr(0, 0, "Ogden"); err == nil {
+		t.Error("UpdateAttr of a deleted row succeeded")
+	}
+	if err := tx.UpdateAttr(1, 0, "Capital City"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.ReplaceShape(1, &PolyLine{}); err == nil {
+		t.Error("ReplaceShape with another type succeeded")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	points, names := readCities(t, filename)
+	if want := []Point{{3, 4}}; !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+	if want := []string{"Capital City"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+}
+
+func TestTxAllOrNothing(t *testing.T) {
+	filename := filenamePrefix + "tx_fail"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	for _, deleteFirst := range []bool{false, true} {
+		tx, err := Edit(filename + ".shp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleteFirst {
+			tx.Delete(0)
+		}
+		tx.ReplaceShape(1, &Point{7, 8})
+		tx.UpdateAttr(1, 0, "Ogden")
+		tx.UpdateAttr(1, 1, []int{1})
+		if err := tx.Commit(); err == nil {
+			t.Errorf("Commit with an unsupported value succeeded (delete: %v)", deleteFirst)
+		}
+
+		points, names := readCities(t, filename)
+		if want := []Point{{1, 2}, {3, 4}}; !reflect.DeepEqual(points, want) {
+			t.Errorf("points = %v, want %v (delete: %v)", points, want, deleteFirst)
+		}
+		if want := []string{"Springfield", "Shelbyville"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("names = %q, want %q (delete: %v)", names, want, deleteFirst)
+		}
+	}
+	// no temporary files are left behind
+	matches, err := os.ReadDir("test_files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range matches {
+		if strings.HasPrefix(m.Name(), ".") {
+			t.Errorf("temporary file %s left behind", m.Name())
+		}
+	}
+}
+
+func TestTxRollback(t *testing.T) {
+	filename := filenamePrefix + "tx_rollback"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	tx, err := Edit(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Delete(1)
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Delete(0); err == nil {
+		t.Error("Delete after Rollback succeeded")
+	}
+	if points, _ := readCities(t, filename); len(points) != 2 {
+		t.Errorf("got %d records after Rollback, want 2", len(points))
+	}
+}
@@ -0,0 +1,183 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UndoPatch makes OpenEditor, and Commit of a transaction started with Edit,
+// write a reverse patch of the edits to w: the bytes of the files that were
+// overwritten, with their offsets, and the lengths of the files before. The
+// Editor writes it on Close. ApplyPatch uses the patch to roll the edits back,
+// and keeping the patches gives an audit trail of the edits. When Commit
+// rewrites the shapefile, the patch holds the whole files as they were.
+func UndoPatch(w io.Writer) WriterOption {
+	return func(o *writerOptions) {
+		o.undo = w
+	}
+}
+
+const patchMagic = "SHPU"
+
+// The patch starts with a patchHeader, which is followed by the files. Each
+// file is a patchFileHeader followed by its ranges, each of which is a
+// patchRangeHeader followed by the bytes of the range.
+type patchHeader struct {
+	Magic [4]byte
+	Files int32
+}
+
+type patchFileHeader struct {
+	Ext    [4]byte // e.g. ".shp", as in the name of the file
+	Length int64   // of the file before the edits
+	Ranges int32
+}
+
+type patchRangeHeader struct {
+	Offset int64
+	Length int64
+}
+
+// patchFile is a file in a reverse patch.
+type patchFile struct {
+	ext    string
+	length int64
+	ranges []patchRange
+}
+
+// patchRange is a range of old bytes of a file, which src reads at their
+// offsets in the file.
+type patchRange struct {
+	ByteRange
+	src io.ReaderAt
+}
+
+// writePatch writes a reverse patch of files to w.
+func writePatch(w io.Writer, files []patchFile) error {
+	h := patchHeader{Files: int32(len(files))}
+	copy(h.Magic[:], patchMagic)
+	if err := binary.Write(w, binary.LittleEndian, h); err != nil {
+		return fmt.Errorf("Error when writing undo patch: %v", err)
+	}
+	for _, f := range files {
+		fh := patchFileHeader{Length: f.length, Ranges: int32(len(f.ranges))}
+		copy(fh.Ext[:], f.ext)
+		if err := binary.Write(w, binary.LittleEndian, fh); err != nil {
+			return fmt.Errorf("Error when writing undo patch: %v", err)
+		}
+		for _, r := range f.ranges {
+			rh := patchRangeHeader{Offset: r.Offset, Length: r.Length}
+			if err := binary.Write(w, binary.LittleEndian, rh); err != nil {
+				return fmt.Errorf("Error when writing undo patch: %v", err)
+			}
+			if _, err := io.Copy(w, io.NewSectionReader(r.src, r.Offset, r.Length)); err != nil {
+				return fmt.Errorf("Error when writing undo patch: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyPatch rolls back edits of the shapefile filename by applying the
+// reverse patch written by UndoPatch: it writes back the old bytes and
+// truncates the files to their old lengths. Patches of several edits must be
+// applied in reverse order. If ApplyPatch fails, the files may be partly
+// restored.
+func ApplyPatch(filename string, patch io.Reader) error {
+	basename := strings.TrimSuffix(filename, filepath.Ext(filename))
+	var h patchHeader
+	if err := binary.Read(patch, binary.LittleEndian, &h); err != nil {
+		return fmt.Errorf("Error when reading undo patch: %v", err)
+	}
+	if string(h.Magic[:]) != patchMagic {
+		return errors.New("Not an undo patch")
+	}
+	for n := 0; n < int(h.Files); n++ {
+		var fh patchFileHeader
+		if err := binary.Read(patch, binary.LittleEndian, &fh); err != nil {
+			return fmt.Errorf("Error when reading undo patch: %v", err)
+		}
+		ext := string(bytes.TrimRight(fh.Ext[:], "\x00"))
+		if err := applyPatchFile(basename+ext, fh, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPatchFile applies the ranges of a file in a patch to the file name.
+func applyPatchFile(name string, fh patchFileHeader, patch io.Reader) error {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for n := 0; n < int(fh.Ranges); n++ {
+		var rh patchRangeHeader
+		if err := binary.Read(patch, binary.LittleEndian, &rh); err != nil {
+			return fmt.Errorf("Error when reading undo patch: %v", err)
+		}
+		if rh.Offset < 0 || rh.Length < 0 || rh.Offset+rh.Length > fh.Length {
+			return fmt.Errorf("Invalid range of %d bytes at %d in undo patch", rh.Length, rh.Offset)
+		}
+		if _, err := io.CopyN(io.NewOffsetWriter(f, rh.Offset), patch, rh.Length); err != nil {
+			return fmt.Errorf("Error when restoring %s: %v", name, err)
+		}
+	}
+	if err := f.Truncate(fh.Length); err != nil {
+		return fmt.Errorf("Error when restoring %s: %v", name, err)
+	}
+	return f.Sync()
+}
+
+// undoLog keeps the bytes of a file before they are first overwritten.
+type undoLog struct {
+	length int64       // of the file when the log was started
+	saved  []ByteRange // sorted and merged
+	ranges []patchRange
+}
+
+// save keeps the bytes in the range of length bytes at offset that were not
+// saved before.
+func (u *undoLog) save(f io.ReaderAt, offset, length int64) error {
+	end := min(offset+length, u.length)
+	if offset >= end {
+		return nil
+	}
+	pos := offset
+	for _, r := range append(u.saved, ByteRange{Offset: end}) {
+		if r.Offset+r.Length <= pos {
+			continue
+		}
+		if gap := min(r.Offset, end) - pos; gap > 0 {
+			buf := make([]byte, gap)
+			if _, err := f.ReadAt(buf, pos); err != nil {
+				return fmt.Errorf("Error when saving bytes for undo: %v", err)
+			}
+			u.ranges = append(u.ranges, patchRange{ByteRange{pos, gap}, savedBytes{buf, pos}})
+		}
+		pos = max(pos, r.Offset+r.Length)
+		if pos >= end {
+			break
+		}
+	}
+	u.saved = addRange(u.saved, offset, end-offset)
+	return nil
+}
+
+// savedBytes are bytes saved from a file at offset. Its ReadAt takes offsets
+// in the file.
+type savedBytes struct {
+	buf    []byte
+	offset int64
+}
+
+func (b savedBytes) ReadAt(p []byte, off int64) (int, error) {
+	return bytes.NewReader(b.buf).ReadAt(p, off-b.offset)
+}
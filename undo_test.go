@@ -0,0 +1,138 @@
+package shp
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// readShapefile returns the contents of the SHP, SHX and DBF files.
+func readShapefile(t *testing.T, filename string) [][]byte {
+	t.Helper()
+	var files [][]byte
+	for _, ext := range []string{".shp", ".shx", ".dbf"} {
+		data, err := os.ReadFile(filename + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, data)
+	}
+	return files
+}
+
+func checkRestored(t *testing.T, filename string, want [][]byte) {
+	t.Helper()
+	for i, got := range readShapefile(t, filename) {
+		if !bytes.Equal(got, want[i]) {
+			t.Errorf("file %d differs after ApplyPatch: got %d bytes, want %d", i, len(got), len(want[i]))
+		}
+	}
+}
+
+func TestUndoPatchEditor(t *testing.T) {
+	filename := filenamePrefix + "undo_editor"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+	before := readShapefile(t, filename)
+
+	var patch bytes.Buffer
+	e, err := OpenEditor(filename+".shp", UndoPatch(&patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteAttribute(0, 0, "Ogden"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteAttribute(0, 0, "Logan"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ReplaceShape(0, &Null{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ReplaceShape(1, &Point{10, 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(readShapefile(t, filename)[0], before[0]) {
+		t.Fatal("SHP file unchanged by edits")
+	}
+
+	if err := ApplyPatch(filename+".shp", &patch); err != nil {
+		t.Fatal(err)
+	}
+	checkRestored(t, filename, before)
+}
+
+func TestUndoPatchCompact(t *testing.T) {
+	filename := filenamePrefix + "undo_compact"
+	defer removeShapefile(filename)
+	w, err := Create(filename+".shp", POLYLINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		x := float64(i)
+		w.Write(NewPolyLine([][]Point{{{x, 0}, {x, 1}}}))
+	}
+	w.Close()
+	before := readShapefile(t, filename)
+
+	var patch bytes.Buffer
+	e, err := OpenEditor(filename+".shp", UndoPatch(&patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ReplaceShape(0, NewPolyLine([][]Point{{{0, 0}, {1, 1}, {2, 2}}})); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ReplaceShape(1, NewPolyLine([][]Point{{{0, 0}, {1, 1}, {2, 2}, {3, 3}}})); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyPatch(filename+".shp", &patch); err != nil {
+		t.Fatal(err)
+	}
+	checkRestored(t, filename, before)
+}
+
+func TestUndoPatchTx(t *testing.T) {
+	filename := filenamePrefix + "undo_tx"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+	before := readShapefile(t, filename)
+
+	var patch bytes.Buffer
+	tx, err := Edit(filename+".shp", UndoPatch(&patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Delete(0)
+	tx.UpdateAttr(1, 0, "Ogden")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, names := readCities(t, filename); len(names) != 1 {
+		t.Fatalf("got %d records after Commit, want 1", len(names))
+	}
+
+	if err := ApplyPatch(filename+".shp", &patch); err != nil {
+		t.Fatal(err)
+	}
+	checkRestored(t, filename, before)
+}
+
+func TestApplyPatchInvalid(t *testing.T) {
+	err := ApplyPatch(filenamePrefix+"undo_invalid.shp", strings.NewReader("not a patch"))
+	if err == nil {
+		t.Error("ApplyPatch of an invalid patch succeeded")
+	}
+}
@@ -0,0 +1,209 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// structField describes how a field of a Go struct maps to a DBF column or to
+// the geometry of a record. The mapping is controlled by struct tags of the
+// form `shp:"NAME"`. Fields without a tag are mapped by their Go name, fields
+// tagged with `shp:"-"` are ignored and the field tagged with
+// `shp:",geometry"` (or the first field whose type is a Shape) receives the
+// shape of the record. When writing, the options size=N and prec=N set the
+// width and precision of the DBF column.
+type structField struct {
+	index    int
+	name     string
+	tagged   bool
+	geometry bool
+	size     int
+	prec     int
+}
+
+var shapeType = reflect.TypeOf((*Shape)(nil)).Elem()
+
+// structFieldsOf returns the mapping of the exported fields of the struct type
+// t.
+func structFieldsOf(t reflect.Type) ([]structField, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct type, got %v", t)
+	}
+	var fields []structField
+	hasGeometry := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		tag := f.Tag.Get("shp")
+		if tag == "-" {
+			continue
+		}
+		sf := structField{index: i, name: f.Name}
+		if tag != "" {
+			sf.tagged = true
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				sf.name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				var err error
+				switch {
+				case opt == "geometry":
+					sf.geometry = true
+				case strings.HasPrefix(opt, "size="):
+					sf.size, err = strconv.Atoi(opt[len("size="):])
+				case strings.HasPrefix(opt, "prec="):
+					sf.prec, err = strconv.Atoi(opt[len("prec="):])
+				}
+				if err != nil {
+					return nil, fmt.Errorf("invalid tag on field %s: %v", f.Name, err)
+				}
+			}
+		}
+		if !sf.geometry && !hasGeometry && f.Type.Implements(shapeType) {
+			sf.geometry = true
+		}
+		if sf.geometry {
+			if hasGeometry {
+				return nil, fmt.Errorf("%v has more than one geometry field", t)
+			}
+			hasGeometry = true
+		}
+		fields = append(fields, sf)
+	}
+	return fields, nil
+}
+
+// Unmarshal stores the shape and the attributes of the record that sr was
+// last advanced to in the struct pointed to by v. See structField for the
+// supported struct tags. Attributes are converted to strings, bools, integers,
+// floats and time.Time (for dates formatted as YYYYMMDD) as needed.
+func Unmarshal(sr SequentialReader, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Unmarshal needs a non-nil pointer, got %T", v)
+	}
+	fields, err := structFieldsOf(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return unmarshalRecord(sr, columnIndex(sr.Fields()), fields, rv.Elem())
+}
+
+// ReadAll reads all remaining records from sr into a slice of T, which must be
+// a struct type. It uses the same mapping as Unmarshal and is meant for small
+// shapefiles that fit into memory. On error, the records that were read so far
+// are returned.
+func ReadAll[T any](sr SequentialReader) ([]T, error) {
+	var zero T
+	fields, err := structFieldsOf(reflect.TypeOf(zero))
+	if err != nil {
+		return nil, err
+	}
+	columns := columnIndex(sr.Fields())
+	var rows []T
+	for sr.Next() {
+		var row T
+		if err := unmarshalRecord(sr, columns, fields, reflect.ValueOf(&row).Elem()); err != nil {
+			n, _ := sr.Shape()
+			return rows, fmt.Errorf("record %d: %v", n, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, sr.Err()
+}
+
+func unmarshalRecord(sr SequentialReader, columns map[string]int, fields []structField, v reflect.Value) error {
+	for _, f := range fields {
+		fv := v.Field(f.index)
+		if f.geometry {
+			_, shape := sr.Shape()
+			if shape == nil {
+				continue
+			}
+			sv := reflect.ValueOf(shape)
+			if !sv.Type().AssignableTo(fv.Type()) {
+				return fmt.Errorf("cannot assign %T to field %s", shape, f.name)
+			}
+			fv.Set(sv)
+			continue
+		}
+		col, ok := columns[strings.ToUpper(f.name)]
+		if !ok {
+			if f.tagged {
+				return fmt.Errorf("no DBF field named %s", f.name)
+			}
+			continue
+		}
+		if c := converterFor(sr.Fields()[col].Fieldtype); c != nil {
+			if err := setConverted(fv, c, sr.Attribute(col)); err != nil {
+				return fmt.Errorf("field %s: %v", f.name, err)
+			}
+			continue
+		}
+		if err := setValue(fv, sr.Attribute(col)); err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// setValue parses the attribute value s into v. Empty values leave v
+// untouched.
+func setValue(v reflect.Value, s string) error {
+	s = strings.Trim(s, " \x00")
+	if s == "" {
+		return nil
+	}
+	if v.Type() == timeType {
+		t, err := time.Parse("20060102", s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		switch s {
+		case "T", "t", "Y", "y":
+			v.SetBool(true)
+		case "F", "f", "N", "n", "?":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid logical value %q", s)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
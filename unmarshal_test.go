@@ -0,0 +1,51 @@
+//go:build !shp_minimal
+
+package shp
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadAll(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t))
+	defer sr.Close()
+	cities, err := ReadAll[city](sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cities) != 2 {
+		t.Fatalf("got %d cities, want 2", len(cities))
+	}
+	c := cities[1]
+	if c.Name != "Shelbyville" || c.Pop != 25000 || c.Area != 9.25 {
+		t.Errorf("got %+v", c)
+	}
+	if c.Geometry == nil || *c.Geometry != (Point{3, 4}) {
+		t.Errorf("got geometry %v, want {3 4}", c.Geometry)
+	}
+}
+
+func TestUnmarshalUnknownField(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	shp, _ := os.Open(filename + ".shp")
+	dbf, _ := os.Open(filename + ".dbf")
+	sr := SequentialReaderFromExt(shp, dbf)
+	defer sr.Close()
+	if !sr.Next() {
+		t.Fatal(sr.Err())
+	}
+	var v struct {
+		Missing string `shp:"MISSING"`
+	}
+	if err := Unmarshal(sr, &v); err == nil {
+		t.Error("unmarshaled unknown DBF field without error")
+	}
+}
@@ -0,0 +1,99 @@
+package shp
+
+// ForEachVertex calls f for every vertex of s with the index of the vertex and
+// pointers to its coordinates, which f may modify in place. Afterwards, the
+// bounding box stored in s is updated to match the modified coordinates. The
+// coordinates of a *Shape32 are passed as float64 and rounded when stored.
+func ForEachVertex(s Shape, f func(i int, x, y *float64)) {
+	switch p := s.(type) {
+	case *Point:
+		f(0, &p.X, &p.Y)
+	case *PointZ:
+		f(0, &p.X, &p.Y)
+	case *PointM:
+		f(0, &p.X, &p.Y)
+	case *Shape32:
+		s := p.Float64()
+		ForEachVertex(s, f)
+		*p = *toShape32(s)
+	default:
+		points := shapePoints(s)
+		for i := range points {
+			f(i, &points[i].X, &points[i].Y)
+		}
+		updateBBox(s)
+	}
+}
+
+// shapePoints returns the Points slice of the multi-vertex shape s, or nil for
+// single points and Null shapes.
+func shapePoints(s Shape) []Point {
+	switch p := s.(type) {
+	case *PolyLine:
+		return p.Points
+	case *Polygon:
+		return p.Points
+	case *MultiPoint:
+		return p.Points
+	case *PolyLineZ:
+		return p.Points
+	case *PolygonZ:
+		return p.Points
+	case *MultiPointZ:
+		return p.Points
+	case *PolyLineM:
+		return p.Points
+	case *PolygonM:
+		return p.Points
+	case *MultiPointM:
+		return p.Points
+	case *MultiPatch:
+		return p.Points
+	}
+	return nil
+}
+
+// updateBBox recalculates the bounding box stored in s from its points.
+func updateBBox(s Shape) {
+	box := s.BBox()
+	switch p := s.(type) {
+	case *PolyLine:
+		p.Box = box
+	case *Polygon:
+		p.Box = box
+	case *MultiPoint:
+		p.Box = box
+	case *PolyLineZ:
+		p.Box = box
+	case *PolygonZ:
+		p.Box = box
+	case *MultiPointZ:
+		p.Box = box
+	case *PolyLineM:
+		p.Box = box
+	case *PolygonM:
+		p.Box = box
+	case *MultiPointM:
+		p.Box = box
+	case *MultiPatch:
+		p.Box = box
+	}
+}
+
+// SwapXY exchanges the X and Y coordinates of all vertices of s in place. This
+// fixes data that was written with latitude as X and longitude as Y.
+func SwapXY(s Shape) {
+	ForEachVertex(s, func(i int, x, y *float64) {
+		*x, *y = *y, *x
+	})
+}
+
+// ScaleCoords multiplies the X and Y coordinates of all vertices of s by
+// factor in place. Use it with the factor returned by LinearUnit to convert
+// coordinates to meters, for example.
+func ScaleCoords(s Shape, factor float64) {
+	ForEachVertex(s, func(i int, x, y *float64) {
+		*x *= factor
+		*y *= factor
+	})
+}
@@ -0,0 +1,42 @@
+package shp
+
+import "testing"
+
+func TestForEachVertex(t *testing.T) {
+	l := NewPolyLine([][]Point{{{0, 0}, {1, 2}}, {{3, 4}}})
+	var seen []int
+	ForEachVertex(l, func(i int, x, y *float64) {
+		seen = append(seen, i)
+		*x += 10
+		*y *= 2
+	})
+	if len(seen) != 3 || seen[2] != 2 {
+		t.Errorf("got vertex indices %v", seen)
+	}
+	if l.Points[2] != (Point{13, 8}) {
+		t.Errorf("got %v, want {13 8}", l.Points[2])
+	}
+	if want := (Box{10, 0, 13, 8}); l.Box != want {
+		t.Errorf("got box %v, want %v", l.Box, want)
+	}
+
+	p := &PointZ{1, 2, 3, 4}
+	ForEachVertex(p, func(i int, x, y *float64) {
+		*x, *y = *y, *x
+	})
+	if *p != (PointZ{2, 1, 3, 4}) {
+		t.Errorf("got %v, want {2 1 3 4}", *p)
+	}
+}
+
+func TestSwapXYAndScale(t *testing.T) {
+	m := PointsToMultiPoint([]Point{{1, 2}, {3, 4}})
+	SwapXY(m)
+	if m.Points[1] != (Point{4, 3}) || m.Box != (Box{2, 1, 4, 3}) {
+		t.Errorf("got %+v after SwapXY", m)
+	}
+	ScaleCoords(m, FootToMeter)
+	if m.Points[0] != (Point{2 * 0.3048, 1 * 0.3048}) {
+		t.Errorf("got %v after ScaleCoords", m.Points[0])
+	}
+}
@@ -0,0 +1,51 @@
+package shp
+
+// VirtualFieldSize is the size of the character fields added by
+// AddVirtualField, the largest a DBF character field can be.
+const VirtualFieldSize = 254
+
+type virtualField struct {
+	field Field
+	value func(Record) string
+}
+
+// AddVirtualField makes the reader add a character field named name after the
+// fields of the DBF, whose value is computed by value from the shape and the
+// other attributes of each record, e.g. to concatenate attributes, convert
+// units or pad codes with zeros. Consumers such as CSV exports or database
+// sinks then see the derived column without rewriting the shapefile. The
+// field is VirtualFieldSize characters wide and its values are returned by
+// Attribute and NextBatch; with several virtual fields, the Record passed to
+// value only holds the attributes of the DBF. Unlike AddComputedField, which
+// wraps any SequentialReader, it is set when opening a reader.
+func AddVirtualField(name string, value func(Record) string) ReaderOption {
+	return func(o *readerOptions) {
+		o.virtualFields = append(o.virtualFields, virtualField{StringField(name, VirtualFieldSize), value})
+	}
+}
+
+// withVirtual returns fields followed by the virtual fields.
+func (o readerOptions) withVirtual(fields []Field) []Field {
+	if len(o.virtualFields) == 0 {
+		return fields
+	}
+	out := make([]Field, 0, len(fields)+len(o.virtualFields))
+	out = append(out, fields...)
+	for _, v := range o.virtualFields {
+		out = append(out, v.field)
+	}
+	return out
+}
+
+// virtualAttribute returns the value of the n-th virtual field for the record
+// with index and shape and the attributes of its numFields DBF fields.
+func (o readerOptions) virtualAttribute(n, index int, shape Shape, numFields int, attribute func(int) string) string {
+	if n < 0 || n >= len(o.virtualFields) {
+		return ""
+	}
+	attrs := make([]string, numFields)
+	for i := range attrs {
+		attrs[i] = attribute(i)
+	}
+	return o.virtualFields[n].value(Record{Index: index, Shape: shape, Attributes: attrs})
+}
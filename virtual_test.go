@@ -0,0 +1,47 @@
+package shp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAddVirtualField(t *testing.T) {
+	filename := filenamePrefix + "cities"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	label := AddVirtualField("LABEL", func(r Record) string {
+		return fmt.Sprintf("%d:%s", r.Index, strings.TrimRight(r.Attributes[0], "\x00"))
+	})
+	x := AddVirtualField("X", func(r Record) string {
+		return fmt.Sprint(r.Shape.(*Point).X)
+	})
+
+	r, err := Open(filename+".shp", label, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	fields := r.Fields()
+	if len(fields) != 5 || fields[3].String() != "LABEL" || fields[4].Size != VirtualFieldSize {
+		t.Fatalf("got fields %v", fields)
+	}
+	r.Next()
+	if got := r.Attribute(3); got != "0:Springfield" {
+		t.Errorf("got LABEL %q", got)
+	}
+	if got := r.Attribute(4); got != "1" {
+		t.Errorf("got X %q", got)
+	}
+
+	sr := SequentialReaderFromExt(openFile(filename+".shp", t), openFile(filename+".dbf", t), SelectFields("NAME"), label)
+	defer sr.Close()
+	batch, err := NextBatch(sr, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := batch[1].Attributes; len(got) != 2 || got[1] != "1:Shelbyville" {
+		t.Errorf("got attributes %q", got)
+	}
+}
@@ -0,0 +1,73 @@
+package shp
+
+import "fmt"
+
+// Warning is a non-fatal issue found while reading a shapefile. Unlike
+// errors, warnings do not stop reading.
+type Warning struct {
+	Index int   // index of the record, or -1 if it concerns the whole file
+	Err   error // the issue, e.g. a *ShapeTypeError or *MisalignedError
+}
+
+func (w Warning) String() string {
+	if w.Index < 0 {
+		return w.Err.Error()
+	}
+	return fmt.Sprintf("record %d: %v", w.Index, w.Err)
+}
+
+// OnWarning makes the reader pass every warning to f as soon as it is found,
+// instead of collecting them for Warnings.
+func OnWarning(f func(Warning)) ReaderOption {
+	return func(o *readerOptions) {
+		o.onWarning = f
+	}
+}
+
+// ValidateShapes makes the reader check every decoded shape with
+// CheckShapeInvariants and report violations as warnings. Shapes read with
+// LazyDecoding are checked when they are decoded, so their warnings are only
+// reported then.
+func ValidateShapes() ReaderOption {
+	return func(o *readerOptions) {
+		o.validate = true
+	}
+}
+
+// warn reports w to the OnWarning callback or appends it to *list.
+func (o readerOptions) warn(list *[]Warning, w Warning) {
+	o.debug("warning", "record", w.Index, "error", w.Err)
+	if o.warnMu != nil {
+		o.warnMu.Lock()
+		defer o.warnMu.Unlock()
+	}
+	if o.onWarning != nil {
+		o.onWarning(w)
+		return
+	}
+	*list = append(*list, w)
+}
+
+// validateShape reports the violated invariant of the shape at index, if any.
+func (o readerOptions) validateShape(list *[]Warning, index int, s Shape) {
+	if !o.validate {
+		return
+	}
+	if err := CheckShapeInvariants(s); err != nil {
+		o.warn(list, Warning{Index: index, Err: err})
+	}
+}
+
+// Warnings returns the warnings found so far, unless OnWarning is used.
+func (r *Reader) Warnings() []Warning {
+	r.warnMu.Lock()
+	defer r.warnMu.Unlock()
+	return r.warnings
+}
+
+// Warnings implements WarningReporter for seqReader.
+func (sr *seqReader) Warnings() []Warning {
+	sr.warnMu.Lock()
+	defer sr.warnMu.Unlock()
+	return sr.warnings
+}
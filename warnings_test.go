@@ -0,0 +1,85 @@
+package shp
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	filename := writeMixed(t)
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for r.Next() {
+	}
+	w := r.Warnings()
+	var te *ShapeTypeError
+	if len(w) != 1 || w[0].Index != 1 || !errors.As(w[0].Err, &te) {
+		t.Fatalf("got warnings %v", w)
+	}
+	if got, want := w[0].String(), "record 1: record 1 has shape type POINTZ, but the file has POINT"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	filename = writeMisaligned(t, t.TempDir(), 3, 2)
+	var warnings []Warning
+	r2, err := Open(filename, OnWarning(func(w Warning) { warnings = append(warnings, w) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r2.Close()
+	var me *MisalignedError
+	if len(warnings) != 1 || warnings[0].Index != -1 || !errors.As(warnings[0].Err, &me) {
+		t.Errorf("got warnings %v", warnings)
+	}
+	if len(r2.Warnings()) != 0 {
+		t.Errorf("Warnings() = %v with OnWarning", r2.Warnings())
+	}
+}
+
+func TestValidateShapes(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "open.shp")
+	w, err := Create(filename, POLYGON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(NewPolygon([][]Point{{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}}))
+	w.Write(NewPolygon([][]Point{{{0, 0}, {0, 1}, {1, 1}, {1, 0}}}))
+	w.Close()
+
+	sr := SequentialReaderFromExt(openFile(filename, t), nil, ValidateShapes())
+	defer sr.Close()
+	n := 0
+	for ; sr.Next(); n++ {
+	}
+	if sr.Err() != nil || n != 2 {
+		t.Fatalf("read %d shapes: %v", n, sr.Err())
+	}
+	if w := sr.(WarningReporter).Warnings(); len(w) != 1 || w[0].Index != 1 {
+		t.Errorf("got warnings %v", w)
+	}
+	r, err := Open(filename, ValidateShapes(), LazyDecoding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var shapes []Shape
+	for r.Next() {
+		_, s := r.Shape()
+		shapes = append(shapes, s)
+	}
+	if w := r.Warnings(); len(w) != 0 {
+		t.Errorf("got warnings %v before decoding", w)
+	}
+	for _, s := range shapes {
+		if _, err := s.(*LazyShape).Decode(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w := r.Warnings(); len(w) != 1 || w[0].Index != 1 {
+		t.Errorf("got lazy warnings %v", w)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Writer is the type that is used to write a new shapefile.
@@ -18,8 +20,26 @@ type Writer struct {
 	shp          writeSeekCloser
 	shx          writeSeekCloser
 	GeometryType ShapeType
-	num          int32
-	bbox         Box
+	// Logger, if set, traces the patching of headers at debug level.
+	Logger *slog.Logger
+	// Metrics, if set, receives the records written and errors.
+	Metrics Metrics
+	num     int32
+	extent  ExtentTracker
+
+	lock        *os.File    // held from Create or Append until Close, if locking
+	target      string      // basename to rename the files to on Close, if atomic
+	journal     *os.File    // if journaling
+	transformer Transformer // applied to the shapes before writing, if set
+	overflow    OverflowPolicy
+
+	truncateStrings bool
+	warnings        []Warning
+	lastUpdate      time.Time
+	deterministic   bool
+	prj, cpg        string     // written on Close, if set
+	style           *Style     // written on Close, if set
+	zip             *zipTarget // if created by CreateZip
 
 	dbf             writeSeekCloser
 	dbfFields       []Field
@@ -40,25 +60,60 @@ type writeSeekCloser interface {
 // and DBF).
 // If filename does not end on ".shp" already, it will be treated as the basename
 // for the file and the ".shp" extension will be appended to that name.
-func Create(filename string, t ShapeType) (*Writer, error) {
+func Create(filename string, t ShapeType, opts ...WriterOption) (*Writer, error) {
 	if strings.HasSuffix(strings.ToLower(filename), ".shp") {
 		filename = filename[0 : len(filename)-4]
 	}
-	shp, err := os.Create(filename + ".shp")
+	o := newWriterOptions(opts)
+	var lock *os.File
+	if o.lock {
+		var err error
+		if lock, err = acquireLock(lockName(filename, o.atomic)); err != nil {
+			return nil, err
+		}
+	}
+	var target string
+	var shp *os.File
+	var err error
+	if o.atomic {
+		target = filename
+		shp, filename, err = createTemp(target)
+	} else {
+		shp, err = os.Create(filename + ".shp")
+	}
 	if err != nil {
+		releaseLock(lock)
 		return nil, err
 	}
 	shx, err := os.Create(filename + ".shx")
 	if err != nil {
+		releaseLock(lock)
 		return nil, err
 	}
 	shp.Seek(100, io.SeekStart)
 	shx.Seek(100, io.SeekStart)
 	w := &Writer{
 		filename:     filename,
+		target:       target,
 		shp:          shp,
 		shx:          shx,
 		GeometryType: t,
+		lock:         lock,
+		transformer:  o.transformer,
+		overflow:     o.overflow,
+
+		truncateStrings: o.truncateStrings,
+		lastUpdate:      o.lastUpdate,
+		deterministic:   o.deterministic,
+		prj:             o.prj,
+		cpg:             o.cpg,
+		style:           o.style,
+	}
+	if o.journal {
+		if err := w.startJournal(); err != nil {
+			w.Close()
+			return nil, err
+		}
 	}
 	return w, nil
 }
@@ -66,7 +121,40 @@ func Create(filename string, t ShapeType) (*Writer, error) {
 // Append returns a Writer pointer that will append to the given shapefile and
 // the first error that was encounted during creation of that Writer. The
 // shapefile must have a valid index file.
-func Append(filename string) (*Writer, error) {
+func Append(filename string, opts ...WriterOption) (*Writer, error) {
+	o := newWriterOptions(opts)
+	if o.atomic {
+		return nil, errors.New("Cannot append to a shapefile atomically")
+	}
+	var lock *os.File
+	if o.lock {
+		var err error
+		if lock, err = acquireLock(filename); err != nil {
+			return nil, err
+		}
+	}
+	w, err := appendTo(filename)
+	if err != nil {
+		releaseLock(lock)
+		return nil, err
+	}
+	w.lock = lock
+	w.transformer = o.transformer
+	w.overflow = o.overflow
+	w.truncateStrings = o.truncateStrings
+	w.lastUpdate = o.lastUpdate
+	w.deterministic = o.deterministic
+	if o.journal {
+		if err := w.startJournal(); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// appendTo implements Append.
+func appendTo(filename string) (*Writer, error) {
 	shp, err := os.OpenFile(filename, os.O_RDWR, 0666)
 	if err != nil {
 		return nil, err
@@ -86,15 +174,23 @@ func Append(filename string) (*Writer, error) {
 		return nil, fmt.Errorf("cannot read geometry type: %v", err)
 	}
 	er := &errReader{Reader: shp}
-	w.bbox.MinX = readFloat64(er)
-	w.bbox.MinY = readFloat64(er)
-	w.bbox.MaxX = readFloat64(er)
-	w.bbox.MaxY = readFloat64(er)
+	var bbox Box
+	bbox.MinX = readFloat64(er)
+	bbox.MinY = readFloat64(er)
+	bbox.MaxX = readFloat64(er)
+	bbox.MaxY = readFloat64(er)
 	if er.e != nil {
 		return nil, fmt.Errorf("cannot read bounding box: %v", er.e)
 	}
 
-	shx, err := os.OpenFile(basename+".shx", os.O_RDWR, 0666)
+	sidecars, err := FindSidecars(filename)
+	if err != nil {
+		return nil, err
+	}
+	if sidecars.SHX == "" {
+		sidecars.SHX = basename + ".shx"
+	}
+	shx, err := os.OpenFile(sidecars.SHX, os.O_RDWR, 0666)
 	if os.IsNotExist(err) {
 		// TODO allow index file to not exist, in that case just
 		// read through all the shapes and create it on the fly
@@ -120,6 +216,9 @@ func Append(filename string) (*Writer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot read number of last shape: %v", err)
 	}
+	if w.num > 0 {
+		w.extent.AddBox(bbox)
+	}
 	_, err = shp.Seek(0, io.SeekEnd)
 	if err != nil {
 		return nil, fmt.Errorf("cannot seek to SHP end: %v", err)
@@ -130,7 +229,10 @@ func Append(filename string) (*Writer, error) {
 	}
 	w.shx = shx
 
-	dbf, err := os.Open(basename + ".dbf")
+	if sidecars.DBF == "" {
+		return w, nil // it's okay if the DBF does not exist
+	}
+	dbf, err := os.OpenFile(sidecars.DBF, os.O_RDWR, 0666)
 	if os.IsNotExist(err) {
 		return w, nil // it's okay if the DBF does not exist
 	}
@@ -175,12 +277,11 @@ func Append(filename string) (*Writer, error) {
 // initialized). Returns the index of the written object
 // which can be used in WriteAttribute.
 func (w *Writer) Write(shape Shape) int32 {
-	// increate bbox
-	if w.num == 0 {
-		w.bbox = shape.BBox()
-	} else {
-		w.bbox.Extend(shape.BBox())
+	if w.transformer != nil {
+		shape = cloneShape(shape)
+		TransformShape(shape, w.transformer)
 	}
+	w.extent.Add(shape)
 
 	w.num++
 	binary.Write(w.shp, binary.BigEndian, w.num)
@@ -193,6 +294,9 @@ func (w *Writer) Write(shape Shape) int32 {
 	w.shp.Seek(start-4, io.SeekStart)
 	binary.Write(w.shp, binary.BigEndian, length)
 	w.shp.Seek(finish, io.SeekStart)
+	if w.Metrics != nil {
+		w.Metrics.RecordWritten(finish - start + 8)
+	}
 
 	// write shx
 	binary.Write(w.shx, binary.BigEndian, int32((start-8)/2))
@@ -202,24 +306,46 @@ func (w *Writer) Write(shape Shape) int32 {
 	if w.dbf != nil {
 		w.writeEmptyRecord()
 	}
+	w.writeJournal(false)
 
 	return w.num - 1
 }
 
 // Close closes the Writer. This must be used at the end of
 // the transaction because it writes the correct headers
-// to the SHP/SHX and DBF files before closing.
-func (w *Writer) Close() {
+// to the SHP/SHX and DBF files before closing. It returns the first error
+// encountered when closing or, with Atomic, renaming the files.
+func (w *Writer) Close() error {
+	// the journal is not updated while the files are finished
+	journal := w.journal
+	w.journal = nil
+
 	w.writeHeader(w.shx)
 	w.writeHeader(w.shp)
-	w.shp.Close()
-	w.shx.Close()
+	err := w.shp.Close()
+	if e := w.shx.Close(); err == nil {
+		err = e
+	}
 
 	if w.dbf == nil {
 		w.SetFields([]Field{})
 	}
 	w.writeDbfHeader(w.dbf)
-	w.dbf.Close()
+	if e := w.dbf.Close(); err == nil {
+		err = e
+	}
+	if e := w.writeSidecars(); err == nil {
+		err = e
+	}
+	if w.target != "" {
+		err = w.commit(err)
+	}
+	if w.zip != nil {
+		err = w.zip.finish(w.filename, err)
+	}
+	closeJournal(journal, err)
+	releaseLock(w.lock)
+	return err
 }
 
 // writeHeader wrires SHP/SHX headers to ws.
@@ -228,6 +354,8 @@ func (w *Writer) writeHeader(ws io.WriteSeeker) {
 	if filelength == 0 {
 		filelength = 100
 	}
+	w.debug("patching header", "file", w.filename, "length", filelength,
+		"type", w.GeometryType, "bbox", w.extent.Box())
 	ws.Seek(0, io.SeekStart)
 	// file code
 	binary.Write(ws, binary.BigEndian, []int32{9994, 0, 0, 0, 0, 0})
@@ -236,16 +364,17 @@ func (w *Writer) writeHeader(ws io.WriteSeeker) {
 	// version and shape type
 	binary.Write(ws, binary.LittleEndian, []int32{1000, int32(w.GeometryType)})
 	// bounding box
-	binary.Write(ws, binary.LittleEndian, w.bbox)
+	binary.Write(ws, binary.LittleEndian, w.extent.Box())
 	// elevation, measure
 	binary.Write(ws, binary.LittleEndian, []float64{0.0, 0.0, 0.0, 0.0})
 }
 
 // writeDbfHeader writes a DBF header to ws.
 func (w *Writer) writeDbfHeader(ws io.WriteSeeker) {
+	w.debug("patching DBF header", "file", w.filename, "records", w.num, "fields", len(w.dbfFields))
 	ws.Seek(0, 0)
-	// version, year (YEAR-1990), month, day
-	binary.Write(ws, binary.LittleEndian, []byte{3, 24, 5, 3})
+	// version, year (YEAR-1900), month, day
+	binary.Write(ws, binary.LittleEndian, append([]byte{3}, dbfDateBytes(w.lastUpdate)...))
 	// number of records
 	binary.Write(ws, binary.LittleEndian, w.num)
 	// header length, record length
@@ -274,6 +403,12 @@ func (w *Writer) SetFields(fields []Field) error {
 		return fmt.Errorf("Failed to open %s.dbf: %v", w.filename, err)
 	}
 	w.dbfFields = fields
+	if w.deterministic {
+		w.dbfFields = make([]Field, len(fields))
+		for i, f := range fields {
+			w.dbfFields[i] = normalizeField(f)
+		}
+	}
 
 	// calculate record length
 	w.dbfRecordLength = int16(1)
@@ -292,6 +427,7 @@ func (w *Writer) SetFields(fields []Field) error {
 	for n := int32(0); n < w.num; n++ {
 		w.writeEmptyRecord()
 	}
+	w.writeJournal(true)
 	return nil
 }
 
@@ -311,8 +447,39 @@ func (w *Writer) writeEmptyRecord() {
 // Shapefile. The field value corresponds to the field in the slice used in
 // SetFields.
 func (w *Writer) WriteAttribute(row int, field int, value interface{}) error {
+	err := w.writeAttribute(row, field, value)
+	if err != nil && w.Metrics != nil {
+		w.Metrics.Error("write")
+	}
+	return err
+}
+
+// writeAttribute implements WriteAttribute.
+func (w *Writer) writeAttribute(row int, field int, value interface{}) error {
+	if w.dbf == nil {
+		return errors.New("Initialize DBF by using SetFields first")
+	}
+	buf, err := w.encodeAttribute(row, field, value)
+	if err != nil {
+		return err
+	}
+	return w.writeField(row, field, buf)
+}
+
+// encodeAttribute encodes value for field in row, fitting it to the size of
+// the field according to the options of w.
+func (w *Writer) encodeAttribute(row int, field int, value interface{}) ([]byte, error) {
+	if w.dbfFields[field].Fieldtype == 'Y' {
+		return encodeCurrency(value)
+	}
+
 	var buf []byte
 	switch v := value.(type) {
+	case bool:
+		buf = []byte("F")
+		if v {
+			buf = []byte("T")
+		}
 	case int:
 		buf = []byte(strconv.Itoa(v))
 	case float64:
@@ -321,25 +488,32 @@ func (w *Writer) WriteAttribute(row int, field int, value interface{}) error {
 	case string:
 		buf = []byte(v)
 	default:
-		return fmt.Errorf("Unsupported value type: %T", v)
+		return nil, fmt.Errorf("Unsupported value type: %T", v)
 	}
 
-	if w.dbf == nil {
-		return errors.New("Initialize DBF by using SetFields first")
-	}
 	if sz := int(w.dbfFields[field].Size); len(buf) > sz {
-		return fmt.Errorf("Unable to write field %v: %q exceeds field length %v", field, buf, sz)
+		if _, ok := value.(string); ok {
+			return w.fitString(row, field, buf)
+		}
+		return w.fitNumber(field, value, buf)
 	}
+	return buf, nil
+}
 
+// writeField writes the encoded value buf to field in row.
+func (w *Writer) writeField(row, field int, buf []byte) error {
 	seekTo := 1 + int64(w.dbfHeaderLength) + (int64(row) * int64(w.dbfRecordLength))
 	for n := 0; n < field; n++ {
 		seekTo += int64(w.dbfFields[n].Size)
 	}
+	if size := int(w.dbfFields[field].Size); w.deterministic && len(buf) < size {
+		buf = append(buf, make([]byte, size-len(buf))...)
+	}
 	w.dbf.Seek(seekTo, io.SeekStart)
 	return binary.Write(w.dbf, binary.LittleEndian, buf)
 }
 
 // BBox returns the bounding box of the Writer.
 func (w *Writer) BBox() Box {
-	return w.bbox
+	return w.extent.Box()
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -16,6 +17,36 @@ func removeShapefile(filename string) {
 	os.Remove(filename + ".dbf")
 }
 
+type city struct {
+	Geometry *Point
+	Name     string  `shp:"NAME"`
+	Pop      int     `shp:"POP"`
+	Area     float64 `shp:"AREA"`
+	Ignored  string  `shp:"-"`
+}
+
+func writeCities(t *testing.T, filename string) {
+	w, err := Create(filename+".shp", POINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{
+		StringField("NAME", 20),
+		NumberField("POP", 10),
+		FloatField("AREA", 10, 2),
+	})
+	for _, c := range []city{
+		{&Point{1, 2}, "Springfield", 30000, 12.5, ""},
+		{&Point{3, 4}, "Shelbyville", 25000, 9.25, ""},
+	} {
+		n := int(w.Write(c.Geometry))
+		w.WriteAttribute(n, 0, c.Name)
+		w.WriteAttribute(n, 1, c.Pop)
+		w.WriteAttribute(n, 2, c.Area)
+	}
+	w.Close()
+}
+
 func pointsToFloats(points []Point) [][]float64 {
 	floats := make([][]float64, len(points))
 	for k, v := range points {
@@ -74,6 +105,36 @@ func TestAppend(t *testing.T) {
 	testPoint(t, points, shapes)
 }
 
+func TestAppendAttributes(t *testing.T) {
+	filename := filenamePrefix + "cities_append"
+	defer removeShapefile(filename)
+	writeCities(t, filename)
+
+	w, err := Append(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := int(w.Write(&Point{5, 6}))
+	if err := w.WriteAttribute(n, 0, "Capital City"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(filename + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got := r.AttributeCount(); got != 3 {
+		t.Fatalf("AttributeCount() = %d, want 3", got)
+	}
+	if name := strings.TrimRight(r.ReadAttribute(2, 0), "\x00"); name != "Capital City" {
+		t.Errorf("got name %q, want Capital City", name)
+	}
+}
+
 func TestWritePoint(t *testing.T) {
 	filename := filenamePrefix + "point"
 	defer removeShapefile(filename)
@@ -20,14 +20,19 @@ func openFromZIP(z *zip.ReadCloser, name string) (io.ReadCloser, error) {
 	for _, f := range z.File {
 		if f.Name == name {
 			return f.Open()
-
+		}
+	}
+	// fall back to a case-insensitive match, e.g. for FOO.DBF next to foo.shp
+	for _, f := range z.File {
+		if strings.EqualFold(f.Name, name) {
+			return f.Open()
 		}
 	}
 	return nil, fmt.Errorf("No such file in archive: %s", name)
 }
 
 // OpenZip opens a ZIP file that contains a single shapefile.
-func OpenZip(zipFilePath string) (*ZipReader, error) {
+func OpenZip(zipFilePath string, opts ...ReaderOption) (*ZipReader, error) {
 	z, err := zip.OpenReader(zipFilePath)
 	if err != nil {
 		return nil, err
@@ -43,17 +48,34 @@ func OpenZip(zipFilePath string) (*ZipReader, error) {
 		return nil, fmt.Errorf("archive does contain multiple .shp files")
 	}
 
-	shp, err := openFromZIP(zr.z, shapeFiles[0].Name)
-	if err != nil {
+	if err := zr.openSequential(shapeFiles[0].Name, opts); err != nil {
 		return nil, err
 	}
-	withoutExt := strings.TrimSuffix(shapeFiles[0].Name, ".shp")
-	// dbf is optional, so no error checking here
-	dbf, _ := openFromZIP(zr.z, withoutExt+".dbf")
-	zr.sr = SequentialReaderFromExt(shp, dbf)
 	return zr, nil
 }
 
+// openSequential sets up the SequentialReader for the shapefile called name
+// in the archive. The SHP and DBF files are only opened if they are not
+// skipped by opts.
+func (zr *ZipReader) openSequential(name string, opts []ReaderOption) error {
+	o := newReaderOptions(opts)
+	var shp, dbf io.ReadCloser
+	if !o.skipGeometry {
+		var err error
+		shp, err = openFromZIP(zr.z, name)
+		if err != nil {
+			return err
+		}
+	}
+	if !o.skipAttributes {
+		// dbf is optional, so no error checking here
+		prefix := strings.TrimSuffix(name, path.Ext(name))
+		dbf, _ = openFromZIP(zr.z, prefix+".dbf")
+	}
+	zr.sr = SequentialReaderFromExt(shp, dbf, opts...)
+	return nil
+}
+
 // ShapesInZip returns a string-slice with the names (i.e. relatives paths in
 // archive file tree) of all shapes that are in the ZIP archive at zipFilePath.
 func ShapesInZip(zipFilePath string) ([]string, error) {
@@ -72,7 +94,7 @@ func ShapesInZip(zipFilePath string) ([]string, error) {
 func shapesInZip(z *zip.ReadCloser) []*zip.File {
 	var shapeFiles []*zip.File
 	for _, f := range z.File {
-		if strings.HasSuffix(f.Name, ".shp") {
+		if strings.ToLower(path.Ext(f.Name)) == ".shp" {
 			shapeFiles = append(shapeFiles, f)
 		}
 	}
@@ -85,7 +107,7 @@ func shapesInZip(z *zip.ReadCloser) []*zip.File {
 // drive letter (e.g. C:) or leading slash, and only forward slashes are
 // allowed. These rules are the same as in
 // https://golang.org/pkg/archive/zip/#FileHeader.
-func OpenShapeFromZip(zipFilePath string, name string) (*ZipReader, error) {
+func OpenShapeFromZip(zipFilePath string, name string, opts ...ReaderOption) (*ZipReader, error) {
 	z, err := zip.OpenReader(zipFilePath)
 	if err != nil {
 		return nil, err
@@ -94,14 +116,9 @@ func OpenShapeFromZip(zipFilePath string, name string) (*ZipReader, error) {
 		z: z,
 	}
 
-	shp, err := openFromZIP(zr.z, name)
-	if err != nil {
+	if err := zr.openSequential(name, opts); err != nil {
 		return nil, err
 	}
-	// dbf is optional, so no error checking here
-	prefix := strings.TrimSuffix(name, path.Ext(name))
-	dbf, _ := openFromZIP(zr.z, prefix+".dbf")
-	zr.sr = SequentialReaderFromExt(shp, dbf)
 	return zr, nil
 }
 
@@ -145,6 +162,45 @@ func (zr *ZipReader) Fields() []Field {
 	return zr.sr.Fields()
 }
 
+// Offset returns the byte offset of the current record in the SHP file, or -1
+// if it is unknown.
+func (zr *ZipReader) Offset() int64 {
+	if o, ok := zr.sr.(Offsetter); ok {
+		return o.Offset()
+	}
+	return -1
+}
+
+// Checkpoint returns the position after the current record.
+func (zr *ZipReader) Checkpoint() CheckpointToken {
+	if c, ok := zr.sr.(Checkpointer); ok {
+		return c.Checkpoint()
+	}
+	return CheckpointToken{}
+}
+
+// NextBatch reads up to n records at once.
+func (zr *ZipReader) NextBatch(n int) ([]Record, error) {
+	return NextBatch(zr.sr, n)
+}
+
+// MixedTypes returns how many of the records read so far have each shape type
+// that differs from the header of the SHP file.
+func (zr *ZipReader) MixedTypes() map[ShapeType]int {
+	if m, ok := zr.sr.(MixedTypesReporter); ok {
+		return m.MixedTypes()
+	}
+	return nil
+}
+
+// Warnings returns the non-fatal issues found so far.
+func (zr *ZipReader) Warnings() []Warning {
+	if w, ok := zr.sr.(WarningReporter); ok {
+		return w.Warnings()
+	}
+	return nil
+}
+
 // Err returns the last non-EOF error that was encountered by this ZipReader.
 func (zr *ZipReader) Err() error {
 	return zr.sr.Err()
@@ -0,0 +1,99 @@
+package shp
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithPRJ makes Create and CreateZip write wkt, the coordinate reference
+// system of the shapefile, to its PRJ file on Close.
+func WithPRJ(wkt string) WriterOption {
+	return func(o *writerOptions) {
+		o.prj = wkt
+	}
+}
+
+// WithCPG makes Create and CreateZip write codepage, the encoding of the
+// attributes such as "UTF-8", to the CPG file of the shapefile on Close.
+func WithCPG(codepage string) WriterOption {
+	return func(o *writerOptions) {
+		o.cpg = codepage
+	}
+}
+
+// zipTarget is where a Writer created by CreateZip streams its files.
+type zipTarget struct {
+	w    io.Writer
+	name string
+	dir  string // temporary directory of the files
+}
+
+// CreateZip returns a Writer that writes the shapefile called name, as a ZIP
+// archive of its SHP, SHX and DBF files and of the PRJ and CPG files set with
+// WithPRJ and WithCPG, to w. This is the "zipped shapefile" that many web
+// APIs accept as a single upload. The files are written to a temporary
+// directory first, as the headers are only known at the end, and streamed to
+// w by Close, which then removes them. name may have a ".shp" extension and
+// is the basename of the files in the archive.
+func CreateZip(w io.Writer, name string, t ShapeType, opts ...WriterOption) (*Writer, error) {
+	name = trimSidecarExt(name)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("Invalid shapefile name %q", name)
+	}
+	dir, err := os.MkdirTemp("", "shp-zip-")
+	if err != nil {
+		return nil, err
+	}
+	sw, err := Create(filepath.Join(dir, name), t, opts...)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	sw.zip = &zipTarget{w: w, name: name, dir: dir}
+	return sw, nil
+}
+
+// writeSidecars writes the PRJ and CPG files and the style of w, if set.
+func (w *Writer) writeSidecars() error {
+	for ext, content := range map[string]string{".prj": w.prj, ".cpg": w.cpg} {
+		if content == "" {
+			continue
+		}
+		if err := os.WriteFile(w.filename+ext, []byte(content), 0666); err != nil {
+			return err
+		}
+	}
+	return w.writeStyle()
+}
+
+// finish streams the files of the shapefile filename to the archive if err
+// is nil and removes them.
+func (z *zipTarget) finish(filename string, err error) error {
+	defer os.RemoveAll(z.dir)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(z.w)
+	for _, ext := range append(sidecarExts[:len(sidecarExts):len(sidecarExts)], styleExts...) {
+		f, err := os.Open(filename + ext)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		entry, err := zw.Create(z.name + ext)
+		if err == nil {
+			_, err = io.Copy(entry, f)
+		}
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("Failed to write %s%s to the archive: %v", z.name, ext, err)
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,62 @@
+package shp
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"sort"
+	"testing"
+)
+
+func TestCreateZip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := CreateZip(&buf, "points.shp", POINT, WithPRJ("GEOGCS[\"WGS 84\"]"), WithCPG("UTF-8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetFields([]Field{StringField("NAME", 10)})
+	n := w.Write(&Point{1, 2})
+	w.WriteAttribute(int(n), 0, "a")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string]string{}
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(b)
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	want := []string{"points.cpg", "points.dbf", "points.prj", "points.shp", "points.shx"}
+	if len(names) != len(want) {
+		t.Fatalf("entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("entries = %v, want %v", names, want)
+		}
+	}
+	if contents["points.cpg"] != "UTF-8" {
+		t.Errorf("cpg = %q", contents["points.cpg"])
+	}
+	if len(contents["points.shp"]) != 100+28 {
+		t.Errorf("shp size = %d, want %d", len(contents["points.shp"]), 128)
+	}
+}
+
+func TestCreateZipInvalidName(t *testing.T) {
+	if _, err := CreateZip(io.Discard, "dir/points", POINT); err == nil {
+		t.Error("expected an error for a name with a directory")
+	}
+}